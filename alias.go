@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// AliasMapper translates value of a deprecated flag name to value of current flag,
+// e.g. values of `--verbose-level=high` to `--log-level=debug`
+type AliasMapper func(value string) (string, error)
+
+var aliasMappers = map[string]AliasMapper{}
+
+// RegisterAliasMapper registers AliasMapper by name, it's referenced by `aliasmap` tag:
+//
+//	LogLevel string `cli:"log-level" alias:"verbose-level" aliasmap:"levels"`
+//
+// Flags named by `alias` tag keep working with a warning but aren't listed in usage.
+func RegisterAliasMapper(name string, mapper AliasMapper) {
+	if _, ok := aliasMappers[name]; ok {
+		panic("RegisterAliasMapper has registered: " + name)
+	}
+	aliasMappers[name] = mapper
+}
+
+// parseAliasNames parses value of `alias` tag into flag names with dashes
+func parseAliasNames(s string) []string {
+	names := []string{}
+	for _, name := range splitTagList(s) {
+		if name = strings.TrimSpace(name); len(name) == 1 {
+			names = append(names, dashOne+name)
+		} else if name != "" {
+			names = append(names, dashTwo+name)
+		}
+	}
+	return names
+}
+
+func (fl *flag) isAlias(name string) bool {
+	for _, alias := range fl.tag.aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// aliasValue translates value of flag set by deprecated name
+func (fl *flag) aliasValue(name, value string) (string, error) {
+	if !fl.isAlias(name) {
+		return value, nil
+	}
+	fl.usedAlias = name
+	if fl.tag.aliasMapper == nil {
+		return value, nil
+	}
+	return fl.tag.aliasMapper(value)
+}
+
+// deprecations returns warnings of flags set by deprecated names
+func (fs *flagSet) deprecations() []string {
+	warnings := []string{}
+	for _, fl := range fs.flagSlice {
+		if fl.usedAlias == "" {
+			continue
+		}
+		names := append(append([]string{}, fl.tag.longNames...), fl.tag.shortNames...)
+		warnings = append(warnings, fmt.Sprintf(tr(MsgFlagDeprecated), fl.usedAlias, names[0]))
+	}
+	return warnings
+}
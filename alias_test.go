@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	RegisterAliasMapper("test-levels", func(value string) (string, error) {
+		switch value {
+		case "high":
+			return "debug", nil
+		case "low":
+			return "error", nil
+		}
+		return "", fmt.Errorf("unknown level %s", value)
+	})
+}
+
+func TestAlias(t *testing.T) {
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	warnings := new(bytes.Buffer)
+	WarningOutput = warnings
+
+	type argT struct {
+		Output   string `cli:"output" alias:"out,O"`
+		LogLevel string `cli:"log-level" alias:"verbose-level" aliasmap:"test-levels" dft:"info"`
+	}
+	var argv *argT
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			argv = ctx.Argv().(*argT)
+			return nil
+		},
+	}
+
+	assert.Nil(t, root.RunWith([]string{"--output=a.txt", "--log-level", "warn"}, ioutil.Discard, nil))
+	assert.Equal(t, *argv, argT{Output: "a.txt", LogLevel: "warn"})
+	assert.Equal(t, warnings.String(), "")
+
+	assert.Nil(t, root.RunWith([]string{"-O", "b.txt", "--verbose-level=high"}, ioutil.Discard, nil))
+	assert.Equal(t, *argv, argT{Output: "b.txt", LogLevel: "debug"})
+	assert.Equal(t, warnings.String(), "WARN! flag -O is deprecated, use --output instead\n"+
+		"WARN! flag --verbose-level is deprecated, use --log-level instead\n")
+
+	assert.Error(t, root.RunWith([]string{"--verbose-level=medium"}, ioutil.Discard, nil))
+
+	assert.NotContains(t, root.Usage(&Context{}), "verbose-level")
+
+	// warnings are translated
+	RegisterMessages("zz", map[string]string{MsgFlagDeprecated: "%s -> %s"})
+	SetLanguage("zz")
+	defer SetLanguage("")
+	warnings.Reset()
+	assert.Nil(t, root.RunWith([]string{"--out", "c.txt"}, ioutil.Discard, nil))
+	assert.Equal(t, warnings.String(), "WARN! --out -> --output\n")
+}
+
+func TestAliasMapperNotRegistered(t *testing.T) {
+	type argT struct {
+		Level string `cli:"level" alias:"lvl" aliasmap:"undefined"`
+	}
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn:   donothing,
+	}
+	err := root.RunWith([]string{"--level", "x"}, ioutil.Discard, nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "aliasmap undefined of field Level not registered")
+	}
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ConfigDir returns config directory of app, it's created if not exist, e.g.
+//
+//	linux:   $XDG_CONFIG_HOME/<app> or ~/.config/<app>
+//	darwin:  ~/Library/Application Support/<app>
+//	windows: %AppData%\<app>
+func (ctx *Context) ConfigDir() (string, error) {
+	return ctx.appDir(os.UserConfigDir)
+}
+
+// CacheDir returns cache directory of app, it's created if not exist, e.g.
+//
+//	linux:   $XDG_CACHE_HOME/<app> or ~/.cache/<app>
+//	darwin:  ~/Library/Caches/<app>
+//	windows: %LocalAppData%\<app>
+func (ctx *Context) CacheDir() (string, error) {
+	return ctx.appDir(os.UserCacheDir)
+}
+
+// DataDir returns data directory of app, it's created if not exist, e.g.
+//
+//	linux:   $XDG_DATA_HOME/<app> or ~/.local/share/<app>
+//	darwin:  ~/Library/Application Support/<app>
+//	windows: %LocalAppData%\<app>
+func (ctx *Context) DataDir() (string, error) {
+	return ctx.appDir(userDataDir)
+}
+
+// appName returns name of root command, or name of executable if empty
+func (ctx *Context) appName() string {
+	if ctx.command != nil {
+		if name := ctx.command.Root().Name; name != "" {
+			return name
+		}
+	}
+	return executableName(os.Args[0])
+}
+
+// executableName returns base name of executable path without extension
+func executableName(path string) string {
+	name := filepath.Base(path)
+	return name[:len(name)-len(filepath.Ext(name))]
+}
+
+func (ctx *Context) appDir(baseDir func() (string, error)) (string, error) {
+	dir, err := baseDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, ctx.appName())
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func userDataDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%LocalAppData% is not defined")
+	case "darwin", "ios":
+		return os.UserConfigDir()
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
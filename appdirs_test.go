@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextAppDirs(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("XDG directories only")
+	}
+	dir, err := ioutil.TempDir("", "cli-appdirs")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_DATA_HOME"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, filepath.Join(dir, name))
+	}
+
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "sub",
+		Fn: func(ctx *Context) error {
+			for name, fn := range map[string]func() (string, error){
+				"XDG_CONFIG_HOME": ctx.ConfigDir,
+				"XDG_CACHE_HOME":  ctx.CacheDir,
+				"XDG_DATA_HOME":   ctx.DataDir,
+			} {
+				path, err := fn()
+				require.Nil(t, err)
+				assert.Equal(t, path, filepath.Join(dir, name, "app"))
+				info, err := os.Stat(path)
+				require.Nil(t, err)
+				assert.True(t, info.IsDir())
+			}
+			return nil
+		},
+	})
+	assert.Nil(t, root.RunWith([]string{"sub"}, ioutil.Discard, nil))
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// WriteFileAtomic writes data to a temp file in the same directory and renames it
+// to filename, so readers see either the old content or the new one, never a partial write
+func WriteFileAtomic(filename string, data []byte, perm os.FileMode) error {
+	dir, base := filepath.Split(filename)
+	if dir == "" {
+		dir = "."
+	}
+	file, err := ioutil.TempFile(dir, "."+base+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpname := file.Name()
+	defer os.Remove(tmpname)
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpname, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpname, filename); err != nil {
+		return err
+	}
+	return syncDir(dir)
+}
+
+// syncDir flushes directory entry of renamed file, it's not supported on windows
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
+
+// EditFileAtomic rewrites filename in place with content returned by edit, the file
+// is not modified if edit returns an error. Old content is kept in filename+backupSuffix
+// if backupSuffix is not empty, e.g. ".bak". A missing file is edited from empty content.
+//
+// The file is locked by LockFile while editing, and an error reported by IsConcurrentEdit
+// is returned if the file was modified by another process which ignores the lock.
+func EditFileAtomic(filename, backupSuffix string, edit func(data []byte) ([]byte, error)) error {
+	unlock, err := LockFile(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var (
+		perm    = os.FileMode(0644)
+		version FileVersion
+	)
+	data, err := ioutil.ReadFile(filename)
+	if err == nil {
+		if version, err = fileVersion(filename, data); err != nil {
+			return err
+		}
+		if info, err := os.Stat(filename); err == nil {
+			perm = info.Mode().Perm()
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	newData, err := edit(data)
+	if err != nil {
+		return err
+	}
+	if err := checkFileVersion(filename, version); err != nil {
+		return err
+	}
+	if version.Exists && backupSuffix != "" {
+		if err := WriteFileAtomic(filename+backupSuffix, data, perm); err != nil {
+			return err
+		}
+	}
+	return WriteFileAtomic(filename, newData, perm)
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-atomic")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.conf")
+
+	require.Nil(t, WriteFileAtomic(filename, []byte("v1"), 0600))
+	require.Nil(t, WriteFileAtomic(filename, []byte("v2"), 0600))
+	data, err := ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "v2")
+	info, err := os.Stat(filename)
+	require.Nil(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0600))
+
+	// no temp files left
+	infos, err := ioutil.ReadDir(dir)
+	require.Nil(t, err)
+	assert.Equal(t, len(infos), 1)
+}
+
+func TestEditFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-atomic")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.conf")
+	upper := func(data []byte) ([]byte, error) { return bytes.ToUpper(append(data, 'x')), nil }
+
+	require.Nil(t, EditFileAtomic(filename, ".bak", upper))
+	data, err := ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "X")
+	_, err = os.Stat(filename + ".bak")
+	assert.True(t, os.IsNotExist(err))
+
+	require.Nil(t, EditFileAtomic(filename, ".bak", upper))
+	data, err = ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "XX")
+	data, err = ioutil.ReadFile(filename + ".bak")
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "X")
+
+	assert.Error(t, EditFileAtomic(filename, "", func([]byte) ([]byte, error) {
+		return []byte("broken"), fmt.Errorf("abort")
+	}))
+	data, err = ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "XX")
+}
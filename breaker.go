@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CircuitState is state of circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed : calls pass through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen : calls fail fast until cooldown elapsed
+	CircuitOpen
+	// CircuitHalfOpen : a trial call passes through after cooldown
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+type circuitOpenError struct {
+	name string
+}
+
+func (e circuitOpenError) Error() string {
+	return fmt.Sprintf("circuit %s is open", e.name)
+}
+
+// IsCircuitOpen reports whether err is returned by an open circuit breaker
+func IsCircuitOpen(err error) bool {
+	var e circuitOpenError
+	return errors.As(err, &e)
+}
+
+// CircuitBreaker stops calling a flaky backend after Threshold consecutive failures,
+// and tries again after Cooldown
+type CircuitBreaker struct {
+	Name      string
+	Threshold int
+	Cooldown  time.Duration
+
+	locker    sync.Mutex // protect following data
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	lastError error
+}
+
+// CircuitBreakerStatus is snapshot of a circuit breaker
+type CircuitBreakerStatus struct {
+	Name      string
+	State     CircuitState
+	Failures  int
+	LastError error
+}
+
+var (
+	breakersLocker sync.Mutex
+	breakers       = map[string]*CircuitBreaker{}
+)
+
+// NewCircuitBreaker creates and registers a circuit breaker by name,
+// registered breakers are reported by CircuitBreakerStates
+func NewCircuitBreaker(name string, threshold int, cooldown time.Duration) *CircuitBreaker {
+	if threshold <= 0 {
+		threshold = 1
+	}
+	cb := &CircuitBreaker{Name: name, Threshold: threshold, Cooldown: cooldown}
+	breakersLocker.Lock()
+	breakers[name] = cb
+	breakersLocker.Unlock()
+	return cb
+}
+
+// CircuitBreakerStates returns status of all registered circuit breakers sorted by name
+func CircuitBreakerStates() []CircuitBreakerStatus {
+	breakersLocker.Lock()
+	list := make([]*CircuitBreaker, 0, len(breakers))
+	for _, cb := range breakers {
+		list = append(list, cb)
+	}
+	breakersLocker.Unlock()
+	states := make([]CircuitBreakerStatus, 0, len(list))
+	for _, cb := range list {
+		states = append(states, cb.Status())
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+// Status returns current status of breaker
+func (cb *CircuitBreaker) Status() CircuitBreakerStatus {
+	cb.locker.Lock()
+	defer cb.locker.Unlock()
+	return CircuitBreakerStatus{
+		Name:      cb.Name,
+		State:     cb.currentState(),
+		Failures:  cb.failures,
+		LastError: cb.lastError,
+	}
+}
+
+func (cb *CircuitBreaker) currentState() CircuitState {
+	if cb.state == CircuitOpen && time.Since(cb.openedAt) >= cb.Cooldown {
+		return CircuitHalfOpen
+	}
+	return cb.state
+}
+
+// allow reports whether a call can pass through
+func (cb *CircuitBreaker) allow() bool {
+	cb.locker.Lock()
+	defer cb.locker.Unlock()
+	switch cb.currentState() {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if cb.state == CircuitHalfOpen {
+			// a trial call is in flight
+			return false
+		}
+		cb.state = CircuitHalfOpen
+	}
+	return true
+}
+
+func (cb *CircuitBreaker) done(err error) {
+	cb.locker.Lock()
+	defer cb.locker.Unlock()
+	if err == nil {
+		cb.state = CircuitClosed
+		cb.failures = 0
+		return
+	}
+	cb.failures++
+	cb.lastError = err
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.Threshold {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// Do calls fn if circuit is not open, a panic of fn is recorded as a failure and repanicked
+func (cb *CircuitBreaker) Do(fn func() error) (err error) {
+	if !cb.allow() {
+		return circuitOpenError{name: cb.Name}
+	}
+	returned := false
+	defer func() {
+		if returned {
+			cb.done(err)
+			return
+		}
+		r := recover()
+		cb.done(fmt.Errorf("panic: %v", r))
+		if r != nil {
+			panic(r)
+		}
+	}()
+	err = fn()
+	returned = true
+	return err
+}
+
+// Run runs cmd through the breaker, see Do
+func (cb *CircuitBreaker) Run(cmd *exec.Cmd) error {
+	return cb.Do(cmd.Run)
+}
+
+// Transport wraps rt with the breaker, a response with status code 5xx is a failure.
+// http.DefaultTransport used if rt is nil.
+//
+//	httpc := &http.Client{Transport: cb.Transport(nil)}
+//	cmd.RPC(httpc, ctx)
+func (cb *CircuitBreaker) Transport(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return breakerTransport{cb: cb, rt: rt}
+}
+
+type breakerTransport struct {
+	cb *CircuitBreaker
+	rt http.RoundTripper
+}
+
+func (t breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := t.cb.Do(func() error {
+		var err error
+		resp, err = t.rt.RoundTrip(req)
+		if err == nil && resp.StatusCode >= 500 {
+			return fmt.Errorf("%s %s: %s", req.Method, req.URL, resp.Status)
+		}
+		return err
+	})
+	if resp != nil {
+		// 5xx response is returned to caller as it is
+		return resp, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	cb := NewCircuitBreaker("test-breaker", 2, 20*time.Millisecond)
+	fail := func() error { return errors.New("failed") }
+	succeed := func() error { return nil }
+
+	assert.Equal(t, cb.Status().State, CircuitClosed)
+	assert.Error(t, cb.Do(fail))
+	assert.Equal(t, cb.Status().State, CircuitClosed)
+	assert.Error(t, cb.Do(fail))
+	assert.Equal(t, cb.Status().State, CircuitOpen)
+	assert.Equal(t, cb.Status().Failures, 2)
+
+	err := cb.Do(succeed)
+	assert.True(t, IsCircuitOpen(err))
+	assert.Equal(t, err.Error(), "circuit test-breaker is open")
+	assert.True(t, IsCircuitOpen(fmt.Errorf("call: %w", err)))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, cb.Status().State, CircuitHalfOpen)
+	assert.Error(t, cb.Do(fail))
+	assert.Equal(t, cb.Status().State, CircuitOpen)
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Nil(t, cb.Do(succeed))
+	assert.Equal(t, cb.Status().State, CircuitClosed)
+	assert.Equal(t, cb.Status().Failures, 0)
+
+	found := false
+	for _, status := range CircuitBreakerStates() {
+		if status.Name == "test-breaker" {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestCircuitBreakerPanic(t *testing.T) {
+	cb := NewCircuitBreaker("test-panic", 1, time.Hour)
+	assert.Panics(t, func() {
+		cb.Do(func() error { panic("boom") })
+	})
+	assert.Equal(t, cb.Status().State, CircuitOpen)
+	assert.Equal(t, cb.Status().Failures, 1)
+	assert.True(t, IsCircuitOpen(cb.Do(func() error { return nil })))
+}
+
+func TestCircuitBreakerTransport(t *testing.T) {
+	status := http.StatusInternalServerError
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	cb := NewCircuitBreaker("test-transport", 1, time.Hour)
+	httpc := &http.Client{Transport: cb.Transport(nil)}
+	resp, err := httpc.Get(server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, http.StatusInternalServerError)
+	assert.Equal(t, cb.Status().State, CircuitOpen)
+
+	status = http.StatusOK
+	_, err = httpc.Get(server.URL)
+	assert.Error(t, err)
+}
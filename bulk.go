@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Resumer represents interface for resuming interrupted bulk operations
+	Resumer interface {
+		ResumeBulk() bool
+	}
+
+	// ResumeHelper is builtin resume flag
+	ResumeHelper struct {
+		Resume bool `cli:"resume" usage:"resume interrupted bulk operation from checkpoint" json:"-"`
+	}
+)
+
+// ResumeBulk implements Resumer interface
+func (h ResumeHelper) ResumeBulk() bool {
+	return h.Resume
+}
+
+func (ctx *Context) resumeBulk() bool {
+	for _, argv := range ctx.allArgvs() {
+		if resumer, ok := argv.(Resumer); ok && resumer.ResumeBulk() {
+			return true
+		}
+	}
+	return false
+}
+
+// Bulk runs Do for many items of an API with limited concurrency and rate.
+// Completed keys are checkpointed, so an interrupted bulk operation picks up
+// where it left off if argv implements Resumer and ResumeBulk returns true.
+type Bulk struct {
+	Name string   // Name of bulk operation, used as name of checkpoint, path of command used if empty
+	Keys []string // Keys of items
+
+	// Do processes an item, it's called concurrently if Parallel > 1
+	Do func(key string) error
+
+	Parallel int     // Maximum number of concurrent calls, 1 if not positive
+	Rate     float64 // Maximum calls per second, unlimited if not positive
+
+	// Checkpoint is filename of checkpoint, <DataDir>/<Name>.checkpoint used if empty
+	Checkpoint string
+}
+
+func (b *Bulk) checkpointFile(ctx *Context) (string, error) {
+	if b.Checkpoint != "" {
+		return b.Checkpoint, nil
+	}
+	name := b.Name
+	if name == "" {
+		// e.g. `app user import` => user-import
+		if name = strings.Join(strings.Fields(ctx.Path()), "-"); name == "" {
+			name = ctx.appName()
+		}
+	}
+	dir, err := ctx.DataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+".checkpoint"), nil
+}
+
+// loadBulkCheckpoint loads completed keys, checkpoint has a key encoded as JSON
+// string per line. Incomplete last line written by interrupted process is ignored.
+func loadBulkCheckpoint(filename string) (map[string]bool, error) {
+	done := map[string]bool{}
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines[:len(lines)-1] {
+		var key string
+		if err := json.Unmarshal([]byte(line), &key); err != nil {
+			return nil, fmt.Errorf("checkpoint %s: line %d: %v", filename, i+1, err)
+		}
+		done[key] = true
+	}
+	return done, nil
+}
+
+// appendBulkCheckpoint appends completed key to checkpoint file
+func appendBulkCheckpoint(file *os.File, key string) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Bulk runs b, progress is emitted as progress events, see EmitProgress.
+// Keys are counted by Summary: succeeded keys as updated, failed keys as failed
+// and keys completed in previous run as skipped while resuming. Errors of items
+// don't stop others, they are returned together and failed keys are retried by
+// next resuming. Dispatching stops if Context.Context is done. Checkpoint is
+// locked by LockFile while running and removed after all items succeeded.
+func (ctx *Context) Bulk(b *Bulk) error {
+	filename, err := b.checkpointFile(ctx)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	unlock, err := LockFile(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	done := map[string]bool{}
+	if ctx.resumeBulk() {
+		if done, err = loadBulkCheckpoint(filename); err != nil {
+			return err
+		}
+	} else if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	checkpoint, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer checkpoint.Close()
+
+	ctx.Summary().Total(len(b.Keys))
+	pending := make([]string, 0, len(b.Keys))
+	for _, key := range b.Keys {
+		if done[key] {
+			ctx.Summary().Skipped(1)
+		} else {
+			pending = append(pending, key)
+		}
+	}
+
+	parallel := b.Parallel
+	if parallel <= 0 {
+		parallel = 1
+	}
+	var tick <-chan time.Time
+	if b.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / b.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	var (
+		wg      sync.WaitGroup
+		locker  sync.Mutex // protect checkpoint, count and errs
+		count   int64
+		errs    multiError
+		queue   = make(chan string)
+		total   = int64(len(pending))
+		goctx   = ctx.Context()
+		stopped error
+	)
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range queue {
+				err := b.Do(key)
+				locker.Lock()
+				count++
+				if err != nil {
+					ctx.Summary().Failed(1)
+					errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				} else {
+					ctx.Summary().Updated(1)
+					if err := appendBulkCheckpoint(checkpoint, key); err != nil {
+						errs = append(errs, fmt.Errorf("save checkpoint: %w", err))
+					}
+				}
+				ctx.EmitProgress(count, total, key)
+				locker.Unlock()
+			}
+		}()
+	}
+dispatch:
+	for i, key := range pending {
+		if tick != nil && i > 0 {
+			select {
+			case <-tick:
+			case <-goctx.Done():
+				stopped = goctx.Err()
+				break dispatch
+			}
+		}
+		select {
+		case queue <- key:
+		case <-goctx.Done():
+			stopped = goctx.Err()
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if stopped != nil {
+		errs = append(errs, stopped)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	checkpoint.Close()
+	if err := os.Remove(filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextBulk(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bulk")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		locker sync.Mutex
+		calls  []string
+		broken = true
+	)
+	b := &Bulk{
+		Name: "tag",
+		Keys: []string{"a", "b", "c", "d"},
+		Do: func(key string) error {
+			locker.Lock()
+			defer locker.Unlock()
+			calls = append(calls, key)
+			if key == "c" && broken {
+				return errors.New("rate limited")
+			}
+			return nil
+		},
+		Parallel:   2,
+		Checkpoint: filepath.Join(dir, "tag.checkpoint"),
+	}
+	type argT struct {
+		ResumeHelper
+	}
+	var result SummaryResult
+	root := &Command{
+		Name: "tag",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			err := ctx.Bulk(b)
+			result = ctx.Summary().Result()
+			return err
+		},
+	}
+
+	err = root.RunWith(nil, ioutil.Discard, nil)
+	assert.Equal(t, err.Error(), "c: rate limited")
+	assert.Equal(t, result.Failed, 1)
+	assert.Equal(t, result.Updated, 3)
+	done, err := loadBulkCheckpoint(b.Checkpoint)
+	require.Nil(t, err)
+	assert.Equal(t, done, map[string]bool{"a": true, "b": true, "d": true})
+
+	// resume retries failed keys only
+	calls, broken = nil, false
+	assert.Nil(t, root.RunWith([]string{"--resume"}, ioutil.Discard, nil))
+	assert.Equal(t, calls, []string{"c"})
+	assert.Equal(t, result.Skipped, 3)
+	_, err = os.Stat(b.Checkpoint)
+	assert.True(t, os.IsNotExist(err))
+
+	// start over without --resume
+	calls = nil
+	require.Nil(t, ioutil.WriteFile(b.Checkpoint, []byte("\"a\"\n"), 0644))
+	assert.Nil(t, root.RunWith(nil, ioutil.Discard, nil))
+	sort.Strings(calls)
+	assert.Equal(t, calls, []string{"a", "b", "c", "d"})
+}
+
+func TestContextBulkRate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bulk")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	b := &Bulk{
+		Name:       "rate",
+		Keys:       []string{"a", "b", "c"},
+		Do:         func(key string) error { return nil },
+		Parallel:   3,
+		Rate:       50,
+		Checkpoint: filepath.Join(dir, "rate.checkpoint"),
+	}
+	root := &Command{
+		Name: "rate",
+		Fn: func(ctx *Context) error {
+			return ctx.Bulk(b)
+		},
+	}
+	start := time.Now()
+	assert.Nil(t, root.RunWith(nil, ioutil.Discard, nil))
+	assert.True(t, time.Since(start) >= 40*time.Millisecond)
+}
+
+func TestBulkCheckpointFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bulk")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "x.checkpoint")
+	// incomplete last line is ignored
+	require.Nil(t, ioutil.WriteFile(filename, []byte("\"a\"\n\"b\\n\"\n\"c"), 0644))
+	done, err := loadBulkCheckpoint(filename)
+	require.Nil(t, err)
+	assert.Equal(t, done, map[string]bool{"a": true, "b\n": true})
+	require.Nil(t, ioutil.WriteFile(filename, []byte("a\n"), 0644))
+	_, err = loadBulkCheckpoint(filename)
+	assert.Error(t, err)
+
+	// name of checkpoint is derived from path of command
+	defer os.Setenv("XDG_DATA_HOME", os.Getenv("XDG_DATA_HOME"))
+	os.Setenv("XDG_DATA_HOME", dir)
+	var got string
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{Name: "user"})
+	user.Register(&Command{Name: "import", Fn: func(ctx *Context) error {
+		got, err = (&Bulk{}).checkpointFile(ctx)
+		return err
+	}})
+	assert.Nil(t, root.RunWith([]string{"user", "import"}, ioutil.Discard, nil))
+	assert.Equal(t, filepath.Base(got), "user-import.checkpoint")
+}
+
+func TestContextBulkStopped(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bulk")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	b := &Bulk{
+		Name: "slow",
+		Keys: []string{"a", "b", "c", "d", "e"},
+		Do: func(key string) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+		Checkpoint: filepath.Join(dir, "slow.checkpoint"),
+	}
+	root := &Command{
+		Name:    "slow",
+		Timeout: 30 * time.Millisecond,
+		Fn: func(ctx *Context) error {
+			return ctx.Bulk(b)
+		},
+	}
+	err = root.RunWith(nil, ioutil.Discard, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, ExitCode(err), TimeoutExitCode)
+
+	// checkpoint is locked while running
+	defer func(d time.Duration) { FileLockTimeout = d }(FileLockTimeout)
+	FileLockTimeout = 10 * time.Millisecond
+	unlock, err := LockFile(b.Checkpoint)
+	require.Nil(t, err)
+	defer unlock()
+	root.Timeout = 0
+	assert.Error(t, root.RunWith(nil, ioutil.Discard, nil))
+}
@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// CacheStore stores rendered results of commands
+	CacheStore interface {
+		Get(key string) ([]byte, bool)
+		Set(key string, value []byte, ttl time.Duration) error
+	}
+
+	// CacheSkipper represents interface for bypassing cached result
+	CacheSkipper interface {
+		SkipCache() bool
+	}
+
+	// CacheHelper is builtin no-cache flag
+	CacheHelper struct {
+		NoCache bool `cli:"no-cache" usage:"bypass cached result" json:"-"`
+	}
+)
+
+// SkipCache implements CacheSkipper interface
+func (h CacheHelper) SkipCache() bool {
+	return h.NoCache
+}
+
+// Cached wraps fn with a cache middleware, output of fn is cached in store
+// with key of command path, flags and args. Cached result is served if not expired
+// unless argv implements CacheSkipper and SkipCache returns true.
+// Result is cached only if fn returns nil.
+func Cached(ttl time.Duration, store CacheStore, fn CommandFunc) CommandFunc {
+	return func(ctx *Context) error {
+		key := cacheKey(ctx)
+		if !skipCache(ctx) {
+			if data, ok := store.Get(key); ok {
+				_, err := ctx.Write(data)
+				return err
+			}
+		}
+		var (
+			buf    = new(bytes.Buffer)
+			writer = ctx.Writer()
+		)
+		ctx.writer = io.MultiWriter(writer, buf)
+		err := fn(ctx)
+		ctx.writer = writer
+		if err != nil {
+			return err
+		}
+		return store.Set(key, buf.Bytes(), ttl)
+	}
+}
+
+func skipCache(ctx *Context) bool {
+	for _, argv := range ctx.allArgvs() {
+		if skipper, ok := argv.(CacheSkipper); ok && skipper.SkipCache() {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheKey(ctx *Context) string {
+	values := url.Values{}
+	for key, value := range ctx.FormValues() {
+		if key != dashTwo+"no-cache" {
+			values[key] = value
+		}
+	}
+	return ctx.Path() + "?" + values.Encode() + "\x00" + strings.Join(ctx.Args(), "\x00")
+}
+
+type memoryCacheItem struct {
+	data     []byte
+	expireAt time.Time
+}
+
+type memoryCache struct {
+	locker sync.Mutex
+	items  map[string]memoryCacheItem
+}
+
+// NewMemoryCache creates an in-process CacheStore, it's useful for server mode
+func NewMemoryCache() CacheStore {
+	return &memoryCache{items: make(map[string]memoryCacheItem)}
+}
+
+func (c *memoryCache) Get(key string) ([]byte, bool) {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	item, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(item.expireAt) {
+		delete(c.items, key)
+		return nil, false
+	}
+	return item.data, true
+}
+
+func (c *memoryCache) Set(key string, value []byte, ttl time.Duration) error {
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	c.items[key] = memoryCacheItem{data: value, expireAt: time.Now().Add(ttl)}
+	return nil
+}
+
+type fileCache struct {
+	dir string
+}
+
+// NewFileCache creates a CacheStore which stores results as files in dir,
+// cached results are shared between invocations
+func NewFileCache(dir string) CacheStore {
+	return &fileCache{dir: dir}
+}
+
+func (c *fileCache) filename(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:])+".cache")
+}
+
+func (c *fileCache) Get(key string) ([]byte, bool) {
+	content, err := ioutil.ReadFile(c.filename(key))
+	if err != nil {
+		return nil, false
+	}
+	index := bytes.IndexByte(content, '\n')
+	if index < 0 {
+		return nil, false
+	}
+	expireAt, err := strconv.ParseInt(string(content[:index]), 10, 64)
+	if err != nil || time.Now().UnixNano() > expireAt {
+		return nil, false
+	}
+	return content[index+1:], true
+}
+
+func (c *fileCache) Set(key string, value []byte, ttl time.Duration) error {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	buf := bytes.NewBufferString(strconv.FormatInt(time.Now().Add(ttl).UnixNano(), 10))
+	buf.WriteByte('\n')
+	buf.Write(value)
+	return ioutil.WriteFile(c.filename(key), buf.Bytes(), 0644)
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-cache")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	type argT struct {
+		CacheHelper
+		Name string `cli:"name"`
+	}
+	for _, store := range []CacheStore{NewMemoryCache(), NewFileCache(dir)} {
+		calls := 0
+		root := &Command{
+			Name: "root",
+			Argv: func() interface{} { return new(argT) },
+			Fn: Cached(time.Hour, store, func(ctx *Context) error {
+				calls++
+				ctx.String("hello %s %d", ctx.Argv().(*argT).Name, calls)
+				return nil
+			}),
+		}
+		run := func(args ...string) string {
+			w := bytes.NewBufferString("")
+			assert.Nil(t, root.RunWith(args, w, nil))
+			return w.String()
+		}
+		assert.Equal(t, run("--name=a"), "hello a 1")
+		assert.Equal(t, run("--name=a"), "hello a 1")
+		assert.Equal(t, run("--name=b"), "hello b 2")
+		assert.Equal(t, run("--name=a", "--no-cache"), "hello a 3")
+		assert.Equal(t, run("--name=a"), "hello a 3")
+		assert.Equal(t, run("--name=a", "x"), "hello a 4")
+		assert.Equal(t, calls, 4)
+	}
+}
+
+func TestCacheExpire(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-cache")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, store := range []CacheStore{NewMemoryCache(), NewFileCache(dir)} {
+		assert.Nil(t, store.Set("k", []byte("v"), time.Hour))
+		assert.Nil(t, store.Set("expired", []byte("v"), -time.Second))
+		data, ok := store.Get("k")
+		assert.True(t, ok)
+		assert.Equal(t, data, []byte("v"))
+		_, ok = store.Get("expired")
+		assert.False(t, ok)
+		_, ok = store.Get("not-found")
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"sync"
+)
+
+// Capability is an optional external dependency of app, functionality
+// depending on it is degraded if it's unavailable
+type Capability struct {
+	Name    string       // Capability name, e.g. "fzf"
+	Program string       // Executable looked up in PATH, Name used if empty and Check is nil
+	Desc    string       // Functionality provided by the capability, e.g. "interactive selection"
+	Check   func() error // Custom availability check, overrides Program
+}
+
+// CapabilityStatus is availability of a capability
+type CapabilityStatus struct {
+	Capability
+	Available bool
+	Path      string // path of Program if found
+	Error     error  // reason why unavailable
+}
+
+var (
+	capabilitiesLocker sync.Mutex
+	capabilities       = map[string]Capability{}
+)
+
+// RegisterCapability registers an optional dependency, it panics if name registered
+func RegisterCapability(c Capability) {
+	capabilitiesLocker.Lock()
+	defer capabilitiesLocker.Unlock()
+	if _, ok := capabilities[c.Name]; ok {
+		panic("RegisterCapability has registered: " + c.Name)
+	}
+	capabilities[c.Name] = c
+}
+
+// HasCapability reports whether registered capability name is available
+func HasCapability(name string) bool {
+	return RequireCapability(name) == nil
+}
+
+// RequireCapability returns an error describing degraded functionality
+// if capability name is unavailable or not registered
+func RequireCapability(name string) error {
+	capabilitiesLocker.Lock()
+	c, ok := capabilities[name]
+	capabilitiesLocker.Unlock()
+	if !ok {
+		return fmt.Errorf("capability %s not registered", name)
+	}
+	status := c.status()
+	if status.Available {
+		return nil
+	}
+	if c.Desc != "" {
+		return fmt.Errorf("%s unavailable(%v), %s is disabled", name, status.Error, c.Desc)
+	}
+	return fmt.Errorf("%s unavailable(%v)", name, status.Error)
+}
+
+// Capabilities returns status of all registered capabilities sorted by name
+func Capabilities() []CapabilityStatus {
+	capabilitiesLocker.Lock()
+	list := make([]Capability, 0, len(capabilities))
+	for _, c := range capabilities {
+		list = append(list, c)
+	}
+	capabilitiesLocker.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	statuses := make([]CapabilityStatus, 0, len(list))
+	for _, c := range list {
+		statuses = append(statuses, c.status())
+	}
+	return statuses
+}
+
+func (c Capability) status() CapabilityStatus {
+	status := CapabilityStatus{Capability: c}
+	if c.Check != nil {
+		status.Error = c.Check()
+	} else {
+		program := c.Program
+		if program == "" {
+			program = c.Name
+		}
+		status.Path, status.Error = exec.LookPath(program)
+	}
+	status.Available = status.Error == nil
+	return status
+}
+
+// DoctorCommand returns a command which reports missing optional dependencies
+// and states of circuit breakers, e.g. `app doctor`
+func DoctorCommand(desc string) *Command {
+	if desc == "" {
+		desc = "report missing dependencies and degraded functionality"
+	}
+	return &Command{
+		Name:   "doctor",
+		Desc:   desc,
+		NoHook: true,
+		Fn: func(ctx *Context) error {
+			clr := ctx.Color()
+			for _, status := range Capabilities() {
+				if status.Available {
+					detail := status.Path
+					if detail == "" {
+						detail = "ok"
+					}
+					ctx.String("%s %s: %s\n", clr.Green("[ok]"), clr.Bold(status.Name), detail)
+					continue
+				}
+				ctx.String("%s %s: %v\n", clr.Red("[missing]"), clr.Bold(status.Name), status.Error)
+				if status.Desc != "" {
+					ctx.String("    degraded: %s\n", status.Desc)
+				}
+			}
+			for _, state := range CircuitBreakerStates() {
+				switch state.State {
+				case CircuitClosed:
+					ctx.String("%s circuit %s: %s\n", clr.Green("[ok]"), clr.Bold(state.Name), state.State)
+				default:
+					ctx.String("%s circuit %s: %s, last error: %v\n", clr.Yellow("[degraded]"), clr.Bold(state.Name), state.State, state.LastError)
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCapability(t *testing.T) {
+	defer func() {
+		capabilitiesLocker.Lock()
+		delete(capabilities, "cli-test-ok")
+		delete(capabilities, "cli-test-missing")
+		capabilitiesLocker.Unlock()
+	}()
+	RegisterCapability(Capability{Name: "cli-test-ok", Check: func() error { return nil }})
+	RegisterCapability(Capability{Name: "cli-test-missing", Program: "cli-test-no-such-program", Desc: "fancy output"})
+	assert.Panics(t, func() { RegisterCapability(Capability{Name: "cli-test-ok"}) })
+
+	assert.True(t, HasCapability("cli-test-ok"))
+	assert.False(t, HasCapability("cli-test-missing"))
+	assert.False(t, HasCapability("cli-test-unregistered"))
+	if err := RequireCapability("cli-test-missing"); assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "fancy output is disabled")
+	}
+
+	root := &Command{}
+	root.Register(DoctorCommand(""))
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith([]string{"doctor"}, w, nil))
+	out := w.String()
+	assert.Contains(t, out, "cli-test-ok: ok")
+	assert.Contains(t, out, "cli-test-missing:")
+	assert.Contains(t, out, "degraded: fancy output")
+	assert.True(t, strings.Index(out, "cli-test-missing") < strings.Index(out, "cli-test-ok"), fmt.Sprintf("sorted: %s", out))
+}
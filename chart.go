@@ -0,0 +1,107 @@
+package cli
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	sparkTicks = []rune("▁▂▃▄▅▆▇█")
+	barEighths = []rune(" ▏▎▍▌▋▊▉")
+)
+
+// Bar is an item of bar chart
+type Bar struct {
+	Label string
+	Value float64
+}
+
+// Sparkline returns sparkline of values scaled between min and max of values, e.g. `▁▃▅█`
+func Sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	min, max := values[0], values[0]
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	ticks := make([]rune, 0, len(values))
+	for _, v := range values {
+		index := len(sparkTicks) - 1
+		if max > min {
+			index = int((v - min) / (max - min) * float64(len(sparkTicks)-1))
+		}
+		ticks = append(ticks, sparkTicks[index])
+	}
+	return string(ticks)
+}
+
+// BarChart returns lines of horizontal bars with labels and values, bars are
+// scaled to width by the max value. Negative values are drawn as empty bars.
+//
+//	cpu ████████████ 60
+//	mem ████▌        22.5
+func BarChart(bars []Bar, width int) string {
+	var (
+		labelWidth int
+		max        float64
+	)
+	for _, bar := range bars {
+		if n := utf8.RuneCountInString(bar.Label); n > labelWidth {
+			labelWidth = n
+		}
+		max = math.Max(max, bar.Value)
+	}
+	var buf bytes.Buffer
+	for _, bar := range bars {
+		buf.WriteString(bar.Label)
+		buf.WriteString(strings.Repeat(" ", labelWidth-utf8.RuneCountInString(bar.Label)+1))
+		eighths := 0
+		if max > 0 && bar.Value > 0 {
+			eighths = int(math.Round(bar.Value / max * float64(width*8)))
+		}
+		buf.WriteString(strings.Repeat(string(sparkTicks[len(sparkTicks)-1]), eighths/8))
+		n := eighths / 8
+		if eighths%8 > 0 {
+			buf.WriteRune(barEighths[eighths%8])
+			n++
+		}
+		buf.WriteString(strings.Repeat(" ", width-n+1))
+		buf.WriteString(formatNumber(bar.Value))
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Sparkline writes sparkline of values and a newline to writer,
+// values are written as plain numbers if writer is not a terminal
+func (ctx *Context) Sparkline(values ...float64) *Context {
+	if !ctx.IsTTY() {
+		nums := make([]string, 0, len(values))
+		for _, v := range values {
+			nums = append(nums, formatNumber(v))
+		}
+		return ctx.String("%s\n", strings.Join(nums, " "))
+	}
+	return ctx.String("%s\n", Sparkline(values))
+}
+
+// BarChart writes bar chart of bars to writer, see BarChart.
+// Labels and values are written as plain `<label> <value>` lines if writer is not a terminal.
+func (ctx *Context) BarChart(bars []Bar, width int) *Context {
+	if !ctx.IsTTY() {
+		for _, bar := range bars {
+			ctx.String("%s %s\n", bar.Label, formatNumber(bar.Value))
+		}
+		return ctx
+	}
+	return ctx.String("%s", BarChart(bars, width))
+}
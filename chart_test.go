@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, Sparkline(nil), "")
+	assert.Equal(t, Sparkline([]float64{1, 2, 3, 4, 5, 6, 7, 8}), "▁▂▃▄▅▆▇█")
+	assert.Equal(t, Sparkline([]float64{0, 10, 5}), "▁█▄")
+	assert.Equal(t, Sparkline([]float64{3, 3}), "██")
+}
+
+func TestBarChart(t *testing.T) {
+	bars := []Bar{{"cpu", 60}, {"memory", 22.5}, {"io", -1}}
+	assert.Equal(t, BarChart(bars, 8), ""+
+		"cpu    ████████ 60\n"+
+		"memory ███      22.5\n"+
+		"io              -1\n")
+	assert.Equal(t, BarChart([]Bar{{"a", 1}, {"b", 3}}, 2), ""+
+		"a ▋  1\n"+
+		"b ██ 3\n")
+}
+
+func TestContextChart(t *testing.T) {
+	w := new(bytes.Buffer)
+	ctx := &Context{writer: w}
+	ctx.Sparkline(1, 2.5, 3)
+	ctx.BarChart([]Bar{{"a", 1}, {"b", 3}}, 10)
+	assert.Equal(t, w.String(), "1 2.5 3\na 1\nb 3\n")
+}
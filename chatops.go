@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// ChatMessage is a chat message mapped to command invocation,
+	// Text is the command line, e.g. `deploy api --env=prod`
+	ChatMessage struct {
+		Channel string
+		User    string
+		Text    string
+	}
+
+	// ChatReply is reply of a chat message, Private reply is only visible to sender
+	ChatReply struct {
+		Text    string
+		Private bool
+	}
+
+	// ChatAuthorizer checks whether sender of msg is allowed to run cmd,
+	// e.g. restricts deployment commands to ops channels
+	ChatAuthorizer func(msg ChatMessage, cmd *Command) error
+
+	// ChatOps maps chat messages, e.g. Slack or Mattermost slash commands,
+	// to invocations of command tree and replies output as formatted messages
+	ChatOps struct {
+		Authorize ChatAuthorizer // Allows all if nil
+
+		// SigningSecret verifies signatures(X-Slack-Signature) of Slack requests and
+		// Token verifies token of Mattermost requests, ServeHTTP rejects requests
+		// not verified by either of them
+		SigningSecret string
+		Token         string
+
+		engine *Engine
+	}
+)
+
+// MaxChatRequestAge is maximum age of timestamps of signed Slack requests, older
+// requests are rejected as replays
+var MaxChatRequestAge = 5 * time.Minute
+
+// maxChatRequestSize limits size of body of slash command requests
+const maxChatRequestSize = 1 << 20
+
+// NewChatOps creates a ChatOps adapter for root
+func NewChatOps(root *Command) *ChatOps {
+	return &ChatOps{engine: NewEngine(root)}
+}
+
+// Handle runs command line of msg and returns reply, output is formatted as a code block.
+// Errors are replied privately, goctx is returned by Context.Context.
+func (c *ChatOps) Handle(goctx context.Context, msg ChatMessage) ChatReply {
+	args, err := SplitArgs(msg.Text)
+	if err != nil {
+		return ChatReply{Text: err.Error(), Private: true}
+	}
+	// command resolved by running is authorized, e.g. DefaultCommand applied
+	var (
+		denied    error
+		authorize func(*Context) error
+	)
+	if c.Authorize != nil {
+		authorize = func(ctx *Context) error {
+			denied = c.Authorize(msg, ctx.Command())
+			return denied
+		}
+	}
+	out := new(bytes.Buffer)
+	err = c.engine.execute(goctx, Execution{Args: args, Stdout: out, Stderr: out, Remote: true}, authorize)
+	if denied != nil {
+		return ChatReply{Text: fmt.Sprintf("permission denied: %v", denied), Private: true}
+	}
+	text := strings.TrimRight(out.String(), "\n")
+	if text != "" {
+		text = "```\n" + text + "\n```"
+	}
+	if err != nil {
+		if text != "" {
+			text += "\n"
+		}
+		return ChatReply{Text: text + err.Error(), Private: true}
+	}
+	return ChatReply{Text: text}
+}
+
+// ServeHTTP implements http.Handler, it handles slash command requests of Slack
+// and Mattermost, which post form fields channel_name, user_name and text, and
+// replies JSON with response_type and text. Requests are verified by SigningSecret
+// or Token, unverified requests are rejected with status 401.
+func (c *ChatOps) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxChatRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := c.verify(r, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	reply := c.Handle(r.Context(), ChatMessage{
+		Channel: r.PostForm.Get("channel_name"),
+		User:    r.PostForm.Get("user_name"),
+		Text:    r.PostForm.Get("text"),
+	})
+	responseType := "in_channel"
+	if reply.Private {
+		responseType = "ephemeral"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{
+		"response_type": responseType,
+		"text":          reply.Text,
+	})
+}
+
+// verify checks Slack signature if SigningSecret set and request signed,
+// or Mattermost token if Token set
+func (c *ChatOps) verify(r *http.Request, body []byte) error {
+	if c.SigningSecret != "" && r.Header.Get("X-Slack-Signature") != "" {
+		return verifySlackSignature(c.SigningSecret, r.Header, body, time.Now())
+	}
+	if c.Token != "" {
+		if subtle.ConstantTimeCompare([]byte(r.PostForm.Get("token")), []byte(c.Token)) != 1 {
+			return errors.New("invalid token")
+		}
+		return nil
+	}
+	return errors.New("request not verified")
+}
+
+// verifySlackSignature verifies X-Slack-Signature which is `v0=` followed by hex encoded
+// HMAC-SHA256 of `v0:<timestamp>:<body>`, see https://api.slack.com/authentication/verifying-requests-from-slack
+func verifySlackSignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("invalid timestamp")
+	}
+	if age := now.Sub(time.Unix(seconds, 0)); age > MaxChatRequestAge || age < -MaxChatRequestAge {
+		return errors.New("timestamp expired")
+	}
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(slackSignature(secret, timestamp, body))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func slackSignature(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
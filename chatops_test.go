@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatOps(t *testing.T) {
+	type argT struct {
+		Env string `cli:"env" dft:"dev"`
+	}
+	root := &Command{Name: "bot"}
+	root.Register(&Command{
+		Name:        "deploy",
+		CanSubRoute: true,
+		Argv:        func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			if ctx.NArg() == 0 {
+				return errors.New("service required")
+			}
+			ctx.String("deploying %s to %s\n", ctx.Args()[0], ctx.Argv().(*argT).Env)
+			return nil
+		},
+	})
+	root.Register(&Command{Name: "ping", Fn: func(ctx *Context) error { return nil }})
+
+	chat := NewChatOps(root)
+	chat.Authorize = func(msg ChatMessage, cmd *Command) error {
+		if cmd.Name == "deploy" && msg.Channel != "ops" {
+			return errors.New("deploy is only allowed in #ops")
+		}
+		return nil
+	}
+	goctx := context.Background()
+	assert.Equal(t, chat.Handle(goctx, ChatMessage{Channel: "ops", Text: "deploy api --env=prod"}),
+		ChatReply{Text: "```\ndeploying api to prod\n```"})
+	assert.Equal(t, chat.Handle(goctx, ChatMessage{Channel: "random", Text: "deploy api"}),
+		ChatReply{Text: "permission denied: deploy is only allowed in #ops", Private: true})
+	assert.Equal(t, chat.Handle(goctx, ChatMessage{Channel: "ops", Text: "deploy"}),
+		ChatReply{Text: "service required", Private: true})
+	assert.Equal(t, chat.Handle(goctx, ChatMessage{Text: `deploy "api`}).Private, true)
+	assert.Equal(t, chat.Handle(goctx, ChatMessage{Text: "ping"}), ChatReply{})
+
+	// DefaultCommand resolved by running is authorized
+	root.DefaultCommand = "deploy"
+	assert.Equal(t, chat.Handle(goctx, ChatMessage{Channel: "random", Text: "--env=prod"}),
+		ChatReply{Text: "permission denied: deploy is only allowed in #ops", Private: true})
+	root.DefaultCommand = ""
+
+	form := url.Values{"channel_name": {"ops"}, "user_name": {"jack"}, "text": {"deploy web"}}
+	newRequest := func(form url.Values) *http.Request {
+		req := httptest.NewRequest("POST", "/slack", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req
+	}
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		chat.ServeHTTP(resp, req)
+		return resp
+	}
+
+	// unverified requests are rejected
+	assert.Equal(t, serve(newRequest(form)).Code, http.StatusUnauthorized)
+
+	// signed by Slack
+	chat.SigningSecret = "secret"
+	sign := func(req *http.Request, timestamp time.Time) {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", slackSignature("secret", ts, []byte(form.Encode())))
+	}
+	req := newRequest(form)
+	sign(req, time.Now())
+	resp := serve(req)
+	assert.Equal(t, resp.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, resp.Body.String(), `{"response_type":"in_channel","text":"`+"```"+`\ndeploying web to dev\n`+"```"+`"}`+"\n")
+
+	req = newRequest(form)
+	sign(req, time.Now().Add(-time.Hour))
+	assert.Equal(t, serve(req).Code, http.StatusUnauthorized)
+	req = newRequest(form)
+	sign(req, time.Now())
+	req.Header.Set("X-Slack-Signature", "v0=00")
+	assert.Equal(t, serve(req).Code, http.StatusUnauthorized)
+
+	// token of Mattermost
+	chat.SigningSecret, chat.Token = "", "token"
+	form.Set("token", "token")
+	assert.Equal(t, serve(newRequest(form)).Code, http.StatusOK)
+	form.Set("token", "forged")
+	assert.Equal(t, serve(newRequest(form)).Code, http.StatusUnauthorized)
+}
@@ -10,6 +10,9 @@ import (
 	"github.com/labstack/gommon/color"
 )
 
+// Version is version of cli package
+const Version = "0.0.2"
+
 // Run runs a single command app
 func Run(argv interface{}, fn CommandFunc, descs ...string) {
 	RunWithArgs(argv, os.Args, fn, descs...)
@@ -59,7 +62,10 @@ func parseArgv(args []string, argv interface{}, clr color.Color) *flagSet {
 }
 
 func parseArgvList(args []string, argvList []interface{}, clr color.Color) *flagSet {
-	flagSet := newFlagSet()
+	return parseArgvListWithFlagSet(newFlagSet(), args, argvList, clr)
+}
+
+func parseArgvListWithFlagSet(flagSet *flagSet, args []string, argvList []interface{}, clr color.Color) *flagSet {
 	for _, argv := range argvList {
 		if argv == nil {
 			continue
@@ -87,9 +93,31 @@ func parseArgvList(args []string, argvList []interface{}, clr color.Color) *flag
 	return flagSet
 }
 
-func usage(argvList []interface{}, clr color.Color, style UsageStyle) string {
+// parseArgvListLeniently parses args into argvList, unknown flags are ignored
+func parseArgvListLeniently(args []string, argvList []interface{}, clr color.Color) *flagSet {
 	flagSet := newFlagSet()
+	flagSet.allowUnknown = true
+	flagSet.noPrompt = true
+	return parseArgvListWithFlagSet(flagSet, args, argvList, clr)
+}
+
+func usage(argvList []interface{}, clr color.Color, style UsageStyle) string {
+	theme := DefaultTheme()
+	return usageWithWidth(argvList, clr, &theme, style, 0)
+}
+
+// usageWithWidth is similar to usage, usages of flags are wrapped to fit width if width > 0
+// and colored by theme
+func usageWithWidth(argvList []interface{}, clr color.Color, theme *Theme, style UsageStyle, width int) string {
 	buf := bytes.NewBufferString("")
+	buf.WriteString(visibleFlags(argvList, clr).styledString(clr, theme, style, width))
+	return buf.String()
+}
+
+// visibleFlags returns flags of argvList supported on current platform, flags of
+// ancestors are listed first
+func visibleFlags(argvList []interface{}, clr color.Color) flagSlice {
+	flagSet := newFlagSet()
 	for i := len(argvList) - 1; i >= 0; i-- {
 		v := argvList[i]
 		if v == nil {
@@ -104,12 +132,17 @@ func usage(argvList []interface{}, clr color.Color, style UsageStyle) string {
 			// initialize flagSet
 			initFlagSet(typ, val, flagSet, clr, true)
 			if flagSet.err != nil {
-				return ""
+				return nil
 			}
 		}
 	}
-	buf.WriteString(flagSlice(flagSet.flagSlice).StringWithStyle(clr, style))
-	return buf.String()
+	visible := flagSlice{}
+	for _, fl := range flagSet.flagSlice {
+		if fl.tag.unsupported == "" {
+			visible = append(visible, fl)
+		}
+	}
+	return visible
 }
 
 func initFlagSet(typ reflect.Type, val reflect.Value, flagSet *flagSet, clr color.Color, dontSetValue bool) {
@@ -145,7 +178,7 @@ func initFlagSet(typ reflect.Type, val reflect.Value, flagSet *flagSet, clr colo
 			}
 			continue
 		}
-		fl, err := newFlag(typField, valField, tag, clr, dontSetValue)
+		fl, err := newFlag(typField, valField, tag, clr, dontSetValue || !flagSet.hasSource(SourceDefault))
 		if flagSet.err = err; err != nil {
 			return
 		}
@@ -166,11 +199,11 @@ func initFlagSet(typ reflect.Type, val reflect.Value, flagSet *flagSet, clr colo
 			if encoder, ok := intf.(Encoder); ok {
 				value = encoder.Encode()
 			} else {
-				value = fmt.Sprintf("%v", intf)
+				value = fl.valueString()
 			}
 		}
 
-		names := append(fl.tag.shortNames, fl.tag.longNames...)
+		names := append(append(fl.tag.shortNames, fl.tag.longNames...), fl.tag.aliases...)
 		for i, name := range names {
 			if _, ok := flagSet.flagMap[name]; ok {
 				flagSet.err = fmt.Errorf("option %s repeated", clr.Bold(name))
@@ -192,6 +225,11 @@ func parseArgsToFlagSet(args []string, flagSet *flagSet, clr color.Color) {
 	for i := 0; i < size; i++ {
 		arg := args[i]
 		if !strings.HasPrefix(arg, dashOne) {
+			if flagSet.noInterspersed {
+				// terminate the flag parse while occur first free argument
+				flagSet.args = append(flagSet.args, args[i:]...)
+				break
+			}
 			// append a free argument
 			flagSet.args = append(flagSet.args, arg)
 			continue
@@ -209,13 +247,16 @@ func parseArgsToFlagSet(args []string, flagSet *flagSet, clr color.Color) {
 		}
 
 		if arg == dashOne {
-			flagSet.err = fmt.Errorf("unexpected single dash")
-			return
+			if flagSet.fail(fmt.Errorf("unexpected single dash")) {
+				return
+			}
+			continue
 		}
 
 		// terminate the flag parse while occur `--`
 		if arg == dashTwo {
 			flagSet.args = append(flagSet.args, args[i+1:]...)
+			flagSet.tailArgs = args[i+1:]
 			break
 		}
 
@@ -232,7 +273,7 @@ func parseArgsToFlagSet(args []string, flagSet *flagSet, clr color.Color) {
 		// found in flagMap
 		if ok {
 			retOffset := parseToFoundFlag(flagSet, fl, strs, arg, next, offset, clr)
-			if flagSet.err != nil {
+			if flagSet.fail(flagSet.err) {
 				return
 			}
 			i += retOffset
@@ -242,44 +283,74 @@ func parseArgsToFlagSet(args []string, flagSet *flagSet, clr color.Color) {
 		// not found in flagMap
 		// it's an invalid flag if arg has prefix `--`
 		if strings.HasPrefix(arg, dashTwo) {
-			flagSet.err = fmt.Errorf("undefined option %s", clr.Bold(arg))
-			return
+			if flagSet.allowUnknown {
+				flagSet.unknownFlags = append(flagSet.unknownFlags, args[i])
+				continue
+			}
+			if flagSet.fail(flagSet.undefinedOption(arg, nil, clr)) {
+				return
+			}
+			continue
 		}
 
 		// try parse `-F<value>`
 		if _, ok := parseSiameseFlag(flagSet, arg[0:2], args[i][2:], clr); ok {
 			continue
 		} else if flagSet.err != nil {
-			return
+			if flagSet.fail(flagSet.err) {
+				return
+			}
+			continue
 		}
 
 		// other cases, find flag char by char
 		arg = strings.TrimPrefix(arg, dashOne)
-		parseFlagCharByChar(flagSet, arg, clr)
-		if flagSet.err != nil {
+		if flagSet.allowUnknown && !flagSet.isFoldFlags(arg) {
+			flagSet.unknownFlags = append(flagSet.unknownFlags, args[i])
+			continue
+		}
+		retOffset := parseFlagCharByChar(flagSet, arg, strs, next, offset, clr)
+		if flagSet.fail(flagSet.err) {
 			return
 		}
+		i += retOffset
 		continue
 	}
 
+	// read env and config file
+	if len(flagSet.errs) == 0 {
+		if flagSet.fail(flagSet.readSources(clr)) {
+			return
+		}
+	}
+
 	// read delay flags
+	numErrs := len(flagSet.errs)
 	for _, fl := range flagSet.flagSlice {
 		if fl.isNeedDelaySet && fl.isAssigned {
 			err := setWithProperType(fl, fl.field.Type, fl.value, fl.lastValue, clr, false)
-			if flagSet.err == nil && err != nil {
-				flagSet.err = err
+			if err != nil && (flagSet.err == nil || flagSet.allErrors) {
+				flagSet.fail(throwParameterInvalid(clr.Bold(fl.name()), err))
+			} else if err == nil {
+				flagSet.values[fl.valueKey()] = []string{fl.valueString()}
 			}
 		}
 		if fl.tag.isForce && fl.getBool() {
 			flagSet.hasForce = true
 		}
 	}
+	if flagSet.hasForce {
+		flagSet.err = nil
+		flagSet.errs = flagSet.errs[:numErrs]
+		flagSet.joinErrors()
+		return
+	}
+	if flagSet.err != nil {
+		return
+	}
 
 	// read prompt flags
-	if !flagSet.hasForce {
-		if flagSet.err != nil {
-			return
-		}
+	if len(flagSet.errs) == 0 && !flagSet.noPrompt {
 		flagSet.readPrompt(os.Stdout, clr)
 		if flagSet.err != nil {
 			return
@@ -288,22 +359,25 @@ func parseArgsToFlagSet(args []string, flagSet *flagSet, clr color.Color) {
 		if flagSet.err != nil {
 			return
 		}
-	} else {
-		flagSet.err = nil
 	}
 
 	buff := bytes.NewBufferString("")
 	for _, fl := range flagSet.flagSlice {
-		if !fl.isAssigned && fl.tag.isRequired {
+		if !fl.isAssigned && fl.tag.isRequired && fl.tag.unsupported == "" {
+			if flagSet.allErrors {
+				flagSet.fail(fmt.Errorf(tr(MsgRequiredMissing), clr.Bold(fl.name())))
+				continue
+			}
 			if buff.Len() > 0 {
 				buff.WriteByte('\n')
 			}
-			fmt.Fprintf(buff, "required parameter %s missing", clr.Bold(fl.name()))
+			fmt.Fprintf(buff, tr(MsgRequiredMissing), clr.Bold(fl.name()))
 		}
 	}
-	if buff.Len() > 0 && !flagSet.hasForce {
+	if buff.Len() > 0 {
 		flagSet.err = fmt.Errorf(buff.String())
 	}
+	flagSet.joinErrors()
 }
 
 func parseToFoundFlag(flagSet *flagSet, fl *flag, strs []string, arg, next string, offset int, clr color.Color) int {
@@ -324,37 +398,56 @@ func parseToFoundFlag(flagSet *flagSet, fl *flag, strs []string, arg, next strin
 	} else if l == 2 {
 		flagSet.err = fl.set(arg, strs[1], clr)
 	} else {
-		flagSet.err = fmt.Errorf("too many(%d) arguments", l)
+		flagSet.err = fmt.Errorf(tr(MsgTooManyArguments), l)
 	}
 	if flagSet.err != nil {
-		flagSet.err = fmt.Errorf("parameter %s invalid: %v", clr.Bold(arg), flagSet.err)
+		flagSet.err = throwParameterInvalid(clr.Bold(arg), flagSet.err)
 		return retOffset
 	}
-	flagSet.values[arg] = []string{fmt.Sprintf("%v", fl.value.Interface())}
+	flagSet.values[arg] = []string{fl.valueString()}
 	return retOffset
 }
 
-func parseFlagCharByChar(flagSet *flagSet, arg string, clr color.Color) {
-	// NOTE: each fold flag should be boolean
+// parseFlagCharByChar parses fold flags like getopt, e.g. `-abc` => `-a -b -c`.
+// The first non-boolean flag takes rest of chars as its value, e.g. `-abn5` => `-a -b -n 5`,
+// or takes next argument if it's the last one, e.g. `-abn 5` => `-a -b -n 5`.
+// It returns offset of consumed arguments.
+func parseFlagCharByChar(flagSet *flagSet, arg string, strs []string, next string, offset int, clr color.Color) int {
 	chars := []byte(arg)
-	for _, c := range chars {
+	for i, c := range chars {
 		tmp := dashOne + string([]byte{c})
 		fl, ok := flagSet.flagMap[tmp]
 		if !ok {
-			flagSet.err = fmt.Errorf("undefined option %s", clr.Bold(tmp))
-			return
+			var extra []string
+			// maybe user wants a long flag, e.g. `-port` => `--port`
+			if _, ok := flagSet.flagMap[dashTwo+arg]; ok && len(arg) > 1 {
+				extra = append(extra, dashTwo+arg)
+			}
+			flagSet.err = flagSet.undefinedOption(tmp, extra, clr)
+			return 0
 		}
 
-		if fl.isBoolean() {
-			fl.set(tmp, "true", clr)
-			flagSet.values[tmp] = []string{"true"}
-		} else if fl.isCounter() {
-			fl.counterIncr("", clr)
-		} else {
-			flagSet.err = fmt.Errorf("each fold option should be boolean, but %s not", clr.Bold(tmp))
-			return
+		isLast := i+1 == len(chars)
+		takesValue := !fl.isBoolean() && !fl.isCounter()
+		switch {
+		case isLast && len(strs) == 2:
+			// `-ab=value` => `-a -b=value`
+			return parseToFoundFlag(flagSet, fl, []string{tmp, strs[1]}, tmp, next, offset, clr)
+		case takesValue && !isLast:
+			value := arg[i+1:]
+			if len(strs) == 2 {
+				value += "=" + strs[1]
+			}
+			return parseToFoundFlag(flagSet, fl, []string{tmp, value}, tmp, next, offset, clr)
+		case takesValue:
+			return parseToFoundFlag(flagSet, fl, []string{tmp}, tmp, next, offset, clr)
+		}
+		parseToFoundFlag(flagSet, fl, []string{tmp}, tmp, next, 0, clr)
+		if flagSet.err != nil {
+			return 0
 		}
 	}
+	return 0
 }
 
 func parseSiameseFlag(flagSet *flagSet, firstHalf, latterHalf string, clr color.Color) (*flag, bool) {
@@ -367,6 +460,7 @@ func parseSiameseFlag(flagSet *flagSet, firstHalf, latterHalf string, clr color.
 		if flagSet.err = fl.set(key, val, clr); flagSet.err != nil {
 			return fl, false
 		}
+		flagSet.values[key] = []string{fl.valueString()}
 		return fl, true
 	}
 	return nil, false
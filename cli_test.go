@@ -7,6 +7,7 @@ import (
 	"math"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/labstack/gommon/color"
@@ -301,7 +302,7 @@ func TestParse(t *testing.T) {
 			args:  []string{"--required=0", "--f64=not-a-float64"},
 			isErr: true,
 		},
-		//Case: fold flag not boolean
+		//Case: fold flag ends with non-boolean flag but missing value
 		{
 			args:  []string{"--required=0", "-2y"},
 			isErr: true,
@@ -679,11 +680,79 @@ func TestIsSet(t *testing.T) {
 			assert.Equal(t, ctx.IsSet("-a"), tt.isSetA, "case %d", i)
 			assert.Equal(t, ctx.IsSet("-a", "--aa"), tt.isSetA, "case %d", i)
 			assert.Equal(t, ctx.IsSet("-b"), tt.isSetB, "case %d", i)
+			assert.Equal(t, ctx.IsSet("aa"), tt.isSetA, "case %d", i)
+			assert.Equal(t, ctx.IsSet("b"), tt.isSetB, "case %d", i)
 			return nil
 		})
 	}
 }
 
+func TestShortFlagCluster(t *testing.T) {
+	type argT struct {
+		A bool    `cli:"a"`
+		B bool    `cli:"b"`
+		V Counter `cli:"v"`
+		N int     `cli:"n"`
+		S string  `cli:"s"`
+	}
+	for i, tt := range []struct {
+		args []string
+		want argT
+		rest []string
+	}{
+		{args: []string{"-abc"}, want: argT{}},
+		{args: []string{"-ab"}, want: argT{A: true, B: true}},
+		{args: []string{"-n5"}, want: argT{N: 5}},
+		{args: []string{"-abn5"}, want: argT{A: true, B: true, N: 5}},
+		{args: []string{"-abn", "5", "x"}, want: argT{A: true, B: true, N: 5}, rest: []string{"x"}},
+		{args: []string{"-vvn=5"}, want: argT{V: Counter{2}, N: 5}},
+		{args: []string{"-asn5"}, want: argT{A: true, S: "n5"}},
+		{args: []string{"-as=x=y"}, want: argT{A: true, S: "x=y"}},
+		{args: []string{"-asx=y"}, want: argT{A: true, S: "x=y"}},
+	} {
+		argv := new(argT)
+		flagSet := parseArgv(tt.args, argv, color.Color{})
+		if i == 0 {
+			assert.Error(t, flagSet.err, "case %d", i)
+			continue
+		}
+		if assert.Nil(t, flagSet.err, "case %d", i) {
+			assert.Equal(t, *argv, tt.want, "case %d", i)
+			assert.Equal(t, strings.Join(flagSet.args, " "), strings.Join(tt.rest, " "), "case %d", i)
+		}
+	}
+}
+
+func TestPointerField(t *testing.T) {
+	type argT struct {
+		S *string `cli:"s"`
+		I *int    `cli:"i"`
+		B *bool   `cli:"b"`
+		D *int    `cli:"d" dft:"8"`
+	}
+	clr := color.Color{}
+
+	v := new(argT)
+	flagSet := parseArgv([]string{}, v, clr)
+	require.Nil(t, flagSet.err)
+	assert.Nil(t, v.S)
+	assert.Nil(t, v.I)
+	assert.Nil(t, v.B)
+	require.NotNil(t, v.D)
+	assert.Equal(t, *v.D, 8)
+
+	v = new(argT)
+	flagSet = parseArgv([]string{"-s", "", "-i=0", "-b"}, v, clr)
+	require.Nil(t, flagSet.err)
+	require.NotNil(t, v.S)
+	require.NotNil(t, v.I)
+	require.NotNil(t, v.B)
+	assert.Equal(t, *v.S, "")
+	assert.Equal(t, *v.I, 0)
+	assert.Equal(t, *v.B, true)
+	assert.Equal(t, flagSet.values.Get("-i"), "0")
+}
+
 func TestHelpCommand(t *testing.T) {
 	w := bytes.NewBufferString("")
 	root := &Command{Name: "root"}
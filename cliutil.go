@@ -10,26 +10,23 @@ import (
 	"strings"
 
 	"github.com/labstack/gommon/color"
-	"github.com/mattn/go-isatty"
 )
 
-func colorSwitch(clr *color.Color, w io.Writer, fds ...uintptr) {
-	clr.Disable()
-	if len(fds) > 0 {
-		if isatty.IsTerminal(fds[0]) {
-			clr.Enable()
-		}
-	} else if w, ok := w.(*os.File); ok && isatty.IsTerminal(w.Fd()) {
-		clr.Enable()
-	}
+// colorEnabled reports whether clr outputs escape sequences
+func colorEnabled(clr color.Color) bool {
+	return clr.Bold("") != ""
 }
 
-// HelpCommandFn implements buildin help command function
+// HelpCommandFn implements buildin help command function,
+// `help --search <query>` searches whole command tree, see Command.Search
 func HelpCommandFn(ctx *Context) error {
 	var (
 		args   = ctx.NativeArgs()
 		parent = ctx.Command().Parent()
 	)
+	if query, ok := searchQuery(args); ok {
+		return writeSearchResults(ctx, query)
+	}
 	if len(args) == 0 {
 		ctx.String(parent.Usage(ctx))
 		return nil
@@ -39,7 +36,7 @@ func HelpCommandFn(ctx *Context) error {
 		clr   = ctx.Color()
 	)
 	if child == nil {
-		return fmt.Errorf("command %s not found", clr.Yellow(strings.Join(args, " ")))
+		return fmt.Errorf(tr(MsgCommandNotFound), parent.theme().Command(clr, strings.Join(args, " ")))
 	}
 	ctx.String(child.Usage(ctx))
 	return nil
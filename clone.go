@@ -0,0 +1,114 @@
+package cli
+
+import "sync/atomic"
+
+// Clone returns an independent deep copy of cmd and its descendants, the copy
+// is detached from parent of cmd. Slices, config file options, suggestion options,
+// theme and routers of copies can be changed without affecting the original tree,
+// e.g. hiding commands or replacing Argv to change defaults of flags. Functions and
+// Telemetry are shared.
+func (cmd *Command) Clone() *Command {
+	c := &Command{
+		Name:              cmd.Name,
+		Aliases:           cloneStrings(cmd.Aliases),
+		Desc:              cmd.Desc,
+		Descs:             cloneStringMap(cmd.Descs),
+		Text:              cmd.Text,
+		Examples:          append([]Example(nil), cmd.Examples...),
+		CanSubRoute:       cmd.CanSubRoute,
+		NoHook:            cmd.NoHook,
+		NoHTTP:            cmd.NoHTTP,
+		Global:            cmd.Global,
+		Hidden:            cmd.Hidden,
+		Deprecated:        cmd.Deprecated,
+		AllErrors:         cmd.AllErrors,
+		OS:                cloneStrings(cmd.OS),
+		Arch:              cloneStrings(cmd.Arch),
+		NoInterspersed:    cmd.NoInterspersed,
+		Passthrough:       cmd.Passthrough,
+		AllowUnknownFlags: cmd.AllowUnknownFlags,
+		DotEnv:            cmd.DotEnv,
+		Telemetry:         cmd.Telemetry,
+		EnvelopeVersion:   cmd.EnvelopeVersion,
+		OutputSchema:      cmd.OutputSchema,
+		CancelOnInterrupt: cmd.CancelOnInterrupt,
+		Timeout:           cmd.Timeout,
+		DefaultCommand:    cmd.DefaultCommand,
+		PrefixMatching:    cmd.PrefixMatching,
+		PathPlugins:       cmd.PathPlugins,
+		IgnoreCase:        cmd.IgnoreCase,
+
+		Fn:          cmd.Fn,
+		UsageFn:     cmd.UsageFn,
+		Argv:        cmd.Argv,
+		Argvs:       append([]ArgvFunc(nil), cmd.Argvs...),
+		ArgvContext: cmd.ArgvContext,
+		NumArg:      cmd.NumArg,
+		NumOption:   cmd.NumOption,
+
+		HTTPRouters: cloneStrings(cmd.HTTPRouters),
+		HTTPMethods: cloneStrings(cmd.HTTPMethods),
+
+		HTTPMiddlewares: append([]Middleware(nil), cmd.HTTPMiddlewares...),
+
+		OnBefore:           cmd.OnBefore,
+		OnAfter:            cmd.OnAfter,
+		OnRootPrepareError: cmd.OnRootPrepareError,
+		OnRootBefore:       cmd.OnRootBefore,
+		OnRootAfter:        cmd.OnRootAfter,
+		OnNotFound:         cmd.OnNotFound,
+
+		isServer: atomic.LoadInt32(&cmd.isServer),
+	}
+	cmd.locker.Lock()
+	c.usageTemplate = cmd.usageTemplate
+	cmd.locker.Unlock()
+	if cmd.ConfigFile != nil {
+		c.ConfigFile = &ConfigFile{Flag: cmd.ConfigFile.Flag, Paths: cloneStrings(cmd.ConfigFile.Paths)}
+	}
+	if cmd.Suggest != nil {
+		suggest := *cmd.Suggest
+		suggest.Matchers = append([]SuggestMatcher(nil), cmd.Suggest.Matchers...)
+		c.Suggest = &suggest
+	}
+	if cmd.Theme != nil {
+		theme := *cmd.Theme
+		c.Theme = &theme
+	}
+	if cmd.Sources != nil {
+		c.Sources = append([]Source{}, cmd.Sources...)
+	}
+	if routersMap := cmd.getRoutersMap(); routersMap != nil {
+		c.routersMap = make(map[string]string, len(routersMap))
+		for router, path := range routersMap {
+			c.routersMap[router] = path
+		}
+	}
+	children := cmd.getChildren()
+	copies := make([]*Command, 0, len(children))
+	for _, child := range children {
+		childCopy := child.Clone()
+		childCopy.parent = c
+		copies = append(copies, childCopy)
+	}
+	c.setChildren(copies)
+	return c
+}
+
+func cloneStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneStrings(list []string) []string {
+	if list == nil {
+		return nil
+	}
+	return append([]string{}, list...)
+}
@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClone(t *testing.T) {
+	type argT struct {
+		Name string `cli:"name" dft:"world"`
+	}
+	hello := func(ctx *Context) error {
+		ctx.String("hello %s", ctx.Argv().(*argT).Name)
+		return nil
+	}
+	root := &Command{Name: "app", ConfigFile: &ConfigFile{Paths: []string{"a.json"}}}
+	sub := root.Register(&Command{Name: "sub", Aliases: []string{"s"}})
+	sub.Register(&Command{Name: "hello", Argv: func() interface{} { return new(argT) }, Fn: hello})
+
+	c := root.Clone()
+	assert.Nil(t, c.Parent())
+	assert.Equal(t, c.ListChildren(), root.ListChildren())
+	csub := c.Route([]string{"s"})
+	require.NotNil(t, csub)
+	assert.True(t, csub != sub)
+	assert.True(t, csub.Parent() == c)
+	assert.Equal(t, csub.Path(), "sub")
+
+	// customize cloned tree
+	csub.Aliases[0] = "x"
+	c.ConfigFile.Paths[0] = "b.json"
+	chello := c.Route([]string{"sub", "hello"})
+	chello.Argv = func() interface{} { return &argT{Name: "tenant"} }
+	csub.Register(&Command{Name: "bye", Fn: donothing})
+	csub.Hidden = true
+
+	assert.Equal(t, sub.Aliases, []string{"s"})
+	assert.Equal(t, root.ConfigFile.Paths, []string{"a.json"})
+	assert.Nil(t, root.Route([]string{"sub", "bye"}))
+	assert.False(t, sub.Hidden)
+
+	w := new(bytes.Buffer)
+	require.Nil(t, root.RunWith([]string{"sub", "hello"}, w, nil))
+	assert.Equal(t, w.String(), "hello world")
+	w.Reset()
+	require.Nil(t, c.RunWith([]string{"sub", "hello"}, w, nil))
+	assert.Equal(t, w.String(), "hello tenant")
+}
+
+func TestCloneFields(t *testing.T) {
+	// shared fields are copied by assignment, other references must not be shared
+	shared := map[string]bool{"Telemetry": true, "OutputSchema": true}
+	// unexported fields are states of the tree, copied by Clone or initialized lazily
+	states := map[string]bool{
+		"routersLocker": true, "routersMap": true, "parent": true, "registerLocker": true,
+		"childrenLocker": true, "children": true, "childrenMap": true, "childrenFoldMap": true,
+		"isServer": true, "dotEnvOnce": true, "dotEnvErr": true, "locker": true,
+		"usages": true, "usagesVersion": true, "usageTemplate": true,
+	}
+
+	// fill all exported fields, Clone must copy all of them
+	cmd := &Command{}
+	v := reflect.ValueOf(cmd).Elem()
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field, fv := typ.Field(i), v.Field(i)
+		if field.PkgPath != "" {
+			assert.True(t, states[field.Name], "unexported field %s of Command not handled by Clone", field.Name)
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.String:
+			fv.SetString("x")
+		case reflect.Bool:
+			fv.SetBool(true)
+		case reflect.Int, reflect.Int64:
+			fv.SetInt(1)
+		case reflect.Slice:
+			fv.Set(reflect.MakeSlice(fv.Type(), 1, 1))
+		case reflect.Map:
+			fv.Set(reflect.MakeMap(fv.Type()))
+		case reflect.Ptr:
+			fv.Set(reflect.New(fv.Type().Elem()))
+		case reflect.Func:
+			fv.Set(reflect.MakeFunc(fv.Type(), func([]reflect.Value) []reflect.Value { return nil }))
+		case reflect.Interface:
+			if fv.NumMethod() == 0 {
+				fv.Set(reflect.ValueOf("x"))
+			}
+		default:
+			t.Fatalf("field %s of Command with kind %s not handled by test", field.Name, fv.Kind())
+		}
+	}
+
+	cv := reflect.ValueOf(cmd.Clone()).Elem()
+	for i := 0; i < typ.NumField(); i++ {
+		field, fv, cfv := typ.Field(i), v.Field(i), cv.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		switch fv.Kind() {
+		case reflect.Func:
+			assert.Equal(t, cfv.Pointer(), fv.Pointer(), "field %s", field.Name)
+		case reflect.Slice, reflect.Map, reflect.Ptr:
+			assert.False(t, cfv.IsNil(), "field %s not copied", field.Name)
+			if !shared[field.Name] {
+				assert.NotEqual(t, cfv.Pointer(), fv.Pointer(), "field %s shared by copy", field.Name)
+			}
+			assert.True(t, reflect.DeepEqual(cfv.Interface(), fv.Interface()), "field %s", field.Name)
+		default:
+			assert.True(t, reflect.DeepEqual(cfv.Interface(), fv.Interface()), "field %s not copied", field.Name)
+		}
+	}
+}
@@ -4,16 +4,18 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
+	"reflect"
 	"regexp"
-	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/labstack/gommon/color"
-	"github.com/mattn/go-colorable"
-	"github.com/mkideal/pkg/debug"
 )
 
 var commandNameRegexp = regexp.MustCompile("^[a-zA-Z_0-9][a-zA-Z_\\-0-9]*$")
@@ -30,6 +32,10 @@ type (
 	// ArgvFunc ...
 	ArgvFunc func() interface{}
 
+	// ArgvContextFunc represents argument factory function which receives Context,
+	// see Command.ArgvContext
+	ArgvContextFunc func(*Context) interface{}
+
 	// NumCheckFunc represents function type which used to check num of args
 	NumCheckFunc func(n int) bool
 
@@ -49,10 +55,101 @@ type (
 		Desc    string   // Command abstract
 		Text    string   // Command detail description
 
+		// Descs are Desc in other languages keyed by language tags, e.g. zh-CN, see SetLanguage
+		Descs map[string]string
+
+		// Examples are listed in usage and verified by VerifyExamples
+		Examples []Example
+
 		CanSubRoute bool
 		NoHook      bool
 		NoHTTP      bool
 		Global      bool
+		Hidden      bool // Hidden command is not listed in usage and suggestions
+		AllErrors   bool // Reports all parse errors at once instead of the first one
+
+		// Deprecated marks command deprecated with a hint, e.g. "use `app user add` instead",
+		// a warning is recorded when the command runs
+		Deprecated string
+
+		// OS and Arch restrict command to listed GOOS and GOARCH, e.g. []string{"linux", "darwin"},
+		// the command is hidden on other platforms. Empty means all platforms.
+		OS   []string
+		Arch []string
+
+		// NoInterspersed stops parsing flags at the first free argument,
+		// following arguments are all free arguments, e.g. `app exec ls -l`.
+		// Words after the command which aren't its children are free arguments too.
+		NoInterspersed bool
+
+		// Passthrough delivers all arguments after command name verbatim as NativeArgs
+		// and Args without parsing flags, including `--help` and `--`. Sub commands of
+		// a passthrough command are not routed. It's useful while wrapping tools
+		// with conflicting flag syntaxes, e.g. `app kubectl get pods -o wide`.
+		Passthrough bool
+
+		// AllowUnknownFlags collects undefined flags into Context.UnknownFlags instead of failing,
+		// it's useful while proxying args to another program
+		AllowUnknownFlags bool
+
+		// ConfigFile loads values of flags from config file, config file of root
+		// command is used if nil. Keys of config file inherited from root which
+		// aren't defined by the command are ignored, so the file can be shared
+		// by all commands.
+		ConfigFile *ConfigFile
+
+		// Sources orders and selects value sources, only used by root command.
+		// See Sources function.
+		Sources []Source
+
+		// DotEnv is path of `.env` file loaded once before resolving env variables of flags
+		// by the first invocation, only used by root command. Missing file is ignored.
+		DotEnv string
+
+		// Telemetry records invocations locally if not nil, only used by root command.
+		// See StatsCommand.
+		Telemetry TelemetryStore
+
+		// EnvelopeVersion wraps JSON output of Context.Render in an Envelope
+		// with the api version if not empty, only used by root command
+		EnvelopeVersion string
+
+		// OutputSchema is a value of type of objects passed to Context.Render,
+		// objects are validated against it if ValidateOutput is true
+		OutputSchema interface{}
+
+		// CancelOnInterrupt cancels Context.Context on the first interrupt signal
+		// instead of killing the process, so the command can stop gracefully.
+		// The next interrupt kills the process. Only used by root command.
+		CancelOnInterrupt bool
+
+		// Timeout cancels Context.Context if command runs longer than it, a distinct error
+		// reported by IsTimeout is returned. Zero means no timeout. It's overridden by
+		// argv which implements Timeouter, see TimeoutHelper.
+		Timeout time.Duration
+
+		// DefaultCommand is name of child which runs if command without Fn is called
+		// without subcommands, e.g. `app` and `app --json` run `app serve` if DefaultCommand
+		// of root is "serve"
+		DefaultCommand string
+
+		// PrefixMatching routes an unambiguous prefix of command name or alias to the
+		// command, e.g. `app stat` runs `app status` if no other child starts with `stat`.
+		// An error listing candidates is returned for ambiguous prefixes. Hidden commands
+		// are never matched by prefix. Only used by root command.
+		PrefixMatching bool
+
+		// IgnoreCase matches names and aliases of commands case-insensitively while routing,
+		// e.g. `app LIST` runs `app list`. Exact names win. Only used by root command.
+		IgnoreCase bool
+
+		// Suggest configures suggestions of commands not found, only used by root command.
+		// Commands are matched by edit distance if nil.
+		Suggest *SuggestOptions
+
+		// Theme colors usages and errors, only used by root command. DefaultTheme used if nil.
+		// Cached usages are rendered again after InvalidateUsage called.
+		Theme *Theme
 
 		// functions
 		Fn        CommandFunc // Command handler
@@ -61,9 +158,28 @@ type (
 		NumArg    NumCheckFunc
 		NumOption NumCheckFunc
 
+		// Argvs are additional argument factories whose flags are merged with flags of
+		// Argv, shared option sets could be mixed into commands, e.g.
+		//
+		//	Argvs: []cli.ArgvFunc{newConnectionT, newPaginationT},
+		//
+		// Objects created are returned by Context.Argvs in order. Argvs of Global
+		// command are merged into descendants like Argv.
+		Argvs []ArgvFunc
+
+		// ArgvContext is used instead of Argv if not nil, argv could depend on environment
+		// or global flags of ancestors, e.g. default namespace from a root flag.
+		// Global argvs of ancestors in ctx are parsed from args leniently: unknown flags
+		// are ignored and errors are not reported. Args are empty while rendering usages.
+		ArgvContext ArgvContextFunc
+
 		HTTPRouters []string
 		HTTPMethods []string
 
+		// HTTPMiddlewares wrap handler returned by HTTPHandler, the first one is
+		// the outermost, only used by root command
+		HTTPMiddlewares []Middleware
+
 		// hooks for current command
 		OnBefore func(*Context) error
 		OnAfter  func(*Context) error
@@ -73,16 +189,49 @@ type (
 		OnRootBefore       func(*Context) error
 		OnRootAfter        func(*Context) error
 
-		routersMap map[string]string
+		// PathPlugins runs executables named `<root>-<name>` found in $PATH for commands
+		// not found, e.g. `app foo bar` runs `app-foo bar` if `foo` is not a child of root,
+		// plugins are listed in usage of root. Plugins are never run or listed for commands
+		// invoked by remote clients, e.g. requests of HTTP, WebSocket and ChatOps.
+		// Only used by root command. See DiscoverPathPlugins.
+		PathPlugins bool
+
+		// OnNotFound handles commands not found instead of failing if current command is root
+		// command, e.g. dispatching to external executables. Context.Command is the deepest
+		// matched command, path is the command line not found and Context.NativeArgs holds
+		// arguments after matched commands, flags are not parsed. Hooks are not called.
+		OnNotFound func(ctx *Context, path string) error
+
+		routersLocker sync.RWMutex // protect routersMap, routersMap is replaced instead of modified
+		routersMap    map[string]string
+
+		parent *Command
+
+		registerLocker sync.Mutex // serializes changes of children
+
+		childrenLocker  sync.RWMutex // protect children, childrenMap and childrenFoldMap, all are replaced instead of modified
+		children        []*Command
+		childrenMap     map[string]*Command // children indexed by names and aliases
+		childrenFoldMap map[string]*Command // children indexed by lower case names and aliases
 
-		parent   *Command
-		children []*Command
+		isServer int32 // accessed atomically
 
-		isServer bool
+		dotEnvOnce sync.Once // loads DotEnv
+		dotEnvErr  error
 
-		locker     sync.Mutex // protect following data
-		usage      string
-		usageStyle UsageStyle
+		locker        sync.Mutex // protect following data
+		usages        map[usageKey]string
+		usagesVersion int // increased while cached usages invalidated
+		usageTemplate *template.Template
+	}
+
+	// usageKey is key of cached usages, usage is rendered by style, width and language
+	// with or without color
+	usageKey struct {
+		style    UsageStyle
+		color    bool
+		width    int
+		language string
 	}
 
 	// CommandTree represents a tree of commands
@@ -92,34 +241,194 @@ type (
 	}
 )
 
-// Register registers a child command
+// Register registers a child command, it panics if child can't be registered, see TryRegister
 func (cmd *Command) Register(child *Command) *Command {
+	if err := cmd.TryRegister(child); err != nil {
+		panicf("%v", err)
+	}
+	return child
+}
+
+// TryRegister registers a child command like Register, an error is returned instead
+// of panicking if child is nil, has an illegal name, has been registered, or its
+// name or aliases are used by another child
+func (cmd *Command) TryRegister(child *Command) error {
+	if err := cmd.checkNewChild(child); err != nil {
+		return err
+	}
+	cmd.registerLocker.Lock()
+	defer cmd.registerLocker.Unlock()
+	return cmd.replaceChild(nil, child)
+}
+
+// checkNewChild returns error if child can't be registered
+func (cmd *Command) checkNewChild(child *Command) error {
 	if child == nil {
-		debug.Panicf("command `%s` try register a nil command", cmd.Name)
+		return fmt.Errorf("command `%s` try register a nil command", cmd.Name)
 	}
 	if !IsValidCommandName(child.Name) {
-		debug.Panicf("illegal command name `%s`", cmd.Name)
+		return fmt.Errorf("illegal command name `%s`", child.Name)
 	}
-	if cmd.children == nil {
-		cmd.children = []*Command{}
+	// child removed from cmd keeps cmd as parent, it could be registered to cmd again
+	if child.parent != nil && (child.parent != cmd || cmd.hasChild(child)) {
+		return fmt.Errorf("command `%s` has been child of `%s`", child.Name, child.parent.Name)
 	}
-	if child.parent != nil {
-		debug.Panicf("command `%s` has been child of `%s`", child.Name, child.parent.Name)
+	return nil
+}
+
+// hasChild reports whether child is a child of cmd
+func (cmd *Command) hasChild(child *Command) bool {
+	for _, c := range cmd.getChildren() {
+		if c == child {
+			return true
+		}
 	}
-	if cmd.findChild(child.Name) != nil {
-		debug.Panicf("repeat register child `%s` for command `%s`", child.Name, cmd.Name)
+	return false
+}
+
+// childNamed returns child named name, aliases are not matched
+func (cmd *Command) childNamed(name string) *Command {
+	for _, c := range cmd.getChildren() {
+		if c.Name == name {
+			return c
+		}
 	}
-	if child.Aliases != nil {
-		for _, alias := range child.Aliases {
-			if cmd.findChild(alias) != nil {
-				debug.Panicf("repeat register child `%s` for command `%s`", alias, cmd.Name)
-			}
+	return nil
+}
+
+// Unregister removes child with name or alias, the removed child is returned and
+// could be registered to cmd again. Nil returned if child not found. The removed
+// child keeps its parent, so that running invocations of it are not affected.
+// Routers of the removed child are removed from HTTP routers registered by RegisterHTTP.
+func (cmd *Command) Unregister(name string) *Command {
+	cmd.registerLocker.Lock()
+	defer cmd.registerLocker.Unlock()
+	cmd.childrenLocker.RLock()
+	child := cmd.childrenMap[name]
+	cmd.childrenLocker.RUnlock()
+	if child == nil {
+		return nil
+	}
+	cmd.unregisterChild(child)
+	return child
+}
+
+// Replace registers child in place of the child with same name, the replaced child
+// is returned and keeps its parent like Unregister. Child is registered like Register
+// if no child has same name. It panics if name or an alias of child is used by another
+// child, or HTTP routers of child are registered by another command.
+// HTTP routers registered by RegisterHTTP are updated.
+func (cmd *Command) Replace(child *Command) *Command {
+	if err := cmd.checkNewChild(child); err != nil {
+		panicf("%v", err)
+	}
+	cmd.registerLocker.Lock()
+	defer cmd.registerLocker.Unlock()
+	old := cmd.childNamed(child.Name)
+	if err := cmd.replaceChild(old, child); err != nil {
+		panicf("%v", err)
+	}
+	return old
+}
+
+// replaceChild registers child in place of old, child is appended if old is nil.
+// An error is returned if name or aliases of child are used by another child, or
+// HTTP routers of child are registered by another command.
+// registerLocker must be held.
+func (cmd *Command) replaceChild(old, child *Command) error {
+	for _, name := range append([]string{child.Name}, child.Aliases...) {
+		if c := cmd.findChild(name); c != nil && c != old {
+			return fmt.Errorf("repeat register child `%s` for command `%s`", name, cmd.Name)
 		}
 	}
-	cmd.children = append(cmd.children, child)
+	prevParent := child.parent
 	child.parent = cmd
+	routers, err := cmd.replacedRouters(old, child)
+	if err != nil {
+		child.parent = prevParent
+		return err
+	}
+	children := append([]*Command{}, cmd.getChildren()...)
+	if old == nil {
+		children = append(children, child)
+	} else {
+		for i, c := range children {
+			if c == old {
+				children[i] = child
+			}
+		}
+		old.InvalidateUsage()
+	}
+	// global flags of ancestors are listed in usages of child and its descendants
+	child.InvalidateUsage()
+	cmd.setChildren(children)
+	routers.apply()
+	return nil
+}
 
-	return child
+// unregisterChild removes child, registerLocker must be held
+func (cmd *Command) unregisterChild(child *Command) {
+	routers, _ := cmd.replacedRouters(child, nil)
+	children := []*Command{}
+	for _, c := range cmd.getChildren() {
+		if c != child {
+			children = append(children, c)
+		}
+	}
+	cmd.setChildren(children)
+	routers.apply()
+	child.InvalidateUsage()
+}
+
+// getChildren returns children of command, the returned slice must not be modified
+func (cmd *Command) getChildren() []*Command {
+	cmd.childrenLocker.RLock()
+	defer cmd.childrenLocker.RUnlock()
+	return cmd.children
+}
+
+// setChildren replaces children of command with a copy of children
+func (cmd *Command) setChildren(children []*Command) {
+	newChildren := make([]*Command, len(children))
+	copy(newChildren, children)
+	childrenMap := make(map[string]*Command, len(children))
+	childrenFoldMap := make(map[string]*Command, len(children))
+	for _, child := range newChildren {
+		// the former child wins if names conflict
+		for _, name := range append([]string{child.Name}, child.Aliases...) {
+			if _, ok := childrenMap[name]; !ok {
+				childrenMap[name] = child
+			}
+			if _, ok := childrenFoldMap[strings.ToLower(name)]; !ok {
+				childrenFoldMap[strings.ToLower(name)] = child
+			}
+		}
+	}
+	cmd.childrenLocker.Lock()
+	cmd.children = newChildren
+	cmd.childrenMap = childrenMap
+	cmd.childrenFoldMap = childrenFoldMap
+	cmd.childrenLocker.Unlock()
+	cmd.invalidateUsages()
+}
+
+// InvalidateUsage drops cached usages of command and its descendants, usages are
+// rendered again while requested. Registering children invalidates usages
+// automatically, call it after changing fields listed in usage, e.g. Text and
+// Examples of command or Desc and Hidden of children.
+func (cmd *Command) InvalidateUsage() {
+	cmd.invalidateUsages()
+	for _, child := range cmd.getChildren() {
+		child.InvalidateUsage()
+	}
+}
+
+// invalidateUsages drops cached usages of command
+func (cmd *Command) invalidateUsages() {
+	cmd.locker.Lock()
+	cmd.usages = nil
+	cmd.usagesVersion++
+	cmd.locker.Unlock()
 }
 
 // RegisterFunc registers handler as child command
@@ -127,6 +436,16 @@ func (cmd *Command) RegisterFunc(name string, fn CommandFunc, argvFn ArgvFunc) *
 	return cmd.Register(&Command{Name: name, Fn: fn, Argv: argvFn})
 }
 
+// TryRegisterFunc registers handler as child command like RegisterFunc, an error is
+// returned instead of panicking, see TryRegister
+func (cmd *Command) TryRegisterFunc(name string, fn CommandFunc, argvFn ArgvFunc) (*Command, error) {
+	child := &Command{Name: name, Fn: fn, Argv: argvFn}
+	if err := cmd.TryRegister(child); err != nil {
+		return nil, err
+	}
+	return child, nil
+}
+
 // RegisterTree registers a command tree
 func (cmd *Command) RegisterTree(forest ...*CommandTree) {
 	for _, tree := range forest {
@@ -137,6 +456,34 @@ func (cmd *Command) RegisterTree(forest ...*CommandTree) {
 	}
 }
 
+// TryRegisterTree registers forest like RegisterTree, an error is returned instead of
+// panicking. Commands of forest registered before the error are unregistered.
+func (cmd *Command) TryRegisterTree(forest ...*CommandTree) error {
+	registered := []*Command{}
+	if err := cmd.tryRegisterTree(forest, &registered); err != nil {
+		for i := len(registered) - 1; i >= 0; i-- {
+			registered[i].parent.Unregister(registered[i].Name)
+			// commands of forest never ran, they could be registered to other commands
+			registered[i].parent = nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (cmd *Command) tryRegisterTree(forest []*CommandTree, registered *[]*Command) error {
+	for _, tree := range forest {
+		if err := cmd.TryRegister(tree.command); err != nil {
+			return err
+		}
+		*registered = append(*registered, tree.command)
+		if err := tree.command.tryRegisterTree(tree.forest, registered); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Parent returns command's parent
 func (cmd *Command) Parent() *Command {
 	return cmd.parent
@@ -144,7 +491,7 @@ func (cmd *Command) Parent() *Command {
 
 // IsServer returns command whether if run as server
 func (cmd *Command) IsServer() bool {
-	return cmd.isServer
+	return atomic.LoadInt32(&cmd.isServer) != 0
 }
 
 // IsClient returns command whether if run as client
@@ -154,7 +501,11 @@ func (cmd *Command) IsClient() bool {
 
 // SetIsServer sets command running mode(server or not)
 func (cmd *Command) SetIsServer(yes bool) {
-	cmd.Root().isServer = yes
+	var value int32
+	if yes {
+		value = 1
+	}
+	atomic.StoreInt32(&cmd.Root().isServer, value)
 }
 
 // Run runs the command with args
@@ -164,55 +515,207 @@ func (cmd *Command) Run(args []string) error {
 
 // RunWith runs the command with args and writer,httpMethods
 func (cmd *Command) RunWith(args []string, writer io.Writer, resp http.ResponseWriter, httpMethods ...string) error {
+	return cmd.runWith(args, writer, resp, nil, httpMethods...)
+}
+
+// RunWithIO runs the command with args, reader and writer, reader is returned by Context.Reader
+func (cmd *Command) RunWithIO(args []string, reader io.Reader, writer io.Writer) error {
+	return cmd.runWith(args, writer, nil, func(ctx *Context) {
+		ctx.reader = reader
+	})
+}
+
+// RunOptions are options of RunWithWriter
+type RunOptions struct {
+	Reader    io.Reader // Returned by Context.Reader, os.Stdin used if nil
+	ErrWriter io.Writer // Writer for diagnostics, e.g. warnings and progress, os.Stderr used if nil
+
+	// Color is one of ColorAuto(default), ColorAlways and ColorNever, e.g. ColorAlways
+	// forces colored output for piped CI logs. It's overridden by flag --color, see ColorHelper.
+	Color string
+}
+
+// RunWithWriter runs the command with args, output is written to writer and colored by opts.Color.
+// In auto mode, output is colored if writer is a terminal or a ColorCapable writer which supports color.
+func (cmd *Command) RunWithWriter(args []string, writer io.Writer, opts RunOptions) error {
+	return cmd.runWithColor(args, writer, nil, opts.Color, func(ctx *Context) {
+		if opts.Reader != nil {
+			ctx.reader = opts.Reader
+		}
+		if opts.ErrWriter != nil {
+			ctx.errWriter = opts.ErrWriter
+		}
+	})
+}
+
+// runWith is similar to RunWith, setup is called after context prepared
+func (cmd *Command) runWith(args []string, writer io.Writer, resp http.ResponseWriter, setup func(*Context), httpMethods ...string) error {
+	return cmd.runWithColor(args, writer, resp, ColorAuto, setup, httpMethods...)
+}
+
+// runRemote is similar to runWith, it's used by servers for commands invoked by
+// remote clients, plugins in $PATH are not run, see PathPlugins
+func (cmd *Command) runRemote(args []string, writer io.Writer, resp http.ResponseWriter, setup func(*Context), httpMethods ...string) error {
+	return cmd.invoke(args, writer, resp, invocation{remote: true}, setup, httpMethods...)
+}
+
+// runWithColor is similar to runWith, output is colored by mode unless flag --color chose
+func (cmd *Command) runWithColor(args []string, writer io.Writer, resp http.ResponseWriter, mode string, setup func(*Context), httpMethods ...string) error {
+	return cmd.invoke(args, writer, resp, invocation{color: mode}, setup, httpMethods...)
+}
+
+// invocation holds options of running a command internally
+type invocation struct {
+	color  string // color mode, ColorAuto used if empty
+	remote bool   // invoked by remote clients, e.g. HTTP requests and chat messages
+
+	// embedded marks invocation by Engine, prompts of flags read stdin and write
+	// stderr instead of standard streams of process
+	embedded bool
+	stdin    io.Reader // empty if nil
+	stderr   io.Writer // discarded if nil
+
+	// authorize checks prepared Context before running, command isn't run if
+	// error returned
+	authorize func(*Context) error
+}
+
+// isolated reports whether standard streams of process are not used by invocation,
+// see Context.isolated
+func (inv invocation) isolated() bool {
+	return inv.embedded || inv.remote
+}
+
+// invoke runs command with args by inv, setup is called after context prepared
+func (cmd *Command) invoke(args []string, writer io.Writer, resp http.ResponseWriter, inv invocation, setup func(*Context), httpMethods ...string) error {
 	fds := []uintptr{}
 	if writer == nil {
-		writer = colorable.NewColorableStdout()
+		writer = newStdout()
 		fds = append(fds, os.Stdout.Fd())
 	}
 	clr := color.Color{}
-	colorSwitch(&clr, writer, fds...)
+	colorSwitch(&clr, inv.color, writer, fds...)
+	theme := cmd.theme()
 
 	var ctx *Context
 	var suggestion string
-	ctx, suggestion, err := cmd.prepare(clr, args, writer, resp, httpMethods...)
+	ctx, suggestion, err := cmd.prepare(clr, args, writer, resp, inv, httpMethods...)
 	if err == ExitError {
 		return nil
 	}
+	if ctx != nil {
+		// color may be chosen by flag --color
+		clr = ctx.color
+	}
 
 	if err != nil {
 		if cmd.OnRootPrepareError != nil {
 			err = cmd.OnRootPrepareError(err)
 		}
 		if err != nil {
-			return wrapErr(err, suggestion, clr)
+			return theme.wrapErr(err, suggestion, clr)
 		}
 		return nil
 	}
 
+	if setup != nil {
+		setup(ctx)
+	}
+	if inv.authorize != nil {
+		if err := inv.authorize(ctx); err != nil {
+			return err
+		}
+	}
+	if err := ctx.openEvents(); err != nil {
+		return theme.wrapErr(err, "", ctx.color)
+	}
+	ctx.Emit(Event{Type: EventStarted})
+	start := time.Now()
+	ctx.startedAt = start
+	err = cmd.execute(ctx)
+	if ctx.envelopeEnabled() {
+		if e := ctx.writeEnvelope(err); e != nil && err == nil {
+			err = e
+		}
+	} else {
+		if !IsInterrupted(err) {
+			ctx.writeSummary()
+		}
+		ctx.writeWarnings()
+	}
+	cmd.recordInvocation(ctx, start, err)
+	ctx.emitFinished(err)
+	ctx.closeEvents()
+	return err
+}
+
+// execute runs command of ctx, functions registered by Context.Defer are called
+// even if command panics
+func (cmd *Command) execute(ctx *Context) (err error) {
+	stop := cmd.notifyInterrupt(ctx)
+	cancel := ctx.applyTimeout()
+	defer func() {
+		cancel()
+		stop()
+		ctx.runDefers(err)
+	}()
+	return ctx.interrupted(cmd.run(ctx))
+}
+
+func (cmd *Command) run(ctx *Context) error {
+	if ctx.pathPlugin != nil {
+		return ctx.pathPlugin.Exec(ctx, ctx.NativeArgs())
+	}
+	if ctx.notFound != "" {
+		if err := cmd.OnNotFound(ctx, ctx.notFound); err != ExitError {
+			return err
+		}
+		return nil
+	}
 	if ctx.command.NoHook {
 		return ctx.command.Fn(ctx)
 	}
 
-	funcs := []func(*Context) error{
-		ctx.command.OnBefore,
-		cmd.OnRootBefore,
-		ctx.command.Fn,
-		cmd.OnRootAfter,
-		ctx.command.OnAfter,
+	var (
+		beforeHooks = []func(*Context) error{ctx.command.OnBefore, cmd.OnRootBefore}
+		afterHooks  = []func(*Context) error{cmd.OnRootAfter, ctx.command.OnAfter}
+	)
+	if err := runHooks(ctx, beforeHooks); err != nil {
+		return exitIgnored(err)
 	}
-	for _, f := range funcs {
-		if f != nil {
-			if err := f(ctx); err != nil {
-				if err == ExitError {
-					return nil
-				}
-				return err
+	if ctx.command.Fn != nil {
+		if err := ctx.command.Fn(ctx); err != nil {
+			if err != ExitError && ctx.Context().Err() != nil {
+				// After hooks run for cleanup while command cancelled
+				ctx.runAfterHooks(afterHooks)
 			}
+			return exitIgnored(err)
+		}
+	}
+	return exitIgnored(runHooks(ctx, afterHooks))
+}
+
+// runHooks calls hooks in order until one fails, nil hooks are skipped
+func runHooks(ctx *Context, hooks []func(*Context) error) error {
+	for _, hook := range hooks {
+		if hook == nil {
+			continue
+		}
+		if err := hook(ctx); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// exitIgnored returns nil if err is ExitError, err otherwise
+func exitIgnored(err error) error {
+	if err == ExitError {
+		return nil
+	}
+	return err
+}
+
 func isEmptyArgvList(argvList []interface{}) bool {
 	if argvList == nil {
 		return true
@@ -226,52 +729,232 @@ func isEmptyArgvList(argvList []interface{}) bool {
 }
 
 func (cmd *Command) argvList() []interface{} {
-	argvList := make([]interface{}, 0, 1)
-	if cmd.Argv != nil {
-		argvList = append(argvList, cmd.Argv())
-	} else {
-		argvList = append(argvList, nil)
+	return cmd.argvLevels(nil, color.Color{}).argvList()
+}
+
+// argvLevel holds argvs created by a command
+type argvLevel struct {
+	argv  interface{}   // created by Argv or ArgvContext
+	argvs []interface{} // created by Argvs
+}
+
+// argvLevels are argvs of a command followed by argvs of its ancestors from parent
+// to root, levels of ancestors which are not global are empty
+type argvLevels []argvLevel
+
+// argvList returns argv of each level, see Context.GetArgvList
+func (levels argvLevels) argvList() []interface{} {
+	list := make([]interface{}, 0, len(levels))
+	for _, level := range levels {
+		list = append(list, level.argv)
 	}
-	next := cmd.parent
-	for next != nil {
-		if next.Argv != nil && next.Global {
-			argvList = append(argvList, next.Argv())
-		} else {
-			argvList = append(argvList, nil)
+	return list
+}
+
+// all returns argvs of all levels, argvs of command come first, so that flags and
+// mixins of command take precedence over those of ancestors
+func (levels argvLevels) all() []interface{} {
+	list := []interface{}{}
+	for _, level := range levels {
+		list = append(append(list, level.argv), level.argvs...)
+	}
+	return list
+}
+
+// flagArgvList returns argvs for listing flags, argvs created by Argvs are placed
+// reversely before argv of each level, so that visibleFlags which iterates reversely
+// lists their flags after flags of argv
+func (levels argvLevels) flagArgvList() []interface{} {
+	list := []interface{}{}
+	for _, level := range levels {
+		for i := len(level.argvs) - 1; i >= 0; i-- {
+			list = append(list, level.argvs[i])
 		}
-		next = next.parent
+		list = append(list, level.argv)
 	}
-	return argvList
+	return list
 }
 
-func (cmd *Command) prepare(clr color.Color, args []string, writer io.Writer, resp http.ResponseWriter, httpMethods ...string) (ctx *Context, suggestion string, err error) {
-	// split args
-	router := []string{}
-	for _, arg := range args {
-		if strings.HasPrefix(arg, dashOne) {
-			break
+// argvLevels creates argvs of cmd followed by argvs of global ancestors, args are
+// passed to ArgvContext. Levels are created from root down, so that argvs of each
+// ancestor are created once for the result and at most once more to be leniently
+// parsed for ArgvContext of its descendants.
+func (cmd *Command) argvLevels(args []string, clr color.Color) argvLevels {
+	lineage := []*Command{}
+	for next := cmd; next != nil; next = next.parent {
+		lineage = append(lineage, next)
+	}
+	var (
+		levels  = make(argvLevels, len(lineage))
+		parents = argvLevels{} // leniently parsed levels above current one, from parent to root
+		flagSet *flagSet       // flags of parents
+	)
+	for i := len(lineage) - 1; i >= 0; i-- {
+		next := lineage[i]
+		if i > 0 && !next.Global {
+			parents = append(argvLevels{{}}, parents...)
+			continue
+		}
+		var ctx *Context
+		if next.ArgvContext != nil {
+			ctx = next.argvContext(args, parents, flagSet, clr)
+		}
+		levels[i] = argvLevel{argv: next.newArgv(ctx), argvs: next.newArgvs()}
+		if i > 0 && hasArgvContext(lineage[:i]) {
+			level := argvLevel{argv: next.newArgv(ctx), argvs: next.newArgvs()}
+			flagSet = mergeFlagSets(flagSet, parseArgvListLeniently(args, argvLevels{level}.all(), clr))
+			parents = append(argvLevels{level}, parents...)
 		}
-		router = append(router, arg)
 	}
-	path := strings.Join(router, " ")
-	child, end := cmd.SubRoute(router)
+	return levels
+}
+
+// hasArgvContext reports whether any of cmds has ArgvContext
+func hasArgvContext(cmds []*Command) bool {
+	for _, cmd := range cmds {
+		if cmd.ArgvContext != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// newArgv creates argv of cmd by ArgvContext with ctx or Argv, nil returned if neither set
+func (cmd *Command) newArgv(ctx *Context) interface{} {
+	if cmd.ArgvContext != nil {
+		return cmd.ArgvContext(ctx)
+	}
+	if cmd.Argv != nil {
+		return cmd.Argv()
+	}
+	return nil
+}
+
+// hasArgv reports whether cmd has argument factory function
+func (cmd *Command) hasArgv() bool {
+	return cmd.Argv != nil || cmd.ArgvContext != nil || len(cmd.Argvs) > 0
+}
+
+// newArgvs creates argvs by Argvs of cmd
+func (cmd *Command) newArgvs() []interface{} {
+	if len(cmd.Argvs) == 0 {
+		return nil
+	}
+	argvs := make([]interface{}, 0, len(cmd.Argvs))
+	for _, fn := range cmd.Argvs {
+		argvs = append(argvs, fn())
+	}
+	return argvs
+}
+
+// flagArgvList returns argvList of cmd for listing flags, see argvLevels.flagArgvList
+func (cmd *Command) flagArgvList() []interface{} {
+	return cmd.argvLevels(nil, color.Color{}).flagArgvList()
+}
+
+// argvContext returns Context passed to ArgvContext, parents are global argvs of
+// ancestors which were leniently parsed from args into flagSet
+func (cmd *Command) argvContext(args []string, parents argvLevels, flagSet *flagSet, clr color.Color) *Context {
+	path := cmd.Path()
+	levels := append(argvLevels{{}}, parents...)
+	if flagSet == nil {
+		flagSet = newFlagSet()
+		flagSet.allowUnknown = true
+	}
+	return &Context{
+		router:     strings.Fields(path),
+		path:       path,
+		argvList:   levels.argvList(),
+		argvLevels: levels,
+		nativeArgs: args,
+		command:    cmd,
+		color:      clr,
+		flagSet:    flagSet,
+	}
+}
+
+// flagSlice returns flags of cmd without setting values
+func (cmd *Command) flagSlice() []*flag {
+	flagSet := newFlagSet()
+	for _, argv := range cmd.flagArgvList() {
+		if argv == nil {
+			continue
+		}
+		typ := reflect.TypeOf(argv)
+		if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		initFlagSet(typ, reflect.ValueOf(argv), flagSet, color.Color{}, true)
+		if flagSet.err != nil {
+			return nil
+		}
+	}
+	return flagSet.flagSlice
+}
+
+// prepare routes args and creates Context, plugins in $PATH are not looked up if remote
+func (cmd *Command) prepare(clr color.Color, args []string, writer io.Writer, resp http.ResponseWriter, inv invocation, httpMethods ...string) (ctx *Context, suggestion string, err error) {
+	var (
+		remote  = inv.remote
+		routing = cmd.routeArgs(args, !remote)
+		router  = routing.router
+		child   = routing.child
+		end     = routing.end
+		path    = strings.Join(router, " ")
+		theme   = cmd.theme()
+	)
 
 	// if route fail
-	if !child.CanSubRoute && end != len(router) {
+	if !routing.routed() {
+		if cmd.Root().PrefixMatching {
+			if candidates := child.prefixChildren(router[end]); len(candidates) > 1 {
+				names := make([]string, 0, len(candidates))
+				for _, candidate := range candidates {
+					names = append(names, theme.Suggestion(&clr, candidate.Name))
+				}
+				err = throwAmbiguousCommand(theme.Command(&clr, path), names)
+				return
+			}
+		}
+		if plugin, n := routing.plugin, routing.pluginEnd; plugin != nil {
+			flagSet := newFlagSet()
+			flagSet.passthrough = true
+			ctx, err = newContext(path, router[:n], args[n:], nil, clr, flagSet)
+			ctx.command = cmd
+			ctx.writer = writer
+			ctx.HTTPResponse = resp
+			ctx.pathPlugin = plugin
+			return
+		}
+		if cmd.OnNotFound != nil {
+			flagSet := newFlagSet()
+			flagSet.passthrough = true
+			ctx, err = newContext(child.Path(), router[:end], args[end:], nil, clr, flagSet)
+			ctx.command = child
+			ctx.writer = writer
+			ctx.HTTPResponse = resp
+			ctx.notFound = path
+			return
+		}
 		suggestions := cmd.Suggestions(path)
 		buff := bytes.NewBufferString("")
 		if suggestions != nil && len(suggestions) > 0 {
 			if len(suggestions) == 1 {
-				fmt.Fprintf(buff, "\nDid you mean %s?", clr.Bold(suggestions[0]))
+				fmt.Fprintf(buff, "\n"+tr(MsgDidYouMean), theme.Suggestion(&clr, suggestions[0]))
 			} else {
-				fmt.Fprintf(buff, "\n\nDid you mean one of these?\n")
+				fmt.Fprintf(buff, "\n\n%s\n", tr(MsgDidYouMeanOneOf))
 				for _, sug := range suggestions {
 					fmt.Fprintf(buff, "    %s\n", sug)
 				}
 			}
 		}
 		suggestion = buff.String()
-		err = throwCommandNotFound(clr.Yellow(path))
+		err = throwCommandNotFound(theme.Command(&clr, path))
+		return
+	}
+
+	if platform := unsupportedPlatform(child.OS, child.Arch); platform != "" {
+		err = fmt.Errorf(tr(MsgCommandNotSupported), theme.Command(&clr, child.Path()), platform)
 		return
 	}
 
@@ -290,18 +973,56 @@ func (cmd *Command) prepare(clr color.Color, args []string, writer io.Writer, re
 		}
 	}
 	if !methodAllowed {
-		err = throwMethodNotAllowed(clr.Yellow(httpMethods[0]))
+		err = throwMethodNotAllowed(theme.Command(&clr, httpMethods[0]))
 		return
 	}
 
-	// create argvList
-	argvList := child.argvList()
+	if err = cmd.loadDotEnv(); err != nil {
+		return
+	}
+
+	// create argvs, flags of passthrough command are never parsed
+	var levels argvLevels
+	if !child.Passthrough {
+		levels = child.argvLevels(args[end:], clr)
+	}
 
 	// create Context
 	path = child.Path()
-	ctx, err = newContext(path, router[:end], args[end:], argvList, clr)
+	flagSet := newFlagSet()
+	flagSet.allErrors = child.AllErrors || cmd.AllErrors
+	flagSet.noInterspersed = child.NoInterspersed
+	flagSet.passthrough = child.Passthrough
+	flagSet.allowUnknown = child.AllowUnknownFlags
+	flagSet.configFile = child.ConfigFile
+	if flagSet.configFile == nil && child != cmd {
+		flagSet.configFile = cmd.ConfigFile
+		flagSet.configInherited = cmd.ConfigFile != nil
+	}
+	flagSet.sources = cmd.Sources
+	flagSet.theme = theme
+	if inv.isolated() {
+		flagSet.isolated = true
+		flagSet.promptInput = inv.stdin
+		if flagSet.promptInput == nil {
+			flagSet.promptInput = strings.NewReader("")
+		}
+		flagSet.promptOutput = inv.stderr
+		if flagSet.promptOutput == nil {
+			flagSet.promptOutput = ioutil.Discard
+		}
+	}
+	ctx, err = newContext(path, router[:end], args[end:], levels, clr, flagSet)
 	ctx.command = child
 	ctx.writer = writer
+	ctx.remote = remote
+	ctx.remainRouter = router[end:]
+	if child.Deprecated != "" {
+		ctx.warnings.list = append(ctx.warnings.list, fmt.Sprintf(tr(MsgCommandDeprecated), path, child.Deprecated))
+	}
+	if err == nil {
+		err = ctx.switchColor(writer)
+	}
 	if !ctx.flagSet.hasForce {
 		if !child.checkNumOption(ctx.NOpt()) || !ctx.command.checkNumArg(ctx.NArg()) {
 			ctx.WriteUsage()
@@ -315,7 +1036,7 @@ func (cmd *Command) prepare(clr color.Color, args []string, writer io.Writer, re
 	ctx.HTTPResponse = resp
 
 	// auto help
-	for _, argv := range argvList {
+	for _, argv := range ctx.allArgvs() {
 		if argv != nil {
 			if helper, ok := argv.(AutoHelper); ok && helper.AutoHelp() {
 				ctx.WriteUsage()
@@ -325,13 +1046,13 @@ func (cmd *Command) prepare(clr color.Color, args []string, writer io.Writer, re
 		}
 	}
 
-	if len(router) == 0 && cmd.Fn == nil {
-		err = throwCommandNotFound(clr.Yellow(cmd.Name))
+	if len(router) == 0 && child.Fn == nil {
+		err = throwCommandNotFound(theme.Command(&ctx.color, cmd.Name))
 		return
 	}
 
 	if !ctx.flagSet.hasForce {
-		for _, argv := range argvList {
+		for _, argv := range ctx.allArgvs() {
 			// validate argv if argv implements interface Validator
 			if argv != nil {
 				if validator, ok := argv.(Validator); ok {
@@ -369,40 +1090,94 @@ func (cmd *Command) defaultUsageFn(ctx *Context) string {
 		clr   = *(ctx.Color())
 	)
 
-	// get usage form cache
+	// get usage form cache, usages are cached by style and color, since
+	// requests of servers may render usage with different colors concurrently.
+	// Usages listing plugins in $PATH are never cached since plugins may change.
+	width := ctx.usageWidth()
+	plugins := cmd.parent == nil && cmd.PathPlugins && !ctx.remote
+	key := usageKey{style: style, color: colorEnabled(clr), width: width, language: GetLanguage()}
 	cmd.locker.Lock()
-	tmpUsage := cmd.usage
-	usageStyle := cmd.usageStyle
+	tmpUsage, ok := cmd.usages[key]
+	version := cmd.usagesVersion
 	cmd.locker.Unlock()
-	if tmpUsage != "" && usageStyle == style {
-		debug.Debugf("get usage of command %s from cache", clr.Bold(cmd.Name))
+	if ok && !plugins {
+		debugf("get usage of command %s from cache", clr.Bold(cmd.Name))
 		return tmpUsage
 	}
 
+	tmpUsage = ""
+	if tmpl := cmd.getUsageTemplate(); tmpl != nil {
+		var err error
+		if tmpUsage, err = cmd.templateUsage(ctx, tmpl, style, width); err != nil {
+			debugf("render usage template of command %s: %v", clr.Bold(cmd.Name), err)
+		}
+	}
+	if tmpUsage == "" {
+		tmpUsage = cmd.builtinUsage(clr, style, width, plugins)
+	}
+	cmd.locker.Lock()
+	// usage rendered before children changed is not cached
+	if version == cmd.usagesVersion && !plugins {
+		if cmd.usages == nil {
+			cmd.usages = make(map[usageKey]string)
+		}
+		cmd.usages[key] = tmpUsage
+	}
+	cmd.locker.Unlock()
+	return tmpUsage
+}
+
+// usageWidth returns width of usage, see SetUsageWidth
+func (ctx *Context) usageWidth() int {
+	width := GetUsageWidth()
+	if width < 0 {
+		return 0
+	}
+	if width == 0 && ctx.IsTTY() {
+		width, _, _ = ctx.TerminalSize()
+	}
+	return width
+}
+
+// builtinUsage renders usage with description, flags, children and examples of command,
+// plugins in $PATH are listed if plugins is true
+func (cmd *Command) builtinUsage(clr color.Color, style UsageStyle, width int, plugins bool) string {
+	theme := cmd.theme()
 	buff := bytes.NewBufferString("")
-	if cmd.Desc != "" {
-		fmt.Fprintf(buff, "%s\n\n", cmd.Desc)
+	if desc := cmd.localDesc(); desc != "" {
+		fmt.Fprintf(buff, "%s\n\n", desc)
 	}
 	if cmd.Text != "" {
 		fmt.Fprintf(buff, "%s\n\n", cmd.Text)
 	}
-	argvList := cmd.argvList()
+	argvList := cmd.flagArgvList()
 	isEmpty := isEmptyArgvList(argvList)
 	if !isEmpty {
-		fmt.Fprintf(buff, "%s:\n\n%s", clr.Bold("Options"), usage(argvList, clr, style))
+		fmt.Fprintf(buff, "%s:\n\n%s", theme.Header(&clr, tr(MsgOptions)), usageWithWidth(argvList, clr, theme, style, width))
 	}
-	if cmd.children != nil && len(cmd.children) > 0 {
+	if !cmd.novisiblechild() {
 		if !isEmpty {
 			buff.WriteByte('\n')
 		}
-		fmt.Fprintf(buff, "%s:\n\n%v", clr.Bold("Commands"), cmd.ChildrenDescriptions("  ", "   "))
+		fmt.Fprintf(buff, "%s:\n\n%v", theme.Header(&clr, tr(MsgCommands)), cmd.childrenDescriptions("  ", "   ", width))
 	}
-	tmpUsage = buff.String()
-	cmd.locker.Lock()
-	cmd.usage = tmpUsage
-	cmd.usageStyle = style
-	cmd.locker.Unlock()
-	return tmpUsage
+	pluginsUsage := ""
+	if plugins {
+		pluginsUsage = cmd.pathPluginsUsage("  ")
+	}
+	if pluginsUsage != "" {
+		if !isEmpty || !cmd.novisiblechild() {
+			buff.WriteByte('\n')
+		}
+		fmt.Fprintf(buff, "%s:\n\n%s", theme.Header(&clr, tr(MsgPlugins)), pluginsUsage)
+	}
+	if len(cmd.Examples) > 0 {
+		if !isEmpty || !cmd.novisiblechild() || pluginsUsage != "" {
+			buff.WriteByte('\n')
+		}
+		fmt.Fprintf(buff, "%s:\n\n%s", theme.Header(&clr, tr(MsgExamples)), cmd.examplesUsage())
+	}
+	return buff.String()
 }
 
 // Path returns space-separated command full name
@@ -450,7 +1225,15 @@ func (cmd *Command) Route(router []string) *Command {
 func (cmd *Command) SubRoute(router []string) (*Command, int) {
 	cur := cmd
 	for i, name := range router {
+		if cur.Passthrough {
+			return cur, i
+		}
 		child := cur.findChild(name)
+		if child == nil && cmd.Root().PrefixMatching {
+			if candidates := cur.prefixChildren(name); len(candidates) == 1 {
+				child = candidates[0]
+			}
+		}
 		if child == nil {
 			return cur, i
 		}
@@ -459,24 +1242,88 @@ func (cmd *Command) SubRoute(router []string) (*Command, int) {
 	return cur, len(router)
 }
 
-// findChild finds child command by name
-func (cmd *Command) findChild(name string) *Command {
-	if cmd.nochild() {
-		return nil
+// routing is result of routing args, it's shared by prepare and Engine.Resolve
+type routing struct {
+	router    []string // leading args before the first flag
+	child     *Command // command matched by router, DefaultCommand applied
+	end       int      // number of segments of router matched by child
+	plugin    *Plugin  // plugin found in $PATH if router not routed, see PathPlugins
+	pluginEnd int      // number of segments of router used by plugin
+}
+
+// routeArgs routes leading args before the first flag, DefaultCommand of matched
+// command is applied if all segments matched. Plugins in $PATH are looked up for
+// commands not found if plugins is true and PathPlugins of cmd is set.
+func (cmd *Command) routeArgs(args []string, plugins bool) routing {
+	r := routing{router: []string{}}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, dashOne) {
+			break
+		}
+		r.router = append(r.router, arg)
+	}
+	r.child, r.end = cmd.SubRoute(r.router)
+	if r.end == len(r.router) {
+		r.child = r.child.defaultChild()
+	}
+	if plugins && cmd.PathPlugins && r.end == 0 && !r.routed() {
+		r.plugin, r.pluginEnd = cmd.lookupPathPlugin(r.router)
+	}
+	return r
+}
+
+// routed reports whether router is routed to child, rest segments of router are
+// allowed if child CanSubRoute, is Passthrough or NoInterspersed
+func (r routing) routed() bool {
+	return r.child.CanSubRoute || r.child.Passthrough || r.child.NoInterspersed || r.end == len(r.router)
+}
+
+// defaultChild returns child named DefaultCommand if cmd has no Fn, cmd returned otherwise
+func (cmd *Command) defaultChild() *Command {
+	for cmd.Fn == nil && cmd.DefaultCommand != "" {
+		child := cmd.findChild(cmd.DefaultCommand)
+		if child == nil {
+			break
+		}
+		cmd = child
+	}
+	return cmd
+}
+
+// prefixChildren returns visible children whose name or one of aliases starts with prefix
+func (cmd *Command) prefixChildren(prefix string) []*Command {
+	candidates := []*Command{}
+	ignoreCase := cmd.Root().IgnoreCase
+	if ignoreCase {
+		prefix = strings.ToLower(prefix)
 	}
-	for _, child := range cmd.children {
-		if child.Name == name {
-			return child
+	for _, child := range cmd.getChildren() {
+		if child.isHidden() {
+			continue
 		}
-		if child.Aliases != nil {
-			for _, alias := range child.Aliases {
-				if alias == name {
-					return child
-				}
+		for _, name := range append([]string{child.Name}, child.Aliases...) {
+			if ignoreCase {
+				name = strings.ToLower(name)
+			}
+			if strings.HasPrefix(name, prefix) {
+				candidates = append(candidates, child)
+				break
 			}
 		}
 	}
-	return nil
+	return candidates
+}
+
+// findChild finds child command by name or alias, case-insensitively if IgnoreCase
+// of root command is set
+func (cmd *Command) findChild(name string) *Command {
+	ignoreCase := cmd.Root().IgnoreCase
+	cmd.childrenLocker.RLock()
+	defer cmd.childrenLocker.RUnlock()
+	if child, ok := cmd.childrenMap[name]; ok || !ignoreCase {
+		return child
+	}
+	return cmd.childrenFoldMap[strings.ToLower(name)]
 }
 
 // ListChildren returns all names of command children
@@ -485,8 +1332,9 @@ func (cmd *Command) ListChildren() []string {
 		return []string{}
 	}
 
-	ret := make([]string, 0, len(cmd.children))
-	for _, child := range cmd.children {
+	children := cmd.getChildren()
+	ret := make([]string, 0, len(children))
+	for _, child := range children {
 		ret = append(ret, child.Name)
 	}
 	return ret
@@ -494,18 +1342,32 @@ func (cmd *Command) ListChildren() []string {
 
 // ChildrenDescriptions returns all children's brief infos by one string
 func (cmd *Command) ChildrenDescriptions(prefix, indent string) string {
+	return cmd.childrenDescriptions(prefix, indent, 0)
+}
+
+// childrenDescriptions is similar to ChildrenDescriptions, descriptions are wrapped
+// to fit width if width > 0
+func (cmd *Command) childrenDescriptions(prefix, indent string, width int) string {
 	if cmd.nochild() {
 		return ""
 	}
-	buff := bytes.NewBufferString("")
-	length := 0
-	for _, child := range cmd.children {
-		if len(child.Name) > length {
-			length = len(child.Name)
+	var (
+		buff     = bytes.NewBufferString("")
+		length   = 0
+		children = cmd.getChildren()
+	)
+	for _, child := range children {
+		if child.isHidden() {
+			continue
+		}
+		if w := stringWidth(child.Name); w > length {
+			length = w
 		}
 	}
-	format := fmt.Sprintf("%s%%-%ds%s%%s%%s\n", prefix, length, indent)
-	for _, child := range cmd.children {
+	for _, child := range children {
+		if child.isHidden() {
+			continue
+		}
 		aliases := ""
 		if child.Aliases != nil && len(child.Aliases) > 0 {
 			aliasesBuff := bytes.NewBufferString("(aliases ")
@@ -513,16 +1375,31 @@ func (cmd *Command) ChildrenDescriptions(prefix, indent string) string {
 			aliasesBuff.WriteString(")")
 			aliases = aliasesBuff.String()
 		}
-		fmt.Fprintf(buff, format, child.Name, child.Desc, aliases)
+		desc := wrapIndent(child.localDesc()+aliases, stringWidth(prefix)+length+stringWidth(indent), width)
+		fmt.Fprintf(buff, "%s%s%s%s\n", prefix, padRight(child.Name, length), indent, desc)
 	}
 	return buff.String()
 }
 
+// isHidden reports whether cmd is hidden or not supported on current platform
+func (cmd *Command) isHidden() bool {
+	return cmd.Hidden || unsupportedPlatform(cmd.OS, cmd.Arch) != ""
+}
+
 func (cmd *Command) nochild() bool {
-	return cmd.children == nil || len(cmd.children) == 0
+	return len(cmd.getChildren()) == 0
+}
+
+func (cmd *Command) novisiblechild() bool {
+	for _, child := range cmd.getChildren() {
+		if !child.isHidden() {
+			return false
+		}
+	}
+	return true
 }
 
-// Suggestions returns all similar commands
+// Suggestions returns similar commands ranked by Suggest of root command
 func (cmd *Command) Suggestions(path string) []string {
 	if cmd.parent != nil {
 		return cmd.Root().Suggestions(path)
@@ -536,22 +1413,17 @@ func (cmd *Command) Suggestions(path string) []string {
 		if cmds[0].nochild() {
 			cmds = cmds[1:]
 		} else {
-			for _, child := range cmds[0].children {
-				targets = append(targets, child.Path())
+			// descendants of hidden commands are hidden too
+			visible := []*Command{}
+			for _, child := range cmds[0].getChildren() {
+				if !child.isHidden() {
+					targets = append(targets, child.Path())
+					visible = append(visible, child)
+				}
 			}
-			cmds = append(cmds[0].children, cmds[1:]...)
+			cmds = append(visible, cmds[1:]...)
 		}
 	}
 
-	dists := []editDistanceRank{}
-	for i, size := 0, len(targets); i < size; i++ {
-		if d, ok := match(path, targets[i]); ok {
-			dists = append(dists, editDistanceRank{s: targets[i], d: d})
-		}
-	}
-	sort.Sort(editDistanceRankSlice(dists))
-	for i := 0; i < len(dists); i++ {
-		targets[i] = dists[i].s
-	}
-	return targets[:len(dists)]
+	return cmd.Suggest.suggest(path, targets)
 }
@@ -1,9 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/labstack/gommon/color"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -116,6 +121,33 @@ func TestRegisterTree(t *testing.T) {
 	assert.Equal(t, cmd.children[0].children[0].Parent().Name, "sub")
 }
 
+func TestTryRegister(t *testing.T) {
+	root := &Command{Name: "root"}
+	sub := &Command{Name: "sub", Aliases: []string{"s"}, Fn: donothing}
+	assert.Nil(t, root.TryRegister(sub))
+	assert.Equal(t, root.TryRegister(nil).Error(), "command `root` try register a nil command")
+	assert.Equal(t, root.TryRegister(&Command{Name: "-invalid-"}).Error(), "illegal command name `-invalid-`")
+	assert.Equal(t, root.TryRegister(sub).Error(), "command `sub` has been child of `root`")
+	assert.Equal(t, root.TryRegister(&Command{Name: "sub"}).Error(), "repeat register child `sub` for command `root`")
+	assert.Equal(t, root.TryRegister(&Command{Name: "other", Aliases: []string{"s"}}).Error(), "repeat register child `s` for command `root`")
+
+	child, err := root.TryRegisterFunc("hello", donothing, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, child.Parent(), root)
+	_, err = root.TryRegisterFunc("hello", donothing, nil)
+	assert.Error(t, err)
+
+	// registered commands of forest are unregistered while failed
+	a, b := &Command{Name: "a"}, &Command{Name: "b"}
+	err = root.TryRegisterTree(Tree(a, Tree(b)), Tree(&Command{Name: "sub"}))
+	assert.Error(t, err)
+	assert.Equal(t, root.ListChildren(), []string{"sub", "hello"})
+	assert.Nil(t, a.Parent())
+	assert.Nil(t, b.Parent())
+	assert.Nil(t, root.TryRegisterTree(Tree(a, Tree(b))))
+	assert.Equal(t, root.Route([]string{"a", "b"}), b)
+}
+
 func TestRegisterFunc(t *testing.T) {
 	root := &Command{Name: "root"}
 	out := ""
@@ -212,3 +244,675 @@ func TestCommandMisc(t *testing.T) {
 
 	assert.Equal(t, root.Suggestions("su"), []string{"sub"})
 }
+
+func TestHiddenCommand(t *testing.T) {
+	root := &Command{Name: "root"}
+	root.Register(&Command{Name: "sub", Desc: "sub command", Fn: donothing})
+	root.Register(&Command{Name: "hidden", Desc: "hidden command", Hidden: true, Fn: donothing})
+	assert.Equal(t, root.ChildrenDescriptions("", " "), "sub sub command\n")
+	assert.Equal(t, root.Suggestions("hiden"), []string{})
+	assert.Nil(t, root.Run([]string{"hidden"}))
+}
+
+func TestAllErrors(t *testing.T) {
+	type argT struct {
+		Help bool   `cli:"!h"`
+		A    int    `cli:"a"`
+		B    uint8  `cli:"b"`
+		C    string `cli:"*c"`
+		D    string `cli:"*d"`
+	}
+	clr := color.Color{}
+	clr.Disable()
+	newRoot := func(allErrors bool) *Command {
+		return &Command{
+			Name:      "root",
+			AllErrors: allErrors,
+			Argv:      func() interface{} { return new(argT) },
+			Fn:        donothing,
+		}
+	}
+	args := []string{"-a=x", "--undefined", "-b", "256", "-d=d"}
+
+	err := newRoot(false).RunWith(args, nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, err.Error(), wrapErr(fmt.Errorf("undefined option --undefined"), "", clr).Error())
+
+	err = newRoot(true).RunWith(args, nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, err.Error(), strings.Join([]string{
+		"ERR! undefined option --undefined",
+		"ERR! parameter -a invalid: `x' couldn't converted to an int",
+		"ERR! parameter -b invalid: value overflow",
+		"ERR! required parameter -c missing",
+	}, "\n"))
+
+	// force flag ignores invalid values, but not undefined options
+	assert.Nil(t, newRoot(true).RunWith([]string{"-a=x", "-b", "256", "-h"}, nil, nil))
+	err = newRoot(true).RunWith([]string{"-a=x", "--undefined", "-h"}, nil, nil)
+	require.Error(t, err)
+	assert.Equal(t, err.Error(), "ERR! undefined option --undefined")
+}
+
+var errBadPort = errors.New("bad port")
+
+type portT int
+
+func (p *portT) Decode(s string) error {
+	if s == "0" {
+		return errBadPort
+	}
+	*p = 1
+	return nil
+}
+
+func TestParameterInvalidUnwrap(t *testing.T) {
+	type argT struct {
+		Port portT `cli:"port"`
+	}
+	for _, allErrors := range []bool{false, true} {
+		root := &Command{
+			AllErrors: allErrors,
+			Argv:      func() interface{} { return new(argT) },
+			Fn:        donothing,
+		}
+		err := root.RunWith([]string{"--port=0"}, nil, nil)
+		assert.True(t, errors.Is(err, errBadPort), "all errors: %v, error: %v", allErrors, err)
+	}
+}
+
+func TestNoInterspersed(t *testing.T) {
+	type argT struct {
+		Force bool   `cli:"f,force"`
+		Dir   string `cli:"C"`
+	}
+	for _, tt := range []struct {
+		noInterspersed bool
+		args           []string
+		force          bool
+		freeArgs       []string
+	}{
+		{false, []string{"cp", "src", "dst", "--force"}, true, []string{"src", "dst"}},
+		{false, []string{"cp", "-C", "dir", "src", "-f", "dst"}, true, []string{"src", "dst"}},
+		{true, []string{"cp", "src", "dst", "--force"}, false, []string{"src", "dst", "--force"}},
+		{true, []string{"cp", "-C", "dir", "-f", "ls", "-l"}, true, []string{"ls", "-l"}},
+		{true, []string{"cp", "ls", "-l"}, false, []string{"ls", "-l"}},
+	} {
+		root := &Command{Name: "app"}
+		root.Register(&Command{
+			Name:           "cp",
+			CanSubRoute:    !tt.noInterspersed,
+			NoInterspersed: tt.noInterspersed,
+			Argv:           func() interface{} { return new(argT) },
+			Fn: func(ctx *Context) error {
+				argv := ctx.Argv().(*argT)
+				assert.Equal(t, argv.Force, tt.force)
+				assert.Equal(t, ctx.Args(), tt.freeArgs)
+				return nil
+			},
+		})
+		assert.Nil(t, root.Run(tt.args))
+	}
+}
+
+func TestAllowUnknownFlags(t *testing.T) {
+	type argT struct {
+		A bool   `cli:"a"`
+		B string `cli:"b"`
+	}
+	newRoot := func(allowUnknown bool, fn CommandFunc) *Command {
+		return &Command{
+			Name:              "proxy",
+			CanSubRoute:       true,
+			AllowUnknownFlags: allowUnknown,
+			Argv:              func() interface{} { return new(argT) },
+			Fn:                fn,
+		}
+	}
+	assert.Error(t, newRoot(false, donothing).Run([]string{"-a", "--xyz=1"}))
+	assert.Nil(t, newRoot(true, func(ctx *Context) error {
+		argv := ctx.Argv().(*argT)
+		assert.True(t, argv.A)
+		assert.Equal(t, argv.B, "b")
+		assert.Equal(t, ctx.UnknownFlags(), []string{"--xyz=1", "-c", "-ax", "--long"})
+		assert.Equal(t, ctx.Args(), []string{"v"})
+		return nil
+	}).Run([]string{"-a", "--xyz=1", "-c", "-b", "b", "-ax", "--long", "v"}))
+}
+
+func TestPassthrough(t *testing.T) {
+	type argT struct {
+		Helper
+		Verbose bool `cli:"v"`
+	}
+	var got []string
+	root := &Command{Name: "app", Argv: func() interface{} { return new(argT) }}
+	wrap := root.Register(&Command{
+		Name:        "kubectl",
+		Passthrough: true,
+		Argv:        func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			assert.Nil(t, ctx.Argv())
+			assert.Equal(t, ctx.Args(), ctx.NativeArgs())
+			got = ctx.NativeArgs()
+			return nil
+		},
+	})
+	wrap.Register(&Command{Name: "get", Fn: donothing})
+
+	for _, args := range [][]string{
+		{},
+		{"get", "pods", "-o", "wide"},
+		{"--help"},
+		{"-v", "--", "x", "--undefined"},
+	} {
+		got = nil
+		assert.Nil(t, root.Run(append([]string{"kubectl"}, args...)))
+		assert.Equal(t, strings.Join(got, " "), strings.Join(args, " "))
+	}
+
+	root = &Command{Name: "wrapper", Passthrough: true, Fn: func(ctx *Context) error {
+		got = ctx.NativeArgs()
+		return nil
+	}}
+	assert.Nil(t, root.Run([]string{"run", "--help", "-x"}))
+	assert.Equal(t, got, []string{"run", "--help", "-x"})
+}
+
+func TestConcurrentRun(t *testing.T) {
+	type argT struct {
+		Name string `cli:"name" usage:"name of user"`
+	}
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{
+		Name:        "user",
+		Desc:        "manage users",
+		Argv:        func() interface{} { return new(argT) },
+		HTTPRouters: []string{"/v1/user"},
+		Fn: func(ctx *Context) error {
+			ctx.String("hello %s", ctx.Argv().(*argT).Name)
+			return nil
+		},
+	})
+	assert.Nil(t, root.RegisterHTTP())
+
+	var (
+		wg      sync.WaitGroup
+		colored = color.Color{}
+		plain   = color.Color{}
+	)
+	plain.Disable()
+	for i := 0; i < 20; i++ {
+		wg.Add(5)
+		go func() {
+			defer wg.Done()
+			// usage cached with color is never returned for plain output
+			usage := user.Usage(&Context{color: plain})
+			assert.False(t, strings.Contains(usage, "\x1b["), usage)
+		}()
+		go func() {
+			defer wg.Done()
+			user.Usage(&Context{color: colored})
+		}()
+		go func(i int) {
+			defer wg.Done()
+			w := new(strings.Builder)
+			assert.Nil(t, root.RunWith([]string{"user", fmt.Sprintf("--name=u%d", i)}, w, nil))
+			assert.Equal(t, w.String(), fmt.Sprintf("hello u%d", i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			w := httptest.NewRecorder()
+			root.ServeHTTP(w, httptest.NewRequest("GET", "/v1/user?name=web", nil))
+			assert.Equal(t, w.Body.String(), "hello web")
+		}()
+		go func(i int) {
+			defer wg.Done()
+			user.Register(&Command{Name: fmt.Sprintf("child%d", i), Fn: donothing})
+			root.SetIsServer(i%2 == 0)
+			SetUsageStyle(GetUsageStyle())
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, len(user.ListChildren()), 20)
+	assert.True(t, strings.Contains(user.Usage(&Context{color: plain}), "child19"))
+}
+
+func TestFindChildByMap(t *testing.T) {
+	root := &Command{Name: "root"}
+	for i := 0; i < 500; i++ {
+		root.Register(&Command{
+			Name:    fmt.Sprintf("cmd%d", i),
+			Aliases: []string{fmt.Sprintf("c%d", i)},
+			Fn:      donothing,
+		})
+	}
+	assert.Equal(t, root.findChild("cmd42").Name, "cmd42")
+	assert.Equal(t, root.findChild("c499").Name, "cmd499")
+	assert.Nil(t, root.findChild("cmd500"))
+	assert.Equal(t, root.Route([]string{"c7"}).Name, "cmd7")
+
+	// routing of clones uses their own children
+	clone := root.Clone()
+	assert.Equal(t, clone.findChild("c42").Parent(), clone)
+}
+
+func TestInvalidateUsage(t *testing.T) {
+	type globalT struct {
+		Verbose bool `cli:"v" usage:"verbose"`
+	}
+	clr := color.Color{}
+	clr.Disable()
+	ctx := &Context{color: clr}
+
+	root := &Command{Name: "root", Desc: "root command"}
+	sub := root.Register(&Command{Name: "sub", Desc: "sub command", Fn: donothing})
+	assert.Contains(t, root.Usage(ctx), "sub command")
+
+	sub.Desc = "changed"
+	assert.Contains(t, root.Usage(ctx), "sub command")
+	root.InvalidateUsage()
+	assert.Contains(t, root.Usage(ctx), "changed")
+
+	// registering children invalidates usage
+	root.Register(&Command{Name: "other", Desc: "other command", Fn: donothing})
+	assert.Contains(t, root.Usage(ctx), "other command")
+
+	// usage of registered tree lists global flags of new parent
+	tree := &Command{Name: "tree", Fn: donothing}
+	leaf := tree.Register(&Command{Name: "leaf", Argv: func() interface{} { return new(argT) }, Fn: donothing})
+	assert.NotContains(t, leaf.Usage(ctx), "verbose")
+	parent := root.Register(&Command{Name: "parent", Global: true, Argv: func() interface{} { return new(globalT) }})
+	parent.Register(tree)
+	assert.Contains(t, leaf.Usage(ctx), "verbose")
+
+	// usages are cached by color
+	colored := &Context{color: color.Color{}}
+	assert.Contains(t, root.Usage(colored), "\x1b[")
+	assert.NotContains(t, root.Usage(ctx), "\x1b[")
+}
+
+func TestPrefixMatching(t *testing.T) {
+	var ran string
+	fn := func(ctx *Context) error {
+		ran = ctx.Command().Name
+		return nil
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{Name: "status", Fn: fn})
+	root.Register(&Command{Name: "start", Aliases: []string{"run"}, Fn: fn})
+	root.Register(&Command{Name: "secret", Hidden: true, Fn: fn})
+	remote := root.Register(&Command{Name: "remote", Fn: fn})
+	remote.Register(&Command{Name: "add", Fn: fn})
+
+	// disabled by default
+	assert.Nil(t, root.Route([]string{"stat"}))
+
+	root.PrefixMatching = true
+	assert.Equal(t, root.Route([]string{"stat"}).Name, "status")
+	assert.Equal(t, root.Route([]string{"ru"}).Name, "start")
+	assert.Equal(t, root.Route([]string{"rem", "a"}).Name, "add")
+	assert.Nil(t, root.Route([]string{"sec"}))
+	assert.Equal(t, root.Route([]string{"secret"}).Name, "secret")
+
+	assert.Nil(t, root.RunWith([]string{"stat"}, new(strings.Builder), nil))
+	assert.Equal(t, ran, "status")
+	err := root.RunWith([]string{"st"}, new(strings.Builder), nil)
+	assert.Error(t, err)
+	assert.Equal(t, err.Error(), "ERR! command st is ambiguous, candidates: status, start")
+}
+
+func TestIgnoreCase(t *testing.T) {
+	root := &Command{Name: "app"}
+	list := root.Register(&Command{Name: "list", Aliases: []string{"ls"}, Fn: donothing})
+	lower := root.Register(&Command{Name: "get", Fn: donothing})
+	upper := root.Register(&Command{Name: "GET", Fn: donothing})
+	list.Register(&Command{Name: "users", Fn: donothing})
+
+	assert.Nil(t, root.Route([]string{"LIST"}))
+
+	root.IgnoreCase = true
+	assert.Equal(t, root.Route([]string{"LIST"}), list)
+	assert.Equal(t, root.Route([]string{"Ls", "USERS"}).Name, "users")
+	// exact names win
+	assert.Equal(t, root.Route([]string{"get"}), lower)
+	assert.Equal(t, root.Route([]string{"GET"}), upper)
+	assert.Equal(t, root.Route([]string{"Get"}), lower)
+
+	root.PrefixMatching = true
+	assert.Equal(t, root.Route([]string{"LI"}), list)
+}
+
+func TestDefaultCommand(t *testing.T) {
+	var ran string
+	fn := func(ctx *Context) error {
+		ran = ctx.Command().Path()
+		return nil
+	}
+	type listT struct {
+		Verbose bool `cli:"v"`
+	}
+	root := &Command{Name: "app", DefaultCommand: "remote"}
+	remote := root.Register(&Command{Name: "remote", DefaultCommand: "list"})
+	remote.Register(&Command{Name: "list", Argv: func() interface{} { return new(listT) }, Fn: fn})
+	remote.Register(&Command{Name: "add", Fn: fn})
+	root.Register(&Command{Name: "serve", Fn: fn})
+
+	assert.Nil(t, root.RunWith(nil, new(strings.Builder), nil))
+	assert.Equal(t, ran, "remote list")
+	assert.Nil(t, root.RunWith([]string{"remote"}, new(strings.Builder), nil))
+	assert.Equal(t, ran, "remote list")
+	assert.Nil(t, root.RunWith([]string{"remote", "add"}, new(strings.Builder), nil))
+	assert.Equal(t, ran, "remote add")
+	// flags don't prevent DefaultCommand
+	ran = ""
+	assert.Nil(t, root.RunWith([]string{"-v"}, new(strings.Builder), nil))
+	assert.Equal(t, ran, "remote list")
+
+	// commands with Fn ignore DefaultCommand
+	root.Fn = fn
+	assert.Nil(t, root.RunWith(nil, new(strings.Builder), nil))
+	assert.Equal(t, ran, "")
+
+	root.Fn = nil
+	root.DefaultCommand = "missing"
+	err := root.RunWith(nil, new(strings.Builder), nil)
+	assert.Error(t, err)
+	assert.Equal(t, err.Error(), "ERR! command app not found")
+}
+
+func TestOnNotFound(t *testing.T) {
+	var (
+		gotPath string
+		gotArgs []string
+		gotCmd  string
+	)
+	root := &Command{
+		Name: "app",
+		OnNotFound: func(ctx *Context, path string) error {
+			gotPath, gotArgs, gotCmd = path, ctx.NativeArgs(), ctx.Command().Name
+			if path == "fail" {
+				return fmt.Errorf("unknown %s", path)
+			}
+			ctx.String("external %s", path)
+			return nil
+		},
+	}
+	root.Register(&Command{Name: "remote", Fn: donothing})
+
+	w := new(strings.Builder)
+	assert.Nil(t, root.RunWith([]string{"remote", "prune", "origin", "--dry-run"}, w, nil))
+	assert.Equal(t, w.String(), "external remote prune origin")
+	assert.Equal(t, gotPath, "remote prune origin")
+	assert.Equal(t, gotArgs, []string{"prune", "origin", "--dry-run"})
+	assert.Equal(t, gotCmd, "remote")
+
+	err := root.RunWith([]string{"fail"}, new(strings.Builder), nil)
+	assert.Error(t, err)
+	assert.Equal(t, err.Error(), "unknown fail")
+}
+
+func TestUnregisterAndReplace(t *testing.T) {
+	type globalT struct {
+		Debug bool `cli:"debug" usage:"debug mode"`
+	}
+	clr := color.Color{}
+	clr.Disable()
+	ctx := &Context{color: clr}
+
+	root := &Command{Name: "app", Global: true, Argv: func() interface{} { return new(globalT) }}
+	list := root.Register(&Command{Name: "list", Aliases: []string{"ls"}, Desc: "list items", Fn: donothing})
+	root.Register(&Command{Name: "get", Desc: "get item", Fn: donothing})
+	assert.Equal(t, root.Usage(ctx), "Options:\n\n  --debug   debug mode\n\nCommands:\n\n  list   list items(aliases ls)\n  get    get item\n")
+
+	assert.Nil(t, root.Unregister("missing"))
+	assert.Equal(t, root.Unregister("ls"), list)
+	assert.Equal(t, list.Parent(), root)
+	assert.Nil(t, root.Route([]string{"list"}))
+	assert.Nil(t, root.Route([]string{"ls"}))
+	assert.Equal(t, root.Usage(ctx), "Options:\n\n  --debug   debug mode\n\nCommands:\n\n  get   get item\n")
+	assert.Equal(t, list.Usage(ctx), "list items\n\nOptions:\n\n  --debug   debug mode\n")
+
+	// removed child could be registered again
+	root.Register(list)
+	assert.Equal(t, root.Route([]string{"ls"}), list)
+
+	get := &Command{Name: "get", Aliases: []string{"g"}, Desc: "get items", Fn: donothing}
+	old := root.Replace(get)
+	assert.Equal(t, old.Desc, "get item")
+	assert.Equal(t, old.Parent(), root)
+	assert.Equal(t, root.Route([]string{"g"}), get)
+	assert.Equal(t, root.ListChildren(), []string{"get", "list"})
+	assert.Equal(t, root.Usage(ctx), "Options:\n\n  --debug   debug mode\n\nCommands:\n\n  get    get items(aliases g)\n  list   list items(aliases ls)\n")
+
+	assert.Nil(t, root.Replace(&Command{Name: "put", Fn: donothing}))
+	assert.Equal(t, root.ListChildren(), []string{"get", "list", "put"})
+	assert.Panics(t, func() { root.Replace(&Command{Name: "del", Aliases: []string{"ls"}}) })
+	// child is replaced by name, not by alias of another child
+	assert.Panics(t, func() { root.Replace(&Command{Name: "ls"}) })
+	assert.Equal(t, root.Route([]string{"ls"}), list)
+}
+
+func TestReplaceHTTPRouters(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{Name: "get", HTTPRouters: []string{"/v1/get"}, Fn: func(ctx *Context) error {
+		ctx.String("old")
+		return nil
+	}})
+	user := root.Register(&Command{Name: "user"})
+	user.Register(&Command{Name: "add", HTTPRouters: []string{"/v1/user/add"}, Fn: donothing})
+	require.Nil(t, root.RegisterHTTP())
+
+	serve := func(path string) string {
+		w := httptest.NewRecorder()
+		root.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w.Body.String()
+	}
+	root.Replace(&Command{Name: "get", HTTPRouters: []string{"/v2/get"}, Fn: func(ctx *Context) error {
+		ctx.String("new")
+		return nil
+	}})
+	assert.Equal(t, serve("/v2/get"), "new")
+	assert.Equal(t, root.getRoutersMap(), map[string]string{"/v2/get": "get", "/v1/user/add": "user/add"})
+
+	assert.Equal(t, root.Unregister("user"), user)
+	assert.Equal(t, root.getRoutersMap(), map[string]string{"/v2/get": "get"})
+
+	assert.Panics(t, func() { root.Replace(&Command{Name: "put", HTTPRouters: []string{"/v2/get"}}) })
+	assert.Equal(t, root.ListChildren(), []string{"get"})
+}
+
+func TestArgvContext(t *testing.T) {
+	type rootT struct {
+		Namespace string `cli:"ns" dft:"default"`
+	}
+	type podT struct {
+		Selector string `cli:"selector" dft:"all"`
+		Label    string `cli:"label"`
+	}
+	root := &Command{
+		Name:   "app",
+		Global: true,
+		Argv:   func() interface{} { return new(rootT) },
+	}
+	var got *podT
+	pod := root.Register(&Command{
+		Name: "pod",
+		ArgvContext: func(ctx *Context) interface{} {
+			assert.Equal(t, ctx.Path(), "pod")
+			return &podT{Label: "ns=" + ctx.RootArgv().(*rootT).Namespace}
+		},
+		Fn: func(ctx *Context) error {
+			got = ctx.Argv().(*podT)
+			return nil
+		},
+	})
+	for _, tt := range []struct {
+		args []string
+		want podT
+	}{
+		{[]string{"pod"}, podT{Selector: "all", Label: "ns=default"}},
+		{[]string{"pod", "--selector=x", "--ns=kube"}, podT{Selector: "x", Label: "ns=kube"}},
+		{[]string{"pod", "--ns=kube", "--label=y"}, podT{Selector: "all", Label: "y"}},
+	} {
+		assert.Nil(t, root.RunWith(tt.args, nil, nil))
+		assert.Equal(t, *got, tt.want, "args: %v", tt.args)
+	}
+	assert.Contains(t, pod.Usage(&Context{}), "--selector")
+}
+
+func TestArgvContextOfNestedCommands(t *testing.T) {
+	type rootT struct {
+		Namespace string `cli:"ns" dft:"default"`
+	}
+	type clusterT struct {
+		Zone string `cli:"zone"`
+	}
+	type podT struct {
+		Label string `cli:"label"`
+	}
+	calls := map[string]int{}
+	root := &Command{
+		Name:   "app",
+		Global: true,
+		Argv: func() interface{} {
+			calls["app"]++
+			return new(rootT)
+		},
+	}
+	cluster := root.Register(&Command{
+		Name:   "cluster",
+		Global: true,
+		ArgvContext: func(ctx *Context) interface{} {
+			calls["cluster"]++
+			return &clusterT{Zone: ctx.RootArgv().(*rootT).Namespace}
+		},
+	})
+	var (
+		got  *podT
+		args []string
+	)
+	cluster.Register(&Command{
+		Name: "pod",
+		ArgvContext: func(ctx *Context) interface{} {
+			calls["pod"]++
+			args = ctx.Args()
+			var (
+				clusterArgv clusterT
+				rootArgv    rootT
+			)
+			assert.Nil(t, ctx.GetArgvList(nil, &clusterArgv, &rootArgv))
+			return &podT{Label: rootArgv.Namespace + "/" + clusterArgv.Zone}
+		},
+		Fn: func(ctx *Context) error {
+			got = ctx.Argv().(*podT)
+			return nil
+		},
+	})
+	assert.Nil(t, root.RunWith([]string{"cluster", "pod", "--ns=kube", "x", "--zone", "z1"}, nil, nil))
+	assert.Equal(t, *got, podT{Label: "kube/z1"})
+	assert.Equal(t, args, []string{"x"})
+	assert.Equal(t, calls, map[string]int{"app": 2, "cluster": 2, "pod": 1})
+}
+
+type pageArgT struct {
+	Page int `cli:"page" usage:"page number" dft:"1"`
+}
+
+func (argv *pageArgT) Validate(ctx *Context) error {
+	if argv.Page < 1 {
+		return fmt.Errorf("invalid page %d", argv.Page)
+	}
+	return nil
+}
+
+func TestArgvs(t *testing.T) {
+	type nameT struct {
+		Name string `cli:"name" usage:"name of user"`
+	}
+	type connT struct {
+		Host string `cli:"host" usage:"host of server" dft:"localhost"`
+	}
+	var (
+		name *nameT
+		conn *connT
+		page *pageArgT
+	)
+	root := &Command{Name: "app"}
+	list := root.Register(&Command{
+		Name:  "list",
+		Argv:  func() interface{} { return new(nameT) },
+		Argvs: []ArgvFunc{func() interface{} { return new(connT) }, func() interface{} { return new(pageArgT) }},
+		Fn: func(ctx *Context) error {
+			name = ctx.Argv().(*nameT)
+			argvs := ctx.Argvs()
+			conn, page = argvs[0].(*connT), argvs[1].(*pageArgT)
+			return nil
+		},
+	})
+	assert.Nil(t, root.RunWith([]string{"list", "--page=2", "--name=x"}, nil, nil))
+	assert.Equal(t, *name, nameT{Name: "x"})
+	assert.Equal(t, *conn, connT{Host: "localhost"})
+	assert.Equal(t, *page, pageArgT{Page: 2})
+
+	// argvs of Argvs are validated
+	assert.Error(t, root.RunWith([]string{"list", "--page=0"}, nil, nil))
+	assert.Error(t, root.RunWith([]string{"list", "--undefined"}, nil, nil))
+
+	usage := list.Usage(&Context{})
+	assert.True(t, strings.Index(usage, "--name") < strings.Index(usage, "--host"))
+	assert.True(t, strings.Index(usage, "--host") < strings.Index(usage, "--page"))
+	assert.Equal(t, len(list.Describe().Flags), 3)
+}
+
+type formatArgT struct {
+	Output string `cli:"output" dft:"yaml"`
+}
+
+func (f *formatArgT) Format() string { return f.Output }
+
+type rootFormatArgT struct {
+	Output string `cli:"root-output" dft:"json"`
+}
+
+func (f *rootFormatArgT) Format() string { return f.Output }
+
+func TestArgvsOfGlobalAncestors(t *testing.T) {
+	type connT struct {
+		Host string `cli:"host" usage:"host of server" dft:"localhost"`
+	}
+	type nameT struct {
+		Name string `cli:"name" usage:"name of user"`
+	}
+	var (
+		conn   *connT
+		format string
+	)
+	root := &Command{
+		Name:   "app",
+		Global: true,
+		Argvs: []ArgvFunc{
+			func() interface{} { return new(connT) },
+			func() interface{} { return new(rootFormatArgT) },
+		},
+	}
+	list := root.Register(&Command{
+		Name:  "list",
+		Argv:  func() interface{} { return new(nameT) },
+		Argvs: []ArgvFunc{func() interface{} { return new(formatArgT) }},
+		Fn: func(ctx *Context) error {
+			for _, argv := range ctx.allArgvs() {
+				if c, ok := argv.(*connT); ok {
+					conn = c
+				}
+			}
+			format = ctx.outputFormat()
+			return nil
+		},
+	})
+	assert.Nil(t, root.RunWith([]string{"list", "--host=example.com", "--name=x"}, nil, nil))
+	assert.Equal(t, *conn, connT{Host: "example.com"})
+	// mixins of command take precedence over those of ancestors
+	assert.Equal(t, format, OutputFormatYAML)
+	assert.Contains(t, list.Usage(&Context{}), "--host")
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+)
+
+// Complete returns candidates for the last word of line, line is arguments after
+// name of cmd, e.g. `sub --na`. Candidates are names of visible sub commands,
+// or names of flags if the word starts with `-`.
+func (cmd *Command) Complete(line string) []string {
+	args, err := SplitArgs(line)
+	if err != nil {
+		return nil
+	}
+	word := ""
+	if len(args) > 0 && !strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\t") {
+		word = args[len(args)-1]
+		args = args[:len(args)-1]
+	}
+
+	target := cmd
+	for _, arg := range args {
+		if strings.HasPrefix(arg, dashOne) {
+			break
+		}
+		child := target.findChild(arg)
+		if child == nil {
+			break
+		}
+		target = child
+	}
+
+	candidates := []string{}
+	if strings.HasPrefix(word, dashOne) {
+		for _, name := range target.flagNames() {
+			if strings.HasPrefix(name, word) {
+				candidates = append(candidates, name)
+			}
+		}
+	} else {
+		for _, child := range target.getChildren() {
+			if !child.isHidden() && strings.HasPrefix(child.Name, word) {
+				candidates = append(candidates, child.Name)
+			}
+		}
+	}
+	sort.Strings(candidates)
+	return candidates
+}
+
+// flagNames returns names of flags supported on current platform
+func (cmd *Command) flagNames() []string {
+	names := []string{}
+	for _, fl := range cmd.flagSlice() {
+		if fl.tag.unsupported == "" {
+			names = append(names, append(fl.tag.shortNames, fl.tag.longNames...)...)
+		}
+	}
+	return names
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplete(t *testing.T) {
+	type argT struct {
+		Name    string `cli:"n,name"`
+		Verbose bool   `cli:"verbose"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{Name: "hello", Argv: func() interface{} { return new(argT) }, Fn: donothing})
+	root.Register(&Command{Name: "help", Fn: donothing})
+	root.Register(&Command{Name: "hidden", Hidden: true, Fn: donothing})
+
+	assert.Equal(t, root.Complete(""), []string{"hello", "help"})
+	assert.Equal(t, root.Complete("hel"), []string{"hello", "help"})
+	assert.Equal(t, root.Complete("hell"), []string{"hello"})
+	assert.Equal(t, root.Complete("hello "), []string{})
+	assert.Equal(t, root.Complete("hello --"), []string{"--name", "--verbose"})
+	assert.Equal(t, root.Complete("hello -n x --v"), []string{"--verbose"})
+	assert.Equal(t, root.Complete("hello '"), []string(nil))
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/labstack/gommon/color"
+)
+
+// ConfigDecoder decodes content of config file into v
+type ConfigDecoder func(data []byte, v interface{}) error
+
+var (
+	configDecoders = map[string]ConfigDecoder{}
+	// builtinConfigDecoders are extensions of builtin decoders which can be replaced
+	builtinConfigDecoders = map[string]bool{}
+)
+
+// RegisterConfigDecoder registers ConfigDecoder by file extension. Decoders of .json,
+// .yaml, .yml and .toml are builtin, the YAML and TOML decoders support the subset
+// used by config files, they can be replaced by full implementations, e.g.
+//
+//	cli.RegisterConfigDecoder(".yaml", yaml.Unmarshal)
+//	cli.RegisterConfigDecoder(".toml", toml.Unmarshal)
+func RegisterConfigDecoder(ext string, decoder ConfigDecoder) {
+	ext = strings.ToLower(ext)
+	if _, ok := configDecoders[ext]; ok && !builtinConfigDecoders[ext] {
+		panic("RegisterConfigDecoder has registered: " + ext)
+	}
+	delete(builtinConfigDecoders, ext)
+	configDecoders[ext] = decoder
+}
+
+func init() {
+	for ext, decoder := range map[string]ConfigDecoder{
+		".json": json.Unmarshal,
+		".yaml": unmarshalYAML,
+		".yml":  unmarshalYAML,
+		".toml": unmarshalTOML,
+	} {
+		RegisterConfigDecoder(ext, decoder)
+		builtinConfigDecoders[ext] = true
+	}
+}
+
+// assignJSON stores value into v by encoding/json
+func assignJSON(value, v interface{}) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// ConfigFile specifies where to load values of flags from. Format of config file is
+// determined by extension: JSON, YAML or TOML, see RegisterConfigDecoder. Keys of
+// config file are names of flags without dashes, e.g.
+//
+//	{"port": 8080, "tags": ["a", "b"], "v": true}
+//
+// Precedence of values is default < env < config file < command line flags,
+// see Sources for customization.
+type ConfigFile struct {
+	// Flag is name of the flag designating path of config file, e.g. "config",
+	// the flag should be defined in argv. Value of the flag may come from its
+	// `env` or `dft` tag too.
+	Flag string
+	// Paths are standard locations tried in order while Flag has no value,
+	// leading `~` is expanded to home directory
+	Paths []string
+}
+
+// path returns path of config file, empty if not found
+func (cf *ConfigFile) path(flagSet *flagSet) (string, error) {
+	if cf.Flag != "" {
+		if fl, ok := flagSet.lookup(cf.Flag); ok && fl.isAssigned {
+			path := fl.valueString()
+			if fl.isNeedDelaySet {
+				// flags are not delay set yet
+				path = fl.lastValue
+			}
+			if path != "" {
+				return path, nil
+			}
+		}
+	}
+	for _, path := range cf.Paths {
+		path, err := expandHome(path)
+		if err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// readConfig sets flags from config file
+func (fs *flagSet) readConfig(clr color.Color) error {
+	path, err := fs.configFile.path(fs)
+	if err != nil || path == "" {
+		return err
+	}
+	decoder, ok := configDecoders[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return fmt.Errorf("config file %s: unsupported format", clr.Bold(path))
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := map[string]interface{}{}
+	if err := decoder(data, &values); err != nil {
+		return fmt.Errorf("config file %s: %v", clr.Bold(path), err)
+	}
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fl, ok := fs.lookup(key)
+		if !ok {
+			if fs.configInherited {
+				continue
+			}
+			return fmt.Errorf("config file %s: undefined option %s", clr.Bold(path), clr.Bold(key))
+		}
+		if values[key] == nil || !fs.canOverride(fl, SourceConfig) {
+			continue
+		}
+		if err := fl.setSourceValue(SourceConfig, values[key], clr); err != nil {
+			return fmt.Errorf("config file %s: parameter %s invalid: %w", clr.Bold(path), clr.Bold(key), err)
+		}
+		if !fl.isNeedDelaySet {
+			fs.values[fl.valueKey()] = []string{fl.valueString()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/gommon/color"
+)
+
+// Config formats supported by GenerateConfig
+const (
+	ConfigFormatYAML = "yaml"
+	ConfigFormatTOML = "toml"
+)
+
+// GenerateConfig writes a commented config file of format(yaml or toml) derived from
+// flags of argv, usage and default values of flags are written as comments and values.
+// Flags without value are commented out. Password flags and fields tagged
+// `json:"-"` (e.g. builtin helpers) are skipped.
+func GenerateConfig(w io.Writer, argv interface{}, format string) error {
+	if format != ConfigFormatYAML && format != ConfigFormatTOML {
+		return fmt.Errorf("unsupported config format %s", format)
+	}
+	typ := reflect.TypeOf(argv)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+		return errNotAPointerToStruct
+	}
+	flagSet := newFlagSet()
+	initFlagSet(typ, reflect.ValueOf(argv), flagSet, color.Color{}, false)
+	if flagSet.err != nil {
+		return flagSet.err
+	}
+
+	buf := new(bytes.Buffer)
+	sep := ": "
+	if format == ConfigFormatTOML {
+		sep = " = "
+	}
+	for _, fl := range flagSet.flagSlice {
+		key := configKey(fl)
+		if key == "" || fl.tag.isPassword || fl.field.Tag.Get("json") == "-" {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		if fl.tag.usage != "" {
+			fmt.Fprintf(buf, "# %s\n", fl.tag.usage)
+		}
+		if fl.tag.isRequired {
+			buf.WriteString("# (required)\n")
+		}
+		if !fl.isAssigned && isEmpty(reflect.Indirect(fl.value)) {
+			buf.WriteString("# ")
+		}
+		fmt.Fprintf(buf, "%s%s%s\n", key, sep, configValue(fl, format))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// configKey returns key of flag in config file, see ConfigFile
+func configKey(fl *flag) string {
+	if len(fl.tag.longNames) > 0 {
+		return strings.TrimPrefix(fl.tag.longNames[0], dashTwo)
+	}
+	if len(fl.tag.shortNames) > 0 {
+		return strings.TrimPrefix(fl.tag.shortNames[0], dashOne)
+	}
+	return ""
+}
+
+func configValue(fl *flag, format string) string {
+	if fl.isNeedDelaySet && fl.isAssigned {
+		return configScalar(fl.elemType().Kind(), fl.lastValue, format)
+	}
+	value := reflect.Indirect(fl.value)
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		elems := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			elem := value.Index(i)
+			elems = append(elems, configScalar(elem.Kind(), fmt.Sprintf("%v", elem.Interface()), format))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	case reflect.Map:
+		sep := ": "
+		if format == ConfigFormatTOML {
+			sep = " = "
+		}
+		elems := make([]string, 0, value.Len())
+		for _, key := range value.MapKeys() {
+			elem := value.MapIndex(key)
+			elems = append(elems, configMapKey(fmt.Sprintf("%v", key.Interface()), format)+sep+
+				configScalar(elem.Kind(), fmt.Sprintf("%v", elem.Interface()), format))
+		}
+		sort.Strings(elems)
+		return "{" + strings.Join(elems, ", ") + "}"
+	}
+	return configScalar(fl.elemType().Kind(), fl.valueString(), format)
+}
+
+// configScalar formats s as a scalar of format, values of numeric kinds which
+// aren't numbers, e.g. durations, are written as strings
+func configScalar(kind reflect.Kind, s, format string) string {
+	switch kind {
+	case reflect.Bool:
+		if s == "" {
+			return "false"
+		}
+		return s
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if s == "" {
+			return "0"
+		}
+		if _, ok := yamlNumber(s); ok {
+			return s
+		}
+	}
+	if format == ConfigFormatYAML {
+		return configYAMLString(s)
+	}
+	return tomlString(s)
+}
+
+// configYAMLString returns s as a YAML scalar which can be an element of flow collection
+func configYAMLString(s string) string {
+	if strings.ContainsAny(s, ",[]{}") {
+		return strconv.Quote(s)
+	}
+	return yamlString(s)
+}
+
+func configMapKey(key, format string) string {
+	if format == ConfigFormatYAML {
+		return configYAMLString(key)
+	}
+	for i := 0; i < len(key); i++ {
+		if !isTOMLBareKeyChar(key[i]) {
+			return tomlString(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// configFormat returns format of config file derived from extension of filename
+func configFormat(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYAML
+	case ".toml":
+		return ConfigFormatTOML
+	}
+	return ""
+}
+
+type configInitT struct {
+	Helper
+	Output string `cli:"o,output" usage:"output file, write to stdout if empty"`
+	Format string `cli:"format" usage:"config format: yaml or toml, derived from extension of output file by default"`
+	Force  bool   `cli:"f,force" usage:"overwrite existing file"`
+}
+
+// ConfigInitCommand returns a command which generates default config file
+// for argv created by argvFn, e.g. register ConfigInitCommand as `app config init`
+func ConfigInitCommand(desc string, argvFn ArgvFunc) *Command {
+	if desc == "" {
+		desc = "generate default config file"
+	}
+	return &Command{
+		Name: "init",
+		Desc: desc,
+		Argv: func() interface{} { return new(configInitT) },
+		Fn: func(ctx *Context) error {
+			argv := ctx.Argv().(*configInitT)
+			format := argv.Format
+			if argv.Output != "" {
+				// config file is loaded by decoder of its extension, see ConfigFile
+				ext := configFormat(argv.Output)
+				if ext == "" {
+					return fmt.Errorf("unsupported config file %s, extension should be .yaml, .yml or .toml", ctx.Color().Bold(argv.Output))
+				}
+				if format != "" && format != ext {
+					return fmt.Errorf("format %s doesn't match extension of %s", format, ctx.Color().Bold(argv.Output))
+				}
+				format = ext
+			}
+			if format == "" {
+				format = ConfigFormatYAML
+			}
+			buf := new(bytes.Buffer)
+			if err := GenerateConfig(buf, argvFn(), format); err != nil {
+				return err
+			}
+			if argv.Output == "" {
+				_, err := ctx.Write(buf.Bytes())
+				return err
+			}
+			version, err := StatFileVersion(argv.Output)
+			if err != nil {
+				return err
+			}
+			if version.Exists && !argv.Force {
+				return fmt.Errorf("file %s exists, use --force to overwrite", ctx.Color().Bold(argv.Output))
+			}
+			// fails if the file is written by another process meanwhile
+			return WriteFileIfUnchanged(argv.Output, buf.Bytes(), 0644, version)
+		},
+	}
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type configInitArgT struct {
+	Helper
+	Host     string         `cli:"H,host" usage:"server host" dft:"localhost"`
+	Port     int            `cli:"*port" usage:"server port"`
+	Verbose  bool           `cli:"v" usage:"verbose mode" dft:"true"`
+	Tags     []string       `cli:"tags" usage:"tags of server"`
+	Weights  map[string]int `cli:"weights"`
+	Password string         `pw:"p,password" usage:"password"`
+}
+
+func TestGenerateConfig(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.Nil(t, GenerateConfig(buf, &configInitArgT{Tags: []string{"a", "b"}}, ConfigFormatYAML))
+	assert.Equal(t, buf.String(), `# server host
+host: localhost
+
+# server port
+# (required)
+# port: 0
+
+# verbose mode
+v: true
+
+# tags of server
+tags: [a, b]
+
+# weights: {}
+`)
+
+	buf.Reset()
+	argv := &configInitArgT{Port: 80, Weights: map[string]int{"y": 2, "x": 1}}
+	require.Nil(t, GenerateConfig(buf, argv, ConfigFormatTOML))
+	assert.Equal(t, buf.String(), `# server host
+host = "localhost"
+
+# server port
+# (required)
+port = 80
+
+# verbose mode
+v = true
+
+# tags of server
+# tags = []
+
+weights = {x = 1, y = 2}
+`)
+
+	assert.Error(t, GenerateConfig(buf, new(configInitArgT), "ini"))
+	assert.Error(t, GenerateConfig(buf, configInitArgT{}, ConfigFormatYAML))
+}
+
+func TestConfigInitCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-config-init")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	root := &Command{}
+	root.Register(&Command{Name: "config"}).Register(ConfigInitCommand("", func() interface{} { return new(configInitArgT) }))
+
+	w := new(bytes.Buffer)
+	require.Nil(t, root.RunWith([]string{"config", "init", "--format", "toml"}, w, nil))
+	assert.Contains(t, w.String(), `host = "localhost"`)
+
+	filename := filepath.Join(dir, "app.yaml")
+	require.Nil(t, root.RunWith([]string{"config", "init", "-o", filename}, w, nil))
+	data, err := ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Contains(t, string(data), `host: localhost`)
+	assert.Error(t, root.RunWith([]string{"config", "init", "-o", filename}, w, nil))
+	assert.Nil(t, root.RunWith([]string{"config", "init", "-o", filename, "--force"}, w, nil))
+
+	// format is derived from extension of output file
+	filename = filepath.Join(dir, "app.toml")
+	require.Nil(t, root.RunWith([]string{"config", "init", "-o", filename}, w, nil))
+	data, err = ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Contains(t, string(data), `host = "localhost"`)
+	assert.Error(t, root.RunWith([]string{"config", "init", "-o", filepath.Join(dir, "app.yml"), "--format", "toml"}, w, nil))
+	assert.Error(t, root.RunWith([]string{"config", "init", "-o", filepath.Join(dir, "app.json")}, w, nil))
+}
+
+func TestGenerateConfigRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-config-init")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	type argT struct {
+		Config  string            `cli:"config" json:"-"`
+		Host    string            `cli:"H,host" usage:"server host" dft:"localhost"`
+		Port    int               `cli:"port" usage:"server port"`
+		Ratio   float64           `cli:"ratio" dft:"0.25"`
+		Mask    int               `cli:"mask" dft:"0x1F"`
+		Mode    string            `cli:"mode" dft:"0o755"`
+		Verbose bool              `cli:"v"`
+		Note    string            `cli:"note"`
+		Tags    []string          `cli:"tags"`
+		Ports   []int             `cli:"ports"`
+		Labels  map[string]string `cli:"labels"`
+		Empty   []string          `cli:"empty"`
+	}
+	src := &argT{
+		Port:    8080,
+		Ratio:   0.5,
+		Verbose: true,
+		Note:    "say \"hi\": #1 \\ 中文\ttab",
+		Tags:    []string{"true", "12", "- x", "a, b"},
+		Ports:   []int{80, 443},
+		Labels:  map[string]string{"app name": "web", "tier": "yes", "k.v": "1"},
+	}
+	for _, format := range []string{ConfigFormatYAML, ConfigFormatTOML} {
+		buf := new(bytes.Buffer)
+		require.Nil(t, GenerateConfig(buf, src, format))
+		path := filepath.Join(dir, "app."+format)
+		require.Nil(t, ioutil.WriteFile(path, buf.Bytes(), 0644))
+
+		argv := new(argT)
+		err := (&Command{
+			Argv:       func() interface{} { return argv },
+			ConfigFile: &ConfigFile{Flag: "config"},
+			Fn:         donothing,
+		}).RunWith([]string{"--config", path}, ioutil.Discard, nil)
+		if assert.Nil(t, err, buf.String()) {
+			want := *src
+			want.Config, want.Host, want.Mask, want.Mode = path, "localhost", 0x1F, "0o755"
+			assert.Equal(t, *argv, want, buf.String())
+		}
+	}
+}
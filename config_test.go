@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	var (
+		stdPath   = filepath.Join(dir, "app.json")
+		otherPath = filepath.Join(dir, "other.json")
+	)
+	require.Nil(t, ioutil.WriteFile(stdPath, []byte(`{"port": 8080, "host": "example.com", "tags": ["a", "b"], "v": true}`), 0644))
+	require.Nil(t, ioutil.WriteFile(otherPath, []byte(`{"port": 9090}`), 0644))
+
+	type argT struct {
+		Config string   `cli:"c,config"`
+		Port   int      `cli:"*port"`
+		Host   string   `cli:"host" dft:"localhost"`
+		Tags   []string `cli:"tags"`
+		DryRun bool     `cli:"dry-run"`
+		V      bool     `cli:"v"`
+	}
+	run := func(args ...string) (*argT, error) {
+		argv := new(argT)
+		err := (&Command{
+			Argv: func() interface{} { return argv },
+			ConfigFile: &ConfigFile{
+				Flag:  "config",
+				Paths: []string{filepath.Join(dir, "missing.json"), stdPath},
+			},
+			Fn: donothing,
+		}).RunWith(args, ioutil.Discard, nil)
+		return argv, err
+	}
+
+	argv, err := run()
+	if assert.Nil(t, err) {
+		assert.Equal(t, *argv, argT{Port: 8080, Host: "example.com", Tags: []string{"a", "b"}, V: true})
+	}
+
+	// flags take precedence over config file
+	argv, err = run("--port=1", "--tags", "c", "--host", "h")
+	if assert.Nil(t, err) {
+		assert.Equal(t, *argv, argT{Port: 1, Host: "h", Tags: []string{"c"}, V: true})
+	}
+
+	// config file designated by flag
+	argv, err = run("-c", otherPath)
+	if assert.Nil(t, err) {
+		assert.Equal(t, *argv, argT{Config: otherPath, Port: 9090, Host: "localhost"})
+	}
+
+	_, err = run("-c", filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+
+	badPath := filepath.Join(dir, "bad.json")
+	require.Nil(t, ioutil.WriteFile(badPath, []byte(`{"undefined": 1}`), 0644))
+	_, err = run("-c", badPath)
+	assert.Error(t, err)
+
+	require.Nil(t, ioutil.WriteFile(badPath, []byte(`{"port": "not-a-number"}`), 0644))
+	_, err = run("-c", badPath)
+	assert.Error(t, err)
+
+	_, err = run("-c", filepath.Join(dir, "app.ini"))
+	assert.Error(t, err)
+}
+
+func TestConfigFileFromEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.json")
+	require.Nil(t, ioutil.WriteFile(path, []byte(`{"port": 9090}`), 0644))
+	defer os.Unsetenv("ZZ_CONFIG")
+
+	type argT struct {
+		Config string `cli:"config" env:"ZZ_CONFIG"`
+		Port   int    `cli:"port" dft:"8080"`
+	}
+	run := func(args ...string) *argT {
+		argv := new(argT)
+		require.Nil(t, (&Command{
+			Argv:       func() interface{} { return argv },
+			ConfigFile: &ConfigFile{Flag: "config"},
+			Fn:         donothing,
+		}).RunWith(args, ioutil.Discard, nil))
+		return argv
+	}
+
+	assert.Equal(t, *run(), argT{Port: 8080})
+	os.Setenv("ZZ_CONFIG", path)
+	assert.Equal(t, *run(), argT{Config: path, Port: 9090})
+	assert.Equal(t, *run("--port", "1"), argT{Config: path, Port: 1})
+}
+
+func TestConfigFileInherited(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.json")
+	require.Nil(t, ioutil.WriteFile(path, []byte(`{"port": 8080, "short": true}`), 0644))
+
+	type serveT struct {
+		Port int `cli:"port"`
+	}
+	type versionT struct {
+		Short bool `cli:"short"`
+	}
+	serveArgv, versionArgv := new(serveT), new(versionT)
+	root := &Command{
+		Name:       "app",
+		ConfigFile: &ConfigFile{Paths: []string{path}},
+	}
+	root.Register(&Command{
+		Name: "serve",
+		Argv: func() interface{} { return serveArgv },
+		Fn:   donothing,
+	})
+	root.Register(&Command{
+		Name: "version",
+		Argv: func() interface{} { return versionArgv },
+		Fn:   donothing,
+	})
+	root.Register(&Command{
+		Name: "ping",
+		Fn:   donothing,
+	})
+	strict := root.Register(&Command{
+		Name:       "strict",
+		Argv:       func() interface{} { return new(serveT) },
+		ConfigFile: &ConfigFile{Paths: []string{path}},
+		Fn:         donothing,
+	})
+
+	// keys undefined by child are ignored while config file is inherited from root
+	if assert.Nil(t, root.RunWith([]string{"serve"}, ioutil.Discard, nil)) {
+		assert.Equal(t, serveArgv.Port, 8080)
+	}
+	if assert.Nil(t, root.RunWith([]string{"version"}, ioutil.Discard, nil)) {
+		assert.True(t, versionArgv.Short)
+	}
+	assert.Nil(t, root.RunWith([]string{"ping"}, ioutil.Discard, nil))
+
+	// config file of command itself is strict
+	assert.Error(t, root.RunWith([]string{strict.Name}, ioutil.Discard, nil))
+}
+
+func TestConfigFileFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-config")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	type argT struct {
+		Config string            `cli:"config"`
+		Port   int               `cli:"port"`
+		Host   string            `cli:"host"`
+		Tags   []string          `cli:"tags"`
+		Env    map[string]string `cli:"env"`
+		Labels map[string]string `cli:"labels" sep:":"`
+		V      bool              `cli:"v"`
+	}
+	want := argT{Port: 8080, Host: "example.com", Tags: []string{"a", "b"}, Env: map[string]string{"k": "v"}, Labels: map[string]string{"a=b": "c"}, V: true}
+	for name, content := range map[string]string{
+		"app.json": `{"port": 8080, "host": "example.com", "tags": ["a", "b"], "env": {"k": "v"}, "labels": {"a=b": "c"}, "v": true}`,
+		"app.yaml": "# app\nport: 8080\nhost: example.com # comment\ntags:\n  - a\n  - \"b\"\nenv: {k: v}\nlabels:\n  \"a=b\": c\nv: true\n",
+		"app.yml":  "port: 8080\nhost: 'example.com'\ntags: [a, b]\nenv:\n  k: v\nlabels:\n  \"a=b\": c\nv: yes\n",
+		"app.toml": "# app\nport = 8080\nhost = \"example.com\" # comment\ntags = [\n  \"a\",\n  'b',\n]\nv = true\n\n[env]\nk = \"v\"\n\n[labels]\n\"a=b\" = \"c\"\n",
+	} {
+		path := filepath.Join(dir, name)
+		require.Nil(t, ioutil.WriteFile(path, []byte(content), 0644))
+		argv := new(argT)
+		err := (&Command{
+			Argv:       func() interface{} { return argv },
+			ConfigFile: &ConfigFile{Flag: "config"},
+			Fn:         donothing,
+		}).RunWith([]string{"--config", path}, ioutil.Discard, nil)
+		if assert.Nil(t, err, name) {
+			want.Config = path
+			assert.Equal(t, *argv, want, name)
+		}
+	}
+}
+
+func TestRegisterConfigDecoder(t *testing.T) {
+	defer func(decoder ConfigDecoder) {
+		configDecoders[".yaml"] = decoder
+		builtinConfigDecoders[".yaml"] = true
+	}(configDecoders[".yaml"])
+
+	// builtin decoders can be replaced once
+	assert.NotPanics(t, func() { RegisterConfigDecoder(".yaml", unmarshalYAML) })
+	assert.Panics(t, func() { RegisterConfigDecoder(".yaml", unmarshalYAML) })
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConflictAction is action for resolving a conflict, e.g. target file exists
+type ConflictAction int
+
+// Conflict actions returned by Context.ResolveConflict
+const (
+	ConflictSkip ConflictAction = iota + 1
+	ConflictOverwrite
+	ConflictAbort
+)
+
+func (action ConflictAction) String() string {
+	switch action {
+	case ConflictSkip:
+		return "skip"
+	case ConflictOverwrite:
+		return "overwrite"
+	case ConflictAbort:
+		return "abort"
+	}
+	return fmt.Sprintf("ConflictAction(%d)", int(action))
+}
+
+func parseConflictAction(s string) (ConflictAction, error) {
+	for _, action := range []ConflictAction{ConflictSkip, ConflictOverwrite, ConflictAbort} {
+		if s == action.String() {
+			return action, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported conflict policy %s", s)
+}
+
+type (
+	// ConflictPolicy represents interface for choosing action of conflicts
+	// which are not asked, it should return skip, overwrite or abort
+	ConflictPolicy interface {
+		ConflictPolicy() string
+	}
+
+	// ConflictHelper is builtin on-conflict flag
+	ConflictHelper struct {
+		OnConflict string `cli:"on-conflict" usage:"action for conflicts if not asked: skip, overwrite or abort" dft:"skip" json:"-"`
+	}
+)
+
+// ConflictPolicy implements ConflictPolicy interface
+func (h ConflictHelper) ConflictPolicy() string {
+	return h.OnConflict
+}
+
+func (ctx *Context) conflictPolicy() (ConflictAction, error) {
+	for _, argv := range ctx.allArgvs() {
+		if policy, ok := argv.(ConflictPolicy); ok && policy.ConflictPolicy() != "" {
+			return parseConflictAction(policy.ConflictPolicy())
+		}
+	}
+	return ConflictSkip, nil
+}
+
+// ResolveConflict asks whether to overwrite item, answers are
+//
+//	y, yes: overwrite item
+//	n, no:  skip item, it's the default answer
+//	a, all: overwrite item and all following items without asking
+//	q, quit: abort, and all following items are aborted without asking
+//
+// It returns ConflictOverwrite without asking if argv implements Confirmer and
+// AssumeYes returns true. If stdin is not a terminal, action is chosen by argv
+// which implements ConflictPolicy(e.g. ConflictHelper), ConflictSkip by default.
+func (ctx *Context) ResolveConflict(item string) (ConflictAction, error) {
+	if ctx.conflictAnswer != 0 {
+		return ctx.conflictAnswer, nil
+	}
+	if ctx.assumeYes() {
+		return ConflictOverwrite, nil
+	}
+	if _, ok := ctx.promptTerminal(); !ok {
+		return ctx.conflictPolicy()
+	}
+	action, all, err := resolveConflict(ctx.promptReader(), ctx.promptOutput(), item)
+	if all {
+		ctx.conflictAnswer = action
+	}
+	return action, err
+}
+
+// resolveConflict returns action and whether the answer applies to all following items
+func resolveConflict(r *bufio.Reader, w io.Writer, item string) (ConflictAction, bool, error) {
+	for {
+		fmt.Fprintf(w, "overwrite %s? [y]es/[N]o/[a]ll/[q]uit: ", item)
+		answer, err := readAnswer(r)
+		if err != nil {
+			return ConflictAbort, false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return ConflictOverwrite, false, nil
+		case "", "n", "no":
+			return ConflictSkip, false, nil
+		case "a", "all":
+			return ConflictOverwrite, true, nil
+		case "q", "quit":
+			return ConflictAbort, true, nil
+		}
+	}
+}
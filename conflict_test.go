@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveConflict(t *testing.T) {
+	w := new(bytes.Buffer)
+	r := bufio.NewReader(strings.NewReader("what\nY\n\nall\nq\n"))
+	for _, want := range []struct {
+		action ConflictAction
+		all    bool
+	}{
+		{ConflictOverwrite, false},
+		{ConflictSkip, false},
+		{ConflictOverwrite, true},
+		{ConflictAbort, true},
+	} {
+		action, all, err := resolveConflict(r, w, "a.txt")
+		require.Nil(t, err)
+		assert.Equal(t, action, want.action)
+		assert.Equal(t, all, want.all)
+	}
+	_, _, err := resolveConflict(r, w, "a.txt")
+	assert.Error(t, err)
+	assert.True(t, strings.HasPrefix(w.String(), "overwrite a.txt? [y]es/[N]o/[a]ll/[q]uit: overwrite a.txt?"))
+}
+
+func TestContextResolveConflict(t *testing.T) {
+	defer func(r io.Reader) { PromptInput = r }(PromptInput)
+	PromptInput = strings.NewReader("y\n")
+
+	type argT struct {
+		ConflictHelper
+		YesHelper
+	}
+	argv := new(argT)
+	ctx := &Context{argvList: []interface{}{argv}}
+	action, err := ctx.ResolveConflict("a.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, action, ConflictSkip)
+
+	argv.OnConflict = "abort"
+	action, err = ctx.ResolveConflict("a.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, action, ConflictAbort)
+
+	argv.OnConflict = "rename"
+	_, err = ctx.ResolveConflict("a.txt")
+	assert.Error(t, err)
+
+	argv.Yes = true
+	action, err = ctx.ResolveConflict("a.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, action, ConflictOverwrite)
+
+	ctx.conflictAnswer = ConflictAbort
+	action, err = ctx.ResolveConflict("a.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, action, ConflictAbort)
+	assert.Equal(t, action.String(), "abort")
+}
@@ -1,29 +1,61 @@
 package cli
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/labstack/gommon/color"
 	"github.com/mattn/go-colorable"
-	"github.com/mkideal/pkg/debug"
+	"github.com/mattn/go-isatty"
 )
 
 type (
 	// Context provides running context
 	Context struct {
-		router     []string
-		path       string
-		argvList   []interface{}
-		nativeArgs []string
-		flagSet    *flagSet
-		command    *Command
-		writer     io.Writer
-		color      color.Color
+		router         []string
+		remainRouter   []string // segments of router not matched by command which CanSubRoute
+		path           string
+		argvList       []interface{}
+		extraArgvs     []interface{} // argvs created by Argvs of command, see Argvs
+		argvLevels     argvLevels    // argvs of command and global ancestors
+		nativeArgs     []string
+		flagSet        *flagSet
+		command        *Command
+		goctx          context.Context
+		reader         io.Reader
+		writer         io.Writer
+		errWriter      io.Writer // overrides package level outputs of diagnostics if not nil
+		color          color.Color
+		events         *eventEmitter
+		defers         []func()
+		tempDir        string
+		err            error // error returned by command, set before running defers
+		writeErr       error // first error occurred while encoding output, see Err
+		warnings       warnings
+		summary        *Summary
+		startedAt      time.Time
+		timeout        time.Duration  // timeout applied to goctx
+		conflictAnswer ConflictAction // remembered answer of ResolveConflict
+		promptBuffer   *bufio.Reader  // buffered promptSource shared by prompts, see promptReader
+		promptSource   io.Reader
+		envelope       []interface{} // objects rendered in envelope mode
+		values         map[string]interface{}
+		valuesLocker   sync.Mutex // protect values
+		session        map[string]interface{}
+		notFound       string  // path of command not found, handled by OnNotFound
+		pathPlugin     *Plugin // plugin found in $PATH for command not found, see PathPlugins
+		remote         bool    // invoked by remote clients, plugins in $PATH are not listed
+		embedded       bool    // invoked by Engine, see isolated
 
 		HTTPRequest  *http.Request
 		HTTPResponse http.ResponseWriter
@@ -40,20 +72,36 @@ type (
 	}
 )
 
-func newContext(path string, router, args []string, argvList []interface{}, clr color.Color) (*Context, error) {
+func newContext(path string, router, args []string, levels argvLevels, clr color.Color, flagSet *flagSet) (*Context, error) {
 	ctx := &Context{
 		path:       path,
 		router:     router,
-		argvList:   argvList,
+		argvLevels: levels,
 		nativeArgs: args,
 		color:      clr,
-		flagSet:    newFlagSet(),
+		flagSet:    flagSet,
 	}
-	if !isEmptyArgvList(argvList) {
-		ctx.flagSet = parseArgvList(args, argvList, ctx.color)
+	if len(levels) > 0 {
+		ctx.argvList = levels.argvList()
+		ctx.extraArgvs = levels[0].argvs
+	}
+	if flagSet.passthrough {
+		ctx.flagSet.args = args
+		return ctx, nil
+	}
+	if allArgvs := ctx.allArgvs(); !isEmptyArgvList(allArgvs) {
+		ctx.flagSet = parseArgvListWithFlagSet(flagSet, args, allArgvs, ctx.color)
+		ctx.warnings.list = ctx.flagSet.deprecations()
 		if ctx.flagSet.err != nil {
 			return ctx, ctx.flagSet.err
 		}
+	} else {
+		for i, arg := range args {
+			if arg == dashTwo {
+				ctx.flagSet.tailArgs = args[i+1:]
+				break
+			}
+		}
 	}
 	return ctx, nil
 }
@@ -70,18 +118,37 @@ func (ctx *Context) Router() []string {
 	return ctx.router
 }
 
+// RemainRouter returns segments of router not matched by command which CanSubRoute,
+// they are leading free args of Args too, flags are excluded.
+// `./app proxy v1 users -a --xyz=1` will return ["v1" "users"] if `proxy` CanSubRoute
+func (ctx *Context) RemainRouter() []string {
+	return ctx.remainRouter
+}
+
 // NativeArgs returns native args
 // `./app hello world -a --xyz=1` will return ["-a" "--xyz=1"]
 func (ctx *Context) NativeArgs() []string {
 	return ctx.nativeArgs
 }
 
-// Args returns free args
+// Args returns free args, including RemainRouter
 // `./app hello world -a=1 abc xyz` will return ["abc" "xyz"]
 func (ctx *Context) Args() []string {
 	return ctx.flagSet.args
 }
 
+// TailArgs returns args after `--`
+// `./app run -a -- ls -l` will return ["ls" "-l"]
+func (ctx *Context) TailArgs() []string {
+	return ctx.flagSet.tailArgs
+}
+
+// UnknownFlags returns undefined flags if command allows unknown flags
+// `./app proxy -a --xyz=1 -b` will return ["--xyz=1" "-b"] if only `-a` defined
+func (ctx *Context) UnknownFlags() []string {
+	return ctx.flagSet.unknownFlags
+}
+
 // NArg returns length of Args
 func (ctx *Context) NArg() int {
 	return len(ctx.flagSet.args)
@@ -109,6 +176,25 @@ func (ctx *Context) Argv() interface{} {
 	return ctx.argvList[0]
 }
 
+// Argvs returns parsed args objects created by Argvs of command in order
+func (ctx *Context) Argvs() []interface{} {
+	return ctx.extraArgvs
+}
+
+// allArgvs returns argvs of command followed by global argvs of ancestors, argvs
+// created by Argvs of each command follow its argv. Builtin mixins are looked up in
+// all of them, those of command take precedence.
+func (ctx *Context) allArgvs() []interface{} {
+	if ctx.argvLevels != nil {
+		return ctx.argvLevels.all()
+	}
+	if len(ctx.extraArgvs) == 0 || len(ctx.argvList) == 0 {
+		return append(ctx.argvList, ctx.extraArgvs...)
+	}
+	list := append([]interface{}{ctx.argvList[0]}, ctx.extraArgvs...)
+	return append(list, ctx.argvList[1:]...)
+}
+
 func (ctx *Context) RootArgv() interface{} {
 	if isEmptyArgvList(ctx.argvList) {
 		return nil
@@ -143,14 +229,12 @@ func (ctx *Context) GetArgvList(curr interface{}, parents ...interface{}) error
 	return nil
 }
 
-// IsSet determins whether `flag` is set
+// IsSet determins whether `flag` is set explicitly from command line,
+// a flag which only has default value is not set.
+// `flag` could be with or without dash prefix, e.g. "-a", "--aa", "a", "aa"
 func (ctx *Context) IsSet(flag string, aliasFlags ...string) bool {
-	fl, ok := ctx.flagSet.flagMap[flag]
-	if ok {
-		return fl.isSet
-	}
-	for _, alias := range aliasFlags {
-		if fl, ok := ctx.flagSet.flagMap[alias]; ok {
+	for _, name := range append([]string{flag}, aliasFlags...) {
+		if fl, ok := ctx.flagSet.lookup(name); ok {
 			return fl.isSet
 		}
 	}
@@ -160,7 +244,7 @@ func (ctx *Context) IsSet(flag string, aliasFlags ...string) bool {
 // FormValues returns parsed args as url.Values
 func (ctx *Context) FormValues() url.Values {
 	if ctx.flagSet == nil {
-		debug.Panicf("ctx.flagSet == nil")
+		panicf("ctx.flagSet == nil")
 	}
 	return ctx.flagSet.values
 }
@@ -180,14 +264,97 @@ func (ctx *Context) WriteUsage() {
 	ctx.String(ctx.Usage())
 }
 
+// Context returns context of current invocation, it's context.Background() if not given
+func (ctx *Context) Context() context.Context {
+	if ctx.goctx == nil {
+		return context.Background()
+	}
+	return ctx.goctx
+}
+
+// isolated reports whether ctx is invoked by Engine or remote clients, standard
+// streams and file descriptors of process are not used by isolated contexts
+func (ctx *Context) isolated() bool {
+	return ctx.embedded || ctx.remote
+}
+
+// stderr returns writer for diagnostics, e.g. warnings and progress, dft used if not overridden
+func (ctx *Context) stderr(dft io.Writer) io.Writer {
+	if ctx.errWriter != nil {
+		return ctx.errWriter
+	}
+	return dft
+}
+
+// Reader returns reader injected by RunWithIO, os.Stdin by default.
+// Body of request is the reader for commands served over HTTP.
+func (ctx *Context) Reader() io.Reader {
+	if ctx.reader == nil {
+		return os.Stdin
+	}
+	return ctx.reader
+}
+
 // Writer returns writer
 func (ctx *Context) Writer() io.Writer {
 	if ctx.writer == nil {
-		ctx.writer = colorable.NewColorableStdout()
+		ctx.writer = newStdout()
 	}
 	return ctx.writer
 }
 
+// colorableWriter is a colorable writer of file, e.g. stdout on windows console
+// which translates escape sequences
+type colorableWriter struct {
+	io.Writer
+	file *os.File
+}
+
+// newStdout returns colorable writer of stdout
+func newStdout() io.Writer {
+	if w := colorable.NewColorableStdout(); w != io.Writer(os.Stdout) {
+		return colorableWriter{Writer: w, file: os.Stdout}
+	}
+	return os.Stdout
+}
+
+// writerFile returns file written by w
+func writerFile(w io.Writer) (*os.File, bool) {
+	switch w := w.(type) {
+	case *os.File:
+		return w, true
+	case colorableWriter:
+		return w.file, true
+	}
+	return nil, false
+}
+
+// IsTTY reports whether writer of ctx is a terminal, commands could write
+// human-friendly output if true, and machine-friendly output otherwise
+func (ctx *Context) IsTTY() bool {
+	return isTerminalWriter(ctx.Writer())
+}
+
+// IsPiped reports whether reader of ctx is not a terminal, e.g. `cat file | app`
+func (ctx *Context) IsPiped() bool {
+	file, ok := ctx.Reader().(*os.File)
+	return !ok || !isatty.IsTerminal(file.Fd())
+}
+
+// TerminalSize returns width and height of terminal of writer in columns and rows.
+// Environment variables COLUMNS and LINES are used if writer is not a terminal,
+// ok is false if size is unknown.
+func (ctx *Context) TerminalSize() (width, height int, ok bool) {
+	if file, isFile := writerFile(ctx.Writer()); isFile && isatty.IsTerminal(file.Fd()) {
+		if width, height, err := terminalSize(int(file.Fd())); err == nil && width > 0 {
+			return width, height, true
+		}
+	}
+	width, _ = strconv.Atoi(os.Getenv("COLUMNS"))
+	height, _ = strconv.Atoi(os.Getenv("LINES"))
+	return width, height, width > 0
+}
+
 // Write implements io.Writer
 func (ctx *Context) Write(data []byte) (n int, err error) {
 	return ctx.Writer().Write(data)
@@ -207,10 +374,7 @@ func (ctx *Context) String(format string, args ...interface{}) *Context {
 // JSON writes json string of obj to writer
 func (ctx *Context) JSON(obj interface{}) *Context {
 	data, err := json.Marshal(obj)
-	if err == nil {
-		fmt.Fprint(ctx.Writer(), string(data))
-	}
-	return ctx
+	return ctx.writeEncoded(data, err)
 }
 
 // JSONln writes json string of obj end with "\n" to writer
@@ -221,13 +385,46 @@ func (ctx *Context) JSONln(obj interface{}) *Context {
 // JSONIndent writes pretty json string of obj to writer
 func (ctx *Context) JSONIndent(obj interface{}, prefix, indent string) *Context {
 	data, err := json.MarshalIndent(obj, prefix, indent)
-	if err == nil {
-		fmt.Fprint(ctx.Writer(), string(data))
-	}
-	return ctx
+	return ctx.writeEncoded(data, err)
 }
 
 // JSONIndentln writes pretty json string of obj end with "\n" to writer
 func (ctx *Context) JSONIndentln(obj interface{}, prefix, indent string) *Context {
 	return ctx.JSONIndent(obj, prefix, indent).String("\n")
 }
+
+// Set stores value with key in ctx, it's used for passing data from hooks or
+// middlewares to handler, e.g. authenticated user. Values live in current invocation.
+func (ctx *Context) Set(key string, value interface{}) {
+	ctx.valuesLocker.Lock()
+	defer ctx.valuesLocker.Unlock()
+	if ctx.values == nil {
+		ctx.values = make(map[string]interface{})
+	}
+	ctx.values[key] = value
+}
+
+// Get returns value stored by Set, ok is false if key not found
+func (ctx *Context) Get(key string) (value interface{}, ok bool) {
+	ctx.valuesLocker.Lock()
+	defer ctx.valuesLocker.Unlock()
+	value, ok = ctx.values[key]
+	return
+}
+
+// Err returns the first error occurred while encoding output by JSON, JSONIndent, YAML
+// and their variants ending with "ln", output is dropped if encoding failed
+func (ctx *Context) Err() error {
+	return ctx.writeErr
+}
+
+func (ctx *Context) writeEncoded(data []byte, err error) *Context {
+	if err != nil {
+		if ctx.writeErr == nil {
+			ctx.writeErr = err
+		}
+		return ctx
+	}
+	ctx.Write(data)
+	return ctx
+}
@@ -2,6 +2,10 @@ package cli
 
 import (
 	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -130,3 +134,162 @@ func TestContextWriter(t *testing.T) {
 }
 end`)
 }
+
+func TestContextErr(t *testing.T) {
+	w := bytes.NewBufferString("")
+	assert.Nil(t, (&Command{
+		Name: "root",
+		Fn: func(ctx *Context) error {
+			assert.Nil(t, ctx.JSON("%d").Err())
+			ctx.JSON(make(chan int))
+			assert.NotNil(t, ctx.Err())
+			err := ctx.Err()
+			ctx.JSONIndent(func() {}, "", "  ")
+			assert.Equal(t, ctx.Err(), err)
+			return nil
+		},
+	}).RunWith(nil, w, nil))
+	assert.Equal(t, w.String(), `"%d"`)
+}
+
+func TestContextReader(t *testing.T) {
+	root := &Command{Name: "root"}
+	root.Register(&Command{
+		Name: "cat",
+		Fn: func(ctx *Context) error {
+			data, err := ioutil.ReadAll(ctx.Reader())
+			ctx.String("%d:%s", len(data), data)
+			return err
+		},
+	})
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWithIO([]string{"cat"}, strings.NewReader("piped"), w))
+	assert.Equal(t, w.String(), "5:piped")
+
+	resp := httptest.NewRecorder()
+	root.ServeHTTP(resp, httptest.NewRequest("PUT", "/cat", strings.NewReader("body")))
+	assert.Equal(t, resp.Body.String(), "4:body")
+
+	assert.Equal(t, (&Context{}).Reader(), os.Stdin)
+}
+
+func TestContextTerminal(t *testing.T) {
+	defer os.Setenv("COLUMNS", os.Getenv("COLUMNS"))
+	defer os.Setenv("LINES", os.Getenv("LINES"))
+
+	ctx := &Context{writer: new(bytes.Buffer), reader: strings.NewReader("")}
+	assert.False(t, ctx.IsTTY())
+	assert.True(t, ctx.IsPiped())
+
+	// colorable writer of stdout on windows console is checked by stdout
+	file, ok := writerFile(colorableWriter{Writer: new(bytes.Buffer), file: os.Stdout})
+	assert.True(t, ok)
+	assert.Equal(t, file, os.Stdout)
+
+	os.Setenv("COLUMNS", "")
+	_, _, ok = ctx.TerminalSize()
+	assert.False(t, ok)
+	os.Setenv("COLUMNS", "120")
+	os.Setenv("LINES", "40")
+	width, height, ok := ctx.TerminalSize()
+	assert.True(t, ok)
+	assert.Equal(t, width, 120)
+	assert.Equal(t, height, 40)
+}
+
+func TestContextValues(t *testing.T) {
+	root := &Command{
+		Name: "app",
+		OnRootBefore: func(ctx *Context) error {
+			ctx.Set("user", "jack")
+			return nil
+		},
+		Fn: func(ctx *Context) error {
+			user, ok := ctx.Get("user")
+			assert.True(t, ok)
+			_, ok = ctx.Get("token")
+			assert.False(t, ok)
+			ctx.String("hello %v", user)
+			return nil
+		},
+	}
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith(nil, w, nil))
+	assert.Equal(t, w.String(), "hello jack")
+}
+
+func TestContextFormValues(t *testing.T) {
+	type argT struct {
+		Port int    `cli:"p,port" dft:"8080"`
+		Host string `cli:"host"`
+	}
+	assert.Nil(t, (&Command{
+		Name: "root",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			values := ctx.FormValues()
+			assert.Equal(t, values.Get("-p"), "8080")
+			assert.Equal(t, values.Get("--host"), "localhost")
+			return nil
+		},
+	}).RunWith([]string{"--host", "localhost"}, nil, nil))
+}
+
+func TestContextTailArgs(t *testing.T) {
+	type argT struct {
+		Verbose bool `cli:"v"`
+	}
+	for _, tt := range []struct {
+		argv     ArgvFunc
+		args     []string
+		freeArgs []string
+		tailArgs []string
+	}{
+		{func() interface{} { return new(argT) }, []string{"run", "-v", "x", "--", "ls", "-l"}, []string{"x", "ls", "-l"}, []string{"ls", "-l"}},
+		{func() interface{} { return new(argT) }, []string{"run", "-v"}, []string{}, []string{}},
+		{func() interface{} { return new(argT) }, []string{"run", "--"}, []string{}, []string{}},
+		{nil, []string{"run", "--", "ls", "--help"}, []string{}, []string{"ls", "--help"}},
+	} {
+		root := &Command{Name: "app"}
+		root.Register(&Command{
+			Name:        "run",
+			CanSubRoute: true,
+			Argv:        tt.argv,
+			Fn: func(ctx *Context) error {
+				assert.Equal(t, ctx.TailArgs(), tt.tailArgs)
+				assert.Equal(t, ctx.Args(), tt.freeArgs)
+				return nil
+			},
+		})
+		assert.Nil(t, root.Run(tt.args))
+	}
+}
+
+func TestContextRemainRouter(t *testing.T) {
+	type argT struct {
+		Verbose bool `cli:"v"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name:        "proxy",
+		CanSubRoute: true,
+		Argv:        func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			assert.Equal(t, ctx.RemainRouter(), []string{"v1", "users"})
+			assert.Equal(t, ctx.Args(), []string{"v1", "users", "x"})
+			assert.Equal(t, ctx.Path(), "proxy")
+			assert.True(t, ctx.Argv().(*argT).Verbose)
+			return nil
+		},
+	})
+	assert.Nil(t, root.Run([]string{"proxy", "v1", "users", "-v", "x"}))
+
+	root.Register(&Command{
+		Name: "get",
+		Fn: func(ctx *Context) error {
+			assert.Equal(t, len(ctx.RemainRouter()), 0)
+			return nil
+		},
+	})
+	assert.Nil(t, root.Run([]string{"get"}))
+}
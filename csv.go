@@ -0,0 +1,22 @@
+package cli
+
+import "encoding/csv"
+
+// CSV writes rows as comma-separated values to writer,
+// fields are quoted if needed
+func (ctx *Context) CSV(rows [][]string) *Context {
+	return ctx.writeDelimited(rows, ',')
+}
+
+// TSV writes rows as tab-separated values to writer,
+// fields are quoted if needed
+func (ctx *Context) TSV(rows [][]string) *Context {
+	return ctx.writeDelimited(rows, '\t')
+}
+
+func (ctx *Context) writeDelimited(rows [][]string, comma rune) *Context {
+	w := csv.NewWriter(ctx.Writer())
+	w.Comma = comma
+	w.WriteAll(rows)
+	return ctx
+}
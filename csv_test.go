@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextCSV(t *testing.T) {
+	rows := [][]string{{"name", "note"}, {"a", "x,y"}, {"b", "say \"hi\"\tnow"}, {"c", "line1\nline2"}}
+	w := new(bytes.Buffer)
+	ctx := &Context{writer: w}
+	ctx.CSV(rows)
+	assert.Equal(t, w.String(), "name,note\na,\"x,y\"\nb,\"say \"\"hi\"\"\tnow\"\nc,\"line1\nline2\"\n")
+
+	w.Reset()
+	ctx.TSV(rows)
+	assert.Equal(t, w.String(), "name\tnote\na\tx,y\nb\t\"say \"\"hi\"\"\tnow\"\nc\t\"line1\nline2\"\n")
+}
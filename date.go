@@ -0,0 +1,180 @@
+package cli
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateParser parses human-friendly date relative to now for flags tagged with `parser:"date"`,
+// replace it to support other languages or formats
+var DateParser = ParseDate
+
+// DurationParser parses human-friendly duration for flags tagged with `parser:"duration"`,
+// replace it to support other languages or formats
+var DurationParser = ParseDuration
+
+func init() {
+	RegisterFlagParser("date", newDateParser)
+	RegisterFlagParser("duration", newDurationParser)
+}
+
+var dateFormats = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+}
+
+var (
+	relativeRegexp = regexp.MustCompile(`^(?:in\s+)?(\d+)\s*([a-z]+)(?:\s+(ago))?$`)
+	unitDurations  = map[string]time.Duration{
+		"s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+		"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+		"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+		"d": 24 * time.Hour, "day": 24 * time.Hour, "days": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour, "week": 7 * 24 * time.Hour, "weeks": 7 * 24 * time.Hour,
+	}
+	weekdays = map[string]time.Weekday{
+		"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+		"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	}
+)
+
+// ParseDate parses absolute date like `2006-01-02 15:04` or relative date like
+// `now`, `today`, `yesterday`, `tomorrow`, `2h ago`, `3 days ago`, `in 2 weeks`,
+// `next monday`, `last friday`, `next month` and `last year`.
+// Days and weekdays are at midnight, absolute dates are in location of now.
+func ParseDate(s string, now time.Time) (time.Time, error) {
+	// absolute dates are parsed before lowercasing, e.g. `T` and `Z` of RFC3339
+	s = strings.Join(strings.Fields(s), " ")
+	for _, format := range dateFormats {
+		if t, err := time.ParseInLocation(format, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	s = strings.ToLower(s)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch s {
+	case "now":
+		return now, nil
+	case "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if m := relativeRegexp.FindStringSubmatch(s); m != nil && (m[3] == "ago") != strings.HasPrefix(s, "in ") {
+		n, _ := strconv.Atoi(m[1])
+		if m[3] == "ago" {
+			n = -n
+		}
+		switch m[2] {
+		case "mo", "month", "months":
+			return now.AddDate(0, n, 0), nil
+		case "y", "year", "years":
+			return now.AddDate(n, 0, 0), nil
+		}
+		if unit, ok := unitDurations[m[2]]; ok {
+			return now.Add(time.Duration(n) * unit), nil
+		}
+	}
+	if fields := strings.Fields(s); len(fields) == 2 && (fields[0] == "next" || fields[0] == "last") {
+		sign := 1
+		if fields[0] == "last" {
+			sign = -1
+		}
+		if weekday, ok := weekdays[fields[1]]; ok {
+			days := (int(weekday) - int(today.Weekday()) + 7*sign) % 7
+			if days == 0 {
+				days = 7 * sign
+			}
+			return today.AddDate(0, 0, days), nil
+		}
+		switch fields[1] {
+		case "week":
+			return now.AddDate(0, 0, 7*sign), nil
+		case "month":
+			return now.AddDate(0, sign, 0), nil
+		case "year":
+			return now.AddDate(sign, 0, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+var durationPartRegexp = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]+)`)
+
+// ParseDuration parses duration like time.ParseDuration, with extra units of days and weeks,
+// e.g. `90s`, `1h30m`, `3d`, `2 weeks`, `1 day 12 hours`
+func ParseDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+	var (
+		total time.Duration
+		rest  = s
+	)
+	for _, m := range durationPartRegexp.FindAllStringSubmatch(s, -1) {
+		unit, ok := unitDurations[m[2]]
+		if !ok {
+			return 0, fmt.Errorf("unrecognized duration %q", s)
+		}
+		n, _ := strconv.ParseFloat(m[1], 64)
+		total += time.Duration(n * float64(unit))
+		rest = strings.Replace(rest, m[0], "", 1)
+	}
+	if s == "" || strings.TrimSpace(rest) != "" {
+		return 0, fmt.Errorf("unrecognized duration %q", s)
+	}
+	return total, nil
+}
+
+type dateParser struct {
+	ptr interface{}
+}
+
+func newDateParser(ptr interface{}) FlagParser {
+	return &dateParser{ptr}
+}
+
+func (p dateParser) Parse(s string) error {
+	t, ok := p.ptr.(*time.Time)
+	if !ok {
+		return fmt.Errorf("date parser: unsupported type %T", p.ptr)
+	}
+	v, err := DateParser(s, time.Now())
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
+
+type durationParser struct {
+	ptr interface{}
+}
+
+func newDurationParser(ptr interface{}) FlagParser {
+	return &durationParser{ptr}
+}
+
+func (p durationParser) Parse(s string) error {
+	d, ok := p.ptr.(*time.Duration)
+	if !ok {
+		return fmt.Errorf("duration parser: unsupported type %T", p.ptr)
+	}
+	v, err := DurationParser(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDate(t *testing.T) {
+	// Wednesday
+	now := time.Date(2024, 5, 15, 10, 30, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 0, 0, 0, 0, time.UTC) }
+	for _, tt := range []struct {
+		s    string
+		want time.Time
+	}{
+		{"now", now},
+		{"Today", day(5, 15)},
+		{"yesterday", day(5, 14)},
+		{"tomorrow", day(5, 16)},
+		{"2h ago", now.Add(-2 * time.Hour)},
+		{"3  days ago", now.AddDate(0, 0, -3)},
+		{"in 2 weeks", now.AddDate(0, 0, 14)},
+		{"1 month ago", now.AddDate(0, -1, 0)},
+		{"next monday", day(5, 20)},
+		{"next wednesday", day(5, 22)},
+		{"last monday", day(5, 13)},
+		{"last wednesday", day(5, 8)},
+		{"next month", now.AddDate(0, 1, 0)},
+		{"last year", now.AddDate(-1, 0, 0)},
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2024-01-02 15:04", time.Date(2024, 1, 2, 15, 4, 0, 0, time.UTC)},
+		{"2024-01-02T03:04:05Z", time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{"2024-01-02T03:04:05.5+08:00", time.Date(2024, 1, 1, 19, 4, 5, 5e8, time.UTC)},
+	} {
+		got, err := ParseDate(tt.s, now)
+		require.Nil(t, err, tt.s)
+		assert.True(t, got.Equal(tt.want), "%s: got %v, want %v", tt.s, got, tt.want)
+	}
+	for _, s := range []string{"", "someday", "in 2h ago", "2 fortnights ago", "next tuesday morning"} {
+		_, err := ParseDate(s, now)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want time.Duration
+	}{
+		{"90s", 90 * time.Second},
+		{"1h30m", 90 * time.Minute},
+		{"3d", 72 * time.Hour},
+		{"2 weeks", 14 * 24 * time.Hour},
+		{"1 day 12 hours", 36 * time.Hour},
+		{"1.5h", 90 * time.Minute},
+	} {
+		got, err := ParseDuration(tt.s)
+		require.Nil(t, err, tt.s)
+		assert.Equal(t, got, tt.want)
+	}
+	for _, s := range []string{"", "3 fortnights", "3d x"} {
+		_, err := ParseDuration(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestDateFlag(t *testing.T) {
+	type argT struct {
+		Since time.Time     `cli:"since" parser:"date"`
+		Every time.Duration `cli:"every" parser:"duration"`
+		Bad   int           `cli:"bad" parser:"date"`
+	}
+	argv := new(argT)
+	require.Nil(t, Parse([]string{"--since", "yesterday", "--every=2d"}, argv))
+	assert.True(t, argv.Since.Before(time.Now().AddDate(0, 0, -1)))
+	assert.Equal(t, argv.Every, 48*time.Hour)
+
+	assert.Error(t, Parse([]string{"--since", "someday"}, new(argT)))
+	assert.Error(t, Parse([]string{"--bad", "now"}, new(argT)))
+}
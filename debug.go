@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mkideal/pkg/debug"
+)
+
+// Logger is backend of debug logs of framework
+type Logger interface {
+	Debugf(format string, args ...interface{})
+}
+
+type (
+	pkgDebugLogger struct{}
+	nopLogger      struct{}
+)
+
+func (pkgDebugLogger) Debugf(format string, args ...interface{}) { debug.Debugf(format, args...) }
+func (nopLogger) Debugf(format string, args ...interface{})      {}
+
+// logger writes debug logs by github.com/mkideal/pkg/debug by default
+var logger Logger = pkgDebugLogger{}
+
+// SetLogger routes debug logs of framework to l, nil silences them
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger = l
+}
+
+func debugf(format string, args ...interface{}) {
+	logger.Debugf(format, args...)
+}
+
+// panicf writes message to logger and panics
+func panicf(format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	logger.Debugf("panic: %s", msg)
+	panic(msg)
+}
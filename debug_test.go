@@ -0,0 +1,27 @@
+package cli
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordLogger []string
+
+func (l *recordLogger) Debugf(format string, args ...interface{}) {
+	*l = append(*l, fmt.Sprintf(format, args...))
+}
+
+func TestSetLogger(t *testing.T) {
+	defer SetLogger(logger)
+	l := new(recordLogger)
+	SetLogger(l)
+	debugf("hello %s", "world")
+	assert.Panics(t, func() { panicf("bad %d", 1) })
+	assert.Equal(t, []string(*l), []string{"hello world", "panic: bad 1"})
+
+	SetLogger(nil)
+	debugf("silenced")
+	assert.Equal(t, len(*l), 2)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import "github.com/labstack/gommon/color"
+
+// DescribeCommandName is name of the hidden command which prints description of command tree
+const DescribeCommandName = "__describe"
+
+type (
+	// CommandDescription is a machine-readable description of a command and its
+	// descendants, returned by Command.Describe
+	CommandDescription struct {
+		Name        string               `json:"name"`
+		Path        string               `json:"path"`
+		Aliases     []string             `json:"aliases,omitempty"`
+		Desc        string               `json:"desc,omitempty"`
+		Text        string               `json:"text,omitempty"`
+		Runnable    bool                 `json:"runnable"` // Command has a function
+		Flags       []FlagDescription    `json:"flags,omitempty"`
+		HTTPRouters []string             `json:"httpRouters,omitempty"`
+		HTTPMethods []string             `json:"httpMethods,omitempty"`
+		Examples    []string             `json:"examples,omitempty"` // Arguments of examples after program name
+		Commands    []CommandDescription `json:"commands,omitempty"`
+	}
+
+	// FlagDescription describes a flag of command
+	FlagDescription struct {
+		Names    []string `json:"names"` // Flag names with dashes, e.g. -n, --name
+		Type     string   `json:"type"`  // Go type of flag, e.g. string, []int and time.Duration
+		Usage    string   `json:"usage,omitempty"`
+		Default  string   `json:"default,omitempty"`
+		Env      string   `json:"env,omitempty"`
+		Required bool     `json:"required,omitempty"`
+		Global   bool     `json:"global,omitempty"` // Flag is inherited from an ancestor
+	}
+)
+
+// Describe returns description of cmd and its visible descendants, e.g. for GUIs,
+// documentation and completion engines. Flags include global flags of ancestors.
+func (cmd *Command) Describe() *CommandDescription {
+	clr := color.Color{}
+	clr.Disable()
+	desc := &CommandDescription{
+		Name:        cmd.Name,
+		Path:        cmd.Path(),
+		Aliases:     cloneStrings(cmd.Aliases),
+		Desc:        cmd.Desc,
+		Text:        cmd.Text,
+		Runnable:    cmd.Fn != nil,
+		HTTPRouters: cloneStrings(cmd.HTTPRouters),
+		HTTPMethods: cloneStrings(cmd.HTTPMethods),
+	}
+	// argvs of command are the first ones, others are global argvs of ancestors
+	var (
+		argvList = cmd.flagArgvList()
+		n        = len(cmd.Argvs) + 1
+	)
+	for i, argvs := range [][]interface{}{argvList[n:], argvList[:n]} {
+		for _, fl := range visibleFlags(argvs, clr) {
+			desc.Flags = append(desc.Flags, FlagDescription{
+				Names:    append(append([]string{}, fl.tag.shortNames...), fl.tag.longNames...),
+				Type:     fl.field.Type.String(),
+				Usage:    fl.tag.usage,
+				Default:  fl.tag.dft,
+				Env:      fl.tag.env,
+				Required: fl.tag.isRequired,
+				Global:   i == 0,
+			})
+		}
+	}
+	for _, ex := range cmd.Examples {
+		desc.Examples = append(desc.Examples, ex.Command)
+	}
+	for _, child := range cmd.getChildren() {
+		if child.isHidden() {
+			continue
+		}
+		desc.Commands = append(desc.Commands, *child.Describe())
+	}
+	return desc
+}
+
+// DescribeCommand returns a hidden command which prints description of its parent as JSON
+func DescribeCommand() *Command {
+	return &Command{
+		Name:   DescribeCommandName,
+		Hidden: true,
+		NoHook: true,
+		Fn: func(ctx *Context) error {
+			ctx.JSONln(ctx.Command().Parent().Describe())
+			return nil
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	type globalT struct {
+		Verbose bool `cli:"v,verbose" usage:"verbose output"`
+	}
+	type argT struct {
+		Name    string        `cli:"*n,name" usage:"name of user" env:"USER_NAME"`
+		Tags    []string      `cli:"tag" usage:"tags"`
+		Timeout time.Duration `cli:"timeout" dft:"5s" parser:"duration"`
+	}
+	root := &Command{Name: "app", Desc: "an app", Global: true, Argv: func() interface{} { return new(globalT) }}
+	user := root.Register(&Command{Name: "user", Desc: "manage users", Aliases: []string{"u"}})
+	user.Register(&Command{
+		Name:        "add",
+		Desc:        "add user",
+		Argv:        func() interface{} { return new(argT) },
+		HTTPRouters: []string{"/v1/user"},
+		HTTPMethods: []string{"POST"},
+		Examples:    []Example{{Command: "user add --name=jack"}},
+		Fn:          donothing,
+	})
+	root.Register(&Command{Name: "secret", Hidden: true, Fn: donothing})
+	root.Register(DescribeCommand())
+
+	desc := root.Describe()
+	assert.Equal(t, desc.Name, "app")
+	assert.Equal(t, desc.Runnable, false)
+	assert.Equal(t, len(desc.Commands), 1)
+	assert.Equal(t, desc.Flags, []FlagDescription{{Names: []string{"-v", "--verbose"}, Type: "bool", Usage: "verbose output"}})
+	add := desc.Commands[0].Commands[0]
+	assert.Equal(t, add.Path, "user add")
+	assert.Equal(t, add.Runnable, true)
+	assert.Equal(t, add.HTTPRouters, []string{"/v1/user"})
+	assert.Equal(t, add.HTTPMethods, []string{"POST"})
+	assert.Equal(t, add.Examples, []string{"user add --name=jack"})
+	assert.Equal(t, add.Flags, []FlagDescription{
+		{Names: []string{"-v", "--verbose"}, Type: "bool", Usage: "verbose output", Global: true},
+		{Names: []string{"-n", "--name"}, Type: "string", Usage: "name of user", Env: "USER_NAME", Required: true},
+		{Names: []string{"--tag"}, Type: "[]string", Usage: "tags"},
+		{Names: []string{"--timeout"}, Type: "time.Duration", Default: "5s"},
+	})
+
+	w := new(bytes.Buffer)
+	require.Nil(t, root.RunWith([]string{DescribeCommandName}, w, nil))
+	got := new(CommandDescription)
+	require.Nil(t, json.Unmarshal(w.Bytes(), got))
+	assert.Equal(t, got, desc)
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoadDotEnv reads environment variables from a `.env` file and sets them,
+// variables which already exist are not overridden
+func LoadDotEnv(filename string) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	env, err := ReadDotEnv(file)
+	if err != nil {
+		return fmt.Errorf("%s: %v", filename, err)
+	}
+	for key, value := range env {
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadDotEnv loads DotEnv of cmd once, missing file is ignored
+func (cmd *Command) loadDotEnv() error {
+	if cmd.DotEnv == "" {
+		return nil
+	}
+	cmd.dotEnvOnce.Do(func() {
+		if err := LoadDotEnv(cmd.DotEnv); err != nil && !os.IsNotExist(err) {
+			cmd.dotEnvErr = err
+		}
+	})
+	return cmd.dotEnvErr
+}
+
+// ReadDotEnv parses content of `.env` file:
+//
+//	# comment
+//	KEY=value
+//	export KEY2="double quoted\nvalue" # comment
+//	KEY3='single quoted value'
+func ReadDotEnv(r io.Reader) (map[string]string, error) {
+	env := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		index := strings.Index(line, "=")
+		if index <= 0 {
+			return nil, fmt.Errorf("line %d: malformed", lineno)
+		}
+		key := strings.TrimSpace(line[:index])
+		value, err := parseDotEnvValue(strings.TrimSpace(line[index+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineno, err)
+		}
+		env[key] = value
+	}
+	return env, scanner.Err()
+}
+
+func parseDotEnvValue(s string) (string, error) {
+	if s == "" {
+		return "", nil
+	}
+	quote := s[0]
+	if quote != '"' && quote != '\'' {
+		// unquoted value, strip inline comment
+		if index := strings.Index(s, " #"); index >= 0 {
+			s = s[:index]
+		}
+		return strings.TrimSpace(s), nil
+	}
+	var (
+		buf      strings.Builder
+		escaping bool
+	)
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if escaping {
+			escaping = false
+			switch c {
+			case 'n':
+				buf.WriteByte('\n')
+			case 't':
+				buf.WriteByte('\t')
+			default:
+				buf.WriteByte(c)
+			}
+			continue
+		}
+		if c == '\\' && quote == '"' {
+			escaping = true
+			continue
+		}
+		if c == quote {
+			rest := strings.TrimSpace(s[i+1:])
+			if rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", fmt.Errorf("unexpected %q after quoted value", rest)
+			}
+			return buf.String(), nil
+		}
+		buf.WriteByte(c)
+	}
+	return "", fmt.Errorf("unterminated quoted value")
+}
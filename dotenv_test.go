@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadDotEnv(t *testing.T) {
+	env, err := ReadDotEnv(strings.NewReader(`
+# comment
+A=1
+export B = two words # comment
+C="double\nquoted" # comment
+D='single\n quoted'
+E=
+`))
+	require.Nil(t, err)
+	assert.Equal(t, env, map[string]string{
+		"A": "1",
+		"B": "two words",
+		"C": "double\nquoted",
+		"D": `single\n quoted`,
+		"E": "",
+	})
+
+	for _, content := range []string{"A", "=1", `A="unterminated`, `A="x" y`} {
+		_, err := ReadDotEnv(strings.NewReader(content))
+		assert.Error(t, err, content)
+	}
+}
+
+func TestDotEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-dotenv")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, ".env")
+	require.Nil(t, ioutil.WriteFile(filename, []byte("CLI_TEST_DOTENV_A=dotenv\nCLI_TEST_DOTENV_B=dotenv\n"), 0644))
+	defer os.Unsetenv("CLI_TEST_DOTENV_A")
+	defer os.Unsetenv("CLI_TEST_DOTENV_B")
+	os.Setenv("CLI_TEST_DOTENV_B", "exported")
+
+	type argT struct {
+		A string `cli:"a" dft:"$CLI_TEST_DOTENV_A"`
+		B string `cli:"b" env:"CLI_TEST_DOTENV_B"`
+	}
+	argv := new(argT)
+	assert.Nil(t, (&Command{
+		DotEnv: filename,
+		Argv:   func() interface{} { return argv },
+		Fn:     donothing,
+	}).RunWith(nil, ioutil.Discard, nil))
+	assert.Equal(t, *argv, argT{A: "dotenv", B: "exported"})
+
+	// file is loaded once by the first invocation
+	root := &Command{
+		DotEnv: filename,
+		Argv:   func() interface{} { return argv },
+		Fn:     donothing,
+	}
+	os.Unsetenv("CLI_TEST_DOTENV_A")
+	assert.Nil(t, root.RunWith(nil, ioutil.Discard, nil))
+	assert.Equal(t, os.Getenv("CLI_TEST_DOTENV_A"), "dotenv")
+	os.Unsetenv("CLI_TEST_DOTENV_A")
+	*argv = argT{}
+	assert.Nil(t, root.RunWith(nil, ioutil.Discard, nil))
+	assert.Equal(t, *argv, argT{B: "exported"})
+	_, ok := os.LookupEnv("CLI_TEST_DOTENV_A")
+	assert.False(t, ok)
+
+	assert.Nil(t, (&Command{
+		DotEnv: filepath.Join(dir, "missing.env"),
+		Fn:     donothing,
+	}).RunWith(nil, ioutil.Discard, nil))
+}
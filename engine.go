@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/labstack/gommon/color"
+)
+
+// Engine embeds a command tree in other Go programs, e.g. chatbots and schedulers.
+// Invocations of Engine don't touch os.Stdin, os.Stdout or package level outputs:
+// prompts, including flags tagged by `prompt`, read Stdin and write Stderr of Execution,
+// events are written to Stderr, flags tagged by `edit` and file descriptors of
+// `--status-fd` are rejected, and colors are disabled.
+type Engine struct {
+	root *Command
+}
+
+// Execution holds arguments and streams of running a command by Engine
+type Execution struct {
+	Args   []string
+	Stdin  io.Reader // Returned by Context.Reader, empty if nil
+	Stdout io.Writer // Output of command, discarded if nil
+	Stderr io.Writer // Warnings, progress and logs, discarded if nil
+
+	// Remote marks invocation from remote clients, e.g. chat messages,
+	// plugins in $PATH are not run, see PathPlugins
+	Remote bool
+}
+
+// NewEngine creates an engine of root
+func NewEngine(root *Command) *Engine {
+	return &Engine{root: root}
+}
+
+// Root returns root command of engine
+func (e *Engine) Root() *Command {
+	return e.root
+}
+
+// Resolve finds command routed by leading arguments of args like running,
+// and returns the command with rest arguments. DefaultCommand is applied,
+// and a command running the plugin is returned for plugins in $PATH, see PathPlugins.
+func (e *Engine) Resolve(args []string) (*Command, []string) {
+	r := e.root.routeArgs(args, true)
+	if r.plugin != nil {
+		cmd := r.plugin.Command("")
+		cmd.parent = e.root
+		return cmd, args[r.pluginEnd:]
+	}
+	return r.child, args[r.end:]
+}
+
+// Parse resolves command and parses arguments of exe without running,
+// argv of returned Context is ready for inspecting. ExitError is returned
+// if usage written to Stdout, e.g. `--help`.
+func (e *Engine) Parse(exe Execution) (*Context, error) {
+	clr := color.Color{}
+	clr.Disable()
+	ctx, _, err := e.root.prepare(clr, exe.Args, exe.stdout(), nil, exe.invocation())
+	if ctx != nil {
+		exe.setup(ctx)
+	}
+	return ctx, err
+}
+
+// Execute runs command of exe, goctx is returned by Context.Context
+func (e *Engine) Execute(goctx context.Context, exe Execution) error {
+	return e.execute(goctx, exe, nil)
+}
+
+// execute runs command of exe, authorize checks prepared Context before running
+func (e *Engine) execute(goctx context.Context, exe Execution, authorize func(*Context) error) error {
+	inv := exe.invocation()
+	inv.authorize = authorize
+	return e.root.invoke(exe.Args, exe.stdout(), nil, inv, func(ctx *Context) {
+		exe.setup(ctx)
+		ctx.goctx = goctx
+	})
+}
+
+func (exe Execution) invocation() invocation {
+	return invocation{remote: exe.Remote, embedded: true, stdin: exe.Stdin, stderr: exe.Stderr}
+}
+
+func (exe Execution) stdout() io.Writer {
+	if exe.Stdout == nil {
+		return ioutil.Discard
+	}
+	return exe.Stdout
+}
+
+func (exe Execution) setup(ctx *Context) {
+	ctx.embedded = true
+	ctx.reader = exe.Stdin
+	if ctx.reader == nil {
+		ctx.reader = strings.NewReader("")
+	}
+	ctx.errWriter = exe.Stderr
+	if ctx.errWriter == nil {
+		ctx.errWriter = ioutil.Discard
+	}
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type ctxKey struct{}
+
+func TestEngine(t *testing.T) {
+	type argT struct {
+		Helper
+		Name string `cli:"name" dft:"world"`
+	}
+	root := &Command{Name: "bot"}
+	root.Register(&Command{
+		Name: "greet",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			data, _ := ioutil.ReadAll(ctx.Reader())
+			ctx.Warn("from %v", ctx.Context().Value(ctxKey{}))
+			ctx.String("Hello, %s%s", ctx.Argv().(*argT).Name, data)
+			return nil
+		},
+	})
+	engine := NewEngine(root)
+	assert.Equal(t, engine.Root(), root)
+
+	cmd, rest := engine.Resolve([]string{"greet", "--name", "Jack", "x"})
+	assert.Equal(t, cmd.Name, "greet")
+	assert.Equal(t, rest, []string{"--name", "Jack", "x"})
+	cmd, rest = engine.Resolve([]string{"unknown", "x"})
+	assert.Equal(t, cmd, root)
+	assert.Equal(t, rest, []string{"unknown", "x"})
+
+	// DefaultCommand is applied like running
+	root.DefaultCommand = "greet"
+	cmd, rest = engine.Resolve([]string{"--name", "Jack"})
+	assert.Equal(t, cmd.Name, "greet")
+	assert.Equal(t, rest, []string{"--name", "Jack"})
+	root.DefaultCommand = ""
+
+	ctx, err := engine.Parse(Execution{Args: []string{"greet", "--name", "Jack"}})
+	require.Nil(t, err)
+	assert.Equal(t, ctx.Argv().(*argT).Name, "Jack")
+	_, err = engine.Parse(Execution{Args: []string{"greet", "--help"}})
+	assert.Equal(t, err, ExitError)
+
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	warnings := new(bytes.Buffer)
+	WarningOutput = warnings
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	goctx := context.WithValue(context.Background(), ctxKey{}, "chat")
+	assert.Nil(t, engine.Execute(goctx, Execution{
+		Args:   []string{"greet"},
+		Stdin:  strings.NewReader("!"),
+		Stdout: stdout,
+		Stderr: stderr,
+	}))
+	assert.Equal(t, stdout.String(), "Hello, world!")
+	assert.Equal(t, stderr.String(), "WARN! from chat\n")
+	assert.Equal(t, warnings.String(), "")
+
+	assert.Nil(t, engine.Execute(context.Background(), Execution{Args: []string{"greet"}}))
+	assert.Equal(t, (&Context{}).Context(), context.Background())
+
+	// package level streams and fds of process are not used
+	type eventsT struct {
+		EventsHelper
+		StatusFdHelper
+	}
+	defer func(w io.Writer) { EventsOutput = w }(EventsOutput)
+	defer func(w io.Writer) { PromptOutput = w }(PromptOutput)
+	events, prompts := new(bytes.Buffer), new(bytes.Buffer)
+	EventsOutput, PromptOutput = events, prompts
+	root.Register(&Command{
+		Name: "ask",
+		Argv: func() interface{} { return new(eventsT) },
+		Fn: func(ctx *Context) error {
+			answer, err := ctx.Ask("name", "nobody")
+			ctx.String("%s", answer)
+			return err
+		},
+	})
+	stdout, stderr = new(bytes.Buffer), new(bytes.Buffer)
+	assert.Nil(t, engine.Execute(goctx, Execution{Args: []string{"ask", "--events=ndjson"}, Stdout: stdout, Stderr: stderr}))
+	assert.Equal(t, stdout.String(), "nobody")
+	assert.Contains(t, stderr.String(), `"event":"started"`)
+	assert.Equal(t, events.String(), "")
+	assert.Equal(t, prompts.String(), "")
+	assert.Error(t, engine.Execute(goctx, Execution{Args: []string{"ask", "--status-fd=1"}}))
+}
+
+func TestEnginePromptFlags(t *testing.T) {
+	type loginT struct {
+		User     string `cli:"u" prompt:"user"`
+		Password string `pw:"p" prompt:"password"`
+		Remember bool   `cli:"r" prompt:"remember me"`
+	}
+	type noteT struct {
+		Note string `edit:"note"`
+	}
+	var argv *loginT
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "login",
+		Argv: func() interface{} { return new(loginT) },
+		Fn: func(ctx *Context) error {
+			argv = ctx.Argv().(*loginT)
+			return nil
+		},
+	})
+	root.Register(&Command{Name: "note", Argv: func() interface{} { return new(noteT) }, Fn: donothing})
+	engine := NewEngine(root)
+
+	// prompts of flags read Stdin and write Stderr of execution
+	stderr := new(bytes.Buffer)
+	require.Nil(t, engine.Execute(context.Background(), Execution{
+		Args:   []string{"login"},
+		Stdin:  strings.NewReader("jack\nsecret\ny\n"),
+		Stderr: stderr,
+	}))
+	assert.Equal(t, *argv, loginT{User: "jack", Password: "secret", Remember: true})
+	assert.Equal(t, stderr.String(), "user: password: remember me [y/N]: ")
+
+	// flags are left unassigned if stdin is empty
+	require.Nil(t, engine.Execute(context.Background(), Execution{Args: []string{"login"}, Remote: true}))
+	assert.Equal(t, *argv, loginT{})
+
+	ctx, err := engine.Parse(Execution{Args: []string{"login", "-p", "x"}, Stdin: strings.NewReader("jack\nn\n")})
+	require.Nil(t, err)
+	assert.Equal(t, *ctx.Argv().(*loginT), loginT{User: "jack", Password: "x"})
+
+	// editor is never launched
+	defer func(fn func() (string, error)) { GetEditor = fn }(GetEditor)
+	GetEditor = func() (string, error) { return "true", nil }
+	assert.Error(t, engine.Execute(context.Background(), Execution{Args: []string{"note"}}))
+	assert.Nil(t, engine.Execute(context.Background(), Execution{Args: []string{"note", "--note", "hi"}}))
+}
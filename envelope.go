@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Envelope wraps machine output, so consumers can distinguish payload from
+// diagnostics, e.g.
+//
+//	{"apiVersion":"v1","kind":"user.get","data":{"name":"Jack"},"warnings":[]}
+type Envelope struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`              // Command path joined by ".", or name of root
+	Data       interface{} `json:"data"`              // Object passed to Render, or list of objects if rendered more than once
+	Warnings   []string    `json:"warnings"`          // Warnings recorded by Context.Warn
+	Summary    interface{} `json:"summary,omitempty"` // Result of Context.Summary with elapsed seconds
+	Error      string      `json:"error,omitempty"`   // Error returned by command
+}
+
+// envelopeEnabled reports whether EnvelopeVersion of root is set and output format is json
+func (ctx *Context) envelopeEnabled() bool {
+	return ctx.command != nil &&
+		ctx.command.Root().EnvelopeVersion != "" &&
+		ctx.outputFormat() == OutputFormatJSON
+}
+
+// writeEnvelope writes envelope of output, error of encoding envelope is written
+// to stderr and returned
+func (ctx *Context) writeEnvelope(err error) error {
+	kind := ctx.command.pathWithSep(".")
+	if kind == "" {
+		kind = ctx.command.Root().Name
+	}
+	envelope := Envelope{
+		APIVersion: ctx.command.Root().EnvelopeVersion,
+		Kind:       kind,
+		Warnings:   ctx.Warnings(),
+	}
+	switch len(ctx.envelope) {
+	case 0:
+	case 1:
+		envelope.Data = ctx.envelope[0]
+	default:
+		envelope.Data = ctx.envelope
+	}
+	if ctx.summary != nil && !IsInterrupted(err) {
+		envelope.Summary = ctx.summary.Result().jsonObject()
+	}
+	if err != nil && err != ExitError {
+		envelope.Error = err.Error()
+	}
+	data, e := json.MarshalIndent(envelope, "", "  ")
+	if e == nil {
+		_, e = ctx.Write(append(data, '\n'))
+	}
+	if e != nil {
+		e = fmt.Errorf("write envelope: %v", e)
+		fmt.Fprintln(ctx.stderr(os.Stderr), ctx.command.theme().wrapErr(e, "", ctx.color))
+	}
+	return e
+}
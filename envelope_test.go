@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	type argT struct {
+		OutputFormat
+	}
+	type user struct {
+		Name string `json:"name"`
+	}
+	root := &Command{Name: "app", EnvelopeVersion: "v1"}
+	get := root.Register(&Command{Name: "user"}).Register(&Command{
+		Name: "get",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.Warn("cache is stale")
+			return ctx.Render(user{"Jack"})
+		},
+	})
+
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith([]string{"user", "get", "-o", "json"}, w, nil))
+	assert.Equal(t, w.String(), `{
+  "apiVersion": "v1",
+  "kind": "user.get",
+  "data": {
+    "name": "Jack"
+  },
+  "warnings": [
+    "cache is stale"
+  ]
+}
+`)
+
+	w.Reset()
+	get.Fn = func(ctx *Context) error {
+		ctx.Render(1)
+		ctx.Render(2)
+		return errors.New("partial")
+	}
+	assert.Error(t, root.RunWith([]string{"user", "get", "-o", "json"}, w, nil))
+	assert.Equal(t, w.String(), `{
+  "apiVersion": "v1",
+  "kind": "user.get",
+  "data": [
+    1,
+    2
+  ],
+  "warnings": [],
+  "error": "partial"
+}
+`)
+
+	// summary is included and encoding errors are reported
+	w.Reset()
+	get.Fn = func(ctx *Context) error {
+		ctx.Summary().Created(2)
+		return ctx.Render(user{"Jack"})
+	}
+	assert.Nil(t, root.RunWith([]string{"user", "get", "-o", "json"}, w, nil))
+	assert.Contains(t, w.String(), `"summary": {
+    "created": 2,
+    "updated": 0,
+    "skipped": 0,
+    "failed": 0,
+    "elapsed": `)
+
+	w.Reset()
+	stderr := new(bytes.Buffer)
+	get.Fn = func(ctx *Context) error { return ctx.Render(make(chan int)) }
+	err := root.RunWithWriter([]string{"user", "get", "-o", "json"}, w, RunOptions{ErrWriter: stderr})
+	assert.Error(t, err)
+	assert.Equal(t, ExitCode(err), 1)
+	assert.Equal(t, w.String(), "")
+	assert.Contains(t, stderr.String(), "write envelope: json: unsupported type: chan int")
+
+	// envelope is only used for json
+	w.Reset()
+	get.Fn = func(ctx *Context) error { return ctx.Render(user{"Tom"}) }
+	assert.Nil(t, root.RunWith([]string{"user", "get", "-o", "yaml"}, w, nil))
+	assert.Equal(t, w.String(), "name: Tom\n")
+}
@@ -22,6 +22,11 @@ type (
 		command string
 	}
 
+	ambiguousCommandError struct {
+		command    string
+		candidates []string
+	}
+
 	methodNotAllowedError struct {
 		method string
 	}
@@ -30,11 +35,22 @@ type (
 		router string
 	}
 
+	parameterInvalidError struct {
+		name string
+		err  error
+	}
+
 	wrapError struct {
 		err error
 		msg string
 	}
 
+	multiError []error
+
+	concurrentEditError struct {
+		filename string
+	}
+
 	argvError struct {
 		isEmpty      bool
 		isOutOfRange bool
@@ -53,6 +69,10 @@ func throwCommandNotFound(command string) commandNotFoundError {
 	return commandNotFoundError{command: command}
 }
 
+func throwAmbiguousCommand(command string, candidates []string) ambiguousCommandError {
+	return ambiguousCommandError{command: command, candidates: candidates}
+}
+
 func throwMethodNotAllowed(method string) methodNotAllowedError {
 	return methodNotAllowedError{method: method}
 }
@@ -61,29 +81,67 @@ func throwRouterRepeat(router string) routerRepeatError {
 	return routerRepeatError{router: router}
 }
 
+func throwParameterInvalid(name string, err error) parameterInvalidError {
+	return parameterInvalidError{name: name, err: err}
+}
+
+func throwConcurrentEdit(filename string) concurrentEditError {
+	return concurrentEditError{filename: filename}
+}
+
+// IsConcurrentEdit reports whether err is returned while file was modified by another process
+func IsConcurrentEdit(err error) bool {
+	var e concurrentEditError
+	return errors.As(err, &e)
+}
+
 func (e commandNotFoundError) Error() string {
-	return fmt.Sprintf("command %s not found", e.command)
+	return fmt.Sprintf(tr(MsgCommandNotFound), e.command)
+}
+
+func (e ambiguousCommandError) Error() string {
+	return fmt.Sprintf(tr(MsgAmbiguousCommand), e.command, strings.Join(e.candidates, ", "))
 }
 
 func (e methodNotAllowedError) Error() string {
-	return fmt.Sprintf("method %s not allowed", e.method)
+	return fmt.Sprintf(tr(MsgMethodNotAllowed), e.method)
 }
 
 func (e routerRepeatError) Error() string {
 	return fmt.Sprintf("router %s repeat", e.router)
 }
 
+func (e parameterInvalidError) Error() string {
+	return fmt.Sprintf(tr(MsgParameterInvalid), e.name, e.err)
+}
+
+// Unwrap returns error of parsing value of parameter
+func (e parameterInvalidError) Unwrap() error { return e.err }
+
+func (e concurrentEditError) Error() string {
+	return fmt.Sprintf("file %s was modified by another process, merge changes and retry", e.filename)
+}
+
 func (e wrapError) Error() string {
 	return e.msg
 }
 
+// Unwrap returns the wrapped error
+func (e wrapError) Unwrap() error { return e.err }
+
 func wrapErr(err error, appendString string, clr color.Color) error {
+	theme := DefaultTheme()
+	return theme.wrapErr(err, appendString, clr)
+}
+
+// wrapErr of theme is similar to function wrapErr, prefix "ERR!" is colored by theme
+func (theme *Theme) wrapErr(err error, appendString string, clr color.Color) error {
 	if err == nil {
 		return err
 	}
 	errs := strings.Split(err.Error(), "\n")
 	buff := bytes.NewBufferString("")
-	errPrefix := clr.Red("ERR!") + " "
+	errPrefix := theme.Error(&clr, "ERR!") + " "
 	for i, e := range errs {
 		if i != 0 {
 			buff.WriteByte('\n')
@@ -95,6 +153,17 @@ func wrapErr(err error, appendString string, clr color.Color) error {
 	return wrapError{err: err, msg: buff.String()}
 }
 
+func (e multiError) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Unwrap returns errors of e, errors.Is and errors.As match any of them
+func (e multiError) Unwrap() []error { return e }
+
 func (e argvError) Error() string {
 	if e.isEmpty {
 		return "argv list is empty"
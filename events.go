@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Lifecycle event types
+const (
+	EventStarted  = "started"
+	EventProgress = "progress"
+	EventWarning  = "warning"
+	EventFinished = "finished"
+	EventResult   = "result"
+)
+
+// EventsFormatNDJSON emits each event as a JSON object per line
+const EventsFormatNDJSON = "ndjson"
+
+// EventsOutput is the stream to which lifecycle events are written if writer for
+// diagnostics is not overridden, human output continues on writer of command
+var EventsOutput io.Writer = os.Stderr
+
+type (
+	// Event represents a lifecycle event of running command
+	Event struct {
+		Type    string      `json:"event"`
+		Command string      `json:"command"`
+		Time    time.Time   `json:"time"`
+		Message string      `json:"message,omitempty"`
+		Current int64       `json:"current,omitempty"`
+		Total   int64       `json:"total,omitempty"`
+		Error   string      `json:"error,omitempty"`
+		Result  interface{} `json:"result,omitempty"`
+	}
+
+	// EventsFormatter represents interface for enabling lifecycle events
+	EventsFormatter interface {
+		EventsFormat() string
+	}
+
+	// StatusReporter represents interface for writing events to an extra file descriptor,
+	// like `--status-fd` of gpg
+	StatusReporter interface {
+		StatusFile() int
+	}
+
+	// EventsHelper is builtin events flag
+	EventsHelper struct {
+		Events string `cli:"events" usage:"emit lifecycle events in given format(ndjson) to stderr" json:"-"`
+	}
+
+	// StatusFdHelper is builtin status-fd flag
+	StatusFdHelper struct {
+		StatusFd int `cli:"status-fd" usage:"write machine-readable progress and results as NDJSON to file descriptor" json:"-"`
+	}
+)
+
+// EventsFormat implements EventsFormatter interface
+func (h EventsHelper) EventsFormat() string {
+	return h.Events
+}
+
+// StatusFile implements StatusReporter interface
+func (h StatusFdHelper) StatusFile() int {
+	return h.StatusFd
+}
+
+type eventEmitter struct {
+	locker sync.Mutex
+	w      io.Writer
+	files  []*os.File // duplicated status fds, closed after command finished
+}
+
+func (e *eventEmitter) emit(event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	e.locker.Lock()
+	defer e.locker.Unlock()
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+// openEvents enables events if any argv requires
+func (ctx *Context) openEvents() error {
+	var (
+		writers []io.Writer
+		files   []*os.File
+	)
+	for _, argv := range ctx.allArgvs() {
+		if formatter, ok := argv.(EventsFormatter); ok {
+			switch format := formatter.EventsFormat(); format {
+			case "":
+			case EventsFormatNDJSON:
+				writers = append(writers, ctx.stderr(EventsOutput))
+			default:
+				closeFiles(files)
+				return fmt.Errorf("unsupported events format %s", ctx.color.Bold(format))
+			}
+		}
+		if reporter, ok := argv.(StatusReporter); ok {
+			if fd := reporter.StatusFile(); fd > 0 {
+				if ctx.isolated() {
+					closeFiles(files)
+					return fmt.Errorf("status fd %d: file descriptors of process are not available", fd)
+				}
+				file, err := dupStatusFile(fd)
+				if err != nil {
+					closeFiles(files)
+					return fmt.Errorf("status fd %d: %v", fd, err)
+				}
+				files = append(files, file)
+				writers = append(writers, file)
+			}
+		}
+	}
+	if len(writers) == 1 {
+		ctx.events = &eventEmitter{w: writers[0], files: files}
+	} else if len(writers) > 1 {
+		ctx.events = &eventEmitter{w: io.MultiWriter(writers...), files: files}
+	}
+	return nil
+}
+
+// closeEvents closes files opened by openEvents
+func (ctx *Context) closeEvents() {
+	if ctx.events != nil {
+		closeFiles(ctx.events.files)
+		ctx.events.files = nil
+	}
+}
+
+func closeFiles(files []*os.File) {
+	for _, file := range files {
+		file.Close()
+	}
+}
+
+// Emit writes event to events stream if events enabled,
+// Command and Time are filled if empty
+func (ctx *Context) Emit(event Event) error {
+	if ctx.events == nil {
+		return nil
+	}
+	if event.Command == "" {
+		event.Command = ctx.path
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	return ctx.events.emit(event)
+}
+
+// EmitProgress emits a progress event
+func (ctx *Context) EmitProgress(current, total int64, message string) error {
+	return ctx.Emit(Event{Type: EventProgress, Current: current, Total: total, Message: message})
+}
+
+// EmitWarning emits a warning event
+func (ctx *Context) EmitWarning(message string) error {
+	return ctx.Emit(Event{Type: EventWarning, Message: message})
+}
+
+// EmitResult emits a result event, result is encoded as JSON
+func (ctx *Context) EmitResult(result interface{}) error {
+	if err := ctx.validateOutput(result); err != nil {
+		return err
+	}
+	return ctx.Emit(Event{Type: EventResult, Result: result})
+}
+
+func (ctx *Context) emitFinished(err error) {
+	event := Event{Type: EventFinished}
+	if err != nil && err != ExitError {
+		event.Error = err.Error()
+	}
+	ctx.Emit(event)
+}
@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvents(t *testing.T) {
+	type argT struct {
+		EventsHelper
+	}
+	events := new(bytes.Buffer)
+	defer func(w io.Writer) { EventsOutput = w }(EventsOutput)
+	EventsOutput = events
+
+	newRoot := func(fn CommandFunc) *Command {
+		return &Command{
+			Name: "app",
+			Argv: func() interface{} { return new(argT) },
+			Fn:   fn,
+		}
+	}
+	decode := func() []Event {
+		list := []Event{}
+		for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+			var event Event
+			require.Nil(t, json.Unmarshal([]byte(line), &event), line)
+			list = append(list, event)
+		}
+		events.Reset()
+		return list
+	}
+
+	// events disabled
+	w := new(bytes.Buffer)
+	assert.Nil(t, newRoot(func(ctx *Context) error {
+		ctx.String("hello")
+		return ctx.EmitWarning("ignored")
+	}).RunWith([]string{}, w, nil))
+	assert.Equal(t, events.Len(), 0)
+	assert.Equal(t, w.String(), "hello")
+
+	// events enabled
+	w.Reset()
+	assert.Nil(t, newRoot(func(ctx *Context) error {
+		ctx.String("hello")
+		ctx.EmitProgress(1, 2, "half")
+		return ctx.EmitWarning("careful")
+	}).RunWith([]string{"--events", "ndjson"}, w, nil))
+	assert.Equal(t, w.String(), "hello")
+	list := decode()
+	require.Equal(t, len(list), 4)
+	assert.Equal(t, list[0].Type, EventStarted)
+	assert.Equal(t, list[0].Command, "")
+	assert.Equal(t, list[1].Type, EventProgress)
+	assert.Equal(t, list[1].Current, int64(1))
+	assert.Equal(t, list[1].Total, int64(2))
+	assert.Equal(t, list[2].Type, EventWarning)
+	assert.Equal(t, list[2].Message, "careful")
+	assert.Equal(t, list[3].Type, EventFinished)
+	assert.Equal(t, list[3].Error, "")
+
+	// failed command
+	assert.Error(t, newRoot(func(ctx *Context) error {
+		return fmt.Errorf("boom")
+	}).RunWith([]string{"--events=ndjson"}, w, nil))
+	list = decode()
+	require.Equal(t, len(list), 2)
+	assert.Equal(t, list[1].Type, EventFinished)
+	assert.Equal(t, list[1].Error, "boom")
+
+	// unsupported format
+	assert.Error(t, newRoot(donothing).RunWith([]string{"--events", "xml"}, w, nil))
+	assert.Equal(t, events.Len(), 0)
+}
+
+func TestStatusFd(t *testing.T) {
+	type argT struct {
+		EventsHelper
+		StatusFdHelper
+	}
+	// commands write to duplicates of fd of w, w is still owned by the test
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+	defer r.Close()
+	defer w.Close()
+	events := new(bytes.Buffer)
+	defer func(w io.Writer) { EventsOutput = w }(EventsOutput)
+	EventsOutput = events
+
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.String("hello")
+			return ctx.EmitResult(map[string]int{"count": 2})
+		},
+	}
+	out := new(bytes.Buffer)
+	fd := fmt.Sprintf("%d", w.Fd())
+	assert.Nil(t, root.RunWith([]string{"--status-fd", fd}, out, nil))
+	assert.Nil(t, root.RunWith([]string{"--status-fd", fd, "--events", "ndjson"}, out, nil))
+	assert.Equal(t, out.String(), "hellohello")
+
+	reader := bufio.NewReader(r)
+	types := []string{}
+	for i := 0; i < 6; i++ {
+		line, err := reader.ReadString('\n')
+		require.Nil(t, err)
+		var event Event
+		require.Nil(t, json.Unmarshal([]byte(line), &event))
+		types = append(types, event.Type)
+		if event.Type == EventResult {
+			assert.Equal(t, event.Result, map[string]interface{}{"count": float64(2)})
+		}
+	}
+	assert.Equal(t, types, []string{EventStarted, EventResult, EventFinished, EventStarted, EventResult, EventFinished})
+	assert.Equal(t, strings.Count(events.String(), "\n"), 3)
+
+	assert.Error(t, root.RunWith([]string{"--status-fd", "1000"}, out, nil))
+}
+
+func TestStatusFdOwnership(t *testing.T) {
+	type argT struct {
+		StatusFdHelper
+	}
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn:   donothing,
+	}
+	r, w, err := os.Pipe()
+	require.Nil(t, err)
+	defer r.Close()
+	fd := fmt.Sprintf("%d", w.Fd())
+	require.Nil(t, root.RunWith([]string{"--status-fd", fd}, ioutil.Discard, nil))
+
+	// fd is neither closed after command finished nor by finalizers
+	runtime.GC()
+	runtime.GC()
+	_, err = w.Write([]byte("owner\n"))
+	require.Nil(t, err)
+	require.Nil(t, w.Close())
+	data, err := ioutil.ReadAll(r)
+	require.Nil(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Equal(t, len(lines), 3)
+	assert.Equal(t, lines[2], "owner")
+
+	// closed fd isn't reused
+	assert.Error(t, root.RunWith([]string{"--status-fd", fd}, ioutil.Discard, nil))
+
+	// number of closed fd may be taken by other files, e.g. pipes of other tests,
+	// events are written to the new file
+	r, w, err = os.Pipe()
+	require.Nil(t, err)
+	defer r.Close()
+	fd = fmt.Sprintf("%d", w.Fd())
+	require.Nil(t, root.RunWith([]string{"--status-fd", fd}, ioutil.Discard, nil))
+	require.Nil(t, w.Close())
+	data, err = ioutil.ReadAll(r)
+	require.Nil(t, err)
+	assert.Equal(t, strings.Count(string(data), "\n"), 2)
+}
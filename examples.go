@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Example is an example of command shown in usage, it's run by VerifyExamples
+type Example struct {
+	Desc    string // Description of example
+	Command string // Arguments after program name, e.g. `hello --name=Jack`
+	Input   string // Input of command, returned by Context.Reader
+	Output  string // Expected output, trailing spaces are ignored. Output is not checked if empty.
+}
+
+func (cmd *Command) examplesUsage() string {
+	var (
+		buff = new(bytes.Buffer)
+		name = cmd.Root().Name
+	)
+	for i, ex := range cmd.Examples {
+		if i > 0 {
+			buff.WriteByte('\n')
+		}
+		if ex.Desc != "" {
+			fmt.Fprintf(buff, "  # %s\n", ex.Desc)
+		}
+		fmt.Fprintf(buff, "  $ %s\n", strings.TrimSpace(name+" "+ex.Command))
+	}
+	return buff.String()
+}
+
+// TestingT is the subset of *testing.T used by VerifyExamples
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+// VerifyExamples runs examples of cmd and its descendants against root of cmd
+// and reports failed examples to t, e.g.
+//
+//	func TestExamples(t *testing.T) {
+//		newApp().VerifyExamples(t)
+//	}
+//
+// Commands run in a sandbox like Engine: input of example is the reader of
+// Context and input of prompts, and outputs of prompts, events, warnings,
+// progress and logs are discarded. Package level outputs are not touched.
+func (cmd *Command) VerifyExamples(t TestingT) {
+	root := cmd.Root()
+	cmd.walk(nil, func(c *Command, router []string) {
+		if len(router) > 0 && router[len(router)-1] != c.Name {
+			// visited by alias
+			return
+		}
+		for _, ex := range c.Examples {
+			if err := root.RunExample(ex); err != nil {
+				t.Errorf("example `%s` of command %q: %v", ex.Command, c.Path(), err)
+			}
+		}
+	})
+}
+
+// RunExample runs ex in sandbox, see VerifyExamples. An error is returned
+// if command failed or output mismatched.
+func (cmd *Command) RunExample(ex Example) error {
+	args, err := SplitArgs(ex.Command)
+	if err != nil {
+		return err
+	}
+	out := new(bytes.Buffer)
+	exe := Execution{
+		Args:   args,
+		Stdin:  strings.NewReader(ex.Input),
+		Stdout: out,
+		Stderr: ioutil.Discard,
+	}
+	if err := NewEngine(cmd).Execute(context.Background(), exe); err != nil {
+		return err
+	}
+	if ex.Output == "" {
+		return nil
+	}
+	if got, want := trimLines(out.String()), trimLines(ex.Output); got != want {
+		return fmt.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	return nil
+}
+
+func trimLines(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Join(lines, "\n")
+}
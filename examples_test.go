@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type examplesT struct {
+	errors []string
+}
+
+func (t *examplesT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestVerifyExamples(t *testing.T) {
+	type argT struct {
+		Name string `cli:"name" dft:"world"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name:    "greet",
+		Aliases: []string{"hi"},
+		Argv:    func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.String("Hello, %s!  \n", ctx.Argv().(*argT).Name)
+			return nil
+		},
+		Examples: []Example{
+			{Desc: "greet the world", Command: "greet", Output: "Hello, world!"},
+			{Command: "greet --name=Jack", Output: "Hello, Tom!"},
+			{Command: "greet --undefined"},
+		},
+	})
+	root.Register(&Command{
+		Name: "cat",
+		Fn: func(ctx *Context) error {
+			data, err := ioutil.ReadAll(ctx.Reader())
+			ctx.Write(data)
+			return err
+		},
+		Examples: []Example{
+			{Command: "cat", Input: "a\nb\n", Output: "a\nb"},
+		},
+	})
+
+	root.Register(&Command{
+		Name: "ask",
+		Fn: func(ctx *Context) error {
+			name, err := ctx.Ask("name", "Jack")
+			ctx.Warn("asked")
+			ctx.Logger().Info("answered")
+			ctx.String("Hi, %s\n", name)
+			return err
+		},
+		Examples: []Example{
+			{Command: "ask", Output: "Hi, Jack"},
+		},
+	})
+
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	defer func(w io.Writer) { PromptOutput = w }(PromptOutput)
+	warnings, prompts := new(bytes.Buffer), new(bytes.Buffer)
+	WarningOutput, PromptOutput = warnings, prompts
+	recorder := new(examplesT)
+	root.VerifyExamples(recorder)
+	assert.Equal(t, warnings.String(), "")
+	assert.Equal(t, prompts.String(), "")
+	assert.Equal(t, len(recorder.errors), 2)
+	assert.Equal(t, recorder.errors[0], "example `greet --name=Jack` of command \"greet\": got:\nHello, Jack!\nwant:\nHello, Tom!")
+	assert.Contains(t, recorder.errors[1], "example `greet --undefined` of command \"greet\"")
+
+	assert.Equal(t, root.findChild("greet").examplesUsage(), "  # greet the world\n  $ app greet\n\n  $ app greet --name=Jack\n\n  $ app greet --undefined\n")
+}
@@ -90,6 +90,25 @@ func TestReader(t *testing.T) {
 	assert.Nil(t, argv.Reader.Close())
 }
 
+func TestReaderPointerOmitted(t *testing.T) {
+	type argT struct {
+		Reader *Reader `cli:"r"`
+	}
+	argv := new(argT)
+	cmd := &cli.Command{
+		Argv: func() interface{} { return argv },
+		Fn: func(ctx *cli.Context) error {
+			argv := ctx.Argv().(*argT)
+			require.NotNil(t, argv.Reader)
+			assert.Equal(t, os.Stdin.Name(), argv.Reader.Name())
+			assert.Nil(t, argv.Reader.Close())
+			return nil
+		},
+	}
+	require.Nil(t, cmd.Run([]string{}))
+	assert.NotNil(t, argv.Reader)
+}
+
 func TestWriter(t *testing.T) {
 	type argT struct {
 		Writer Writer `cli:"w"`
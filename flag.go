@@ -24,12 +24,18 @@ type flag struct {
 	// isSet indicates whether the flag is set
 	isSet bool
 
+	// source of assigned value
+	source Source
+
 	// tag properties
 	tag tagProperty
 
 	// actual flag name
 	actualFlagName string
 
+	// deprecated name used for setting the flag
+	usedAlias string
+
 	isNeedDelaySet bool
 
 	// last value for need delay set
@@ -45,8 +51,10 @@ func newFlag(field reflect.StructField, value reflect.Value, tag *tagProperty, c
 		return nil, fmt.Errorf("field %s can not set", clr.Bold(fl.field.Name))
 	}
 	fl.tag = *tag
-	if fl.isPtr() && fl.value.IsNil() {
-		fl.value.Set(reflect.New(fl.field.Type.Elem()))
+	// nil pointer field of Decoder type is allocated up front, only nil
+	// pointer to plain value means the flag is unset
+	if fl.isPtr() && fl.field.Type.Implements(reflect.TypeOf((*Decoder)(nil)).Elem()) {
+		fl.alloc()
 	}
 	isSliceDecoder := fl.value.Type().Implements(reflect.TypeOf((*SliceDecoder)(nil)).Elem())
 	if !isSliceDecoder && fl.value.CanAddr() {
@@ -184,12 +192,27 @@ func (fl *flag) name() string {
 	return ""
 }
 
+// elemType returns type of field, or type of pointed value if field is a pointer
+func (fl *flag) elemType() reflect.Type {
+	if fl.isPtr() {
+		return fl.field.Type.Elem()
+	}
+	return fl.field.Type
+}
+
+// alloc allocates value for nil pointer field, a nil pointer field means the flag is unset
+func (fl *flag) alloc() {
+	if fl.isPtr() && fl.value.IsNil() {
+		fl.value.Set(reflect.New(fl.field.Type.Elem()))
+	}
+}
+
 func (fl *flag) isBoolean() bool {
-	return fl.field.Type.Kind() == reflect.Bool
+	return fl.elemType().Kind() == reflect.Bool
 }
 
 func (fl *flag) isInteger() bool {
-	switch fl.field.Type.Kind() {
+	switch fl.elemType().Kind() {
 	case reflect.Int,
 		reflect.Int8,
 		reflect.Int16,
@@ -214,12 +237,12 @@ func (fl *flag) isMap() bool {
 }
 
 func (fl *flag) isFloat() bool {
-	kind := fl.field.Type.Kind()
+	kind := fl.elemType().Kind()
 	return kind == reflect.Float32 || kind == reflect.Float64
 }
 
 func (fl *flag) isString() bool {
-	return fl.field.Type.Kind() == reflect.String
+	return fl.elemType().Kind() == reflect.String
 }
 
 func (fl *flag) isPtr() bool {
@@ -227,14 +250,39 @@ func (fl *flag) isPtr() bool {
 }
 
 func (fl *flag) getBool() bool {
-	if !fl.isBoolean() {
+	if !fl.isBoolean() || (fl.isPtr() && fl.value.IsNil()) {
 		return false
 	}
-	return fl.value.Bool()
+	return reflect.Indirect(fl.value).Bool()
+}
+
+// valueKey returns key of flag in url.Values, which is actual flag name
+// or first name of flag if it's not set
+func (fl *flag) valueKey() string {
+	if fl.actualFlagName != "" {
+		return fl.actualFlagName
+	}
+	if len(fl.tag.shortNames) > 0 {
+		return fl.tag.shortNames[0]
+	}
+	return fl.tag.longNames[0]
+}
+
+// valueString returns formatted value of flag
+func (fl *flag) valueString() string {
+	if fl.isPtr() && fl.value.IsNil() {
+		return ""
+	}
+	return fmt.Sprintf("%v", reflect.Indirect(fl.value).Interface())
 }
 
 func (fl *flag) setDefault(s string, clr color.Color) error {
+	if fl.tag.unsupported != "" {
+		return nil
+	}
 	fl.isAssigned = true
+	fl.source = SourceDefault
+	fl.alloc()
 	if fl.isNeedDelaySet {
 		fl.lastValue = s
 		return nil
@@ -243,9 +291,18 @@ func (fl *flag) setDefault(s string, clr color.Color) error {
 }
 
 func (fl *flag) set(actualFlagName, s string, clr color.Color) error {
+	if err := fl.checkPlatform(); err != nil {
+		return err
+	}
+	s, err := fl.aliasValue(actualFlagName, s)
+	if err != nil {
+		return err
+	}
 	fl.isSet = true
 	fl.isAssigned = true
+	fl.source = SourceFlag
 	fl.actualFlagName = actualFlagName
+	fl.alloc()
 	if fl.isNeedDelaySet {
 		fl.lastValue = s
 		return nil
@@ -254,9 +311,20 @@ func (fl *flag) set(actualFlagName, s string, clr color.Color) error {
 }
 
 func (fl *flag) counterIncr(s string, clr color.Color) error {
+	if err := fl.checkPlatform(); err != nil {
+		return err
+	}
+	fl.alloc()
 	return setWithProperType(fl, fl.field.Type, fl.value, s, clr, false)
 }
 
+func (fl *flag) checkPlatform() error {
+	if fl.tag.unsupported != "" {
+		return fmt.Errorf(tr(MsgFlagNotSupported), fl.tag.unsupported)
+	}
+	return nil
+}
+
 func (fl *flag) isCounter() bool {
 	if decoder := tryGetDecoder(fl.value.Type().Kind(), fl.value); decoder != nil {
 		if _, ok := decoder.(CounterDecoder); ok {
@@ -267,9 +335,18 @@ func (fl *flag) isCounter() bool {
 }
 
 func (fl *flag) setWithNoDelay(actualFlagName, s string, clr color.Color) error {
+	if err := fl.checkPlatform(); err != nil {
+		return err
+	}
+	s, err := fl.aliasValue(actualFlagName, s)
+	if err != nil {
+		return err
+	}
 	fl.isSet = true
 	fl.isAssigned = true
+	fl.source = SourceFlag
 	fl.actualFlagName = actualFlagName
+	fl.alloc()
 	return setWithProperType(fl, fl.field.Type, fl.value, s, clr, false)
 }
 
@@ -350,6 +427,12 @@ func setWithProperType(fl *flag, typ reflect.Type, val reflect.Value, s string,
 			return err
 		}
 
+	case reflect.Ptr:
+		if val.IsNil() {
+			val.Set(reflect.New(typ.Elem()))
+		}
+		return setWithProperType(fl, typ.Elem(), val.Elem(), s, clr, isSubField)
+
 	case reflect.Slice:
 		if isSubField {
 			return fmt.Errorf("unsupported type %s as a sub field", kind.String())
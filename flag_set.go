@@ -1,11 +1,15 @@
 package cli
 
 import (
+	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"net/url"
+	"sort"
 	"strings"
+	"sync/atomic"
 
 	"github.com/Bowery/prompt"
 	"github.com/labstack/gommon/color"
@@ -16,24 +20,219 @@ type flagSet struct {
 	values url.Values
 	args   []string
 
+	// tailArgs holds arguments after `--`
+	tailArgs []string
+
 	flagMap   map[string]*flag
 	flagSlice []*flag
 
 	hasForce bool
+
+	// allErrors indicates whether to collect all errors instead of stopping at the first one
+	allErrors bool
+	errs      []error
+
+	// noInterspersed indicates whether to stop parsing flags at the first free argument
+	noInterspersed bool
+
+	// passthrough indicates whether to keep all arguments verbatim without parsing
+	passthrough bool
+
+	// allowUnknown indicates whether to collect undefined flags instead of failing
+	allowUnknown bool
+	unknownFlags []string
+
+	// configFile loads values of flags from config file
+	configFile *ConfigFile
+	// configInherited indicates whether configFile is inherited from root,
+	// undefined keys are ignored if true
+	configInherited bool
+
+	// sources are ordered from lowest precedence to highest, DefaultSources used if nil
+	sources []Source
+
+	// theme colors suggestions of undefined options, DefaultTheme used if nil
+	theme *Theme
+
+	// isolated indicates whether flags are parsed for Engine or remote clients,
+	// prompts read promptInput and write promptOutput, editor is never launched
+	isolated     bool
+	promptInput  io.Reader
+	promptOutput io.Writer
+
+	// noPrompt indicates whether to skip prompts and editor, e.g. while parsing leniently
+	noPrompt bool
 }
 
 func newFlagSet() *flagSet {
 	return &flagSet{
-		flagMap:   make(map[string]*flag),
-		flagSlice: []*flag{},
-		values:    url.Values(make(map[string][]string)),
-		args:      make([]string, 0),
+		flagMap:      make(map[string]*flag),
+		flagSlice:    []*flag{},
+		values:       url.Values(make(map[string][]string)),
+		args:         make([]string, 0),
+		tailArgs:     make([]string, 0),
+		unknownFlags: make([]string, 0),
 	}
 }
 
+// mergeFlagSets merges flagSets which are leniently parsed from the same args into
+// separate argvs, args and unknown flags are kept if they are in both flagSets
+func mergeFlagSets(a, b *flagSet) *flagSet {
+	if a == nil {
+		return b
+	}
+	fs := newFlagSet()
+	fs.allowUnknown = true
+	for _, from := range []*flagSet{a, b} {
+		for name, fl := range from.flagMap {
+			fs.flagMap[name] = fl
+		}
+		fs.flagSlice = append(fs.flagSlice, from.flagSlice...)
+		for key, values := range from.values {
+			fs.values[key] = append(fs.values[key], values...)
+		}
+	}
+	fs.args = commonArgs(a.args, b.args)
+	fs.tailArgs = b.tailArgs
+	fs.unknownFlags = commonArgs(a.unknownFlags, b.unknownFlags)
+	return fs
+}
+
+// commonArgs returns args of a which are also in b, a and b are subsequences of the same args
+func commonArgs(a, b []string) []string {
+	common := make([]string, 0)
+	j := 0
+	for _, arg := range a {
+		for k := j; k < len(b); k++ {
+			if b[k] == arg {
+				common = append(common, arg)
+				j = k + 1
+				break
+			}
+		}
+	}
+	return common
+}
+
+// isFoldFlags reports whether each char of chars is a defined flag,
+// chars after a non-boolean flag are treated as its value
+func (fs *flagSet) isFoldFlags(chars string) bool {
+	for _, c := range []byte(chars) {
+		fl, ok := fs.flagMap[dashOne+string([]byte{c})]
+		if !ok {
+			return false
+		}
+		if !fl.isBoolean() && !fl.isCounter() {
+			return true
+		}
+	}
+	return true
+}
+
+// fail records err, returns true if parsing should stop
+func (fs *flagSet) fail(err error) bool {
+	if err == nil {
+		return false
+	}
+	if fs.allErrors {
+		fs.errs = append(fs.errs, err)
+		fs.err = nil
+		return false
+	}
+	fs.err = err
+	return true
+}
+
+// joinErrors joins all collected errors into fs.err
+func (fs *flagSet) joinErrors() {
+	if len(fs.errs) == 1 {
+		fs.err = fs.errs[0]
+	} else if len(fs.errs) > 1 {
+		fs.err = multiError(fs.errs)
+	}
+}
+
+// suggestions returns names of flags similar to name
+func (fs *flagSet) suggestions(name string) []string {
+	names := make([]string, 0, len(fs.flagMap))
+	for key := range fs.flagMap {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	dists := []editDistanceRank{}
+	for _, key := range names {
+		// short flag only matches itself with another case, e.g. `-Q` => `-q`
+		if len(name) == 2 || len(key) == 2 {
+			if strings.EqualFold(name, key) {
+				dists = append(dists, editDistanceRank{s: key})
+			}
+			continue
+		}
+		if d, ok := matchWithMinDifferRate(strings.ToLower(name), strings.ToLower(key), 0.2); ok {
+			dists = append(dists, editDistanceRank{s: key, d: d})
+		}
+	}
+	sort.Stable(editDistanceRankSlice(dists))
+	ret := make([]string, 0, len(dists))
+	for _, dist := range dists {
+		ret = append(ret, dist.s)
+	}
+	return ret
+}
+
+// undefinedOption creates error for undefined option with suggestions
+func (fs *flagSet) undefinedOption(name string, extra []string, clr color.Color) error {
+	suggestions := extra
+	for _, s := range fs.suggestions(name) {
+		if len(extra) == 0 || s != extra[0] {
+			suggestions = append(suggestions, s)
+		}
+	}
+	theme := fs.theme.withDefaults()
+	msg := fmt.Sprintf(tr(MsgUndefinedOption), clr.Bold(name))
+	switch len(suggestions) {
+	case 0:
+		return errors.New(msg)
+	case 1:
+		return fmt.Errorf(tr(MsgOptionDidYouMean), msg, theme.Suggestion(&clr, suggestions[0]))
+	}
+	for i := range suggestions {
+		suggestions[i] = theme.Suggestion(&clr, suggestions[i])
+	}
+	return fmt.Errorf(tr(MsgOptionDidYouMeanOneOf), msg, strings.Join(suggestions, ", "))
+}
+
+// lookup finds flag by name, name could be with or without dash prefix,
+// e.g. "-a", "--aa", "a", "aa"
+func (fs *flagSet) lookup(name string) (*flag, bool) {
+	if fl, ok := fs.flagMap[name]; ok {
+		return fl, true
+	}
+	if strings.HasPrefix(name, dashOne) {
+		return nil, false
+	}
+	if len(name) == 1 {
+		name = dashOne + name
+	} else {
+		name = dashTwo + name
+	}
+	fl, ok := fs.flagMap[name]
+	return fl, ok
+}
+
 func (fs *flagSet) readPrompt(w io.Writer, clr color.Color) {
+	var input *bufio.Reader
+	if fs.isolated {
+		input = bufio.NewReader(fs.promptInput)
+	}
 	for _, fl := range fs.flagSlice {
-		if fl.isAssigned || fl.tag.prompt == "" {
+		if fl.isAssigned || fl.tag.prompt == "" || fl.tag.unsupported != "" {
+			continue
+		}
+		if input != nil {
+			if fs.err = fs.askFlag(input, fl, clr); fs.err != nil {
+				return
+			}
 			continue
 		}
 		// read ...
@@ -69,12 +268,37 @@ func (fs *flagSet) readPrompt(w io.Writer, clr color.Color) {
 	}
 }
 
+// askFlag reads value of prompt flag fl from r for isolated flag set, question is
+// written to promptOutput. The flag is left unassigned if answer is empty or r reaches EOF.
+func (fs *flagSet) askFlag(r *bufio.Reader, fl *flag, clr color.Color) error {
+	if fl.isBoolean() {
+		yes, err := confirm(r, fs.promptOutput, fl.tag.prompt)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return fl.setWithNoDelay("", fmt.Sprintf("%v", yes), clr)
+	}
+	data, err := ask(r, fs.promptOutput, fl.tag.prompt, "")
+	if err == io.EOF || (err == nil && data == "") {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return fl.setWithNoDelay("", data, clr)
+}
+
 func (fs *flagSet) readEditor(clr color.Color) {
 	editor, editorErr := getEditor()
 	for _, fl := range fs.flagSlice {
-		if fl.isAssigned || !fl.tag.isEdit {
+		if fl.isAssigned || !fl.tag.isEdit || fl.tag.unsupported != "" {
 			continue
 		}
+		if fs.isolated {
+			fs.err = fmt.Errorf("parameter %s: editor is not available", clr.Bold(fl.name()))
+			return
+		}
 		if editorErr != nil {
 			fs.err = editorErr
 			return
@@ -105,21 +329,42 @@ const (
 	DenseManualStyle
 )
 
-var defaultStyle = NormalStyle
+var defaultStyle = int32(NormalStyle) // accessed atomically
 
 // GetUsageStyle gets default style
 func GetUsageStyle() UsageStyle {
-	return defaultStyle
+	return UsageStyle(atomic.LoadInt32(&defaultStyle))
 }
 
 // SetUsageStyle sets default style
 func SetUsageStyle(style UsageStyle) {
-	defaultStyle = style
+	atomic.StoreInt32(&defaultStyle, int32(style))
+}
+
+var usageWidth int32 // accessed atomically
+
+// GetUsageWidth gets width of usage set by SetUsageWidth
+func GetUsageWidth() int {
+	return int(atomic.LoadInt32(&usageWidth))
+}
+
+// SetUsageWidth sets width of usage in columns, descriptions of flags and commands
+// are wrapped to fit width. Zero(default) uses width of terminal, usage written to
+// non-terminal is not wrapped. Negative width disables wrapping.
+func SetUsageWidth(width int) {
+	atomic.StoreInt32(&usageWidth, int32(width))
 }
 
 type flagSlice []*flag
 
 func (fs flagSlice) String(clr color.Color) string {
+	theme := DefaultTheme()
+	return fs.stringWithWidth(clr, &theme, 0)
+}
+
+// stringWithWidth is similar to String, usages are wrapped to fit width if width > 0
+// and colored by theme
+func (fs flagSlice) stringWithWidth(clr color.Color, theme *Theme, width int) string {
 	var (
 		lenShort                 = 0
 		lenLong                  = 0
@@ -131,25 +376,25 @@ func (fs flagSlice) String(clr color.Color) string {
 		tag := fl.tag
 		l := 0
 		for _, shortName := range tag.shortNames {
-			l += len(shortName) + lenSep
+			l += stringWidth(shortName) + lenSep
 		}
 		if l > lenShort {
 			lenShort = l
 		}
 		l = 0
 		for _, longName := range tag.longNames {
-			l += len(longName) + lenSep
+			l += stringWidth(longName) + lenSep
 		}
 		if l > lenLong {
 			lenLong = l
 		}
 		lenDft := 0
 		if tag.dft != "" {
-			lenDft = len(tag.dft) + 3 // 3=len("[=]")
+			lenDft = stringWidth(tag.dft) + 3 // 3=len("[=]")
 		}
 		l += lenDft
 		if tag.name != "" {
-			l += len(tag.name) + 1 // 1=len("=")
+			l += stringWidth(tag.name) + 1 // 1=len("=")
 		}
 		if l > lenNameAndDefaultAndLong {
 			lenNameAndDefaultAndLong = l
@@ -162,7 +407,6 @@ func (fs flagSlice) String(clr color.Color) string {
 			tag         = fl.tag
 			shortStr    = strings.Join(tag.shortNames, sepName)
 			longStr     = strings.Join(tag.longNames, sepName)
-			format      = ""
 			defaultStr  = ""
 			nameStr     = ""
 			usagePrefix = " "
@@ -174,32 +418,32 @@ func (fs flagSlice) String(clr color.Color) string {
 			nameStr = "=" + tag.name
 		}
 		if tag.isRequired {
-			usagePrefix = clr.Red("*")
+			usagePrefix = theme.Required(&clr, "*")
 		}
-		usage := usagePrefix + tag.usage
+		// usage starts after short names, long names and a prefix
+		usage := usagePrefix + wrapIndent(tag.usage, lenShort+lenSep+lenNameAndDefaultAndLong+1, width)
 
 		spaceSize := lenNameAndDefaultAndLong
-		spaceSize -= len(nameStr) + len(defaultStr) + len(longStr)
+		spaceSize -= stringWidth(nameStr) + stringWidth(defaultStr) + stringWidth(longStr)
 
 		if defaultStr != "" {
-			defaultStr = clr.Grey(defaultStr)
+			defaultStr = theme.Default(&clr, defaultStr)
 		}
 		if nameStr != "" {
-			nameStr = "=" + clr.Bold(tag.name)
+			nameStr = "=" + theme.Flag(&clr, tag.name)
 		}
 
+		// columns are aligned by display width, names and defaults may be non-ASCII
 		if longStr == "" {
-			format = fmt.Sprintf("%%%ds%%s%s%%s", lenShort, sepSpaces)
 			fillStr := fillSpaces(nameStr+defaultStr, spaceSize)
-			fmt.Fprintf(buff, format+"\n", shortStr, fillStr, usage)
+			fmt.Fprintf(buff, "%s%s%s%s\n", padLeft(shortStr, lenShort), sepSpaces, fillStr, usage)
 		} else {
+			sep := sepName
 			if shortStr == "" {
-				format = fmt.Sprintf("%%%ds%%s%%s", lenShort+lenSep)
-			} else {
-				format = fmt.Sprintf("%%%ds%s%%s%%s", lenShort, sepName)
+				sep = sepSpaces
 			}
 			fillStr := fillSpaces(longStr+nameStr+defaultStr, spaceSize)
-			fmt.Fprintf(buff, format+"\n", shortStr, fillStr, usage)
+			fmt.Fprintf(buff, "%s%s%s%s\n", padLeft(shortStr, lenShort), sep, fillStr, usage)
 		}
 	}
 	return buff.String()
@@ -210,8 +454,15 @@ func fillSpaces(s string, spaceSize int) string {
 }
 
 func (fs flagSlice) StringWithStyle(clr color.Color, style UsageStyle) string {
+	theme := DefaultTheme()
+	return fs.styledString(clr, &theme, style, 0)
+}
+
+// styledString is similar to StringWithStyle, usages are wrapped to fit width if width > 0
+// and colored by theme
+func (fs flagSlice) styledString(clr color.Color, theme *Theme, style UsageStyle, width int) string {
 	if style != ManualStyle && style != DenseManualStyle {
-		return fs.String(clr)
+		return fs.stringWithWidth(clr, theme, width)
 	}
 
 	buf := bytes.NewBufferString("")
@@ -222,20 +473,22 @@ func (fs flagSlice) StringWithStyle(clr color.Color, style UsageStyle) string {
 		}
 		names := strings.Join(append(fl.tag.shortNames, fl.tag.longNames...), sepName)
 		buf.WriteString(linePrefix)
-		buf.WriteString(clr.Bold(names))
+		buf.WriteString(theme.Flag(&clr, names))
 		if fl.tag.name != "" {
-			buf.WriteString("=" + clr.Bold(fl.tag.name))
+			buf.WriteString("=" + theme.Flag(&clr, fl.tag.name))
 		}
 		if fl.tag.dft != "" {
-			buf.WriteString(clr.Grey(fmt.Sprintf("[=%s]", fl.tag.dft)))
+			buf.WriteString(theme.Default(&clr, fmt.Sprintf("[=%s]", fl.tag.dft)))
 		}
 		buf.WriteString("\n")
 		buf.WriteString(linePrefix)
 		buf.WriteString("    ")
+		indent := len(linePrefix) + 4
 		if fl.tag.isRequired {
-			buf.WriteString(clr.Red("*"))
+			buf.WriteString(theme.Required(&clr, "*"))
+			indent++
 		}
-		buf.WriteString(fl.tag.usage)
+		buf.WriteString(wrapIndent(fl.tag.usage, indent, width))
 		if style != DenseManualStyle {
 			buf.WriteString("\n")
 		}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/labstack/gommon/color"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,3 +37,29 @@ func TestParseExpression(t *testing.T) {
 		assert.Equal(t, tt.expr, got)
 	}
 }
+
+func TestUndefinedOptionSuggestions(t *testing.T) {
+	type argT struct {
+		Port  int    `cli:"p,port"`
+		Proto string `cli:"proto"`
+		Host  string `cli:"host"`
+		Quiet bool   `cli:"q"`
+	}
+	clr := color.Color{}
+	clr.Disable()
+	for _, tt := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--hots"}, "undefined option --hots, did you mean --host?"},
+		{[]string{"--prot"}, "undefined option --prot, did you mean one of --proto, --port?"},
+		{[]string{"--undefined"}, "undefined option --undefined"},
+		{[]string{"-Q"}, "undefined option -Q, did you mean -q?"},
+		{[]string{"-host"}, "undefined option -h, did you mean --host?"},
+	} {
+		flagSet := parseArgv(tt.args, new(argT), clr)
+		if assert.Error(t, flagSet.err) {
+			assert.Equal(t, flagSet.err.Error(), tt.err)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/labstack/gommon/color"
+)
+
+// snapshot is a case of generated tests, output and error of running args
+type snapshot struct {
+	args   []string
+	output string
+	err    string
+}
+
+// GenerateTests writes a Go test file of package pkg to w, which covers routing,
+// flag parsing errors, validation errors and help output for every command path
+// of root. rootExpr is the Go expression which returns root in package pkg, e.g.
+// `newApp()`. Snapshots are taken by running root with args which fail before
+// command function called, so commands never run while generating. Commands with
+// prompt or edit flags are covered by routing only.
+func GenerateTests(w io.Writer, root *Command, pkg, rootExpr string) error {
+	var (
+		routes    = [][]string{}
+		paths     = []string{}
+		snapshots = []snapshot{}
+	)
+	root.walk(nil, func(cmd *Command, router []string) {
+		if len(router) > 0 {
+			routes = append(routes, router)
+			paths = append(paths, cmd.Path())
+		}
+		if cmd.isInteractive() {
+			return
+		}
+		for _, args := range cmd.snapshotArgs(router) {
+			if s, ok := root.takeSnapshot(args); ok {
+				snapshots = append(snapshots, s)
+			}
+		}
+	})
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "// Code generated by cli.GenerateTests. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(buf, "import (\n\t\"bytes\"\n\t\"strings\"\n\t\"testing\"\n)\n\n")
+	fmt.Fprintf(buf, "func TestCommandRoutes(t *testing.T) {\n\troot := %s\n", rootExpr)
+	fmt.Fprintf(buf, "\tfor _, tt := range []struct {\n\t\trouter []string\n\t\tpath string\n\t}{\n")
+	for i, router := range routes {
+		fmt.Fprintf(buf, "\t\t{%s, %q},\n", goStrings(router), paths[i])
+	}
+	fmt.Fprintf(buf, "\t} {\n")
+	fmt.Fprintf(buf, "\t\tcmd := root.Route(tt.router)\n")
+	fmt.Fprintf(buf, "\t\tif cmd == nil {\n\t\t\tt.Errorf(\"route %%q: command not found\", strings.Join(tt.router, \" \"))\n")
+	fmt.Fprintf(buf, "\t\t} else if cmd.Path() != tt.path {\n\t\t\tt.Errorf(\"route %%q: got %%q, want %%q\", strings.Join(tt.router, \" \"), cmd.Path(), tt.path)\n\t\t}\n")
+	fmt.Fprintf(buf, "\t}\n}\n\n")
+
+	fmt.Fprintf(buf, "func TestCommandSnapshots(t *testing.T) {\n\troot := %s\n", rootExpr)
+	fmt.Fprintf(buf, "\tfor _, tt := range []struct {\n\t\targs []string\n\t\toutput string\n\t\terr string\n\t}{\n")
+	for _, s := range snapshots {
+		fmt.Fprintf(buf, "\t\t{%s, %q, %q},\n", goStrings(s.args), s.output, s.err)
+	}
+	fmt.Fprintf(buf, "\t} {\n")
+	fmt.Fprintf(buf, "\t\tout := new(bytes.Buffer)\n\t\terrString := \"\"\n")
+	fmt.Fprintf(buf, "\t\tif err := root.RunWith(tt.args, out, nil); err != nil {\n\t\t\terrString = err.Error()\n\t\t}\n")
+	fmt.Fprintf(buf, "\t\tif errString != tt.err {\n\t\t\tt.Errorf(\"run %%q: got error %%q, want %%q\", strings.Join(tt.args, \" \"), errString, tt.err)\n\t\t}\n")
+	fmt.Fprintf(buf, "\t\tif out.String() != tt.output {\n\t\t\tt.Errorf(\"run %%q: got output %%q, want %%q\", strings.Join(tt.args, \" \"), out.String(), tt.output)\n\t\t}\n")
+	fmt.Fprintf(buf, "\t}\n}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// walk visits cmd and its descendants with routers, aliases of commands are visited as routers too
+func (cmd *Command) walk(router []string, visit func(cmd *Command, router []string)) {
+	visit(cmd, router)
+	for _, child := range cmd.getChildren() {
+		if child.isHidden() {
+			continue
+		}
+		for i, name := range append([]string{child.Name}, child.Aliases...) {
+			childRouter := append(append([]string{}, router...), name)
+			if i == 0 {
+				child.walk(childRouter, visit)
+			} else {
+				visit(child, childRouter)
+			}
+		}
+	}
+}
+
+// isInteractive reports whether cmd has flags which read values from prompt or editor
+func (cmd *Command) isInteractive() bool {
+	for _, fl := range cmd.flagSlice() {
+		if fl.tag.prompt != "" || fl.tag.isEdit {
+			return true
+		}
+	}
+	return false
+}
+
+// snapshotArgs returns candidate args to take snapshots for command of router:
+// help, no arguments, undefined flag and each value flag without value
+func (cmd *Command) snapshotArgs(router []string) [][]string {
+	withArgs := func(args ...string) []string {
+		return append(append([]string{}, router...), args...)
+	}
+	list := [][]string{
+		withArgs(dashTwo + "help"),
+		withArgs(),
+		withArgs(dashTwo + "undefined-flag"),
+	}
+	for _, fl := range cmd.flagSlice() {
+		if fl.tag.unsupported != "" || fl.isBoolean() || fl.isCounter() {
+			continue
+		}
+		names := append(append([]string{}, fl.tag.longNames...), fl.tag.shortNames...)
+		if len(names) > 0 {
+			list = append(list, withArgs(names[0]))
+		}
+	}
+	return list
+}
+
+// takeSnapshot runs args if they fail before command function called
+func (cmd *Command) takeSnapshot(args []string) (snapshot, bool) {
+	clr := color.Color{}
+	clr.Disable()
+	if _, _, err := cmd.prepare(clr, args, ioutil.Discard, nil, invocation{}); err == nil {
+		return snapshot{}, false
+	}
+	out := new(bytes.Buffer)
+	s := snapshot{args: args}
+	if err := cmd.RunWith(args, out, nil); err != nil {
+		s.err = err.Error()
+	}
+	s.output = out.String()
+	return s, true
+}
+
+func goStrings(list []string) string {
+	quoted := make([]string, 0, len(list))
+	for _, s := range list {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+type genTestsT struct {
+	Helper
+	Output  string `cli:"o,output" usage:"output file, write to stdout if empty"`
+	Package string `cli:"package" usage:"package name of generated tests" dft:"main"`
+	Root    string `cli:"root" usage:"Go expression which returns root command" dft:"newApp()"`
+}
+
+// GenerateTestsCommand returns a hidden command which generates snapshot tests
+// of root command tree, see GenerateTests
+func GenerateTestsCommand(desc string) *Command {
+	if desc == "" {
+		desc = "generate snapshot tests of command tree"
+	}
+	return &Command{
+		Name:   "gentests",
+		Desc:   desc,
+		Hidden: true,
+		Argv:   func() interface{} { return new(genTestsT) },
+		Fn: func(ctx *Context) error {
+			argv := ctx.Argv().(*genTestsT)
+			buf := new(bytes.Buffer)
+			if err := GenerateTests(buf, ctx.Command().Root(), argv.Package, argv.Root); err != nil {
+				return err
+			}
+			if argv.Output == "" {
+				_, err := ctx.Write(buf.Bytes())
+				return err
+			}
+			return WriteFileAtomic(argv.Output, buf.Bytes(), 0644)
+		},
+	}
+}
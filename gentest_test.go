@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTests(t *testing.T) {
+	type helloT struct {
+		Helper
+		Name string `cli:"*n,name" usage:"your name"`
+		Loud bool   `cli:"loud" usage:"shout"`
+	}
+	type loginT struct {
+		Password string `pw:"p,password" prompt:"password"`
+	}
+	called := false
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name:    "hello",
+		Aliases: []string{"hi"},
+		Argv:    func() interface{} { return new(helloT) },
+		Fn: func(ctx *Context) error {
+			called = true
+			return nil
+		},
+	})
+	root.Register(&Command{Name: "login", Argv: func() interface{} { return new(loginT) }, Fn: donothing})
+	root.Register(&Command{Name: "secret", Hidden: true, Fn: donothing})
+
+	buf := new(bytes.Buffer)
+	require.Nil(t, GenerateTests(buf, root, "main", "newApp()"))
+	assert.False(t, called)
+	src := buf.String()
+	assert.True(t, strings.HasPrefix(src, "// Code generated by cli.GenerateTests. DO NOT EDIT.\n\npackage main\n"))
+	for _, s := range []string{
+		"root := newApp()",
+		`{[]string{"hello"}, "hello"}`,
+		`{[]string{"hi"}, "hello"}`,
+		`{[]string{"login"}, "login"}`,
+		`{[]string{}, "", "ERR! command app not found"}`,
+		`{[]string{"hello", "--help"}, "Options:\n\n  -h, --help`,
+		`{[]string{"hello"}, "", "ERR! required parameter --name missing"}`,
+		`{[]string{"hi", "--undefined-flag"}, "", "ERR! undefined option --undefined-flag"}`,
+	} {
+		assert.Contains(t, src, s)
+	}
+	assert.False(t, strings.Contains(src, "secret"))
+	assert.False(t, strings.Contains(src, `"login", "--`))
+	assert.False(t, strings.Contains(src, `"hello", "--loud"`))
+}
+
+func TestGenerateTestsCommand(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{Name: "hello", Fn: donothing})
+	root.Register(GenerateTestsCommand(""))
+	w := new(bytes.Buffer)
+	require.Nil(t, root.RunWith([]string{"gentests", "--package", "app_test", "--root", "app.Root()"}, w, nil))
+	assert.Contains(t, w.String(), "package app_test\n")
+	assert.Contains(t, w.String(), "root := app.Root()")
+	assert.Contains(t, w.String(), `{[]string{"hello"}, "hello"}`)
+	assert.False(t, strings.Contains(w.String(), "gentests"))
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/labstack/gommon/color"
+)
+
+// Graph formats supported by Command.WriteGraph
+const (
+	GraphDot     = "dot"
+	GraphMermaid = "mermaid"
+)
+
+// Walk visits cmd and its descendants in depth-first order,
+// hidden commands are skipped. Walking stops if fn returns an error.
+func (cmd *Command) Walk(fn func(cmd *Command) error) error {
+	if err := fn(cmd); err != nil {
+		return err
+	}
+	for _, child := range cmd.getChildren() {
+		if child.isHidden() {
+			continue
+		}
+		if err := child.Walk(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteGraph writes hierarchy of cmd and its HTTP routes as a Graphviz(dot)
+// or Mermaid graph, HTTP routes are linked with dashed edges labeled by methods
+func (cmd *Command) WriteGraph(w io.Writer, format string) error {
+	var g graphWriter
+	switch format {
+	case GraphDot:
+		g = dotGraph{w}
+	case GraphMermaid:
+		g = mermaidGraph{w}
+	default:
+		return fmt.Errorf("unsupported graph format %s", format)
+	}
+	var (
+		ids    = map[*Command]string{}
+		routes = 0
+	)
+	g.begin()
+	err := cmd.Walk(func(c *Command) error {
+		id := fmt.Sprintf("n%d", len(ids))
+		ids[c] = id
+		label := c.treeLabel()
+		if c.Deprecated != "" {
+			label += " [deprecated]"
+		}
+		g.node(id, label)
+		if parent, ok := ids[c.parent]; ok && c != cmd {
+			g.edge(parent, id)
+		}
+		for _, router := range c.HTTPRouters {
+			route := fmt.Sprintf("r%d", routes)
+			routes++
+			g.route(id, route, router, strings.Join(c.HTTPMethods, ","))
+		}
+		return nil
+	})
+	g.end()
+	return err
+}
+
+// treeLabel returns name of cmd with aliases, e.g. `user (u, usr)`
+func (cmd *Command) treeLabel() string {
+	if len(cmd.Aliases) == 0 {
+		return cmd.Name
+	}
+	return cmd.Name + " (" + strings.Join(cmd.Aliases, ", ") + ")"
+}
+
+// WriteTree writes hierarchy of cmd as an indented tree, each command is followed by
+// its Desc, names of its own flags and markers of hidden and deprecated commands, e.g.
+//
+//	app
+//	├── user (u)  manage users
+//	│   └── get  get user  [--id, -v]
+//	└── old  [hidden, deprecated]
+//
+// Hidden commands are listed too.
+func (cmd *Command) WriteTree(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, cmd.treeLine()); err != nil {
+		return err
+	}
+	return cmd.writeSubtree(w, "")
+}
+
+func (cmd *Command) writeSubtree(w io.Writer, indent string) error {
+	children := cmd.getChildren()
+	for i, child := range children {
+		branch, next := "├── ", "│   "
+		if i == len(children)-1 {
+			branch, next = "└── ", "    "
+		}
+		if _, err := fmt.Fprintln(w, indent+branch+child.treeLine()); err != nil {
+			return err
+		}
+		if err := child.writeSubtree(w, indent+next); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// treeLine returns label of cmd with Desc, flags and markers
+func (cmd *Command) treeLine() string {
+	parts := []string{cmd.treeLabel()}
+	if cmd.Desc != "" {
+		parts = append(parts, cmd.Desc)
+	}
+	if cmd.hasArgv() {
+		names := []string{}
+		for _, fl := range visibleFlags(cmd.flagArgvList()[:len(cmd.Argvs)+1], color.Color{}) {
+			if name := fl.name(); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) > 0 {
+			parts = append(parts, "["+strings.Join(names, ", ")+"]")
+		}
+	}
+	markers := []string{}
+	if cmd.isHidden() {
+		markers = append(markers, "hidden")
+	}
+	if cmd.Deprecated != "" {
+		markers = append(markers, "deprecated")
+	}
+	if len(markers) > 0 {
+		parts = append(parts, "["+strings.Join(markers, ", ")+"]")
+	}
+	return strings.Join(parts, "  ")
+}
+
+type graphWriter interface {
+	begin()
+	node(id, label string)
+	edge(from, to string)
+	route(from, id, path, methods string)
+	end()
+}
+
+type dotGraph struct {
+	w io.Writer
+}
+
+func (g dotGraph) begin() { fmt.Fprintln(g.w, "digraph commands {") }
+func (g dotGraph) end()   { fmt.Fprintln(g.w, "}") }
+
+func (g dotGraph) node(id, label string) {
+	fmt.Fprintf(g.w, "  %s [label=%q];\n", id, label)
+}
+
+func (g dotGraph) edge(from, to string) {
+	fmt.Fprintf(g.w, "  %s -> %s;\n", from, to)
+}
+
+func (g dotGraph) route(from, id, path, methods string) {
+	fmt.Fprintf(g.w, "  %s [label=%q, shape=note];\n", id, path)
+	if methods != "" {
+		fmt.Fprintf(g.w, "  %s -> %s [style=dashed, label=%q];\n", from, id, methods)
+	} else {
+		fmt.Fprintf(g.w, "  %s -> %s [style=dashed];\n", from, id)
+	}
+}
+
+type mermaidGraph struct {
+	w io.Writer
+}
+
+func (g mermaidGraph) begin() { fmt.Fprintln(g.w, "graph TD") }
+func (g mermaidGraph) end()   {}
+
+func mermaidLabel(label string) string {
+	return `"` + strings.Replace(label, `"`, "#quot;", -1) + `"`
+}
+
+func (g mermaidGraph) node(id, label string) {
+	fmt.Fprintf(g.w, "  %s[%s]\n", id, mermaidLabel(label))
+}
+
+func (g mermaidGraph) edge(from, to string) {
+	fmt.Fprintf(g.w, "  %s --> %s\n", from, to)
+}
+
+func (g mermaidGraph) route(from, id, path, methods string) {
+	fmt.Fprintf(g.w, "  %s>%s]\n", id, mermaidLabel(path))
+	if methods != "" {
+		fmt.Fprintf(g.w, "  %s -.->|%s| %s\n", from, methods, id)
+	} else {
+		fmt.Fprintf(g.w, "  %s -.-> %s\n", from, id)
+	}
+}
+
+type commandsT struct {
+	Helper
+	Graph string `cli:"graph" usage:"export command graph as dot or mermaid"`
+	Tree  bool   `cli:"tree" usage:"print command tree with flags, including hidden commands"`
+}
+
+// CommandsCommand returns a command which lists commands of root,
+// prints them as a tree with --tree, or exports them as a graph with --graph,
+// e.g. `app commands --graph dot`
+func CommandsCommand(desc string) *Command {
+	if desc == "" {
+		desc = "list commands or export command graph"
+	}
+	return &Command{
+		Name: "commands",
+		Desc: desc,
+		Argv: func() interface{} { return new(commandsT) },
+		Fn: func(ctx *Context) error {
+			root := ctx.Command().Root()
+			argv := ctx.Argv().(*commandsT)
+			if argv.Graph != "" {
+				return root.WriteGraph(ctx, argv.Graph)
+			}
+			if argv.Tree {
+				return root.WriteTree(ctx)
+			}
+			rows := [][]string{}
+			root.Walk(func(cmd *Command) error {
+				if cmd != root {
+					rows = append(rows, []string{cmd.Path(), cmd.Desc})
+				}
+				return nil
+			})
+			ctx.Table(nil, rows)
+			return nil
+		},
+	}
+}
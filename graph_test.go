@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newGraphApp() *Command {
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{Name: "user", Desc: "manage users", Aliases: []string{"u"}})
+	user.Register(&Command{Name: "get", Desc: "get user", HTTPRouters: []string{"/v1/user"}, HTTPMethods: []string{"GET"}})
+	root.Register(&Command{Name: "secret", Hidden: true})
+	root.Register(&Command{Name: "version", Desc: "show version"})
+	root.Register(CommandsCommand(""))
+	return root
+}
+
+func TestWalk(t *testing.T) {
+	root := newGraphApp()
+	names := []string{}
+	assert.Nil(t, root.Walk(func(cmd *Command) error {
+		names = append(names, cmd.Name)
+		return nil
+	}))
+	assert.Equal(t, names, []string{"app", "user", "get", "version", "commands"})
+
+	stop := errors.New("stop")
+	names = names[:0]
+	assert.Equal(t, root.Walk(func(cmd *Command) error {
+		names = append(names, cmd.Name)
+		if cmd.Name == "user" {
+			return stop
+		}
+		return nil
+	}), stop)
+	assert.Equal(t, names, []string{"app", "user"})
+}
+
+func TestWriteGraph(t *testing.T) {
+	root := newGraphApp()
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.WriteGraph(w, GraphDot))
+	assert.Equal(t, w.String(), `digraph commands {
+  n0 [label="app"];
+  n1 [label="user (u)"];
+  n0 -> n1;
+  n2 [label="get"];
+  n1 -> n2;
+  r0 [label="/v1/user", shape=note];
+  n2 -> r0 [style=dashed, label="GET"];
+  n3 [label="version"];
+  n0 -> n3;
+  n4 [label="commands"];
+  n0 -> n4;
+}
+`)
+
+	w.Reset()
+	assert.Nil(t, root.RunWith([]string{"commands", "--graph", "mermaid"}, w, nil))
+	assert.Equal(t, w.String(), `graph TD
+  n0["app"]
+  n1["user (u)"]
+  n0 --> n1
+  n2["get"]
+  n1 --> n2
+  r0>"/v1/user"]
+  n2 -.->|GET| r0
+  n3["version"]
+  n0 --> n3
+  n4["commands"]
+  n0 --> n4
+`)
+
+	w.Reset()
+	assert.Nil(t, root.RunWith([]string{"commands"}, w, nil))
+	assert.Equal(t, w.String(), `user      manage users
+user get  get user
+version   show version
+commands  list commands or export command graph
+`)
+
+	assert.Error(t, root.WriteGraph(w, "svg"))
+}
+
+func TestWriteTree(t *testing.T) {
+	type argT struct {
+		ID      int  `cli:"id" usage:"id of user"`
+		Verbose bool `cli:"v" usage:"verbose"`
+	}
+	root := newGraphApp()
+	root.Route([]string{"user", "get"}).Argv = func() interface{} { return new(argT) }
+	root.Register(&Command{Name: "old", Desc: "old version", Deprecated: "use version instead"})
+
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith([]string{"commands", "--tree"}, w, nil))
+	assert.Equal(t, w.String(), `app
+├── user (u)  manage users
+│   └── get  get user  [--id, -v]
+├── secret  [hidden]
+├── version  show version
+├── commands  list commands or export command graph  [--help, --graph, --tree]
+└── old  old version  [deprecated]
+`)
+
+	w.Reset()
+	assert.Nil(t, root.WriteGraph(w, GraphDot))
+	assert.Contains(t, w.String(), `n5 [label="old [deprecated]"];`)
+}
@@ -0,0 +1,174 @@
+//go:build grpc
+// +build grpc
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCContentSubtype is content subtype of messages of CommandService, messages are
+// encoded as JSON, so no generated code is required. Clients call with option
+// grpc.CallContentSubtype(GRPCContentSubtype), see ExecuteGRPC.
+const GRPCContentSubtype = "clijson"
+
+// GRPCServiceName is full name of the service registered by RegisterGRPC
+const GRPCServiceName = "cli.CommandService"
+
+type (
+	// CommandRequest is request of ExecuteCommand
+	CommandRequest struct {
+		Args  []string `json:"args"`            // Command path, flags and arguments
+		Input []byte   `json:"input,omitempty"` // Input of command, returned by Context.Reader
+	}
+
+	// CommandResponse is a chunk of output streamed by ExecuteCommand
+	CommandResponse struct {
+		Stdout []byte `json:"stdout,omitempty"`
+		Stderr []byte `json:"stderr,omitempty"`
+	}
+)
+
+func init() {
+	encoding.RegisterCodec(grpcJSONCodec{})
+}
+
+type grpcJSONCodec struct{}
+
+func (grpcJSONCodec) Marshal(v interface{}) ([]byte, error)      { return json.Marshal(v) }
+func (grpcJSONCodec) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+func (grpcJSONCodec) Name() string                               { return GRPCContentSubtype }
+
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: GRPCServiceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "ExecuteCommand",
+		ServerStreams: true,
+		Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			return srv.(*Command).executeGRPC(stream)
+		},
+	}},
+}
+
+// RegisterGRPC sets IsServer with true and registers CommandService to s, its streaming
+// RPC ExecuteCommand routes a CommandRequest through command tree and streams output.
+// It's built with tag `grpc`, e.g. `go build -tags grpc`. Errors are returned as
+// status with code:
+//
+//	NotFound          command not found
+//	InvalidArgument   parsing flags or arguments failed
+//	DeadlineExceeded  timeout of command or deadline of request exceeded
+//	Canceled          command interrupted
+//	Unknown           error returned by command
+func (cmd *Command) RegisterGRPC(s *grpc.Server) {
+	cmd.SetIsServer(true)
+	s.RegisterService(&grpcServiceDesc, cmd)
+}
+
+func (cmd *Command) executeGRPC(stream grpc.ServerStream) error {
+	req := new(CommandRequest)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	var (
+		locker sync.Mutex
+		stdout = grpcWriter{stream: stream, locker: &locker}
+		stderr = grpcWriter{stream: stream, locker: &locker, stderr: true}
+		setup  = func(ctx *Context) {
+			ctx.reader = bytes.NewReader(req.Input)
+			ctx.errWriter = stderr
+			ctx.goctx = stream.Context()
+		}
+	)
+	return grpcStatus(cmd.runRemote(req.Args, stdout, nil, setup))
+}
+
+// grpcStatus converts error returned by command to status error, message of status
+// is the plain error text without the colored "ERR!" prefix
+func grpcStatus(err error) error {
+	if err == nil || err == ExitError {
+		return nil
+	}
+	code := codes.Unknown
+	if werr, ok := err.(wrapError); ok {
+		code = codes.InvalidArgument
+		err = werr.err
+		switch err.(type) {
+		case commandNotFoundError:
+			code = codes.NotFound
+		case methodNotAllowedError:
+			code = codes.Unimplemented
+		}
+	} else if IsTimeout(err) {
+		code = codes.DeadlineExceeded
+	} else if IsInterrupted(err) {
+		code = codes.Canceled
+		if errors.Is(err, context.DeadlineExceeded) {
+			code = codes.DeadlineExceeded
+		}
+	}
+	return status.Error(code, err.Error())
+}
+
+// grpcWriter sends each write as a CommandResponse
+type grpcWriter struct {
+	stream grpc.ServerStream
+	locker *sync.Mutex // shared by writers of a stream
+	stderr bool
+}
+
+func (w grpcWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	resp := &CommandResponse{}
+	if w.stderr {
+		resp.Stderr = data
+	} else {
+		resp.Stdout = data
+	}
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	if err := w.stream.SendMsg(resp); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// ExecuteGRPC calls ExecuteCommand of CommandService with args, streamed output is
+// written to stdout and stderr. Status error of command is returned, see RegisterGRPC.
+func ExecuteGRPC(goctx context.Context, conn *grpc.ClientConn, args []string, input []byte, stdout, stderr io.Writer) error {
+	stream, err := conn.NewStream(goctx, &grpcServiceDesc.Streams[0],
+		"/"+GRPCServiceName+"/ExecuteCommand", grpc.CallContentSubtype(GRPCContentSubtype))
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(&CommandRequest{Args: args, Input: input}); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+	for {
+		resp := new(CommandResponse)
+		if err := stream.RecvMsg(resp); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		stdout.Write(resp.Stdout)
+		stderr.Write(resp.Stderr)
+	}
+}
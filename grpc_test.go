@@ -0,0 +1,83 @@
+//go:build grpc
+// +build grpc
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type grpcArgT struct {
+	Name string `cli:"name" usage:"name"`
+}
+
+func TestExecuteGRPC(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "hello",
+		Argv: func() interface{} { return new(grpcArgT) },
+		Fn: func(ctx *Context) error {
+			input, err := ioutil.ReadAll(ctx.Reader())
+			if err != nil {
+				return err
+			}
+			ctx.String("hello %s %s\n", ctx.Argv().(*grpcArgT).Name, input)
+			ctx.Warn("careful")
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			return errors.New("oops")
+		},
+	})
+
+	l := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	root.RegisterGRPC(s)
+	go s.Serve(l)
+	defer s.Stop()
+	assert.True(t, root.IsServer())
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return l.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.Nil(t, err)
+	defer conn.Close()
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	err = ExecuteGRPC(context.Background(), conn, []string{"hello", "--name=grpc"}, []byte("input"), stdout, stderr)
+	require.Nil(t, err)
+	assert.Equal(t, stdout.String(), "hello grpc input\n")
+	assert.Equal(t, stderr.String(), "WARN! careful\n")
+
+	for i, tc := range []struct {
+		args []string
+		code codes.Code
+		msg  string
+	}{
+		{[]string{"missing"}, codes.NotFound, "command missing not found"},
+		{[]string{"hello", "--unknown"}, codes.InvalidArgument, "undefined option --unknown"},
+		{[]string{"fail"}, codes.Unknown, "oops"},
+	} {
+		err := ExecuteGRPC(context.Background(), conn, tc.args, nil, ioutil.Discard, ioutil.Discard)
+		st, ok := status.FromError(err)
+		require.True(t, ok, "#%d: %v", i, err)
+		assert.Equal(t, st.Code(), tc.code, "#%d", i)
+		assert.Equal(t, st.Message(), tc.msg, "#%d", i)
+	}
+}
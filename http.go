@@ -2,16 +2,24 @@ package cli
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
+	"mime"
 	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/labstack/gommon/color"
-	"github.com/mkideal/pkg/debug"
 )
 
+// MaxJSONBodySize is maximum size of JSON bodies of requests served by ServeHTTP
+var MaxJSONBodySize int64 = 1 << 20
+
 // RegisterHTTP init HTTPRouters for command
 func (cmd *Command) RegisterHTTP(ctxs ...*Context) error {
 	clr := color.Color{}
@@ -19,29 +27,94 @@ func (cmd *Command) RegisterHTTP(ctxs ...*Context) error {
 	if len(ctxs) > 0 {
 		clr = ctxs[0].color
 	}
-	if cmd.routersMap == nil {
-		cmd.routersMap = make(map[string]string)
+	// routers are registered to a copy, concurrent requests keep using the old one
+	routersMap := make(map[string]string)
+	for r, path := range cmd.getRoutersMap() {
+		routersMap[r] = path
+	}
+	if err := cmd.addRouters(routersMap, clr); err != nil {
+		return err
 	}
+	cmd.routersLocker.Lock()
+	cmd.routersMap = routersMap
+	cmd.routersLocker.Unlock()
+	return nil
+}
+
+// addRouters adds HTTPRouters of cmd and its descendants to routersMap
+func (cmd *Command) addRouters(routersMap map[string]string, clr color.Color) error {
 	commands := []*Command{cmd}
 	for len(commands) > 0 {
 		c := commands[0]
 		commands = commands[1:]
 		if c.HTTPRouters != nil {
 			for _, r := range c.HTTPRouters {
-				if _, exists := c.routersMap[r]; exists {
+				if _, exists := routersMap[r]; exists {
 					return throwRouterRepeat(clr.Yellow(r))
 				}
-				cmd.routersMap[r] = c.Path()
+				routersMap[r] = c.pathWithSep("/")
 			}
 		}
 		if c.nochild() {
 			continue
 		}
-		commands = append(commands, c.children...)
+		commands = append(commands, c.getChildren()...)
 	}
 	return nil
 }
 
+// routersUpdate holds new routers of commands which registered HTTP routers
+type routersUpdate map[*Command]map[string]string
+
+// apply replaces routers of commands
+func (update routersUpdate) apply() {
+	for cmd, routersMap := range update {
+		cmd.routersLocker.Lock()
+		cmd.routersMap = routersMap
+		cmd.routersLocker.Unlock()
+	}
+}
+
+// replacedRouters returns routers registered by RegisterHTTP of cmd and its ancestors,
+// in which routers of old and its descendants are replaced by routers of child and
+// its descendants, old or child could be nil
+func (cmd *Command) replacedRouters(old, child *Command) (routersUpdate, error) {
+	clr := color.Color{}
+	clr.Disable()
+	update := routersUpdate{}
+	for next := cmd; next != nil; next = next.parent {
+		routersMap := next.getRoutersMap()
+		if routersMap == nil {
+			continue
+		}
+		newRoutersMap := make(map[string]string, len(routersMap))
+		for r, path := range routersMap {
+			if old == nil || !isSubPath(path, old.pathWithSep("/")) {
+				newRoutersMap[r] = path
+			}
+		}
+		if child != nil {
+			if err := child.addRouters(newRoutersMap, clr); err != nil {
+				return nil, err
+			}
+		}
+		update[next] = newRoutersMap
+	}
+	return update, nil
+}
+
+// isSubPath reports whether path is parent or a descendant of parent
+func isSubPath(path, parent string) bool {
+	return path == parent || strings.HasPrefix(path, parent+"/")
+}
+
+// getRoutersMap returns routers registered by RegisterHTTP, the returned map must not be modified
+func (cmd *Command) getRoutersMap() map[string]string {
+	cmd.routersLocker.RLock()
+	defer cmd.routersLocker.RUnlock()
+	return cmd.routersMap
+}
+
 // ServeHTTP implements HTTP handler
 func (cmd *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
@@ -52,8 +125,8 @@ func (cmd *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		path  = r.URL.Path
 		found = false
 	)
-	if cmd.routersMap != nil {
-		path, found = cmd.routersMap[path]
+	if routersMap := cmd.getRoutersMap(); routersMap != nil {
+		path, found = routersMap[path]
 	}
 	if !found {
 		path = strings.TrimPrefix(r.URL.Path, "/")
@@ -78,48 +151,326 @@ func (cmd *Command) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 		args = append(args, key, values[len(values)-1])
 	}
-	debug.Debugf("agent: %s", r.UserAgent())
-	debug.Debugf("path: %s", path)
-	debug.Debugf("args: %q", args)
+	var body io.Reader = r.Body
+	if isJSONRequest(r) {
+		data, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxJSONBodySize))
+		if err != nil {
+			// MaxBytesReader fails after the whole limit is read
+			status := http.StatusBadRequest
+			if int64(len(data)) >= MaxJSONBodySize {
+				status = http.StatusRequestEntityTooLarge
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		var ignored map[string]bool
+		if target := cmd.Route(router); target != nil {
+			ignored = target.jsonIgnoredFlags()
+		}
+		jsonArgs, err := jsonBodyArgs(data, ignored)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		args = append(args, jsonArgs...)
+		body = bytes.NewReader(data)
+	}
+	debugf("agent: %s", r.UserAgent())
+	debugf("path: %s", path)
+	debugf("args: %q", args)
+
+	out := &responseWriter{ResponseWriter: w}
+	setup := func(ctx *Context) {
+		ctx.reader = body
+		ctx.HTTPRequest = r
+		ctx.goctx = r.Context()
+	}
+	if acceptsEventStream(r) {
+		sse := newSSEWriter(out)
+		sse.close(cmd.runRemote(args, sse, out, setup, r.Method))
+		return
+	}
+	if err := cmd.runRemote(args, out, out, setup, r.Method); err != nil {
+		// status is committed if command has written output or status
+		out.WriteHeader(httpStatusCode(err))
+		io.WriteString(w, err.Error())
+	}
+}
+
+// acceptsEventStream reports whether client asks for Server-Sent Events, e.g. EventSource of browsers
+func acceptsEventStream(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accept); err == nil && mediaType == "text/event-stream" {
+			return true
+		}
+	}
+	return false
+}
+
+// sseWriter streams output of command as Server-Sent Events, each line of
+// output is sent as a message event. An `error` event with error message or a `done`
+// event is sent at last, so clients can close the stream instead of reconnecting.
+type sseWriter struct {
+	w *responseWriter
+
+	locker  sync.Mutex // protect following data and writing of events
+	buf     []byte     // incomplete line
+	started bool
+}
 
+// newSSEWriter sets headers of event stream before command runs, so that they are
+// sent even if status is written by Context.Status
+func newSSEWriter(w *responseWriter) *sseWriter {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	return &sseWriter{w: w}
+}
+
+func (w *sseWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	w.w.WriteHeader(http.StatusOK)
+}
+
+func (w *sseWriter) Write(data []byte) (int, error) {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	w.start()
+	w.buf = append(w.buf, data...)
+	for {
+		index := bytes.IndexByte(w.buf, '\n')
+		if index < 0 {
+			break
+		}
+		line := strings.TrimSuffix(string(w.buf[:index]), "\r")
+		w.buf = w.buf[index+1:]
+		if err := w.writeEvent("", line); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *sseWriter) writeEvent(event, data string) error {
 	buf := new(bytes.Buffer)
-	statusCode := http.StatusOK
-	if err := cmd.RunWith(args, buf, w, r.Method); err != nil {
-		buf.Write([]byte(err.Error()))
-		nativeError := err
-		if werr, ok := err.(wrapError); ok {
-			nativeError = werr.err
-		}
-		switch nativeError.(type) {
-		case commandNotFoundError:
-			statusCode = http.StatusNotFound
+	if event != "" {
+		fmt.Fprintf(buf, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(data, "\n") {
+		fmt.Fprintf(buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+	_, err := w.w.Write(buf.Bytes())
+	return err
+}
 
-		case methodNotAllowedError:
-			statusCode = http.StatusMethodNotAllowed
+func (w *sseWriter) close(err error) {
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	w.start()
+	if len(w.buf) > 0 {
+		w.writeEvent("", string(w.buf))
+		w.buf = nil
+	}
+	if err != nil {
+		w.writeEvent("error", err.Error())
+		return
+	}
+	w.writeEvent("done", "")
+}
+
+// jsonIgnoredFlags returns long names of flags of cmd which are ignored by JSON,
+// e.g. flags of builtin helpers tagged with `json:"-"`
+func (cmd *Command) jsonIgnoredFlags() map[string]bool {
+	ignored := map[string]bool{}
+	for _, fl := range cmd.flagSlice() {
+		if fl.field.Tag.Get("json") != "-" {
+			continue
+		}
+		for _, name := range fl.tag.longNames {
+			ignored[strings.TrimPrefix(name, dashTwo)] = true
+		}
+	}
+	return ignored
+}
 
+// isJSONRequest reports whether body of request is JSON
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "application/json"
+}
+
+// jsonBodyArgs converts fields of a JSON object to flags, so they are bound
+// to argv and validated as same as command line, e.g.
+//
+//	{"name":"Jack","tags":["a","b"],"labels":{"k":"v"}}
+//
+// is converted to
+//
+//	--name=Jack --tags=a --tags=b --labels=k=v
+//
+// Fields are converted to long flags only, an error is returned for fields named by
+// short flags, dashes or names in ignored, e.g. `h` and `help`.
+func jsonBodyArgs(data []byte, ignored map[string]bool) ([]string, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	fields := map[string]interface{}{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("decode json body: %v", err)
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, 0, len(fields))
+	for _, key := range keys {
+		if len(key) <= 1 || strings.HasPrefix(key, dashOne) || ignored[key] {
+			return nil, fmt.Errorf("field %s is not allowed in json body", key)
+		}
+		name := dashTwo + key
+		switch value := fields[key].(type) {
+		case nil:
+		case []interface{}:
+			for _, elem := range value {
+				s, err := jsonArgValue(elem)
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %v", key, err)
+				}
+				args = append(args, name+"="+s)
+			}
+		case map[string]interface{}:
+			subkeys := make([]string, 0, len(value))
+			for k := range value {
+				subkeys = append(subkeys, k)
+			}
+			sort.Strings(subkeys)
+			for _, k := range subkeys {
+				s, err := jsonArgValue(value[k])
+				if err != nil {
+					return nil, fmt.Errorf("field %s: %v", key, err)
+				}
+				args = append(args, name+"="+k+"="+s)
+			}
 		default:
-			statusCode = http.StatusInternalServerError
+			s, err := jsonArgValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %v", key, err)
+			}
+			args = append(args, name+"="+s)
+		}
+	}
+	return args, nil
+}
+
+func jsonArgValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case json.Number:
+		return v.String(), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	// nested values are passed as JSON encoded string
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+// httpStatusCode returns status code of response for error returned by command,
+// errors occurred before running command(e.g. invalid parameters) are bad requests
+func httpStatusCode(err error) int {
+	if werr, ok := err.(wrapError); ok {
+		switch werr.err.(type) {
+		case commandNotFoundError:
+			return http.StatusNotFound
+		case methodNotAllowedError:
+			return http.StatusMethodNotAllowed
 		}
+		return http.StatusBadRequest
+	}
+	if IsTimeout(err) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// responseWriter streams output of command to response, output is flushed on each write
+type responseWriter struct {
+	http.ResponseWriter
+	wrote bool // header written
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wrote {
+		return
+	}
+	w.wrote = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(data []byte) (int, error) {
+	w.wrote = true
+	n, err := w.ResponseWriter.Write(data)
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return n, err
+}
+
+// Status sets status code of HTTP response, it takes effect only if called before
+// any output written. It does nothing if command is not served over HTTP.
+func (ctx *Context) Status(code int) {
+	if ctx.HTTPResponse != nil {
+		ctx.HTTPResponse.WriteHeader(code)
+	}
+}
+
+// Header returns header of HTTP response, it should be modified before any output
+// written or Status called. A detached header is returned if command is not served
+// over HTTP, so handlers can set headers unconditionally.
+func (ctx *Context) Header() http.Header {
+	if ctx.HTTPResponse == nil {
+		return http.Header{}
+	}
+	return ctx.HTTPResponse.Header()
+}
+
+// ListenAndServe registers HTTPRouters of command tree, sets IsServer flag with true
+// and serves the command tree over HTTP on addr. URL paths are mapped to routed
+// commands, e.g. `/user/get` runs `user get`, and query or form values are bound to
+// flags. Output of command is streamed as response, or as Server-Sent Events
+// if client accepts text/event-stream.
+func (cmd *Command) ListenAndServe(addr string) error {
+	if err := cmd.RegisterHTTP(); err != nil {
+		return err
 	}
-	debug.Debugf("resp: %s", buf.String())
-	w.WriteHeader(statusCode)
-	w.Write(buf.Bytes())
+	return cmd.ListenAndServeHTTP(addr)
 }
 
-// ListenAndServeHTTP set IsServer flag with true and startup http service
+// ListenAndServeHTTP set IsServer flag with true and startup http service,
+// requests are handled by HTTPHandler
 func (cmd *Command) ListenAndServeHTTP(addr string) error {
 	cmd.SetIsServer(true)
-	return http.ListenAndServe(addr, cmd)
+	return http.ListenAndServe(addr, cmd.HTTPHandler())
 }
 
-// Serve set IsServer with true and serve http with listeners
+// Serve set IsServer with true and serve http with listeners,
+// requests are handled by HTTPHandler
 func (cmd *Command) Serve(listeners ...net.Listener) (err error) {
 	cmd.SetIsServer(true)
 	var g sync.WaitGroup
 	for _, ln := range listeners {
 		g.Add(1)
 		go func(ln net.Listener) {
-			if e := http.Serve(ln, cmd); e != nil {
+			if e := http.Serve(ln, cmd.HTTPHandler()); e != nil {
 				panic(e.Error())
 			}
 			g.Done()
@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeHTTP(t *testing.T) {
+	type argT struct {
+		Name string `cli:"name"`
+	}
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{Name: "user"})
+	user.Register(&Command{
+		Name:        "get",
+		HTTPRouters: []string{"/v1/user"},
+		HTTPMethods: []string{"GET"},
+		Argv:        func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.String("user %s\n", ctx.Argv().(*argT).Name)
+			return nil
+		},
+	})
+	user.Register(&Command{
+		Name: "delete",
+		Fn: func(ctx *Context) error {
+			ctx.String("deleting\n")
+			return errors.New("permission denied")
+		},
+	})
+	user.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			return errors.New("oops")
+		},
+	})
+	user.Register(&Command{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Fn: func(ctx *Context) error {
+			<-ctx.Context().Done()
+			return ctx.Context().Err()
+		},
+	})
+	assert.Nil(t, root.RegisterHTTP())
+
+	for _, tc := range []struct {
+		method, url string
+		code        int
+		body        string
+	}{
+		{"GET", "/user/get?name=Jack", 200, "user Jack\n"},
+		{"GET", "/v1/user?name=Tom", 200, "user Tom\n"},
+		{"POST", "/v1/user", 405, "ERR! method POST not allowed"},
+		{"GET", "/user/undefined", 404, "ERR! command user undefined not found"},
+		{"GET", "/user/get?unknown=x", 400, "undefined option --unknown"},
+		{"GET", "/user/fail", 500, "oops"},
+		{"GET", "/user/slow", 504, "command timed out"},
+		// status is committed while output streamed
+		{"GET", "/user/delete", 200, "deleting\npermission denied"},
+	} {
+		resp := httptest.NewRecorder()
+		root.ServeHTTP(resp, httptest.NewRequest(tc.method, tc.url, nil))
+		assert.Equal(t, resp.Code, tc.code)
+		assert.Contains(t, resp.Body.String(), tc.body)
+		if tc.code == 200 {
+			assert.True(t, resp.Flushed)
+		}
+	}
+}
+
+func TestServeHTTPJSONBody(t *testing.T) {
+	type argT struct {
+		Name   string            `cli:"*name"`
+		Age    int               `cli:"age"`
+		Admin  bool              `cli:"admin"`
+		Tags   []string          `cli:"tags"`
+		Labels map[string]string `cli:"labels"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "user",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			argv := ctx.Argv().(*argT)
+			ctx.String("%s %d %v %v %s", argv.Name, argv.Age, argv.Admin, argv.Tags, argv.Labels["k"])
+			return nil
+		},
+	})
+
+	for _, tc := range []struct {
+		url, contentType, body string
+		code                   int
+		want                   string
+	}{
+		{"/user", "application/json", `{"name":"Jack","age":18,"admin":true,"tags":["a","b"],"labels":{"k":"v"}}`, 200, "Jack 18 true [a b] v"},
+		{"/user?age=20", "application/json; charset=utf-8", `{"name":"Tom"}`, 200, "Tom 20 false [] "},
+		{"/user", "application/json", `{"name":"Tom","age":"x"}`, 400, "invalid"},
+		{"/user", "application/json", `{"age":18}`, 400, "required"},
+		{"/user", "application/json", `{"name":`, 400, "decode json body"},
+	} {
+		req := httptest.NewRequest("POST", tc.url, strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", tc.contentType)
+		resp := httptest.NewRecorder()
+		root.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, tc.code)
+		assert.Contains(t, resp.Body.String(), tc.want)
+	}
+
+	defer func(size int64) { MaxJSONBodySize = size }(MaxJSONBodySize)
+	MaxJSONBodySize = 16
+	for _, tc := range []struct {
+		body string
+		code int
+	}{
+		{`{"name":"Jack"}`, 200},
+		{`{"name":"Jack1"}`, 200},
+		{`{"name":"Jack12"}`, 413},
+	} {
+		req := httptest.NewRequest("POST", "/user", strings.NewReader(tc.body))
+		req.Header.Set("Content-Type", "application/json")
+		resp := httptest.NewRecorder()
+		root.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, tc.code, tc.body)
+	}
+}
+
+func TestJSONBodyArgs(t *testing.T) {
+	args, err := jsonBodyArgs([]byte(`{"size":1.5,"na":null,"verbose":true,"opts":{"x":[1]},"dry":false}`), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, args, []string{"--dry=false", "--opts=x=[1]", "--size=1.5", "--verbose=true"})
+
+	args, err = jsonBodyArgs([]byte("  "), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, len(args), 0)
+
+	_, err = jsonBodyArgs([]byte(`[1]`), nil)
+	assert.NotNil(t, err)
+
+	// short flags, dashes and ignored flags are not allowed
+	for _, body := range []string{`{"h":true}`, `{"--dry":true}`, `{"help":true}`} {
+		_, err = jsonBodyArgs([]byte(body), map[string]bool{"help": true})
+		assert.NotNil(t, err, body)
+	}
+
+	type argT struct {
+		Helper
+		Name string `cli:"name"`
+	}
+	cmd := &Command{Argv: func() interface{} { return new(argT) }}
+	assert.Equal(t, cmd.jsonIgnoredFlags(), map[string]bool{"help": true})
+}
+
+func TestContextStatusAndHeader(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "create",
+		Fn: func(ctx *Context) error {
+			ctx.Header().Set("Location", "/items/1")
+			ctx.Status(201)
+			ctx.Status(500) // ignored after status written
+			ctx.String("created")
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "conflict",
+		Fn: func(ctx *Context) error {
+			ctx.Status(409)
+			return errors.New("item exists")
+		},
+	})
+
+	resp := httptest.NewRecorder()
+	root.ServeHTTP(resp, httptest.NewRequest("POST", "/create", nil))
+	assert.Equal(t, resp.Code, 201)
+	assert.Equal(t, resp.Header().Get("Location"), "/items/1")
+	assert.Equal(t, resp.Body.String(), "created")
+
+	resp = httptest.NewRecorder()
+	root.ServeHTTP(resp, httptest.NewRequest("POST", "/conflict", nil))
+	assert.Equal(t, resp.Code, 409)
+	assert.Equal(t, resp.Body.String(), "item exists")
+
+	// no effect out of HTTP mode
+	root.Register(&Command{
+		Name: "local",
+		Fn: func(ctx *Context) error {
+			ctx.Status(201)
+			ctx.Header().Set("X-Test", "1")
+			assert.Nil(t, ctx.HTTPRequest)
+			return nil
+		},
+	})
+	assert.Nil(t, root.RunWith([]string{"local"}, ioutil.Discard, nil))
+}
+
+func TestServeHTTPEventStream(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "deploy",
+		Fn: func(ctx *Context) error {
+			ctx.String("step 1\nstep")
+			ctx.String(" 2\r\nstep 3")
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			return errors.New("oops\nbad")
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/deploy", nil)
+	req.Header.Set("Accept", "text/html, text/event-stream")
+	resp := httptest.NewRecorder()
+	root.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, 200)
+	assert.Equal(t, resp.Header().Get("Content-Type"), "text/event-stream")
+	assert.Equal(t, resp.Body.String(), "data: step 1\n\ndata: step 2\n\ndata: step 3\n\nevent: done\ndata: \n\n")
+	assert.True(t, resp.Flushed)
+
+	req = httptest.NewRequest("GET", "/fail", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp = httptest.NewRecorder()
+	root.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, 200)
+	assert.Equal(t, resp.Body.String(), "event: error\ndata: oops\ndata: bad\n\n")
+
+	// headers of event stream are sent with status set by command, and
+	// output written concurrently is sent line by line
+	root.Register(&Command{
+		Name: "async",
+		Fn: func(ctx *Context) error {
+			ctx.Status(http.StatusAccepted)
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ctx.String("line\n")
+				}()
+			}
+			wg.Wait()
+			return nil
+		},
+	})
+	req = httptest.NewRequest("GET", "/async", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	resp = httptest.NewRecorder()
+	root.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, http.StatusAccepted)
+	assert.Equal(t, resp.Header().Get("Content-Type"), "text/event-stream")
+	assert.Equal(t, resp.Body.String(), strings.Repeat("data: line\n\n", 10)+"event: done\ndata: \n\n")
+}
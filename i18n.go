@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// Framework messages which can be translated by RegisterMessages, messages are
+// format strings of package fmt and translations must keep their verbs
+const (
+	MsgOptions               = "Options"
+	MsgCommands              = "Commands"
+	MsgExamples              = "Examples"
+	MsgPlugins               = "Plugins"
+	MsgDidYouMean            = "Did you mean %s?"
+	MsgDidYouMeanOneOf       = "Did you mean one of these?"
+	MsgCommandNotFound       = "command %s not found"
+	MsgAmbiguousCommand      = "command %s is ambiguous, candidates: %s"
+	MsgMethodNotAllowed      = "method %s not allowed"
+	MsgCommandNotSupported   = "command %s not supported on %s"
+	MsgFlagNotSupported      = "not supported on %s"
+	MsgCommandDeprecated     = "command %s is deprecated, %s"
+	MsgFlagDeprecated        = "flag %s is deprecated, use %s instead"
+	MsgUndefinedOption       = "undefined option %s"
+	MsgOptionDidYouMean      = "%s, did you mean %s?"
+	MsgOptionDidYouMeanOneOf = "%s, did you mean one of %s?"
+	MsgRequiredMissing       = "required parameter %s missing"
+	MsgParameterInvalid      = "parameter %s invalid: %v"
+	MsgTooManyArguments      = "too many(%d) arguments"
+)
+
+var (
+	messagesLocker sync.RWMutex // protect following data
+	messages       = map[string]map[string]string{}
+	language       = ""
+)
+
+// RegisterMessages registers translations of messages for language tag, e.g.
+//
+//	cli.RegisterMessages("zh-CN", map[string]string{
+//		cli.MsgOptions:         "选项",
+//		cli.MsgCommandNotFound: "命令 %s 不存在",
+//	})
+//
+// Messages of same tag are merged, untranslated messages are looked up in
+// base language(e.g. zh for zh-CN) and fall back to English.
+func RegisterMessages(tag string, translations map[string]string) {
+	tag = normalizeLanguage(tag)
+	messagesLocker.Lock()
+	defer messagesLocker.Unlock()
+	catalog, ok := messages[tag]
+	if !ok {
+		catalog = make(map[string]string, len(translations))
+		messages[tag] = catalog
+	}
+	for msg, translation := range translations {
+		catalog[msg] = translation
+	}
+}
+
+// SetLanguage sets language of framework messages and Descs of commands by tag,
+// e.g. zh-CN and pt_BR, empty tag restores English. See LanguageFromEnv.
+func SetLanguage(tag string) {
+	messagesLocker.Lock()
+	language = normalizeLanguage(tag)
+	messagesLocker.Unlock()
+}
+
+// GetLanguage returns normalized language tag set by SetLanguage, e.g. zh-cn
+func GetLanguage() string {
+	messagesLocker.RLock()
+	defer messagesLocker.RUnlock()
+	return language
+}
+
+// LanguageFromEnv returns language tag of environment variables LC_ALL,
+// LC_MESSAGES or LANG, e.g. zh_CN for zh_CN.UTF-8. Empty returned for C and POSIX.
+func LanguageFromEnv() string {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := os.Getenv(key)
+		if value == "" {
+			continue
+		}
+		if i := strings.IndexAny(value, ".@"); i >= 0 {
+			value = value[:i]
+		}
+		if value == "C" || value == "POSIX" {
+			return ""
+		}
+		return value
+	}
+	return ""
+}
+
+// Translate returns translation of msg in current language, msg returned if untranslated
+func Translate(msg string) string {
+	messagesLocker.RLock()
+	defer messagesLocker.RUnlock()
+	for _, tag := range languageChain(language) {
+		if translation, ok := messages[tag][msg]; ok {
+			return translation
+		}
+	}
+	return msg
+}
+
+// tr is short for Translate
+func tr(msg string) string {
+	return Translate(msg)
+}
+
+// localDesc returns Desc of command in current language
+func (cmd *Command) localDesc() string {
+	if len(cmd.Descs) == 0 {
+		return cmd.Desc
+	}
+	for _, tag := range languageChain(GetLanguage()) {
+		for t, desc := range cmd.Descs {
+			if normalizeLanguage(t) == tag {
+				return desc
+			}
+		}
+	}
+	return cmd.Desc
+}
+
+// normalizeLanguage lowers tag and replaces underscores with dashes, e.g. zh_CN to zh-cn
+func normalizeLanguage(tag string) string {
+	return strings.ToLower(strings.Replace(tag, "_", "-", -1))
+}
+
+// languageChain returns tag and its bases, e.g. zh-hant-tw, zh-hant and zh
+func languageChain(tag string) []string {
+	chain := []string{}
+	for tag != "" {
+		chain = append(chain, tag)
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return chain
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestI18n(t *testing.T) {
+	RegisterMessages("zh", map[string]string{
+		MsgOptions:           "选项",
+		MsgCommands:          "命令",
+		MsgCommandNotFound:   "命令 %s 不存在",
+		MsgCommandDeprecated: "命令 %s 已弃用，%s",
+	})
+	RegisterMessages("zh_CN", map[string]string{
+		MsgRequiredMissing: "缺少必需参数 %s",
+	})
+	defer SetLanguage("")
+
+	type argT struct {
+		Name string `cli:"*name" usage:"name"`
+	}
+	clr := color.Color{}
+	clr.Disable()
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{
+		Name:  "user",
+		Desc:  "manage users",
+		Descs: map[string]string{"zh": "管理用户"},
+		Argv:  func() interface{} { return new(argT) },
+		Fn:    donothing,
+	})
+	user.Register(&Command{Name: "list", Desc: "list users", Fn: donothing})
+	root.Register(HelpCommand("display help"))
+	root.Register(&Command{Name: "old", Deprecated: "使用 user", Fn: donothing})
+
+	assert.Equal(t, GetLanguage(), "")
+	assert.True(t, strings.HasPrefix(user.Usage(&Context{color: clr}), "manage users\n\nOptions:"))
+
+	SetLanguage("zh_CN")
+	assert.Equal(t, GetLanguage(), "zh-cn")
+	assert.Equal(t, Translate(MsgRequiredMissing), "缺少必需参数 %s")
+	// messages of base language and English are used if untranslated
+	assert.Equal(t, Translate(MsgOptions), "选项")
+	assert.Equal(t, Translate(MsgExamples), "Examples")
+
+	usage := user.Usage(&Context{color: clr})
+	assert.True(t, strings.HasPrefix(usage, "管理用户\n\n选项:"), usage)
+	assert.Contains(t, usage, "命令:\n\n  list   list users\n")
+	assert.Contains(t, root.Usage(&Context{color: clr}), "user   管理用户")
+
+	err := root.RunWith([]string{"user"}, new(strings.Builder), nil)
+	assert.Contains(t, err.Error(), "缺少必需参数 --name")
+	err = root.RunWith([]string{"undefined"}, new(strings.Builder), nil)
+	assert.Contains(t, err.Error(), "命令 undefined 不存在")
+	err = root.RunWith([]string{"help", "undefined"}, new(strings.Builder), nil)
+	assert.Contains(t, err.Error(), "命令 undefined 不存在")
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	warnings := new(strings.Builder)
+	WarningOutput = warnings
+	assert.Nil(t, root.RunWith([]string{"old"}, new(strings.Builder), nil))
+	assert.Equal(t, warnings.String(), "WARN! 命令 old 已弃用，使用 user\n")
+}
+
+func TestLanguageFromEnv(t *testing.T) {
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Unsetenv(key)
+	}
+	assert.Equal(t, LanguageFromEnv(), "")
+	os.Setenv("LANG", "zh_CN.UTF-8")
+	assert.Equal(t, LanguageFromEnv(), "zh_CN")
+	os.Setenv("LC_ALL", "C")
+	assert.Equal(t, LanguageFromEnv(), "")
+	os.Setenv("LC_ALL", "pt_BR@euro")
+	assert.Equal(t, LanguageFromEnv(), "pt_BR")
+}
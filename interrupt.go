@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// InterruptedExitCode is exit code of interrupted command, as same as shells use for SIGINT
+const InterruptedExitCode = 130
+
+// interruptedError is returned while command failed after Context.Context cancelled
+type interruptedError struct {
+	err       error
+	elapsed   time.Duration
+	completed int
+	pending   int
+}
+
+func (e interruptedError) Error() string {
+	return fmt.Sprintf("interrupted after %s, %d operations completed, %d pending",
+		formatElapsed(e.elapsed), e.completed, e.pending)
+}
+
+// Unwrap returns cause of interruption, e.g. context.Canceled
+func (e interruptedError) Unwrap() error { return e.err }
+
+// IsInterrupted reports whether err is returned by a cancelled command
+func IsInterrupted(err error) bool {
+	var e interruptedError
+	return errors.As(err, &e)
+}
+
+// ExitCode returns exit code of process for err returned by command:
+// 0 for nil or ExitError, InterruptedExitCode for interrupted command, TimeoutExitCode
+// for timed out command and 1 for others
+//
+//	os.Exit(cli.ExitCode(root.Run(os.Args[1:])))
+func ExitCode(err error) int {
+	switch {
+	case err == nil || err == ExitError:
+		return 0
+	case IsInterrupted(err):
+		return InterruptedExitCode
+	case IsTimeout(err):
+		return TimeoutExitCode
+	}
+	return 1
+}
+
+// notifyInterrupt cancels Context.Context on the first interrupt signal if
+// CancelOnInterrupt is set, the returned function stops notifying.
+// Context provided by caller, e.g. Engine.Execute, is not replaced.
+func (cmd *Command) notifyInterrupt(ctx *Context) func() {
+	if !cmd.CancelOnInterrupt || ctx.goctx != nil || cmd.IsServer() {
+		return func() {}
+	}
+	goctx, cancel := context.WithCancel(context.Background())
+	ctx.goctx = goctx
+	var (
+		interrupt = make(chan os.Signal, 1)
+		done      = make(chan struct{})
+	)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			// restore default behavior, so the next interrupt kills the process
+			signal.Stop(interrupt)
+			cancel()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(interrupt)
+		close(done)
+		cancel()
+	}
+}
+
+// runAfterHooks runs hooks of a cancelled command, errors are logged only
+func (ctx *Context) runAfterHooks(hooks []func(*Context) error) {
+	for _, hook := range hooks {
+		if hook == nil {
+			continue
+		}
+		if err := hook(ctx); err != nil && err != ExitError {
+			debugf("after hook of cancelled command: %v", err)
+		}
+	}
+}
+
+// interrupted converts err to interruptedError if command failed after cancelled,
+// or timeoutError if timeout of command exceeded
+func (ctx *Context) interrupted(err error) error {
+	if err == nil || err == ExitError || ctx.Context().Err() == nil {
+		return err
+	}
+	if ctx.timedOut() {
+		return timeoutError{err: err, timeout: ctx.timeout}
+	}
+	r := ctx.Summary().Result()
+	return interruptedError{
+		err:       err,
+		elapsed:   time.Since(ctx.startedAt),
+		completed: r.Completed(),
+		pending:   r.Pending(),
+	}
+}
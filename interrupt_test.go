@@ -0,0 +1,83 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInterrupted(t *testing.T) {
+	var hooks []string
+	root := &Command{
+		Name: "app",
+		OnRootAfter: func(ctx *Context) error {
+			hooks = append(hooks, "root after")
+			return nil
+		},
+	}
+	root.Register(&Command{
+		Name: "import",
+		Fn: func(ctx *Context) error {
+			ctx.Summary().Total(5).Created(2).Failed(1)
+			<-ctx.Context().Done()
+			return ctx.Context().Err()
+		},
+		OnAfter: func(ctx *Context) error {
+			hooks = append(hooks, "after")
+			return errors.New("ignored")
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			return errors.New("oops")
+		},
+		OnAfter: func(ctx *Context) error {
+			hooks = append(hooks, "after")
+			return nil
+		},
+	})
+
+	goctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	w := new(bytes.Buffer)
+	err := NewEngine(root).Execute(goctx, Execution{Args: []string{"import"}, Stdout: w})
+	assert.True(t, IsInterrupted(err))
+	assert.Contains(t, err.Error(), "interrupted after ")
+	assert.Contains(t, err.Error(), ", 3 operations completed, 2 pending")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, ExitCode(err), InterruptedExitCode)
+	assert.True(t, IsInterrupted(fmt.Errorf("wrapped: %w", err)))
+	assert.Equal(t, hooks, []string{"root after", "after"})
+	// summary line is replaced by interruption
+	assert.Equal(t, w.String(), "")
+
+	// After hooks are not run for failures of uncancelled command
+	hooks = nil
+	err = NewEngine(root).Execute(context.Background(), Execution{Args: []string{"fail"}})
+	assert.False(t, IsInterrupted(err))
+	assert.Equal(t, ExitCode(err), 1)
+	assert.Equal(t, len(hooks), 0)
+
+	assert.Equal(t, ExitCode(nil), 0)
+	assert.Equal(t, ExitCode(ExitError), 0)
+}
+
+func TestCancelOnInterrupt(t *testing.T) {
+	root := &Command{
+		Name:              "app",
+		CancelOnInterrupt: true,
+		Fn: func(ctx *Context) error {
+			if ctx.Context().Done() == nil {
+				return errors.New("context is not cancelable")
+			}
+			return nil
+		},
+	}
+	assert.Nil(t, root.RunWith(nil, new(bytes.Buffer), nil))
+}
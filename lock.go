@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// FileLockTimeout is max duration for waiting a file lock
+var FileLockTimeout = 10 * time.Second
+
+const fileLockRetryInterval = 20 * time.Millisecond
+
+// LockFile takes an advisory lock of filename by lock file `filename.lock`,
+// it waits at most FileLockTimeout. Call returned unlock function to release the lock.
+func LockFile(filename string) (unlock func() error, err error) {
+	lockname := filename + ".lock"
+	deadline := time.Now().Add(FileLockTimeout)
+	for {
+		unlock, locked, err := tryLockFile(lockname)
+		if err != nil || locked {
+			return unlock, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("file %s is locked by another process", filename)
+		}
+		time.Sleep(fileLockRetryInterval)
+	}
+}
+
+// FileVersion identifies content of a file, it's used to detect concurrent modification
+type FileVersion struct {
+	Exists  bool
+	ModTime time.Time
+	Size    int64
+	Sum     [sha256.Size]byte
+}
+
+// StatFileVersion returns version of filename, a missing file has a zero version
+func StatFileVersion(filename string) (FileVersion, error) {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return FileVersion{}, nil
+	}
+	if err != nil {
+		return FileVersion{}, err
+	}
+	return fileVersion(filename, data)
+}
+
+func fileVersion(filename string, data []byte) (FileVersion, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return FileVersion{}, err
+	}
+	return FileVersion{
+		Exists:  true,
+		ModTime: info.ModTime(),
+		Size:    info.Size(),
+		Sum:     sha256.Sum256(data),
+	}, nil
+}
+
+// Equal reports whether two versions are identical
+func (v FileVersion) Equal(other FileVersion) bool {
+	return v.Exists == other.Exists &&
+		v.ModTime.Equal(other.ModTime) &&
+		v.Size == other.Size &&
+		bytes.Equal(v.Sum[:], other.Sum[:])
+}
+
+// WriteFileIfUnchanged writes filename atomically while holding its lock if version
+// of filename still equals to version, otherwise an error reported by IsConcurrentEdit returned
+func WriteFileIfUnchanged(filename string, data []byte, perm os.FileMode, version FileVersion) error {
+	unlock, err := LockFile(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := checkFileVersion(filename, version); err != nil {
+		return err
+	}
+	return WriteFileAtomic(filename, data, perm)
+}
+
+func checkFileVersion(filename string, version FileVersion) error {
+	current, err := StatFileVersion(filename)
+	if err != nil {
+		return err
+	}
+	if !current.Equal(version) {
+		return throwConcurrentEdit(filename)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package cli
+
+import (
+	"os"
+)
+
+// tryLockFile creates lock file exclusively, the lock file is removed while unlocked
+func tryLockFile(lockname string) (unlock func() error, locked bool, err error) {
+	file, err := os.OpenFile(lockname, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if os.IsExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return func() error {
+		file.Close()
+		return os.Remove(lockname)
+	}, true, nil
+}
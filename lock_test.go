@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-lock")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "counter")
+	require.Nil(t, ioutil.WriteFile(filename, nil, 0644))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, EditFileAtomic(filename, "", func(data []byte) ([]byte, error) {
+				return append(data, 'x'), nil
+			}))
+		}()
+	}
+	wg.Wait()
+	data, err := ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "xxxxxxxxxx")
+
+	defer func(timeout time.Duration) { FileLockTimeout = timeout }(FileLockTimeout)
+	FileLockTimeout = 50 * time.Millisecond
+	unlock, err := LockFile(filename)
+	require.Nil(t, err)
+	_, err = LockFile(filename)
+	assert.Error(t, err)
+	assert.Nil(t, unlock())
+	unlock, err = LockFile(filename)
+	require.Nil(t, err)
+	unlock()
+}
+
+func TestConcurrentEdit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-lock")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.conf")
+
+	version, err := StatFileVersion(filename)
+	require.Nil(t, err)
+	assert.False(t, version.Exists)
+	require.Nil(t, WriteFileIfUnchanged(filename, []byte("v1"), 0644, version))
+
+	version, err = StatFileVersion(filename)
+	require.Nil(t, err)
+	// modified by another process
+	require.Nil(t, ioutil.WriteFile(filename, []byte("v2"), 0644))
+	err = WriteFileIfUnchanged(filename, []byte("v3"), 0644, version)
+	assert.True(t, IsConcurrentEdit(err))
+	assert.True(t, IsConcurrentEdit(fmt.Errorf("save: %w", err)))
+
+	err = EditFileAtomic(filename, "", func(data []byte) ([]byte, error) {
+		ioutil.WriteFile(filename, []byte("v4"), 0644)
+		return []byte("v5"), nil
+	})
+	assert.True(t, IsConcurrentEdit(err))
+	data, err := ioutil.ReadFile(filename)
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "v4")
+}
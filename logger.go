@@ -0,0 +1,55 @@
+//go:build go1.21
+// +build go1.21
+
+package cli
+
+import (
+	"io"
+	"log/slog"
+	"os"
+)
+
+type (
+	// LogLeveler represents interface for choosing level of Context.Logger
+	LogLeveler interface {
+		LogLevel() slog.Level
+	}
+
+	// LogHelper is builtin verbose and quiet flags, -v enables info logs,
+	// -vv enables debug logs and -q shows errors only. Warnings are shown by default.
+	LogHelper struct {
+		Verbose Counter `cli:"v,verbose" usage:"increase verbosity, repeat for more" json:"-"`
+		Quiet   bool    `cli:"q,quiet" usage:"show errors only" json:"-"`
+	}
+)
+
+// LogLevel implements LogLeveler interface
+func (h LogHelper) LogLevel() slog.Level {
+	switch {
+	case h.Quiet:
+		return slog.LevelError
+	case h.Verbose.Value() >= 2:
+		return slog.LevelDebug
+	case h.Verbose.Value() == 1:
+		return slog.LevelInfo
+	}
+	return slog.LevelWarn
+}
+
+// LogOutput is the stream to which logs of Context.Logger are written
+var LogOutput io.Writer = os.Stderr
+
+// Logger returns a leveled logger writing text records to LogOutput with attribute
+// command of current command path. Level is chosen by argv which implements
+// LogLeveler(e.g. LogHelper), options of root command work if they are Global.
+func (ctx *Context) Logger() *slog.Logger {
+	level := slog.LevelWarn
+	for _, argv := range ctx.allArgvs() {
+		if leveler, ok := argv.(LogLeveler); ok {
+			level = leveler.LogLevel()
+			break
+		}
+	}
+	handler := slog.NewTextHandler(ctx.stderr(LogOutput), &slog.HandlerOptions{Level: level})
+	return slog.New(handler).With("command", ctx.Path())
+}
@@ -0,0 +1,56 @@
+//go:build go1.21
+// +build go1.21
+
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	defer func(w io.Writer) { LogOutput = w }(LogOutput)
+	logs := new(bytes.Buffer)
+	LogOutput = logs
+
+	type rootT struct {
+		LogHelper
+	}
+	root := &Command{
+		Name:   "app",
+		Global: true,
+		Argv:   func() interface{} { return new(rootT) },
+	}
+	root.Register(&Command{
+		Name: "sync",
+		Fn: func(ctx *Context) error {
+			logger := ctx.Logger()
+			logger.Debug("debug")
+			logger.Info("info")
+			logger.Warn("warn")
+			logger.Error("error")
+			return nil
+		},
+	})
+	for _, tc := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"sync"}, []string{"warn", "error"}},
+		{[]string{"sync", "-v"}, []string{"info", "warn", "error"}},
+		{[]string{"sync", "-vv"}, []string{"debug", "info", "warn", "error"}},
+		{[]string{"sync", "-q"}, []string{"error"}},
+	} {
+		logs.Reset()
+		assert.Nil(t, root.RunWith(tc.args, io.Discard, nil))
+		lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+		assert.Equal(t, len(lines), len(tc.want))
+		for i, line := range lines {
+			assert.Contains(t, line, "msg="+tc.want[i]+" command=sync")
+		}
+	}
+}
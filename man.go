@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/labstack/gommon/color"
+)
+
+// GenManPages writes man pages(roff) of cmd and its descendants to dir, hidden
+// commands are skipped. Pages are named by command paths joined with `-` and
+// section, e.g. app.1, app-user.1 and app-user-add.1 for section "1".
+func (cmd *Command) GenManPages(dir, section string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return cmd.Walk(func(c *Command) error {
+		buf := new(bytes.Buffer)
+		if err := c.WriteManPage(buf, section); err != nil {
+			return err
+		}
+		filename := filepath.Join(dir, c.manName()+"."+section)
+		return WriteFileAtomic(filename, buf.Bytes(), 0644)
+	})
+}
+
+// WriteManPage writes man page(roff) of cmd in section to w, it's built from Desc,
+// Text, flags, visible children and examples of cmd
+func (cmd *Command) WriteManPage(w io.Writer, section string) error {
+	var (
+		buf  = new(bytes.Buffer)
+		name = cmd.manName()
+		root = cmd.manRootName()
+		clr  = color.Color{}
+	)
+	clr.Disable()
+	fmt.Fprintf(buf, ".TH %s %s \"\" %s \"\"\n", roffQuote(strings.ToUpper(name)), roffQuote(section), roffQuote(root))
+
+	buf.WriteString(".SH NAME\n")
+	buf.WriteString(roffEscape(name))
+	if desc := cmd.localDesc(); desc != "" {
+		buf.WriteString(` \- ` + roffEscape(desc))
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(buf, ".B %s\n", roffEscape(strings.TrimSpace(root+" "+cmd.Path())))
+	flags := visibleFlags(cmd.flagArgvList(), clr)
+	if len(flags) > 0 {
+		buf.WriteString("[\\fIOPTIONS\\fR]\n")
+	}
+	if !cmd.novisiblechild() {
+		buf.WriteString("\\fICOMMAND\\fR\n")
+	}
+
+	if text := strings.TrimSpace(cmd.Text); text != "" || cmd.Desc != "" {
+		if text == "" {
+			text = cmd.localDesc()
+		}
+		buf.WriteString(".SH DESCRIPTION\n")
+		buf.WriteString(roffText(text))
+	}
+
+	if len(flags) > 0 {
+		buf.WriteString(".SH OPTIONS\n")
+		for _, fl := range flags {
+			names := make([]string, 0, len(fl.tag.shortNames)+len(fl.tag.longNames))
+			for _, n := range append(append([]string{}, fl.tag.shortNames...), fl.tag.longNames...) {
+				names = append(names, `\fB`+roffEscape(n)+`\fR`)
+			}
+			buf.WriteString(".TP\n" + strings.Join(names, ", "))
+			if fl.tag.name != "" {
+				buf.WriteString(`=\fI` + roffEscape(fl.tag.name) + `\fR`)
+			}
+			buf.WriteString("\n")
+			usage := fl.tag.usage
+			if fl.tag.dft != "" {
+				usage += fmt.Sprintf(" (default: %s)", fl.tag.dft)
+			}
+			if fl.tag.env != "" {
+				usage += fmt.Sprintf(" (env: %s)", fl.tag.env)
+			}
+			if fl.tag.isRequired {
+				usage += " (required)"
+			}
+			buf.WriteString(roffText(strings.TrimSpace(usage)))
+		}
+	}
+
+	seeAlso := []string{}
+	if cmd.parent != nil {
+		seeAlso = append(seeAlso, cmd.parent.manName())
+	}
+	if !cmd.novisiblechild() {
+		buf.WriteString(".SH COMMANDS\n")
+		for _, child := range cmd.getChildren() {
+			if child.isHidden() {
+				continue
+			}
+			fmt.Fprintf(buf, ".TP\n\\fB%s\\fR\n", roffEscape(child.Name))
+			desc := child.localDesc()
+			if len(child.Aliases) > 0 {
+				desc += " (aliases " + strings.Join(child.Aliases, ", ") + ")"
+			}
+			buf.WriteString(roffText(strings.TrimSpace(desc)))
+			seeAlso = append(seeAlso, child.manName())
+		}
+	}
+
+	if len(cmd.Examples) > 0 {
+		buf.WriteString(".SH EXAMPLES\n")
+		for i, ex := range cmd.Examples {
+			if i > 0 {
+				buf.WriteString(".PP\n")
+			}
+			if ex.Desc != "" {
+				buf.WriteString(roffText(ex.Desc))
+			}
+			fmt.Fprintf(buf, ".PP\n.RS\n.nf\n$ %s\n.fi\n.RE\n", roffEscape(strings.TrimSpace(root+" "+ex.Command)))
+		}
+	}
+
+	if len(seeAlso) > 0 {
+		buf.WriteString(".SH SEE ALSO\n")
+		for i, page := range seeAlso {
+			if i > 0 {
+				buf.WriteString(",\n")
+			}
+			fmt.Fprintf(buf, "\\fB%s\\fR(%s)", roffEscape(page), section)
+		}
+		buf.WriteString("\n")
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// manName returns name of man page of cmd, e.g. app-user-add
+func (cmd *Command) manName() string {
+	if path := cmd.pathWithSep("-"); path != "" {
+		return cmd.manRootName() + "-" + path
+	}
+	return cmd.manRootName()
+}
+
+// manRootName returns name of root, name of executable used if root has no name
+func (cmd *Command) manRootName() string {
+	if name := cmd.Root().Name; name != "" {
+		return name
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// roffEscape escapes backslashes and dashes of s
+func roffEscape(s string) string {
+	return strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+}
+
+// roffQuote escapes s as a quoted argument of requests, quotes are escaped as \(dq
+func roffQuote(s string) string {
+	return `"` + strings.Replace(roffEscape(s), `"`, `\(dq`, -1) + `"`
+}
+
+// roffText escapes s as paragraphs, lines starting with control characters are
+// escaped and empty lines separate paragraphs
+func roffText(s string) string {
+	buf := new(bytes.Buffer)
+	for _, line := range strings.Split(strings.TrimSpace(s), "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			buf.WriteString(".PP\n")
+			continue
+		}
+		line = roffEscape(line)
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			line = `\&` + line
+		}
+		buf.WriteString(line + "\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newManApp() *Command {
+	type argT struct {
+		Name  string `cli:"n,name" usage:"name of user" name:"NAME" dft:"guest"`
+		Admin bool   `cli:"*admin" usage:"grant admin role" env:"APP_ADMIN"`
+	}
+	root := &Command{Name: "app", Desc: "an app"}
+	user := root.Register(&Command{Name: "user", Desc: "manage users", Aliases: []string{"u"}})
+	user.Register(&Command{
+		Name: "add",
+		Desc: "add a user",
+		Text: "Add a user to the system.\n\n.hidden lines are escaped",
+		Argv: func() interface{} { return new(argT) },
+		Examples: []Example{
+			{Desc: "add the admin", Command: "user add --name=root --admin"},
+		},
+	})
+	root.Register(&Command{Name: "secret", Hidden: true})
+	return root
+}
+
+func TestWriteManPage(t *testing.T) {
+	root := newManApp()
+	buf := new(bytes.Buffer)
+	require.Nil(t, root.Route([]string{"user", "add"}).WriteManPage(buf, "1"))
+	assert.Equal(t, buf.String(), `.TH "APP\-USER\-ADD" "1" "" "app" ""
+.SH NAME
+app\-user\-add \- add a user
+.SH SYNOPSIS
+.B app user add
+[\fIOPTIONS\fR]
+.SH DESCRIPTION
+Add a user to the system.
+.PP
+\&.hidden lines are escaped
+.SH OPTIONS
+.TP
+\fB\-n\fR, \fB\-\-name\fR=\fINAME\fR
+name of user (default: guest)
+.TP
+\fB\-\-admin\fR
+grant admin role (env: APP_ADMIN) (required)
+.SH EXAMPLES
+add the admin
+.PP
+.RS
+.nf
+$ app user add \-\-name=root \-\-admin
+.fi
+.RE
+.SH SEE ALSO
+\fBapp\-user\fR(1)
+`)
+
+	buf.Reset()
+	require.Nil(t, root.WriteManPage(buf, "8"))
+	assert.Equal(t, buf.String(), `.TH "APP" "8" "" "app" ""
+.SH NAME
+app \- an app
+.SH SYNOPSIS
+.B app
+\fICOMMAND\fR
+.SH DESCRIPTION
+an app
+.SH COMMANDS
+.TP
+\fBuser\fR
+manage users (aliases u)
+.SH SEE ALSO
+\fBapp\-user\fR(8)
+`)
+
+	buf.Reset()
+	require.Nil(t, (&Command{Name: `say"hi`}).WriteManPage(buf, "1"))
+	assert.True(t, strings.HasPrefix(buf.String(), `.TH "SAY\(dqHI" "1" "" "say\(dqhi" ""`+"\n"), buf.String())
+}
+
+func TestGenManPages(t *testing.T) {
+	dir := t.TempDir()
+	require.Nil(t, newManApp().GenManPages(dir, "1"))
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	require.Nil(t, err)
+	for i := range files {
+		files[i] = filepath.Base(files[i])
+	}
+	sort.Strings(files)
+	assert.Equal(t, files, []string{"app-user-add.1", "app-user.1", "app.1"})
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app-user.1"))
+	require.Nil(t, err)
+	assert.Contains(t, string(data), ".SH COMMANDS\n.TP\n\\fBadd\\fR\nadd a user\n")
+}
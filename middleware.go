@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps an http.Handler, e.g. for authentication, logging or rate limiting.
+// Standard middlewares with signature func(http.Handler) http.Handler can be used directly.
+type Middleware func(http.Handler) http.Handler
+
+// HTTPHandler returns handler which serves cmd over HTTP wrapped by HTTPMiddlewares
+func (cmd *Command) HTTPHandler() http.Handler {
+	return cmd.wrapHTTP(cmd)
+}
+
+// wrapHTTP wraps h by HTTPMiddlewares of cmd
+func (cmd *Command) wrapHTTP(h http.Handler) http.Handler {
+	for i := len(cmd.HTTPMiddlewares) - 1; i >= 0; i-- {
+		h = cmd.HTTPMiddlewares[i](h)
+	}
+	return h
+}
+
+// TokenAuth rejects requests without a bearer token accepted by valid with 401,
+// the token is read from header `Authorization: Bearer <token>`
+func TokenAuth(valid func(token string) bool) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || token == r.Header.Get("Authorization") || !valid(token) {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// StaticTokens returns a validator for TokenAuth which accepts listed tokens
+func StaticTokens(tokens ...string) func(string) bool {
+	return func(token string) bool {
+		for _, t := range tokens {
+			if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// LogRequests writes a line for each request to w after it's served, e.g.
+//
+//	GET /user/get 200 1.2ms
+func LogRequests(w io.Writer) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: rw, code: http.StatusOK}
+			next.ServeHTTP(sw, r)
+			fmt.Fprintf(w, "%s %s %d %s\n", r.Method, r.URL.Path, sw.code, formatElapsed(time.Since(start)))
+		})
+	}
+}
+
+// statusWriter records status code of response
+type statusWriter struct {
+	http.ResponseWriter
+	code  int
+	wrote bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wrote {
+		w.wrote = true
+		w.code = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(data []byte) (int, error) {
+	w.wrote = true
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush implements http.Flusher, so output of command is still streamed
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, so WebSocket console can be served behind LogRequests
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer can't be hijacked")
+	}
+	w.wrote = true
+	w.code = http.StatusSwitchingProtocols
+	return hijacker.Hijack()
+}
+
+// RateLimit limits requests to rate per second with bursts of up to burst requests,
+// requests over the limit are rejected with 429. The limit is shared by all clients.
+func RateLimit(rate float64, burst int) Middleware {
+	if burst <= 0 {
+		burst = 1
+	}
+	limiter := &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if wait, ok := limiter.take(time.Now()); !ok {
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type tokenBucket struct {
+	locker sync.Mutex // protect following data
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// take takes a token, returns duration to wait for next token if no token left
+func (b *tokenBucket) take(now time.Time) (time.Duration, bool) {
+	b.locker.Lock()
+	defer b.locker.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	if b.rate <= 0 {
+		return time.Second, false
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second)), false
+}
@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	var (
+		order []string
+		trace = func(name string) Middleware {
+			return func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					order = append(order, name)
+					next.ServeHTTP(w, r)
+				})
+			}
+		}
+		log = new(bytes.Buffer)
+	)
+	root := &Command{
+		Name: "app",
+		HTTPMiddlewares: []Middleware{
+			trace("a"),
+			trace("b"),
+			LogRequests(log),
+			TokenAuth(StaticTokens("secret")),
+		},
+	}
+	root.Register(&Command{
+		Name: "ping",
+		Fn: func(ctx *Context) error {
+			ctx.String("pong")
+			return nil
+		},
+	})
+	h := root.HTTPHandler()
+
+	resp := httptest.NewRecorder()
+	h.ServeHTTP(resp, httptest.NewRequest("GET", "/ping", nil))
+	assert.Equal(t, resp.Code, 401)
+	assert.Equal(t, resp.Header().Get("WWW-Authenticate"), "Bearer")
+	assert.Equal(t, order, []string{"a", "b"})
+
+	for _, auth := range []string{"secret", "Bearer wrong"} {
+		req := httptest.NewRequest("GET", "/ping", nil)
+		req.Header.Set("Authorization", auth)
+		resp = httptest.NewRecorder()
+		h.ServeHTTP(resp, req)
+		assert.Equal(t, resp.Code, 401)
+	}
+
+	req := httptest.NewRequest("GET", "/ping", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp = httptest.NewRecorder()
+	h.ServeHTTP(resp, req)
+	assert.Equal(t, resp.Code, 200)
+	assert.Equal(t, resp.Body.String(), "pong")
+	assert.True(t, resp.Flushed)
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	assert.Equal(t, len(lines), 4)
+	assert.True(t, strings.HasPrefix(lines[0], "GET /ping 401 "))
+	assert.True(t, strings.HasPrefix(lines[3], "GET /ping 200 "))
+
+	// handler of root without middlewares is root itself
+	_, isCommand := (&Command{}).HTTPHandler().(*Command)
+	assert.True(t, isCommand)
+}
+
+func TestServeMiddlewares(t *testing.T) {
+	root := &Command{
+		Name:            "app",
+		HTTPMiddlewares: []Middleware{TokenAuth(StaticTokens("secret"))},
+	}
+	root.Register(&Command{
+		Name: "ping",
+		Fn: func(ctx *Context) error {
+			ctx.String("pong")
+			return nil
+		},
+	})
+	// listener is left open since Serve panics if serving failed
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	go root.Serve(ln)
+
+	url := "http://" + ln.Addr().String() + "/ping"
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 401)
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, resp.StatusCode, 200)
+	assert.Equal(t, string(data), "pong")
+}
+
+func TestRateLimit(t *testing.T) {
+	h := RateLimit(1, 2)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	codes := []int{}
+	for i := 0; i < 3; i++ {
+		resp := httptest.NewRecorder()
+		h.ServeHTTP(resp, httptest.NewRequest("GET", "/", nil))
+		codes = append(codes, resp.Code)
+		if resp.Code == 429 {
+			assert.Equal(t, resp.Header().Get("Retry-After"), "1")
+		}
+	}
+	assert.Equal(t, codes, []int{200, 200, 429})
+
+	now := time.Now()
+	b := &tokenBucket{rate: 10, burst: 1, tokens: 0, last: now}
+	wait, ok := b.take(now)
+	assert.False(t, ok)
+	assert.Equal(t, wait, 100*time.Millisecond)
+	_, ok = b.take(now.Add(100 * time.Millisecond))
+	assert.True(t, ok)
+}
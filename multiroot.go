@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"os"
+	"sort"
+)
+
+// MultiRoot selects root command from roots by name of executable(os.Args[0]),
+// so a binary installed with several names or symlinks runs as different apps,
+// e.g. `ln -s app app-admin`.
+// If name of executable is not in roots, it returns a root named by executable
+// which holds copies of roots as children, like `busybox ls`.
+//
+//	cli.MultiRoot(map[string]*cli.Command{
+//		"app":       appRoot,
+//		"app-admin": adminRoot,
+//	}).Run(os.Args[1:])
+func MultiRoot(roots map[string]*Command) *Command {
+	return multiRoot(roots, executableName(os.Args[0]))
+}
+
+func multiRoot(roots map[string]*Command, name string) *Command {
+	if root, ok := roots[name]; ok {
+		return root
+	}
+	names := make([]string, 0, len(roots))
+	for name := range roots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	dispatcher := &Command{
+		Name: name,
+		Fn: func(ctx *Context) error {
+			ctx.WriteUsage()
+			return nil
+		},
+	}
+	for _, name := range names {
+		child := roots[name].Clone()
+		child.Name = name
+		dispatcher.Register(child)
+	}
+	return dispatcher
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiRoot(t *testing.T) {
+	hello := func(name string) *Command {
+		root := &Command{Name: name}
+		root.Register(&Command{Name: "hello", Desc: "say hello", Fn: func(ctx *Context) error {
+			ctx.String("hello from %s", name)
+			return nil
+		}})
+		return root
+	}
+	app, admin := hello("app"), hello("admin")
+	roots := map[string]*Command{"app": app, "app-admin": admin}
+
+	assert.True(t, multiRoot(roots, "app") == app)
+	assert.True(t, multiRoot(roots, "app-admin") == admin)
+
+	box := multiRoot(roots, "box")
+	assert.Equal(t, box.Name, "box")
+	assert.Equal(t, box.ListChildren(), []string{"app", "app-admin"})
+	assert.Nil(t, admin.Parent())
+
+	w := new(bytes.Buffer)
+	require.Nil(t, box.RunWith([]string{"app-admin", "hello"}, w, nil))
+	assert.Equal(t, w.String(), "hello from admin")
+	w.Reset()
+	require.Nil(t, box.RunWith(nil, w, nil))
+	assert.Contains(t, w.String(), "app-admin")
+
+	assert.Equal(t, executableName("/usr/bin/app-admin"), "app-admin")
+	assert.Equal(t, executableName(`app.exe`), "app")
+}
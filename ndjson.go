@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type (
+	// NDJSONRequest is a request line read by ServeNDJSON, e.g.
+	//
+	//	{"id":"1","path":"user get","args":["--name","Jack"]}
+	NDJSONRequest struct {
+		ID    string   `json:"id,omitempty"`    // Echoed in response
+		Path  string   `json:"path"`            // Command path separated by spaces or slashes
+		Args  []string `json:"args,omitempty"`  // Flags and arguments
+		Input string   `json:"input,omitempty"` // Input of command, returned by Context.Reader
+	}
+
+	// NDJSONResponse is a response line written by ServeNDJSON
+	NDJSONResponse struct {
+		ID     string `json:"id,omitempty"`
+		Output string `json:"output"`
+		Error  string `json:"error,omitempty"`
+	}
+)
+
+// ServeNDJSON sets IsServer with true, reads requests from r line by line and writes
+// a response line to w for each request, until r reaches EOF. It lets other processes
+// drive many commands through one long-lived process, e.g.
+//
+//	root.ServeNDJSON(os.Stdin, os.Stdout)
+func (cmd *Command) ServeNDJSON(r io.Reader, w io.Writer) error {
+	cmd.SetIsServer(true)
+	var (
+		reader  = bufio.NewReader(r)
+		encoder = json.NewEncoder(w)
+	)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if err := encoder.Encode(cmd.serveNDJSONLine(line)); err != nil {
+				return err
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (cmd *Command) serveNDJSONLine(line []byte) NDJSONResponse {
+	var req NDJSONRequest
+	if err := json.Unmarshal(line, &req); err != nil {
+		return NDJSONResponse{Error: "malformed request: " + err.Error()}
+	}
+	router := strings.FieldsFunc(req.Path, func(c rune) bool { return c == ' ' || c == '/' })
+	var (
+		args = append(router, req.Args...)
+		out  = new(bytes.Buffer)
+		resp = NDJSONResponse{ID: req.ID}
+	)
+	err := cmd.runRemote(args, out, nil, func(ctx *Context) {
+		ctx.reader = strings.NewReader(req.Input)
+	})
+	if err != nil {
+		resp.Error = err.Error()
+	}
+	resp.Output = out.String()
+	return resp
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeNDJSON(t *testing.T) {
+	type argT struct {
+		Name string `cli:"name"`
+	}
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{Name: "user"})
+	user.Register(&Command{
+		Name: "get",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.String("user %s", ctx.Argv().(*argT).Name)
+			return nil
+		},
+	})
+	user.Register(&Command{
+		Name: "import",
+		Fn: func(ctx *Context) error {
+			data, _ := ioutil.ReadAll(ctx.Reader())
+			return errors.New("import " + string(data) + " failed")
+		},
+	})
+
+	r := strings.NewReader(`{"id":"1","path":"user get","args":["--name","Jack"]}
+
+not json
+{"id":"3","path":"user/import","input":"a.csv"}`)
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.ServeNDJSON(r, w))
+	assert.True(t, root.IsServer())
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	assert.Equal(t, len(lines), 3)
+	assert.Equal(t, lines[0], `{"id":"1","output":"user Jack"}`)
+	assert.Contains(t, lines[1], `{"output":"","error":"malformed request: `)
+	assert.Equal(t, lines[2], `{"id":"3","output":"","error":"import a.csv failed"}`)
+}
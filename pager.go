@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type (
+	// PagerDisabler represents interface for disabling pager
+	PagerDisabler interface {
+		DisablePager() bool
+	}
+
+	// PagerHelper is builtin no-pager flag, it's set by environment variable NO_PAGER too
+	PagerHelper struct {
+		NoPager bool `cli:"no-pager" usage:"do not pipe output into pager" env:"NO_PAGER" json:"-"`
+	}
+)
+
+// DisablePager implements PagerDisabler interface
+func (h PagerHelper) DisablePager() bool {
+	return h.NoPager
+}
+
+// DefaultPager is used if environment variable PAGER is empty
+var DefaultPager = "less"
+
+// Pager pipes subsequent output through $PAGER if writer is a terminal and output
+// exceeds height of terminal, output shorter than a screen is written directly.
+// It does nothing if argv implements PagerDisabler which returns true or $PAGER is `cat`
+// or blank.
+// Pager is closed after command finished.
+func (ctx *Context) Pager() {
+	if !ctx.IsTTY() || ctx.pagerDisabled() {
+		return
+	}
+	_, height, ok := ctx.TerminalSize()
+	if !ok || height <= 1 {
+		return
+	}
+	pager := os.Getenv("PAGER")
+	if pager == "" {
+		pager = DefaultPager
+	}
+	if pager == "cat" || strings.TrimSpace(pager) == "" {
+		return
+	}
+	out := ctx.Writer()
+	w := &pagerWriter{
+		out:    out,
+		height: height - 1,
+		start:  func() (io.WriteCloser, error) { return startPager(pager) },
+	}
+	ctx.writer = w
+	ctx.Defer(func() {
+		w.Close()
+		ctx.writer = out
+	})
+}
+
+func (ctx *Context) pagerDisabled() bool {
+	for _, argv := range ctx.allArgvs() {
+		if disabler, ok := argv.(PagerDisabler); ok && disabler.DisablePager() {
+			return true
+		}
+	}
+	return false
+}
+
+// pagerWriter buffers output until height lines, then starts pager
+type pagerWriter struct {
+	out    io.Writer
+	height int
+	lines  int
+	buf    bytes.Buffer
+	start  func() (io.WriteCloser, error)
+	pager  io.Writer // nil before pager started
+	closer io.Closer
+}
+
+func (w *pagerWriter) Write(p []byte) (int, error) {
+	if w.pager != nil {
+		return w.pager.Write(p)
+	}
+	w.buf.Write(p)
+	if w.lines += bytes.Count(p, []byte{'\n'}); w.lines < w.height {
+		return len(p), nil
+	}
+	if pager, err := w.start(); err != nil {
+		w.pager = w.out
+	} else {
+		w.pager, w.closer = pager, pager
+	}
+	if _, err := w.pager.Write(w.buf.Bytes()); err != nil {
+		return 0, err
+	}
+	w.buf.Reset()
+	return len(p), nil
+}
+
+// Close flushes buffered output if pager not started, or waits pager exit
+func (w *pagerWriter) Close() error {
+	if w.pager == nil {
+		_, err := w.out.Write(w.buf.Bytes())
+		w.buf.Reset()
+		return err
+	}
+	if w.closer != nil {
+		return w.closer.Close()
+	}
+	return nil
+}
+
+type pagerProcess struct {
+	io.WriteCloser
+	cmd *exec.Cmd
+}
+
+func startPager(pager string) (io.WriteCloser, error) {
+	fields := strings.Fields(pager)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("pager is empty")
+	}
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if os.Getenv("LESS") == "" {
+		// quit if one screen, raw control chars for colors, no init
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return pagerProcess{WriteCloser: stdin, cmd: cmd}, nil
+}
+
+func (p pagerProcess) Close() error {
+	p.WriteCloser.Close()
+	return p.cmd.Wait()
+}
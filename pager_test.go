@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type pagerBuffer struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *pagerBuffer) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestPagerWriter(t *testing.T) {
+	out, pager := new(bytes.Buffer), new(pagerBuffer)
+	w := &pagerWriter{out: out, height: 3, start: func() (io.WriteCloser, error) { return pager, nil }}
+	io.WriteString(w, "1\n2\n")
+	assert.Nil(t, w.Close())
+	assert.Equal(t, out.String(), "1\n2\n")
+	assert.Equal(t, pager.String(), "")
+
+	out.Reset()
+	w = &pagerWriter{out: out, height: 3, start: func() (io.WriteCloser, error) { return pager, nil }}
+	io.WriteString(w, "1\n2\n")
+	io.WriteString(w, "3\n")
+	io.WriteString(w, "4\n")
+	assert.Nil(t, w.Close())
+	assert.Equal(t, out.String(), "")
+	assert.Equal(t, pager.String(), "1\n2\n3\n4\n")
+	assert.True(t, pager.closed)
+
+	// output written directly if pager failed to start
+	w = &pagerWriter{out: out, height: 1, start: func() (io.WriteCloser, error) { return nil, errors.New("not found") }}
+	io.WriteString(w, "1\n2\n")
+	assert.Nil(t, w.Close())
+	assert.Equal(t, out.String(), "1\n2\n")
+}
+
+func TestPagerDisabled(t *testing.T) {
+	type argT struct {
+		PagerHelper
+	}
+	w := new(bytes.Buffer)
+	ctx := &Context{writer: w, argvList: []interface{}{&argT{PagerHelper{NoPager: true}}}}
+	assert.True(t, ctx.pagerDisabled())
+	ctx.Pager()
+	assert.Equal(t, ctx.Writer(), w)
+
+	defer os.Unsetenv("NO_PAGER")
+	os.Setenv("NO_PAGER", "1")
+	var argv *argT
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			argv = ctx.Argv().(*argT)
+			return nil
+		},
+	}
+	assert.Nil(t, root.RunWith(nil, w, nil))
+	assert.True(t, argv.NoPager)
+}
+
+func TestStartBlankPager(t *testing.T) {
+	_, err := startPager(" \t")
+	assert.Error(t, err)
+}
@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// DiscoverPathPlugins returns plugins found in $PATH, a plugin is an executable named
+// `<root>-<name>`, e.g. `app-foo` for command `app foo` and `app-foo-bar` for `app foo bar`.
+// The first one is used if same name found in multiple directories, plugins shadowed
+// by commands of tree are skipped. Plugins are sorted by name.
+func (cmd *Command) DiscoverPathPlugins() []*Plugin {
+	var (
+		root    = cmd.Root()
+		prefix  = root.Name + "-"
+		seen    = map[string]bool{}
+		plugins = []*Plugin{}
+	)
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, info := range infos {
+			filename := info.Name()
+			if runtime.GOOS == "windows" {
+				filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+			}
+			if !strings.HasPrefix(filename, prefix) || info.IsDir() || !isExecutable(info) {
+				continue
+			}
+			name := strings.TrimPrefix(filename, prefix)
+			if seen[name] || !isValidPluginName(name) || root.Route(strings.Split(name, "-")[:1]) != nil {
+				continue
+			}
+			seen[name] = true
+			plugins = append(plugins, &Plugin{Name: name, Path: filepath.Join(dir, info.Name())})
+		}
+	}
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+func isExecutable(info os.FileInfo) bool {
+	return runtime.GOOS == "windows" || info.Mode()&0111 != 0
+}
+
+// isValidPluginName reports whether each `-` separated word of name is a valid command name
+func isValidPluginName(name string) bool {
+	for _, word := range strings.Split(name, "-") {
+		if !IsValidCommandName(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupPathPlugin finds plugin of the longest prefix of router in $PATH, the number
+// of words of router used by name of plugin is returned too
+func (cmd *Command) lookupPathPlugin(router []string) (*Plugin, int) {
+	root := cmd.Root()
+	for n := len(router); n > 0; n-- {
+		name := strings.Join(router[:n], "-")
+		if !isValidPluginName(name) {
+			continue
+		}
+		path, err := exec.LookPath(root.Name + "-" + name)
+		if err != nil {
+			continue
+		}
+		debugf("found plugin %s of command %s", path, strings.Join(router[:n], " "))
+		return &Plugin{Name: name, Path: path}, n
+	}
+	return nil, 0
+}
+
+// pathPluginsUsage lists plugins found in $PATH with their paths
+func (cmd *Command) pathPluginsUsage(prefix string) string {
+	plugins := cmd.DiscoverPathPlugins()
+	if len(plugins) == 0 {
+		return ""
+	}
+	length := 0
+	for _, plugin := range plugins {
+		if w := stringWidth(plugin.Name); w > length {
+			length = w
+		}
+	}
+	var buff strings.Builder
+	for _, plugin := range plugins {
+		fmt.Fprintf(&buff, "%s%s   %s\n", prefix, padRight(strings.Replace(plugin.Name, "-", " ", -1), length), plugin.Path)
+	}
+	return buff.String()
+}
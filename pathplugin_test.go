@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/color"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPathPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	foo := writePlugin(t, dir, "app-foo", `echo foo "$@"`)
+	fooBar := writePlugin(t, dir, "app-foo-bar", `echo foo-bar "$@"`)
+	writePlugin(t, dir, "app-list", `echo shadowed`)
+	require.Nil(t, ioutil.WriteFile(dir+"/app-data", []byte("not executable"), 0644))
+
+	root := &Command{Name: "app", PathPlugins: true}
+	root.Register(&Command{Name: "list", Desc: "list items", Fn: func(ctx *Context) error {
+		ctx.String("list\n")
+		return nil
+	}})
+
+	plugins := root.DiscoverPathPlugins()
+	require.Equal(t, len(plugins), 2)
+	assert.Equal(t, plugins[0], &Plugin{Name: "foo", Path: foo})
+	assert.Equal(t, plugins[1], &Plugin{Name: "foo-bar", Path: fooBar})
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"foo", "-x", "y"}, "foo -x y\n"},
+		{[]string{"foo", "bar", "baz"}, "foo-bar baz\n"},
+		{[]string{"foo", "baz"}, "foo baz\n"},
+		{[]string{"list"}, "list\n"},
+	} {
+		w := new(strings.Builder)
+		assert.Nil(t, root.RunWith(tt.args, w, nil))
+		assert.Equal(t, w.String(), tt.want, "args: %v", tt.args)
+	}
+	err = root.RunWith([]string{"data"}, new(strings.Builder), nil)
+	assert.Error(t, err)
+
+	clr := color.Color{}
+	clr.Disable()
+	usage := root.Usage(&Context{color: clr})
+	assert.Equal(t, usage, "Commands:\n\n  list   list items\n\nPlugins:\n\n  foo       "+foo+"\n  foo bar   "+fooBar+"\n")
+
+	// plugins section of usage is not cached
+	baz := writePlugin(t, dir, "app-baz", `echo baz`)
+	assert.Contains(t, root.Usage(&Context{color: clr}), "  baz       "+baz+"\n")
+	// plugins are neither listed nor run for remote clients
+	assert.Equal(t, root.Usage(&Context{color: clr, remote: true}), "Commands:\n\n  list   list items\n")
+	assert.Error(t, root.runRemote([]string{"foo"}, new(strings.Builder), nil, nil))
+	engine := NewEngine(root)
+	assert.Error(t, engine.Execute(context.Background(), Execution{Args: []string{"foo"}, Remote: true}))
+
+	// engine resolves plugins like running
+	cmd, rest := engine.Resolve([]string{"foo", "bar", "-x"})
+	assert.Equal(t, cmd.Name, "foo-bar")
+	assert.Equal(t, cmd.Path(), "foo-bar")
+	assert.Equal(t, rest, []string{"-x"})
+	out := new(strings.Builder)
+	assert.Nil(t, engine.Execute(context.Background(), Execution{Args: []string{"foo", "bar", "-x"}, Stdout: out}))
+	assert.Equal(t, out.String(), "foo-bar -x\n")
+
+	// plugins are not run if disabled
+	root.PathPlugins = false
+	assert.Error(t, root.RunWith([]string{"foo"}, new(strings.Builder), nil))
+}
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"runtime"
+	"strings"
+)
+
+// unsupportedPlatform returns current GOOS or GOARCH if it's not listed,
+// empty list means all platforms supported
+func unsupportedPlatform(osList, archList []string) string {
+	if len(osList) > 0 && !containsFold(osList, runtime.GOOS) {
+		return runtime.GOOS
+	}
+	if len(archList) > 0 && !containsFold(archList, runtime.GOARCH) {
+		return runtime.GOARCH
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitTagList splits comma separated tag value, e.g. `os:"linux,darwin"`
+func splitTagList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,66 @@
+package cli
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsupportedPlatform(t *testing.T) {
+	assert.Equal(t, unsupportedPlatform(nil, nil), "")
+	assert.Equal(t, unsupportedPlatform([]string{"plan10", " " + strings.ToUpper(runtime.GOOS)}, nil), "")
+	assert.Equal(t, unsupportedPlatform([]string{"plan10"}, nil), runtime.GOOS)
+	assert.Equal(t, unsupportedPlatform(nil, []string{runtime.GOARCH}), "")
+	assert.Equal(t, unsupportedPlatform([]string{runtime.GOOS}, []string{"unknown-arch"}), runtime.GOARCH)
+}
+
+func TestPlatformFlag(t *testing.T) {
+	type argT struct {
+		Here  bool   `cli:"here" usage:"here flag"`
+		Other string `cli:"*other" usage:"other flag" os:"plan10" dft:"x"`
+		Arch  bool   `cli:"arch" usage:"arch flag" arch:"unknown-arch"`
+	}
+	argv := new(argT)
+	flagSet := parseArgv([]string{"--here"}, argv, color.Color{})
+	assert.Nil(t, flagSet.err)
+	assert.Equal(t, *argv, argT{Here: true})
+
+	flagSet = parseArgv([]string{"--other=y"}, new(argT), color.Color{})
+	if assert.Error(t, flagSet.err) {
+		assert.Contains(t, flagSet.err.Error(), "not supported on "+runtime.GOOS)
+	}
+	flagSet = parseArgv([]string{"--arch"}, new(argT), color.Color{})
+	if assert.Error(t, flagSet.err) {
+		assert.Contains(t, flagSet.err.Error(), "not supported on "+runtime.GOARCH)
+	}
+	RegisterMessages("zz", map[string]string{MsgFlagNotSupported: "unavailable on %s"})
+	SetLanguage("zz")
+	flagSet = parseArgv([]string{"--arch"}, new(argT), color.Color{})
+	SetLanguage("")
+	if assert.Error(t, flagSet.err) {
+		assert.Contains(t, flagSet.err.Error(), "unavailable on "+runtime.GOARCH)
+	}
+
+	text := usage([]interface{}{new(argT)}, color.Color{}, NormalStyle)
+	assert.Contains(t, text, "--here")
+	assert.False(t, strings.Contains(text, "--other"))
+	assert.False(t, strings.Contains(text, "--arch"))
+}
+
+func TestPlatformCommand(t *testing.T) {
+	root := &Command{}
+	root.Register(&Command{Name: "here", OS: []string{runtime.GOOS}, Fn: donothing})
+	root.Register(&Command{Name: "other", Desc: "other command", OS: []string{"plan10"}, Fn: donothing})
+
+	assert.Nil(t, root.RunWith([]string{"here"}, new(bytes.Buffer), nil))
+	err := root.RunWith([]string{"other"}, new(bytes.Buffer), nil)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "not supported on "+runtime.GOOS)
+	}
+	assert.False(t, strings.Contains(root.ChildrenDescriptions("", " "), "other"))
+	assert.Equal(t, root.Suggestions("othe"), []string{})
+}
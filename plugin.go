@@ -0,0 +1,369 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ManifestCommandName is name of the hidden command which prints manifest of plugin
+const ManifestCommandName = "__manifest"
+
+// ManifestTimeout is timeout of running plugin for its manifest
+var ManifestTimeout = 5 * time.Second
+
+type (
+	// Plugin represents an external executable which runs as a command
+	Plugin struct {
+		Name    string   // Plugin name, used as command name
+		Path    string   // Path of plugin executable
+		Sandbox *Sandbox // Sandbox profile for running plugin, nil means no restriction
+
+		// Manifest is loaded by LoadManifest, commands declared in manifest
+		// are merged into command tree of plugin
+		Manifest *PluginManifest
+	}
+
+	// PluginManifest describes a plugin, plugin prints it as JSON
+	// when invoked with ManifestCommandName
+	PluginManifest struct {
+		Name       string                `json:"name"`
+		Version    string                `json:"version"`
+		Desc       string                `json:"desc,omitempty"`
+		MinVersion string                `json:"minFrameworkVersion,omitempty"`
+		Commands   []PluginManifestEntry `json:"commands,omitempty"`
+	}
+
+	// PluginManifestEntry describes a command of plugin
+	PluginManifestEntry struct {
+		Name     string                `json:"name"`
+		Aliases  []string              `json:"aliases,omitempty"`
+		Desc     string                `json:"desc,omitempty"`
+		Commands []PluginManifestEntry `json:"commands,omitempty"`
+	}
+
+	// Sandbox represents restrictions applied to plugin process
+	Sandbox struct {
+		// CleanEnv runs plugin with a clean environment instead of inheriting
+		// environment of current process
+		CleanEnv bool
+		// Env holds extra environment variables(KEY=VALUE) for plugin
+		Env []string
+		// Path restricts PATH of plugin, empty means unchanged
+		Path []string
+		// Dir specifies working directory of plugin
+		Dir string
+
+		// AppArmorProfile and SeccompProfile are names of profiles
+		// confining plugin process, only supported on linux
+		AppArmorProfile string
+		SeccompProfile  string
+
+		// Checksums maps plugin filename to hex encoded sha256 checksum,
+		// plugin must be listed and match if Checksums is not nil. Plugin is
+		// copied to a private directory while verifying and the copy is run.
+		Checksums map[string]string
+	}
+)
+
+// SeccompLoader sets callback to apply seccomp profile to plugin process
+var SeccompLoader func(cmd *exec.Cmd, profile string) error
+
+// LoadChecksums reads sha256 checksums from a manifest file with format of `sha256sum`:
+//
+//	<hex checksum>  <filename>
+func LoadChecksums(filename string) (map[string]string, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadChecksums(file)
+}
+
+// ReadChecksums is similar to LoadChecksums, but read from reader
+func ReadChecksums(r io.Reader) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("checksum manifest: line %d: malformed", lineno)
+		}
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums, scanner.Err()
+}
+
+// Verify verifies checksum of plugin executable
+func (s *Sandbox) Verify(path string) error {
+	if s == nil || s.Checksums == nil {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return s.verify(filepath.Base(path), file, ioutil.Discard)
+}
+
+// verify verifies checksum of plugin named name read from r, content is copied to w
+func (s *Sandbox) verify(name string, r io.Reader, w io.Writer) error {
+	want, ok := s.Checksums[name]
+	if !ok {
+		return fmt.Errorf("plugin %s not listed in checksum manifest", name)
+	}
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(h, w), r); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("plugin %s checksum mismatch", name)
+	}
+	return nil
+}
+
+// verifiedCopy copies plugin executable into a private directory while verifying
+// its checksum, so that the verified content is run even if path is replaced later.
+// Path of the copy is returned, cleanup removes it.
+func (s *Sandbox) verifiedCopy(path string) (string, func(), error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return "", nil, err
+	}
+	defer src.Close()
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+	name := filepath.Base(path)
+	dst, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0700)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	err = s.verify(name, src, dst)
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return dst.Name(), cleanup, nil
+}
+
+func (s *Sandbox) environ() []string {
+	if s == nil {
+		return nil
+	}
+	env := []string{}
+	if !s.CleanEnv {
+		env = append(env, os.Environ()...)
+	}
+	env = append(env, s.Env...)
+	if s.Path != nil && len(s.Path) > 0 {
+		env = append(env, "PATH="+strings.Join(s.Path, string(os.PathListSeparator)))
+	}
+	return env
+}
+
+// command creates exec.Cmd which runs path with args in sandbox, cleanup should be
+// called after cmd exited. A verified copy of path is run if Checksums is not nil.
+func (s *Sandbox) command(goctx context.Context, path string, args ...string) (cmd *exec.Cmd, cleanup func(), err error) {
+	cleanup = func() {}
+	if s == nil {
+		return exec.CommandContext(goctx, path, args...), cleanup, nil
+	}
+	name := path
+	if s.Checksums != nil {
+		if name, cleanup, err = s.verifiedCopy(path); err != nil {
+			return nil, nil, err
+		}
+	}
+	cmd = exec.CommandContext(goctx, name, args...)
+	cmd.Args[0] = path
+	cmd.Env = s.environ()
+	cmd.Dir = s.Dir
+	if err := applyProfiles(cmd, s); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return cmd, cleanup, nil
+}
+
+// Exec runs plugin with args, plugin reads from reader of ctx and its output is
+// written to ctx. Plugin is killed if Context.Context of ctx is done.
+func (p *Plugin) Exec(ctx *Context, args []string) error {
+	cmd, cleanup, err := p.Sandbox.command(ctx.Context(), p.Path, args...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	cmd.Stdin = ctx.Reader()
+	cmd.Stdout = ctx
+	cmd.Stderr = ctx.stderr(os.Stderr)
+	return cmd.Run()
+}
+
+// LoadManifest runs plugin with ManifestCommandName and reads manifest from its output,
+// plugin is killed if it doesn't exit in ManifestTimeout
+func (p *Plugin) LoadManifest() error {
+	goctx, cancel := context.WithTimeout(context.Background(), ManifestTimeout)
+	defer cancel()
+	cmd, cleanup, err := p.Sandbox.command(goctx, p.Path, ManifestCommandName)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+	data, err := cmd.Output()
+	if err != nil {
+		return fmt.Errorf("plugin %s: read manifest: %v", p.Name, err)
+	}
+	manifest := new(PluginManifest)
+	if err := json.NewDecoder(bytes.NewReader(data)).Decode(manifest); err != nil {
+		return fmt.Errorf("plugin %s: decode manifest: %v", p.Name, err)
+	}
+	if manifest.MinVersion != "" && compareVersion(Version, manifest.MinVersion) < 0 {
+		return fmt.Errorf("plugin %s requires version %s, but current version is %s", p.Name, manifest.MinVersion, Version)
+	}
+	if err := checkManifestEntries(manifest.Commands); err != nil {
+		return fmt.Errorf("plugin %s: %v", p.Name, err)
+	}
+	p.Manifest = manifest
+	return nil
+}
+
+func checkManifestEntries(entries []PluginManifestEntry) error {
+	names := map[string]bool{}
+	for _, entry := range entries {
+		for _, name := range append([]string{entry.Name}, entry.Aliases...) {
+			if !IsValidCommandName(name) {
+				return fmt.Errorf("illegal command name `%s`", name)
+			}
+			if names[name] {
+				return fmt.Errorf("repeat command `%s`", name)
+			}
+			names[name] = true
+		}
+		if err := checkManifestEntries(entry.Commands); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Command returns a command which runs the plugin with native args,
+// commands declared in manifest are registered as children
+func (p *Plugin) Command(desc string) *Command {
+	if desc == "" && p.Manifest != nil {
+		desc = p.Manifest.Desc
+	}
+	cmd := p.command(p.Name, desc, nil)
+	if p.Manifest != nil {
+		p.registerEntries(cmd, p.Manifest.Commands, nil)
+	}
+	return cmd
+}
+
+func (p *Plugin) command(name, desc string, router []string) *Command {
+	return &Command{
+		Name:        name,
+		Desc:        desc,
+		CanSubRoute: true,
+		Fn: func(ctx *Context) error {
+			return p.Exec(ctx, append(append([]string{}, router...), ctx.NativeArgs()...))
+		},
+	}
+}
+
+func (p *Plugin) registerEntries(parent *Command, entries []PluginManifestEntry, router []string) {
+	for _, entry := range entries {
+		childRouter := append(append([]string{}, router...), entry.Name)
+		child := p.command(entry.Name, entry.Desc, childRouter)
+		child.Aliases = entry.Aliases
+		parent.Register(child)
+		p.registerEntries(child, entry.Commands, childRouter)
+	}
+}
+
+// Manifest creates manifest of plugin from command tree
+func (cmd *Command) Manifest(version, minVersion string) *PluginManifest {
+	return &PluginManifest{
+		Name:       cmd.Name,
+		Version:    version,
+		Desc:       cmd.Desc,
+		MinVersion: minVersion,
+		Commands:   cmd.manifestEntries(),
+	}
+}
+
+func (cmd *Command) manifestEntries() []PluginManifestEntry {
+	entries := []PluginManifestEntry{}
+	for _, child := range cmd.getChildren() {
+		if child.isHidden() {
+			continue
+		}
+		entries = append(entries, PluginManifestEntry{
+			Name:     child.Name,
+			Aliases:  child.Aliases,
+			Desc:     child.Desc,
+			Commands: child.manifestEntries(),
+		})
+	}
+	return entries
+}
+
+// ManifestCommand returns a hidden command which prints manifest of its parent,
+// plugin registers it to root command for handshaking with host app
+func ManifestCommand(version, minVersion string) *Command {
+	return &Command{
+		Name:   ManifestCommandName,
+		Hidden: true,
+		NoHook: true,
+		Fn: func(ctx *Context) error {
+			ctx.JSONln(ctx.Command().Parent().Manifest(version, minVersion))
+			return nil
+		},
+	}
+}
+
+// compareVersion compares dotted versions like "v1.2.3"
+func compareVersion(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writePlugin(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, name)
+	require.Nil(t, ioutil.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	return path
+}
+
+func TestReadChecksums(t *testing.T) {
+	checksums, err := ReadChecksums(strings.NewReader("# comment\nABCD  app-foo\n\nef01 *app-bar\n"))
+	require.Nil(t, err)
+	assert.Equal(t, checksums, map[string]string{"app-foo": "abcd", "app-bar": "ef01"})
+
+	_, err = ReadChecksums(strings.NewReader("abcd\n"))
+	assert.Error(t, err)
+}
+
+func TestSandboxVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := writePlugin(t, dir, "app-foo", "echo foo")
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	sum := sha256.Sum256(data)
+
+	assert.Nil(t, (*Sandbox)(nil).Verify(path))
+	assert.Nil(t, (&Sandbox{}).Verify(path))
+	assert.Nil(t, (&Sandbox{Checksums: map[string]string{"app-foo": hex.EncodeToString(sum[:])}}).Verify(path))
+	assert.Error(t, (&Sandbox{Checksums: map[string]string{"app-foo": "abcd"}}).Verify(path))
+	assert.Error(t, (&Sandbox{Checksums: map[string]string{}}).Verify(path))
+}
+
+func TestSandboxCommandVerifiedCopy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path := writePlugin(t, dir, "app-foo", `echo foo "$0"`)
+	data, err := ioutil.ReadFile(path)
+	require.Nil(t, err)
+	sum := sha256.Sum256(data)
+	sandbox := &Sandbox{Checksums: map[string]string{"app-foo": hex.EncodeToString(sum[:])}}
+
+	// plugin replaced after verified is not run
+	cmd, cleanup, err := sandbox.command(context.Background(), path)
+	require.Nil(t, err)
+	writePlugin(t, dir, "app-foo", "echo bar")
+	out, err := cmd.Output()
+	require.Nil(t, err)
+	assert.Equal(t, string(out), "foo "+cmd.Path+"\n")
+	assert.NotEqual(t, cmd.Path, path)
+	assert.Equal(t, cmd.Args[0], path)
+	cleanup()
+	_, err = os.Stat(cmd.Path)
+	assert.True(t, os.IsNotExist(err))
+
+	_, _, err = sandbox.command(context.Background(), path)
+	assert.Error(t, err)
+}
+
+func TestPluginExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	os.Setenv("CLI_PLUGIN_TEST", "inherited")
+
+	plugin := &Plugin{
+		Name: "foo",
+		Path: writePlugin(t, dir, "app-foo", `echo "$@" "$CLI_PLUGIN_TEST" "$EXTRA" "$PATH"`),
+	}
+	root := &Command{Name: "app"}
+	root.Register(plugin.Command("foo plugin"))
+
+	w := bytes.NewBufferString("")
+	assert.Nil(t, root.RunWith([]string{"foo", "bar", "-x", "--help"}, w, nil))
+	assert.Equal(t, w.String(), "bar -x --help inherited  "+os.Getenv("PATH")+"\n")
+
+	plugin.Sandbox = &Sandbox{CleanEnv: true, Env: []string{"EXTRA=extra"}, Path: []string{"/bin", "/usr/bin"}}
+	w.Reset()
+	assert.Nil(t, root.RunWith([]string{"foo"}, w, nil))
+	assert.Equal(t, w.String(), " extra /bin:/usr/bin\n")
+}
+
+func TestPluginExecWithContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	plugin := &Plugin{Name: "foo", Path: writePlugin(t, dir, "app-foo", "read line\necho \"got $line\"\nexec sleep \"$1\"")}
+	root := &Command{Name: "app"}
+	foo := root.Register(plugin.Command("foo plugin"))
+
+	w := bytes.NewBufferString("")
+	assert.Nil(t, root.RunWithIO([]string{"foo", "0"}, strings.NewReader("input\n"), w))
+	assert.Equal(t, w.String(), "got input\n")
+
+	foo.Timeout = 50 * time.Millisecond
+	start := time.Now()
+	err = root.RunWithIO([]string{"foo", "5"}, strings.NewReader("input\n"), ioutil.Discard)
+	assert.True(t, IsTimeout(err))
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestCompareVersion(t *testing.T) {
+	assert.Equal(t, compareVersion("0.0.2", "0.0.2"), 0)
+	assert.Equal(t, compareVersion("v0.0.2", "0.0.10"), -1)
+	assert.Equal(t, compareVersion("1.0", "0.9.9"), 1)
+	assert.Equal(t, compareVersion("1", "1.0.0"), 0)
+}
+
+func TestPluginManifest(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	app := &Command{Name: "app-foo", Desc: "foo plugin"}
+	app.Register(&Command{Name: "get", Desc: "get something", Aliases: []string{"g"}})
+	app.Register(ManifestCommand("1.2.0", "0.0.1"))
+	w := bytes.NewBufferString("")
+	assert.Nil(t, app.RunWith([]string{ManifestCommandName}, w, nil))
+	assert.Equal(t, w.String(), `{"name":"app-foo","version":"1.2.0","desc":"foo plugin","minFrameworkVersion":"0.0.1","commands":[{"name":"get","aliases":["g"],"desc":"get something"}]}`+"\n")
+
+	script := "if [ \"$1\" = " + ManifestCommandName + " ]; then\n  echo '" + strings.TrimSpace(w.String()) + "'\nelse\n  echo \"$@\"\nfi"
+	plugin := &Plugin{Name: "foo", Path: writePlugin(t, dir, "app-foo", script)}
+	require.Nil(t, plugin.LoadManifest())
+	assert.Equal(t, plugin.Manifest.Version, "1.2.0")
+
+	defer func(d time.Duration) { ManifestTimeout = d }(ManifestTimeout)
+	ManifestTimeout = 50 * time.Millisecond
+	hung := &Plugin{Name: "bar", Path: writePlugin(t, dir, "app-bar", "exec sleep 5")}
+	start := time.Now()
+	assert.Error(t, hung.LoadManifest())
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	root := &Command{Name: "app"}
+	foo := root.Register(plugin.Command(""))
+	assert.Equal(t, foo.Desc, "foo plugin")
+	assert.Equal(t, foo.ChildrenDescriptions("", " "), "get get something(aliases g)\n")
+
+	w.Reset()
+	assert.Nil(t, root.RunWith([]string{"foo", "g", "-x"}, w, nil))
+	assert.Equal(t, w.String(), "get -x\n")
+
+	plugin = &Plugin{Name: "bar", Path: writePlugin(t, dir, "app-bar", `echo '{"name":"bar","minFrameworkVersion":"99.0"}'`)}
+	assert.Error(t, plugin.LoadManifest())
+	plugin = &Plugin{Name: "baz", Path: writePlugin(t, dir, "app-baz", `echo '{"name":"baz","commands":[{"name":"-x"}]}'`)}
+	assert.Error(t, plugin.LoadManifest())
+}
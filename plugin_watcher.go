@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPluginWatchInterval is default interval for polling plugin directory
+const DefaultPluginWatchInterval = 2 * time.Second
+
+// ScanPlugins finds executables named `<prefix><name>` in dir and loads their manifests,
+// plugins which fail to handshake are skipped and reported by returned errors
+func ScanPlugins(dir, prefix string, sandbox *Sandbox) ([]*Plugin, []error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, []error{err}
+	}
+	var (
+		plugins = []*Plugin{}
+		errs    = []error{}
+	)
+	for _, info := range infos {
+		name, ok := pluginName(info, prefix)
+		if !ok {
+			continue
+		}
+		plugin := &Plugin{
+			Name:    name,
+			Path:    filepath.Join(dir, info.Name()),
+			Sandbox: sandbox,
+		}
+		if err := plugin.LoadManifest(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, errs
+}
+
+// pluginName returns name of plugin if info is an executable named `<prefix><name>`
+func pluginName(info os.FileInfo, prefix string) (string, bool) {
+	if !info.Mode().IsRegular() || !strings.HasPrefix(info.Name(), prefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(info.Name(), prefix)
+	if runtime.GOOS == "windows" {
+		if !strings.EqualFold(filepath.Ext(name), ".exe") {
+			return "", false
+		}
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	} else if info.Mode().Perm()&0111 == 0 {
+		return "", false
+	}
+	return name, IsValidCommandName(name)
+}
+
+// PluginWatcher watches a plugin directory and mounts plugins to a command,
+// mounted plugins are registered, replaced or unregistered like Register, Replace
+// and Unregister while plugins changed
+type PluginWatcher struct {
+	Dir      string        // Plugin directory
+	Prefix   string        // Prefix of plugin executable name, e.g. "app-"
+	Sandbox  *Sandbox      // Sandbox for running plugins
+	Interval time.Duration // Polling interval, DefaultPluginWatchInterval used if zero
+	OnError  func(error)   // Callback for errors while scanning plugins
+
+	locker   sync.Mutex // protect following data
+	cmd      *Command
+	snapshot map[string]string
+	mounted  []*Command
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// WatchPlugins mounts plugins found by watcher as children of cmd and reloads them
+// while plugin directory changed, it's designed for long-running server or daemon mode.
+// Call Stop to stop watching.
+func (cmd *Command) WatchPlugins(watcher *PluginWatcher) *PluginWatcher {
+	watcher.cmd = cmd
+	watcher.stop = make(chan struct{})
+	watcher.Reload()
+	go watcher.loop()
+	return watcher
+}
+
+// Stop stops watching
+func (watcher *PluginWatcher) Stop() {
+	watcher.stopOnce.Do(func() { close(watcher.stop) })
+}
+
+func (watcher *PluginWatcher) loop() {
+	interval := watcher.Interval
+	if interval <= 0 {
+		interval = DefaultPluginWatchInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-watcher.stop:
+			return
+		case <-ticker.C:
+			watcher.locker.Lock()
+			if watcher.takeSnapshot() {
+				watcher.reload()
+			}
+			watcher.locker.Unlock()
+		}
+	}
+}
+
+// takeSnapshot records modification state of plugin directory, returns true if changed
+func (watcher *PluginWatcher) takeSnapshot() bool {
+	infos, err := ioutil.ReadDir(watcher.Dir)
+	if err != nil {
+		watcher.onError(err)
+		return false
+	}
+	snapshot := make(map[string]string)
+	for _, info := range infos {
+		if _, ok := pluginName(info, watcher.Prefix); ok {
+			snapshot[info.Name()] = fmt.Sprintf("%d-%d-%v", info.Size(), info.ModTime().UnixNano(), info.Mode())
+		}
+	}
+	changed := len(snapshot) != len(watcher.snapshot)
+	for name, state := range snapshot {
+		if watcher.snapshot[name] != state {
+			changed = true
+		}
+	}
+	watcher.snapshot = snapshot
+	return changed
+}
+
+// Reload rescans plugin directory and swaps mounted plugins
+func (watcher *PluginWatcher) Reload() {
+	watcher.locker.Lock()
+	defer watcher.locker.Unlock()
+	watcher.takeSnapshot()
+	watcher.reload()
+}
+
+func (watcher *PluginWatcher) reload() {
+	plugins, errs := ScanPlugins(watcher.Dir, watcher.Prefix, watcher.Sandbox)
+	for _, err := range errs {
+		watcher.onError(err)
+	}
+
+	cmd := watcher.cmd
+	cmd.registerLocker.Lock()
+	defer cmd.registerLocker.Unlock()
+	var (
+		mounted  = make([]*Command, 0, len(plugins))
+		replaced = map[*Command]bool{}
+	)
+	for _, plugin := range plugins {
+		child := plugin.Command("")
+		if err := cmd.checkNewChild(child); err != nil {
+			watcher.onError(err)
+			continue
+		}
+		old := watcher.mountedChild(plugin.Name)
+		if err := cmd.replaceChild(old, child); err != nil {
+			watcher.onError(fmt.Errorf("plugin %s conflicts with command of `%s`", plugin.Name, cmd.Name))
+			continue
+		}
+		replaced[old] = true
+		mounted = append(mounted, child)
+	}
+	for _, child := range watcher.mounted {
+		if !replaced[child] {
+			cmd.unregisterChild(child)
+		}
+	}
+	watcher.mounted = mounted
+}
+
+// mountedChild returns mounted plugin command named name, nil returned if not found
+func (watcher *PluginWatcher) mountedChild(name string) *Command {
+	for _, mounted := range watcher.mounted {
+		if mounted.Name == name {
+			return mounted
+		}
+	}
+	return nil
+}
+
+func (watcher *PluginWatcher) onError(err error) {
+	if watcher.OnError != nil {
+		watcher.OnError(err)
+	}
+}
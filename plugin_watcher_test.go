@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchPlugins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell script plugin not supported on windows")
+	}
+	dir, err := ioutil.TempDir("", "cli-plugin")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	manifestScript := func(manifest string) string {
+		return "if [ \"$1\" = " + ManifestCommandName + " ]; then echo '" + manifest + "'; fi"
+	}
+	writePlugin(t, dir, "app-foo", manifestScript(`{"name":"foo","commands":[{"name":"get"}]}`))
+	writePlugin(t, dir, "app-help", manifestScript(`{"name":"help"}`))
+	writePlugin(t, dir, "app-bad", "exit 1")
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "app-data"), []byte("data"), 0644))
+
+	root := &Command{Name: "app"}
+	root.Register(HelpCommand("display help"))
+	errs := make(chan error, 16)
+	watcher := root.WatchPlugins(&PluginWatcher{
+		Dir:      dir,
+		Prefix:   "app-",
+		Interval: 10 * time.Millisecond,
+		OnError:  func(err error) { errs <- err },
+	})
+	defer watcher.Stop()
+
+	assert.Equal(t, root.ListChildren(), []string{"help", "foo"})
+	assert.NotNil(t, root.Route([]string{"foo", "get"}))
+	assert.Len(t, errs, 2) // app-bad and app-help
+
+	waitFor := func(cond func() bool) bool {
+		for i := 0; i < 200; i++ {
+			if cond() {
+				return true
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return false
+	}
+	writePlugin(t, dir, "app-foo", manifestScript(`{"name":"foo","commands":[{"name":"get"},{"name":"set"}]}`))
+	os.Chtimes(filepath.Join(dir, "app-foo"), time.Now(), time.Now().Add(time.Hour))
+	assert.True(t, waitFor(func() bool { return root.Route([]string{"foo", "set"}) != nil }))
+	assert.Equal(t, root.ListChildren(), []string{"help", "foo"})
+
+	require.Nil(t, os.Remove(filepath.Join(dir, "app-foo")))
+	assert.True(t, waitFor(func() bool { return root.Route([]string{"foo"}) == nil }))
+	assert.Equal(t, root.ListChildren(), []string{"help"})
+
+	root.Register(&Command{Name: "version", Aliases: []string{"bar"}})
+	writePlugin(t, dir, "app-bar", manifestScript(`{"name":"bar"}`))
+	watcher.Reload()
+	assert.Equal(t, root.ListChildren(), []string{"help", "version"})
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-isatty"
+)
+
+// ProgressOutput is the stream to which progress bars and spinners are written
+var ProgressOutput io.Writer = os.Stderr
+
+// isTerminalWriter reports whether w is a terminal
+func isTerminalWriter(w io.Writer) bool {
+	file, ok := writerFile(w)
+	return ok && isatty.IsTerminal(file.Fd())
+}
+
+const progressBarWidth = 30
+
+// ProgressBar renders progress of an operation, it's redrawn in place on terminal,
+// and written as a line per Interval otherwise. ProgressBar implements io.Writer
+// which adds length of written data to progress, e.g.
+//
+//	bar := ctx.ProgressBar(size)
+//	defer bar.Finish()
+//	io.Copy(file, io.TeeReader(resp.Body, bar))
+type ProgressBar struct {
+	Interval time.Duration // Interval of writing lines if not on terminal, 1s by default
+
+	ctx      *Context
+	out      io.Writer
+	tty      bool
+	locker   sync.Mutex // protect following data
+	total    int64
+	current  int64
+	start    time.Time
+	lastDraw time.Time
+	finished bool
+	message  string // message of progress events
+	emitted  bool   // progress event of current emitted
+}
+
+// ProgressBar creates a progress bar with total, total <= 0 means unknown.
+// Progress is emitted as progress events too, see EmitProgress.
+func (ctx *Context) ProgressBar(total int64) *ProgressBar {
+	out := ctx.stderr(ProgressOutput)
+	return &ProgressBar{
+		Interval: time.Second,
+		ctx:      ctx,
+		out:      out,
+		tty:      isTerminalWriter(out),
+		total:    total,
+		start:    time.Now(),
+	}
+}
+
+// Write implements io.Writer
+func (bar *ProgressBar) Write(p []byte) (int, error) {
+	bar.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Add adds n to progress
+func (bar *ProgressBar) Add(n int64) {
+	bar.locker.Lock()
+	defer bar.locker.Unlock()
+	bar.current += n
+	bar.emitted = false
+	bar.update(false)
+}
+
+// Set sets progress to current
+func (bar *ProgressBar) Set(current int64) {
+	bar.locker.Lock()
+	defer bar.locker.Unlock()
+	bar.current = current
+	bar.emitted = false
+	bar.update(false)
+}
+
+// step sets progress to current, message is carried by progress event
+func (bar *ProgressBar) step(current int64, message string) {
+	bar.locker.Lock()
+	defer bar.locker.Unlock()
+	bar.current = current
+	bar.message = message
+	bar.emitted = false
+	bar.update(false)
+}
+
+// Finish draws progress finally and ends the line on terminal,
+// it's safe to call Finish more than once
+func (bar *ProgressBar) Finish() {
+	bar.locker.Lock()
+	defer bar.locker.Unlock()
+	if bar.finished {
+		return
+	}
+	bar.update(true)
+	bar.finished = true
+	if bar.tty {
+		fmt.Fprint(bar.out, "\n")
+	}
+}
+
+func (bar *ProgressBar) update(force bool) {
+	if bar.finished {
+		return
+	}
+	now := time.Now()
+	if !force && !bar.tty && now.Sub(bar.lastDraw) < bar.Interval {
+		return
+	}
+	bar.lastDraw = now
+	line := formatProgress(bar.current, bar.total, now.Sub(bar.start))
+	if bar.tty {
+		fmt.Fprint(bar.out, "\r\033[K"+line)
+	} else {
+		fmt.Fprintln(bar.out, line)
+	}
+	if !bar.emitted {
+		bar.emitted = true
+		bar.ctx.EmitProgress(bar.current, bar.total, bar.message)
+	}
+}
+
+// formatProgress returns a line like `[=========>          ]  45% 450/1000 1.2s`
+func formatProgress(current, total int64, elapsed time.Duration) string {
+	if total <= 0 {
+		return fmt.Sprintf("%d %s", current, formatElapsed(elapsed))
+	}
+	ratio := float64(current) / float64(total)
+	if ratio > 1 {
+		ratio = 1
+	} else if ratio < 0 {
+		ratio = 0
+	}
+	filled := int(ratio * progressBarWidth)
+	bar := strings.Repeat("=", filled)
+	if filled < progressBarWidth {
+		bar += ">" + strings.Repeat(" ", progressBarWidth-filled-1)
+	}
+	return fmt.Sprintf("[%s] %3d%% %d/%d %s", bar, int(ratio*100), current, total, formatElapsed(elapsed))
+}
@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatProgress(t *testing.T) {
+	assert.Equal(t, formatProgress(0, 100, 0), "[>                             ]   0% 0/100 0s")
+	assert.Equal(t, formatProgress(50, 100, time.Second), "[===============>              ]  50% 50/100 1s")
+	assert.Equal(t, formatProgress(120, 100, time.Second), "[==============================] 100% 120/100 1s")
+	assert.Equal(t, formatProgress(42, 0, time.Second), "42 1s")
+}
+
+func TestProgressBar(t *testing.T) {
+	defer func(w io.Writer) { ProgressOutput = w }(ProgressOutput)
+	w := new(bytes.Buffer)
+	ProgressOutput = w
+
+	bar := (&Context{}).ProgressBar(10)
+	bar.Interval = time.Hour
+	_, err := io.Copy(bar, strings.NewReader("hello"))
+	assert.Nil(t, err)
+	bar.Add(5)
+	bar.Finish()
+	bar.Finish()
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+	assert.True(t, strings.HasPrefix(lines[0], "[===============>              ]  50% 5/10"))
+	assert.True(t, strings.HasPrefix(lines[1], "[==============================] 100% 10/10"))
+
+	// redrawn in place on terminal
+	w.Reset()
+	bar = (&Context{}).ProgressBar(2)
+	bar.tty = true
+	bar.step(1, "create a")
+	bar.step(2, "create b")
+	bar.Finish()
+	assert.Equal(t, strings.Count(w.String(), "\r\033[K"), 3)
+	assert.True(t, strings.HasSuffix(w.String(), "\n"))
+	assert.Equal(t, bar.message, "create b")
+	assert.True(t, bar.emitted)
+}
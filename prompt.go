@@ -0,0 +1,198 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+)
+
+type (
+	// Confirmer represents interface for bypassing confirmations
+	Confirmer interface {
+		AssumeYes() bool
+	}
+
+	// YesHelper is builtin yes flag
+	YesHelper struct {
+		Yes bool `cli:"y,yes" usage:"assume yes for confirmations and defaults for questions" json:"-"`
+	}
+)
+
+// AssumeYes implements Confirmer interface
+func (h YesHelper) AssumeYes() bool {
+	return h.Yes
+}
+
+var (
+	// PromptInput is input of Ask, AskSecret and Confirm
+	PromptInput io.Reader = os.Stdin
+	// PromptOutput is output for writing questions
+	PromptOutput io.Writer = os.Stderr
+)
+
+var errNotInteractive = errors.New("stdin is not a terminal")
+
+func (ctx *Context) assumeYes() bool {
+	for _, argv := range ctx.allArgvs() {
+		if confirmer, ok := argv.(Confirmer); ok && confirmer.AssumeYes() {
+			return true
+		}
+	}
+	return false
+}
+
+// promptInput returns input of prompts, reader of ctx is used instead of PromptInput
+// if ctx is isolated
+func (ctx *Context) promptInput() io.Reader {
+	if !ctx.isolated() {
+		return PromptInput
+	}
+	if ctx.reader == nil {
+		return strings.NewReader("")
+	}
+	return ctx.reader
+}
+
+// promptOutput returns output of prompts, diagnostics writer of ctx is used instead
+// of PromptOutput if ctx is isolated
+func (ctx *Context) promptOutput() io.Writer {
+	if !ctx.isolated() {
+		return PromptOutput
+	}
+	return ctx.stderr(ioutil.Discard)
+}
+
+// promptReader returns buffered prompt input, it's shared by prompts of ctx so that
+// input read ahead by a prompt is left for following ones, e.g. piped answers
+func (ctx *Context) promptReader() *bufio.Reader {
+	input := ctx.promptInput()
+	if ctx.promptBuffer == nil || ctx.promptSource != input {
+		ctx.promptBuffer = bufio.NewReader(input)
+		ctx.promptSource = input
+	}
+	return ctx.promptBuffer
+}
+
+// promptTerminal returns fd of prompt input if it's a terminal
+func (ctx *Context) promptTerminal() (int, bool) {
+	file, ok := ctx.promptInput().(*os.File)
+	if !ok || !isatty.IsTerminal(file.Fd()) {
+		return -1, false
+	}
+	return int(file.Fd()), true
+}
+
+// Ask asks a question and returns the answer, dft returned if answer is empty.
+// It returns dft without asking if stdin is not a terminal or argv implements
+// Confirmer and AssumeYes returns true.
+func (ctx *Context) Ask(prompt, dft string) (string, error) {
+	if _, ok := ctx.promptTerminal(); !ok || ctx.assumeYes() {
+		return dft, nil
+	}
+	return ask(ctx.promptReader(), ctx.promptOutput(), prompt, dft)
+}
+
+// AskSecret asks a question and reads answer without echo, e.g. password.
+// It returns an error if stdin is not a terminal.
+func (ctx *Context) AskSecret(prompt string) (string, error) {
+	fd, ok := ctx.promptTerminal()
+	if !ok {
+		return "", fmt.Errorf("%s: %v", prompt, errNotInteractive)
+	}
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+	fmt.Fprint(ctx.promptOutput(), prompt+": ")
+	secret, err := readSecret(ctx.promptReader())
+	fmt.Fprint(ctx.promptOutput(), "\r\n")
+	return secret, err
+}
+
+// Confirm asks a yes/no question, the default answer is no.
+// It returns true without asking if argv implements Confirmer and AssumeYes returns true,
+// or an error if stdin is not a terminal.
+func (ctx *Context) Confirm(prompt string) (bool, error) {
+	if ctx.assumeYes() {
+		return true, nil
+	}
+	if _, ok := ctx.promptTerminal(); !ok {
+		return false, fmt.Errorf("%s: %v", prompt, errNotInteractive)
+	}
+	return confirm(ctx.promptReader(), ctx.promptOutput(), prompt)
+}
+
+func readAnswer(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err == io.EOF && line != "" {
+		err = nil
+	}
+	return strings.TrimSpace(line), err
+}
+
+func ask(r *bufio.Reader, w io.Writer, prompt, dft string) (string, error) {
+	if dft != "" {
+		fmt.Fprintf(w, "%s [%s]: ", prompt, dft)
+	} else {
+		fmt.Fprintf(w, "%s: ", prompt)
+	}
+	answer, err := readAnswer(r)
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return dft, nil
+	}
+	return answer, nil
+}
+
+func confirm(r *bufio.Reader, w io.Writer, prompt string) (bool, error) {
+	for {
+		fmt.Fprintf(w, "%s [y/N]: ", prompt)
+		answer, err := readAnswer(r)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "", "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// readSecret reads a line from terminal in raw mode, Ctrl-C or Ctrl-D cancels.
+func readSecret(r *bufio.Reader) (string, error) {
+	var buf []rune
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				return string(buf), nil
+			}
+			return "", err
+		}
+		switch c {
+		case '\r', '\n':
+			return string(buf), nil
+		case 3, 4: // Ctrl-C, Ctrl-D
+			return "", errors.New("canceled")
+		case 127, 8: // Backspace
+			if len(buf) > 0 {
+				buf = buf[:len(buf)-1]
+			}
+		case 21: // Ctrl-U
+			buf = buf[:0]
+		default:
+			buf = append(buf, c)
+		}
+	}
+}
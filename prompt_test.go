@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsk(t *testing.T) {
+	w := new(bytes.Buffer)
+	r := bufio.NewReader(strings.NewReader("\n Jack \nlast"))
+	answer, err := ask(r, w, "name", "nobody")
+	require.Nil(t, err)
+	assert.Equal(t, answer, "nobody")
+	answer, err = ask(r, w, "name", "")
+	require.Nil(t, err)
+	assert.Equal(t, answer, "Jack")
+	answer, err = ask(r, w, "name", "")
+	require.Nil(t, err)
+	assert.Equal(t, answer, "last")
+	_, err = ask(r, w, "name", "")
+	assert.Error(t, err)
+	assert.Equal(t, w.String(), "name [nobody]: name: name: name: ")
+
+	r = bufio.NewReader(strings.NewReader("what\nY\n\nno\n"))
+	for _, want := range []bool{true, false, false} {
+		yes, err := confirm(r, w, "sure?")
+		require.Nil(t, err)
+		assert.Equal(t, yes, want)
+	}
+
+	r = bufio.NewReader(strings.NewReader("s3\x7fcx\x15secret\r\x03"))
+	secret, err := readSecret(r)
+	require.Nil(t, err)
+	assert.Equal(t, secret, "secret")
+	_, err = readSecret(r)
+	assert.Error(t, err)
+}
+
+func TestContextPrompt(t *testing.T) {
+	defer func(r io.Reader) { PromptInput = r }(PromptInput)
+	PromptInput = strings.NewReader("ignored\n")
+
+	type argT struct {
+		YesHelper
+	}
+	ctx := &Context{argvList: []interface{}{new(argT)}}
+	answer, err := ctx.Ask("name", "nobody")
+	assert.Nil(t, err)
+	assert.Equal(t, answer, "nobody")
+	_, err = ctx.Confirm("sure?")
+	assert.Error(t, err)
+	_, err = ctx.AskSecret("password")
+	assert.Error(t, err)
+
+	ctx.argvList[0].(*argT).Yes = true
+	yes, err := ctx.Confirm("sure?")
+	assert.Nil(t, err)
+	assert.True(t, yes)
+}
@@ -0,0 +1,280 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// maxHistory is max number of lines loaded from history file
+const maxHistory = 1000
+
+// lineEditor reads lines with history and tab completion from a terminal in raw mode
+type lineEditor struct {
+	r        *bufio.Reader
+	w        io.Writer
+	fd       int // fd of terminal, -1 if raw mode not required
+	prompt   string
+	history  []string
+	complete func(line string) []string
+}
+
+func newLineEditor(r io.Reader, w io.Writer, fd int, prompt string) *lineEditor {
+	return &lineEditor{
+		r:      bufio.NewReader(r),
+		w:      w,
+		fd:     fd,
+		prompt: prompt,
+	}
+}
+
+// loadHistory reads history from filename, the last maxHistory lines kept
+func (e *lineEditor) loadHistory(filename string) error {
+	data, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			e.history = append(e.history, line)
+		}
+	}
+	if len(e.history) > maxHistory {
+		e.history = e.history[len(e.history)-maxHistory:]
+	}
+	return nil
+}
+
+// addHistory appends line to history and history file if filename not empty,
+// history file is locked by LockFile while appending since REPLs of the same
+// program may share it
+func (e *lineEditor) addHistory(line, filename string) error {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return nil
+	}
+	e.history = append(e.history, line)
+	if filename == "" {
+		return nil
+	}
+	unlock, err := LockFile(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintln(file, line)
+	return err
+}
+
+// readLine reads a line, Ctrl-C discards current line, Ctrl-D on empty line returns io.EOF
+func (e *lineEditor) readLine() (string, error) {
+	if e.fd >= 0 {
+		restore, err := makeRaw(e.fd)
+		if err != nil {
+			return "", err
+		}
+		defer restore()
+	}
+
+	var (
+		buf       []rune
+		pos       int
+		histIndex = len(e.history)
+		pending   string // editing line while navigating history
+	)
+	refresh := func() {
+		fmt.Fprintf(e.w, "\r%s%s\x1b[K", e.prompt, string(buf))
+		if n := len(buf) - pos; n > 0 {
+			fmt.Fprintf(e.w, "\x1b[%dD", n)
+		}
+	}
+	setLine := func(s string) {
+		buf = []rune(s)
+		pos = len(buf)
+	}
+	insert := func(s string) {
+		rs := []rune(s)
+		buf = append(buf[:pos], append(rs, buf[pos:]...)...)
+		pos += len(rs)
+	}
+	historyMove := func(delta int) {
+		index := histIndex + delta
+		if index < 0 || index > len(e.history) {
+			return
+		}
+		if histIndex == len(e.history) {
+			pending = string(buf)
+		}
+		histIndex = index
+		if index == len(e.history) {
+			setLine(pending)
+		} else {
+			setLine(e.history[index])
+		}
+	}
+
+	fmt.Fprint(e.w, e.prompt)
+	for {
+		r, _, err := e.r.ReadRune()
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				fmt.Fprint(e.w, "\r\n")
+				return string(buf), nil
+			}
+			return "", err
+		}
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.w, "\r\n")
+			return string(buf), nil
+		case 3: // Ctrl-C
+			fmt.Fprint(e.w, "^C\r\n")
+			return "", nil
+		case 4: // Ctrl-D
+			if len(buf) == 0 {
+				fmt.Fprint(e.w, "\r\n")
+				return "", io.EOF
+			}
+			if pos < len(buf) {
+				buf = append(buf[:pos], buf[pos+1:]...)
+			}
+		case 127, 8: // Backspace
+			if pos > 0 {
+				buf = append(buf[:pos-1], buf[pos:]...)
+				pos--
+			}
+		case 1: // Ctrl-A
+			pos = 0
+		case 5: // Ctrl-E
+			pos = len(buf)
+		case 2: // Ctrl-B
+			if pos > 0 {
+				pos--
+			}
+		case 6: // Ctrl-F
+			if pos < len(buf) {
+				pos++
+			}
+		case 11: // Ctrl-K
+			buf = buf[:pos]
+		case 21: // Ctrl-U
+			buf = buf[pos:]
+			pos = 0
+		case 16: // Ctrl-P
+			historyMove(-1)
+		case 14: // Ctrl-N
+			historyMove(1)
+		case '\t':
+			e.completeLine(&buf, &pos, insert)
+		case 27: // escape sequence
+			switch readEscape(e.r) {
+			case 'A':
+				historyMove(-1)
+			case 'B':
+				historyMove(1)
+			case 'C':
+				if pos < len(buf) {
+					pos++
+				}
+			case 'D':
+				if pos > 0 {
+					pos--
+				}
+			case 'H':
+				pos = 0
+			case 'F':
+				pos = len(buf)
+			case '~': // Delete
+				if pos < len(buf) {
+					buf = append(buf[:pos], buf[pos+1:]...)
+				}
+			}
+		default:
+			if unicode.IsPrint(r) {
+				insert(string(r))
+			}
+		}
+		refresh()
+	}
+}
+
+// readEscape reads rest of an escape sequence and returns its final key,
+// e.g. `ESC [ A` => 'A', `ESC [ 3 ~` => '~', `ESC [ 1 ~` => 'H', `ESC [ 4 ~` => 'F'
+func readEscape(reader *bufio.Reader) rune {
+	r, _, err := reader.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return 0
+	}
+	var digits []rune
+	for {
+		r, _, err = reader.ReadRune()
+		if err != nil {
+			return 0
+		}
+		if r < '0' || r > '9' {
+			break
+		}
+		digits = append(digits, r)
+	}
+	if r == '~' {
+		switch string(digits) {
+		case "1", "7":
+			return 'H'
+		case "4", "8":
+			return 'F'
+		case "3":
+			return '~'
+		}
+		return 0
+	}
+	return r
+}
+
+// completeLine completes the word before cursor: the only candidate replaces the word,
+// common prefix of candidates is inserted, or candidates are listed
+func (e *lineEditor) completeLine(buf *[]rune, pos *int, insert func(string)) {
+	if e.complete == nil {
+		return
+	}
+	line := string((*buf)[:*pos])
+	candidates := e.complete(line)
+	if len(candidates) == 0 {
+		fmt.Fprint(e.w, "\a")
+		return
+	}
+	word := line[strings.LastIndexAny(line, " \t")+1:]
+	if len(candidates) == 1 {
+		insert(strings.TrimPrefix(candidates[0], word) + " ")
+		return
+	}
+	prefix := commonPrefix(candidates)
+	if len(prefix) > len(word) && strings.HasPrefix(prefix, word) {
+		insert(prefix[len(word):])
+		return
+	}
+	fmt.Fprintf(e.w, "\r\n%s\r\n", strings.Join(candidates, "  "))
+}
+
+func commonPrefix(list []string) string {
+	if len(list) == 0 {
+		return ""
+	}
+	prefix := []rune(list[0])
+	for _, s := range list[1:] {
+		for !strings.HasPrefix(s, string(prefix)) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return string(prefix)
+}
@@ -0,0 +1,108 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLineEditor(t *testing.T) {
+	out := new(bytes.Buffer)
+	input := strings.Join([]string{
+		"helo\x02\x02l\r",           // Ctrl-B twice then insert
+		"abc\x01x\x05y\r",           // Ctrl-A, Ctrl-E
+		"abc\x7f\x7fd\r",            // backspace
+		"hello world\x02\x02\x0b\r", // Ctrl-K
+		"drop\x03",                  // Ctrl-C
+		"foo\x1b[D\x1b[D\x1b[3~\r",  // arrows and delete
+		"\x1b[A\x1b[A\r",            // history up
+		"x\x1b[A\x1b[B\r",           // history up then down restores editing line
+		"\x04",                      // Ctrl-D on empty line
+	}, "")
+	e := newLineEditor(strings.NewReader(input), out, -1, "> ")
+	e.history = []string{"first", "second"}
+
+	want := []string{"hello", "xabcy", "ad", "hello wor", "", "fo", "first", "x"}
+	for _, line := range want {
+		got, err := e.readLine()
+		require.Nil(t, err)
+		assert.Equal(t, got, line)
+	}
+	_, err := e.readLine()
+	assert.Equal(t, err, io.EOF)
+	assert.True(t, strings.Contains(out.String(), "^C"))
+
+	e = newLineEditor(strings.NewReader("last"), out, -1, "> ")
+	line, err := e.readLine()
+	assert.Nil(t, err)
+	assert.Equal(t, line, "last")
+	_, err = e.readLine()
+	assert.Equal(t, err, io.EOF)
+}
+
+func TestLineEditorComplete(t *testing.T) {
+	out := new(bytes.Buffer)
+	e := newLineEditor(strings.NewReader("he\tw\tx\t\r"), out, -1, "> ")
+	e.complete = func(line string) []string {
+		switch line {
+		case "he":
+			return []string{"hello"}
+		case "hello w":
+			return []string{"world", "worker"}
+		}
+		return nil
+	}
+	line, err := e.readLine()
+	require.Nil(t, err)
+	assert.Equal(t, line, "hello worx")
+	assert.True(t, strings.Contains(out.String(), "\a"))
+
+	out.Reset()
+	e = newLineEditor(strings.NewReader("\t\r"), out, -1, "> ")
+	e.complete = func(string) []string { return []string{"ab", "cd"} }
+	line, err = e.readLine()
+	require.Nil(t, err)
+	assert.Equal(t, line, "")
+	assert.True(t, strings.Contains(out.String(), "ab  cd"))
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-history")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "history")
+
+	e := newLineEditor(strings.NewReader(""), ioutil.Discard, -1, "> ")
+	require.Nil(t, e.loadHistory(filename))
+	assert.Nil(t, e.addHistory("a", filename))
+	assert.Nil(t, e.addHistory("a", filename))
+	assert.Nil(t, e.addHistory("", filename))
+	assert.Nil(t, e.addHistory("b", filename))
+	assert.Equal(t, e.history, []string{"a", "b"})
+
+	e = newLineEditor(strings.NewReader(""), ioutil.Discard, -1, "> ")
+	require.Nil(t, e.loadHistory(filename))
+	assert.Equal(t, e.history, []string{"a", "b"})
+
+	// history file is locked while appending
+	defer func(d time.Duration) { FileLockTimeout = d }(FileLockTimeout)
+	FileLockTimeout = 10 * time.Millisecond
+	unlock, err := LockFile(filename)
+	require.Nil(t, err)
+	assert.Error(t, e.addHistory("c", filename))
+	unlock()
+	assert.Nil(t, e.addHistory("d", filename))
+
+	assert.Equal(t, commonPrefix([]string{"world", "worker"}), "wor")
+	assert.Equal(t, commonPrefix([]string{"ab", "cd"}), "")
+	// runes sharing leading bytes are not split
+	assert.Equal(t, commonPrefix([]string{"café", "cafè"}), "caf")
+}
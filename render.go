@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Output formats supported by Context.Render
+const (
+	OutputFormatTable    = "table"
+	OutputFormatJSON     = "json"
+	OutputFormatYAML     = "yaml"
+	OutputFormatCSV      = "csv"
+	OutputFormatTSV      = "tsv"
+	OutputFormatTemplate = "template" // template=<go template>, e.g. `template={{.Name}}`, see RenderTemplate
+)
+
+type (
+	// OutputFormatter represents interface for choosing output format of Context.Render
+	OutputFormatter interface {
+		Format() string
+	}
+
+	// OutputFormat is builtin output flag
+	OutputFormat struct {
+		Output string `cli:"o,output" usage:"output format: table, json, yaml, csv, tsv or template=<go template>" dft:"table" json:"-"`
+	}
+)
+
+// Format implements OutputFormatter interface
+func (f OutputFormat) Format() string {
+	return f.Output
+}
+
+func (ctx *Context) outputFormat() string {
+	for _, argv := range ctx.allArgvs() {
+		if formatter, ok := argv.(OutputFormatter); ok && formatter.Format() != "" {
+			return formatter.Format()
+		}
+	}
+	return OutputFormatTable
+}
+
+// Render writes obj in output format chosen by argv which implements OutputFormatter,
+// table format is used if not chosen. Table, csv and tsv formats accept *Table,
+// a struct or a slice of structs, see NewTableOf, other values are written as they are.
+func (ctx *Context) Render(obj interface{}) error {
+	if err := ctx.validateOutput(obj); err != nil {
+		return err
+	}
+	format := ctx.outputFormat()
+	switch format {
+	case OutputFormatJSON:
+		if ctx.envelopeEnabled() {
+			ctx.envelope = append(ctx.envelope, obj)
+			return nil
+		}
+		return ctx.JSONIndentln(obj, "", "  ").Err()
+	case OutputFormatYAML:
+		return ctx.YAMLln(obj).Err()
+	case OutputFormatTable, OutputFormatCSV, OutputFormatTSV:
+		table, err := toTable(obj)
+		if err != nil {
+			ctx.String("%v\n", obj)
+			return nil
+		}
+		switch format {
+		case OutputFormatCSV:
+			ctx.CSV(append([][]string{table.Headers}, table.Rows...))
+		case OutputFormatTSV:
+			ctx.TSV(append([][]string{table.Headers}, table.Rows...))
+		default:
+			ctx.RenderTable(table)
+		}
+		return nil
+	}
+	if strings.HasPrefix(format, OutputFormatTemplate+"=") {
+		return ctx.RenderTemplate(strings.TrimPrefix(format, OutputFormatTemplate+"="), obj)
+	}
+	return fmt.Errorf("unsupported output format %s", ctx.Color().Yellow(format))
+}
+
+func toTable(obj interface{}) (*Table, error) {
+	if table, ok := obj.(*Table); ok {
+		return table, nil
+	}
+	val := reflect.ValueOf(obj)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Struct {
+		slice := reflect.MakeSlice(reflect.SliceOf(val.Type()), 0, 1)
+		return NewTableOf(reflect.Append(slice, val).Interface())
+	}
+	return NewTableOf(obj)
+}
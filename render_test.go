@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	type pod struct {
+		Name   string `json:"name" table:"NAME"`
+		Status string `json:"status" table:"STATUS"`
+	}
+	type argT struct {
+		OutputFormat
+	}
+	pods := []pod{{"a", "running"}, {"b", "pending"}}
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			if len(ctx.Args()) > 0 {
+				return ctx.Render(&pods[0])
+			}
+			return ctx.Render(pods)
+		},
+	}
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "NAME  STATUS\na     running\nb     pending\n"},
+		{[]string{"-o", "json"}, "[\n  {\n    \"name\": \"a\",\n    \"status\": \"running\"\n  },\n  {\n    \"name\": \"b\",\n    \"status\": \"pending\"\n  }\n]\n"},
+		{[]string{"-o", "yaml"}, "- name: a\n  status: running\n- name: b\n  status: pending\n"},
+		{[]string{"-o", "csv"}, "NAME,STATUS\na,running\nb,pending\n"},
+		{[]string{"-o", "tsv", "one"}, "NAME\tSTATUS\na\trunning\n"},
+		{[]string{"-o", "template={{range .}}{{.Name}} {{end}}"}, "a b \n"},
+		{[]string{"-o", "table", "one"}, "NAME  STATUS\na     running\n"},
+	} {
+		w := new(bytes.Buffer)
+		assert.Nil(t, root.RunWith(tt.args, w, nil))
+		assert.Equal(t, w.String(), tt.want)
+	}
+	assert.Error(t, root.RunWith([]string{"-o", "xml"}, new(bytes.Buffer), nil))
+	assert.Error(t, root.RunWith([]string{"-o", "template={{"}, new(bytes.Buffer), nil))
+
+	w := new(bytes.Buffer)
+	ctx := &Context{writer: w}
+	assert.Nil(t, ctx.Render("plain"))
+	assert.Nil(t, ctx.Render(&Table{Headers: []string{"A"}}))
+	assert.Equal(t, w.String(), "plain\nA\n")
+}
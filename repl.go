@@ -0,0 +1,194 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/labstack/gommon/color"
+	"github.com/mattn/go-isatty"
+)
+
+// REPL represents an interactive shell which runs lines as commands of command tree
+type REPL struct {
+	Prompt string    // Prompt string, `<root name>> ` used if empty
+	In     io.Reader // Input, os.Stdin used if nil
+	Out    io.Writer // Output, os.Stdout used if nil
+
+	// HistoryFile persists history of lines if not empty, e.g. `~/.app_history`
+	HistoryFile string
+
+	// State is shared across invocations, see Context.Session
+	State map[string]interface{}
+}
+
+// RunREPL runs cmd as an interactive shell which reads commands from stdin,
+// it returns while reading EOF or `exit`, `quit` if no such commands
+func (cmd *Command) RunREPL() error {
+	return cmd.RunREPLWith(&REPL{})
+}
+
+// RunREPLWith is similar to RunREPL, but uses repl as options
+func (cmd *Command) RunREPLWith(repl *REPL) error {
+	var (
+		in     = repl.In
+		out    = repl.Out
+		prompt = repl.Prompt
+	)
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stdout
+	}
+	if prompt == "" {
+		prompt = cmd.Name + "> "
+	}
+	if repl.State == nil {
+		repl.State = make(map[string]interface{})
+	}
+	setup := func(ctx *Context) { ctx.session = repl.State }
+	historyFile, err := expandHome(repl.HistoryFile)
+	if err != nil {
+		return err
+	}
+
+	readLine := newLineReader(in, out, prompt)
+	if file, ok := in.(*os.File); ok && isatty.IsTerminal(file.Fd()) {
+		// line editing with history and tab completion
+		editor := newLineEditor(in, out, int(file.Fd()), prompt)
+		editor.complete = cmd.Complete
+		if historyFile != "" {
+			// REPL works without history, e.g. history file isn't readable
+			if err := editor.loadHistory(historyFile); err != nil {
+				debugf("repl: load history %s: %v", historyFile, err)
+			}
+		}
+		readLine = func() (string, error) {
+			line, err := editor.readLine()
+			if err == nil {
+				if err := editor.addHistory(strings.TrimSpace(line), historyFile); err != nil {
+					debugf("repl: save history %s: %v", historyFile, err)
+				}
+			}
+			return line, err
+		}
+	}
+
+	for {
+		line, err := readLine()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		args, err := SplitArgs(line)
+		if err != nil {
+			fmt.Fprintln(out, cmd.theme().wrapErr(err, "", color.Color{}))
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if (args[0] == "exit" || args[0] == "quit") && len(args) == 1 && cmd.findChild(args[0]) == nil {
+			return nil
+		}
+		if err := cmd.runWith(args, out, nil, setup); err != nil {
+			fmt.Fprintln(out, err)
+		}
+	}
+}
+
+// newLineReader returns a function which reads lines from non-terminal input
+func newLineReader(in io.Reader, out io.Writer, prompt string) func() (string, error) {
+	var (
+		reader = bufio.NewReader(in)
+		eof    bool
+	)
+	return func() (string, error) {
+		if eof {
+			return "", io.EOF
+		}
+		fmt.Fprint(out, prompt)
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			eof = true
+			if line != "" {
+				// last line without newline
+				return line, nil
+			}
+			fmt.Fprintln(out)
+		}
+		return line, err
+	}
+}
+
+// Session returns state shared across invocations of REPL or script, it's nil if not running in REPL or script
+func (ctx *Context) Session() map[string]interface{} {
+	return ctx.session
+}
+
+// SplitArgs splits line into arguments like shell, e.g.
+//
+//	hello -n "Jack Ma" --tag='a b' c\ d
+//
+// returns ["hello", "-n", "Jack Ma", "--tag=a b", "c d"]
+func SplitArgs(line string) ([]string, error) {
+	var (
+		args   = []string{}
+		buf    bytes.Buffer
+		inArg  bool
+		quote  byte
+		escape bool
+	)
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escape:
+			buf.WriteByte(c)
+			escape = false
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				buf.WriteByte(c)
+			}
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else if c == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`", line[i+1]) >= 0 {
+				escape = true
+			} else {
+				buf.WriteByte(c)
+			}
+		case c == '\\':
+			escape, inArg = true, true
+		case c == '\'' || c == '"':
+			quote, inArg = c, true
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inArg {
+				args = append(args, buf.String())
+				buf.Reset()
+				inArg = false
+			}
+		default:
+			buf.WriteByte(c)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quoted string")
+	}
+	if escape {
+		return nil, errors.New("unexpected end after escape character")
+	}
+	if inArg {
+		args = append(args, buf.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArgs(t *testing.T) {
+	for _, tt := range []struct {
+		line string
+		args []string
+	}{
+		{"", []string{}},
+		{"  hello   world ", []string{"hello", "world"}},
+		{`hello -n "Jack Ma" --tag='a b' c\ d`, []string{"hello", "-n", "Jack Ma", "--tag=a b", "c d"}},
+		{`"a \"b\" \n" 'c \d' ""`, []string{`a "b" \n`, `c \d`, ""}},
+		{"a\tb\n", []string{"a", "b"}},
+	} {
+		args, err := SplitArgs(tt.line)
+		require.Nil(t, err, tt.line)
+		assert.Equal(t, args, tt.args, tt.line)
+	}
+	for _, line := range []string{`"abc`, `'abc`, `abc\`} {
+		_, err := SplitArgs(line)
+		assert.Error(t, err, line)
+	}
+}
+
+func TestREPL(t *testing.T) {
+	type argT struct {
+		N int `cli:"n"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "add",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			sum, _ := ctx.Session()["sum"].(int)
+			sum += ctx.Argv().(*argT).N
+			ctx.Session()["sum"] = sum
+			ctx.String("sum=%d\n", sum)
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			return fmt.Errorf("failed: %s", strings.Join(ctx.NativeArgs(), ","))
+		},
+	})
+
+	out := new(bytes.Buffer)
+	repl := &REPL{
+		In:  strings.NewReader("add -n 1\n\nadd -n 2\nfail -- 'a b' c\nadd -n=x\nexit\nadd -n 3\n"),
+		Out: out,
+	}
+	require.Nil(t, root.RunREPLWith(repl))
+	assert.Equal(t, repl.State["sum"], 3)
+	text := out.String()
+	assert.Contains(t, text, "app> sum=1\n")
+	assert.Contains(t, text, "app> sum=3\n")
+	assert.Contains(t, text, "failed: --,a b,c")
+	assert.Contains(t, text, "ERR!")
+	assert.False(t, strings.Contains(text, "sum=6"))
+
+	// EOF without newline
+	out.Reset()
+	require.Nil(t, root.RunREPLWith(&REPL{In: strings.NewReader("add -n 5"), Out: out, Prompt: "$ "}))
+	assert.Equal(t, out.String(), "$ sum=5\n")
+}
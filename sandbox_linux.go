@@ -0,0 +1,31 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// applyProfiles confines cmd with AppArmor(via aa-exec) and seccomp profiles
+func applyProfiles(cmd *exec.Cmd, s *Sandbox) error {
+	if s.SeccompProfile != "" {
+		if SeccompLoader == nil {
+			return fmt.Errorf("seccomp profile %s: SeccompLoader not set", s.SeccompProfile)
+		}
+		if err := SeccompLoader(cmd, s.SeccompProfile); err != nil {
+			return err
+		}
+	}
+	if s.AppArmorProfile != "" {
+		aaExec, err := exec.LookPath("aa-exec")
+		if err != nil {
+			return fmt.Errorf("apparmor profile %s: %v", s.AppArmorProfile, err)
+		}
+		cmd.Args = append([]string{aaExec, "-p", s.AppArmorProfile, "--"}, cmd.Args...)
+		cmd.Args[4] = cmd.Path
+		cmd.Path = aaExec
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package cli
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+func applyProfiles(cmd *exec.Cmd, s *Sandbox) error {
+	if s.SeccompProfile != "" || s.AppArmorProfile != "" {
+		return fmt.Errorf("sandbox profiles not supported on %s", runtime.GOOS)
+	}
+	return nil
+}
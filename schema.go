@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// ValidateOutput enables validating objects passed to Context.Render and EmitResult
+// against OutputSchema of command, it's enabled by environment variable
+// CLI_VALIDATE_OUTPUT, e.g. in tests or CI
+var ValidateOutput = os.Getenv("CLI_VALIDATE_OUTPUT") != ""
+
+func (ctx *Context) validateOutput(obj interface{}) error {
+	if !ValidateOutput || ctx.command == nil || ctx.command.OutputSchema == nil {
+		return nil
+	}
+	if err := validateSchema(ctx.command.OutputSchema, obj); err != nil {
+		return fmt.Errorf("output of command %s mismatches schema: %v", ctx.color.Bold(ctx.command.Name), err)
+	}
+	return nil
+}
+
+// validateSchema checks JSON encoding of obj could be decoded into type of schema
+// without unknown fields, and fields of schema without omitempty are all present.
+// A slice of objects is checked against slice of schema.
+func validateSchema(schema, obj interface{}) error {
+	typ := reflect.TypeOf(schema)
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if v := reflect.Indirect(reflect.ValueOf(obj)); v.IsValid() && typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+		if kind := v.Kind(); kind == reflect.Slice || kind == reflect.Array {
+			typ = reflect.SliceOf(typ)
+		}
+	}
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(reflect.New(typ).Interface()); err != nil {
+		return err
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return checkSchemaFields(typ, value, "")
+}
+
+// checkSchemaFields checks fields of structs in typ are present in decoded JSON value
+func checkSchemaFields(typ reflect.Type, value interface{}, path string) error {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch v := value.(type) {
+	case []interface{}:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return nil
+		}
+		for i, elem := range v {
+			if err := checkSchemaFields(typ.Elem(), elem, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if typ.Kind() == reflect.Map {
+			for key, elem := range v {
+				if err := checkSchemaFields(typ.Elem(), elem, schemaFieldPath(path, key)); err != nil {
+					return err
+				}
+			}
+		} else if typ.Kind() == reflect.Struct {
+			return checkSchemaStruct(typ, v, path)
+		}
+	}
+	return nil
+}
+
+func checkSchemaStruct(typ reflect.Type, object map[string]interface{}, path string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name, opts := field.Name, ""
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			if tag == "-" {
+				continue
+			}
+			if i := strings.Index(tag, ","); i >= 0 {
+				tag, opts = tag[:i], tag[i:]
+			}
+			if tag != "" {
+				name = tag
+			} else if field.Anonymous {
+				name = ""
+			}
+		} else if field.Anonymous {
+			name = ""
+		}
+		if name == "" {
+			// fields of embedded struct are promoted
+			embedded := field.Type
+			for embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if err := checkSchemaStruct(embedded, object, path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		elem, ok := object[name]
+		if !ok {
+			if strings.Contains(opts, ",omitempty") {
+				continue
+			}
+			return fmt.Errorf("missing field %s", schemaFieldPath(path, name))
+		}
+		if err := checkSchemaFields(field.Type, elem, schemaFieldPath(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func schemaFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateSchema(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+	assert.Nil(t, validateSchema(user{}, user{"Jack", 10}))
+	assert.Nil(t, validateSchema(&user{}, &user{"Jack", 10}))
+	assert.Nil(t, validateSchema(user{}, []user{{"Jack", 10}}))
+	assert.Nil(t, validateSchema(user{}, map[string]interface{}{"name": "Jack", "age": 10}))
+	assert.Error(t, validateSchema(user{}, map[string]interface{}{"name": "Jack"}))
+	assert.Error(t, validateSchema(user{}, map[string]interface{}{"username": "Jack"}))
+	assert.Error(t, validateSchema(user{}, map[string]interface{}{"age": "10"}))
+	assert.Error(t, validateSchema(user{}, "Jack"))
+	assert.Error(t, validateSchema(user{}, []interface{}{user{"Jack", 10}, map[string]interface{}{"age": 10}}))
+
+	type base struct {
+		ID string `json:"id"`
+	}
+	type item struct {
+		base
+		Tags  []string        `json:"tags,omitempty"`
+		Owner *user           `json:"owner"`
+		Users map[string]user `json:"users"`
+		Skip  string          `json:"-"`
+		Note  string
+		note  string
+	}
+	valid := map[string]interface{}{"id": "1", "owner": nil, "users": map[string]interface{}{}, "Note": ""}
+	assert.Nil(t, validateSchema(item{}, valid))
+	assert.Nil(t, validateSchema(item{}, item{base: base{"1"}}))
+	for key, value := range map[string]interface{}{
+		"owner": map[string]interface{}{"name": "Jack"},
+		"users": map[string]interface{}{"jack": map[string]interface{}{"age": 10}},
+	} {
+		obj := map[string]interface{}{}
+		for k, v := range valid {
+			obj[k] = v
+		}
+		obj[key] = value
+		err := validateSchema(item{}, obj)
+		if assert.Error(t, err, key) {
+			assert.Contains(t, err.Error(), "missing field "+key+".", key)
+		}
+	}
+	for _, key := range []string{"id", "owner", "users", "Note"} {
+		obj := map[string]interface{}{}
+		for k, v := range valid {
+			if k != key {
+				obj[k] = v
+			}
+		}
+		assert.EqualError(t, validateSchema(item{}, obj), "missing field "+key)
+	}
+}
+
+func TestValidateOutput(t *testing.T) {
+	defer func(b bool) { ValidateOutput = b }(ValidateOutput)
+	type user struct {
+		Name string `json:"name"`
+	}
+	root := &Command{
+		Name:         "app",
+		OutputSchema: user{},
+		Fn: func(ctx *Context) error {
+			return ctx.Render(map[string]string{"nickname": "Jack"})
+		},
+	}
+	w := new(bytes.Buffer)
+	ValidateOutput = false
+	assert.Nil(t, root.RunWith(nil, w, nil))
+	ValidateOutput = true
+	assert.Error(t, root.RunWith(nil, w, nil))
+	root.Fn = func(ctx *Context) error {
+		return ctx.EmitResult(user{"Jack"})
+	}
+	assert.Nil(t, root.RunWith(nil, w, nil))
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Script represents options of running commands from a script, see RunScriptWith
+type Script struct {
+	Out io.Writer // Output, os.Stdout used if nil
+
+	// ContinueOnError runs rest lines after a command failed,
+	// errors of all failed lines are returned together
+	ContinueOnError bool
+
+	// Echo writes each line with prefix `+ ` before running it, like `sh -x`
+	Echo bool
+
+	// State is shared across lines, see Context.Session
+	State map[string]interface{}
+}
+
+// RunScript runs each line read from r as arguments of cmd, e.g.
+//
+//	# comment
+//	hello -n "Jack Ma"
+//	sub cmd --flag=value
+//
+// Empty lines and lines starting with `#` are ignored.
+// It stops at the first failed line and returns its error with line number.
+func (cmd *Command) RunScript(r io.Reader) error {
+	return cmd.RunScriptWith(r, &Script{})
+}
+
+// RunScriptWith is similar to RunScript, but uses script as options
+func (cmd *Command) RunScriptWith(r io.Reader, script *Script) error {
+	out := script.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	if script.State == nil {
+		script.State = make(map[string]interface{})
+	}
+	setup := func(ctx *Context) { ctx.session = script.State }
+
+	var (
+		scanner = bufio.NewScanner(r)
+		errs    multiError
+	)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if script.Echo {
+			fmt.Fprintln(out, "+ "+line)
+		}
+		args, err := SplitArgs(line)
+		if err == nil {
+			err = cmd.runWith(args, out, nil, setup)
+		}
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", lineno, err)
+			if !script.ContinueOnError {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunScript(t *testing.T) {
+	type argT struct {
+		N int `cli:"n"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "add",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			sum, _ := ctx.Session()["sum"].(int)
+			sum += ctx.Argv().(*argT).N
+			ctx.Session()["sum"] = sum
+			ctx.String("sum=%d\n", sum)
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			return fmt.Errorf("failed: %s", strings.Join(ctx.NativeArgs(), ","))
+		},
+	})
+	script := "# setup\nadd -n 1\n\n  add -n 2\nfail -- x\nadd -n 3\nfail 'y\n"
+
+	out := new(bytes.Buffer)
+	err := root.RunScriptWith(strings.NewReader(script), &Script{Out: out})
+	require.Error(t, err)
+	assert.Equal(t, err.Error(), "line 5: failed: --,x")
+	assert.Equal(t, out.String(), "sum=1\nsum=3\n")
+
+	out.Reset()
+	opts := &Script{Out: out, ContinueOnError: true, Echo: true}
+	err = root.RunScriptWith(strings.NewReader(script), opts)
+	require.Error(t, err)
+	assert.Equal(t, err.Error(), "line 5: failed: --,x\nline 7: unterminated quoted string")
+	assert.Equal(t, opts.State["sum"], 6)
+	assert.Equal(t, out.String(), "+ add -n 1\nsum=1\n+ add -n 2\nsum=3\n+ fail -- x\n+ add -n 3\nsum=6\n+ fail 'y\n")
+
+	assert.Nil(t, root.RunScript(strings.NewReader("# nothing to do\n\n")))
+}
+
+func TestRunScriptExitCode(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Fn: func(ctx *Context) error {
+			<-ctx.Context().Done()
+			return ctx.Context().Err()
+		},
+	})
+	root.Register(&Command{Name: "ok", Fn: donothing})
+
+	// errors of lines keep errors of commands
+	err := root.RunScriptWith(strings.NewReader("ok\nslow\nok\n"), &Script{Out: new(bytes.Buffer)})
+	require.Error(t, err)
+	assert.Equal(t, err.Error(), "line 2: command timed out after 10ms")
+	assert.True(t, IsTimeout(err))
+	assert.Equal(t, ExitCode(err), TimeoutExitCode)
+
+	err = root.RunScriptWith(strings.NewReader("slow\nok\n"), &Script{Out: new(bytes.Buffer), ContinueOnError: true})
+	require.Error(t, err)
+	assert.Equal(t, ExitCode(err), TimeoutExitCode)
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SearchResult is a command matched by Command.Search
+type SearchResult struct {
+	Path    string   // Path of command
+	Desc    string   // Abstract of command
+	Score   int      // Rank of result, higher is better
+	Matches []string // Where query matched, e.g. name, desc, text, example or flag names
+}
+
+// Search searches names, aliases, descriptions, flag names and examples of visible
+// commands in tree of cmd, cmd itself excluded. Terms of query are matched case-insensitively
+// and all terms must match. Results are sorted by score and then path.
+func (cmd *Command) Search(query string) []SearchResult {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return nil
+	}
+	results := []SearchResult{}
+	cmd.Walk(func(c *Command) error {
+		if c == cmd {
+			return nil
+		}
+		if result, ok := c.search(terms); ok {
+			results = append(results, result)
+		}
+		return nil
+	})
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Path < results[j].Path
+	})
+	return results
+}
+
+func (cmd *Command) search(terms []string) (SearchResult, bool) {
+	result := SearchResult{Path: cmd.Path(), Desc: cmd.localDesc()}
+	matched := map[string]bool{}
+	hit := func(where string, score int) {
+		result.Score += score
+		if !matched[where] {
+			matched[where] = true
+			result.Matches = append(result.Matches, where)
+		}
+	}
+	flags := cmd.flagSlice()
+	for _, term := range terms {
+		score := result.Score
+		for _, name := range append([]string{cmd.Name}, cmd.Aliases...) {
+			name = strings.ToLower(name)
+			if name == term {
+				hit("name", 100)
+			} else if strings.Contains(name, term) {
+				hit("name", 50)
+			}
+		}
+		for _, fl := range flags {
+			for _, name := range append(append([]string{}, fl.tag.shortNames...), fl.tag.longNames...) {
+				if strings.Contains(strings.ToLower(strings.TrimLeft(name, dashOne)), term) {
+					hit(name, 30)
+				}
+			}
+		}
+		if strings.Contains(strings.ToLower(cmd.Desc), term) {
+			hit("desc", 20)
+		}
+		if strings.Contains(strings.ToLower(cmd.Text), term) {
+			hit("text", 10)
+		}
+		for _, ex := range cmd.Examples {
+			if strings.Contains(strings.ToLower(ex.Desc+"\n"+ex.Command), term) {
+				hit("example", 10)
+			}
+		}
+		if result.Score == score {
+			return result, false
+		}
+	}
+	return result, true
+}
+
+// searchQuery returns query of `help --search <query>` or `help --search=<query>`
+func searchQuery(args []string) (string, bool) {
+	if len(args) == 0 {
+		return "", false
+	}
+	if query := strings.TrimPrefix(args[0], "--search="); query != args[0] {
+		return strings.Join(append([]string{query}, args[1:]...), " "), true
+	}
+	if args[0] == "--search" {
+		return strings.Join(args[1:], " "), true
+	}
+	return "", false
+}
+
+func writeSearchResults(ctx *Context, query string) error {
+	clr := ctx.Color()
+	results := ctx.Command().Root().Search(query)
+	if len(results) == 0 {
+		return fmt.Errorf("no commands match %s", clr.Yellow(query))
+	}
+	// columns are aligned by display width, descriptions may be non-ASCII
+	lenPath, lenDesc := 0, 0
+	for _, result := range results {
+		if w := stringWidth(result.Path); w > lenPath {
+			lenPath = w
+		}
+		if w := stringWidth(result.Desc); w > lenDesc {
+			lenDesc = w
+		}
+	}
+	for _, result := range results {
+		ctx.String("%s  %s  %s\n", padRight(clr.Bold(result.Path), lenPath), padRight(result.Desc, lenDesc),
+			clr.Grey("("+strings.Join(result.Matches, ", ")+")"))
+	}
+	return nil
+}
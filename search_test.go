@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearch(t *testing.T) {
+	type argT struct {
+		Region string `cli:"r,region"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(HelpCommand("show help"))
+	user := root.Register(&Command{Name: "user", Desc: "manage users"})
+	user.Register(&Command{Name: "create", Desc: "create a user", Argv: func() interface{} { return new(argT) }})
+	user.Register(&Command{Name: "secret", Hidden: true, Desc: "create secret user"})
+	root.Register(&Command{
+		Name:     "deploy",
+		Aliases:  []string{"ship"},
+		Desc:     "deploy app",
+		Text:     "deploys to every region",
+		Examples: []Example{{Desc: "create release", Command: "app deploy"}},
+	})
+
+	results := root.Search("create")
+	assert.Equal(t, len(results), 2)
+	assert.Equal(t, results[0].Path, "user create")
+	assert.Equal(t, results[0].Matches, []string{"name", "desc"})
+	assert.Equal(t, results[1].Path, "deploy")
+	assert.Equal(t, results[1].Matches, []string{"example"})
+
+	results = root.Search("REGION")
+	assert.Equal(t, len(results), 2)
+	assert.Equal(t, results[0].Path, "user create")
+	assert.Equal(t, results[0].Matches, []string{"--region"})
+	assert.Equal(t, results[1].Matches, []string{"text"})
+
+	// all terms must match
+	assert.Equal(t, len(root.Search("ship create")), 1)
+	assert.Equal(t, len(root.Search("ship user")), 0)
+	assert.Equal(t, len(root.Search(" ")), 0)
+
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith([]string{"help", "--search", "users"}, w, nil))
+	assert.Equal(t, w.String(), "user  manage users  (desc)\n")
+
+	w.Reset()
+	assert.Nil(t, root.RunWith([]string{"help", "--search=ship"}, w, nil))
+	assert.Equal(t, w.String(), "deploy  deploy app  (name)\n")
+
+	err := root.RunWith([]string{"help", "--search", "undefined"}, w, nil)
+	assert.Equal(t, err.Error(), "no commands match undefined")
+}
+
+func TestSearchQuery(t *testing.T) {
+	for _, tc := range []struct {
+		args  []string
+		query string
+		ok    bool
+	}{
+		{nil, "", false},
+		{[]string{"user"}, "", false},
+		{[]string{"--search", "a", "b"}, "a b", true},
+		{[]string{"--search=a", "b"}, "a b", true},
+		{[]string{"--search"}, "", true},
+	} {
+		query, ok := searchQuery(tc.args)
+		assert.Equal(t, query, tc.query)
+		assert.Equal(t, ok, tc.ok)
+	}
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var errNoOptions = errors.New("no options to select")
+
+// Select asks to choose one of options and returns index of the chosen option.
+// Options are navigated with arrow keys if stdin is a terminal,
+// or chosen by number otherwise.
+func (ctx *Context) Select(label string, options []string) (int, error) {
+	indexes, err := ctx.selectOptions(label, options, false)
+	if err != nil {
+		return -1, err
+	}
+	return indexes[0], nil
+}
+
+// MultiSelect is similar to Select, but chooses any number of options,
+// options are toggled by space key if stdin is a terminal
+func (ctx *Context) MultiSelect(label string, options []string) ([]int, error) {
+	return ctx.selectOptions(label, options, true)
+}
+
+func (ctx *Context) selectOptions(label string, options []string, multi bool) ([]int, error) {
+	if len(options) == 0 {
+		return nil, errNoOptions
+	}
+	r := ctx.promptReader()
+	fd, ok := ctx.promptTerminal()
+	if !ok {
+		return selectNumbered(r, ctx.promptOutput(), label, options, multi)
+	}
+	restore, err := makeRaw(fd)
+	if err != nil {
+		return selectNumbered(r, ctx.promptOutput(), label, options, multi)
+	}
+	defer restore()
+	return selectMenu(r, ctx.promptOutput(), label, options, multi)
+}
+
+// selectNumbered lists numbered options and reads numbers of chosen options,
+// numbers of multi-select are separated by commas or spaces
+func selectNumbered(r *bufio.Reader, w io.Writer, label string, options []string, multi bool) ([]int, error) {
+	fmt.Fprintf(w, "%s:\n", label)
+	for i, option := range options {
+		fmt.Fprintf(w, "  %d) %s\n", i+1, option)
+	}
+	for {
+		if multi {
+			fmt.Fprintf(w, "choose [1-%d, e.g. 1,3]: ", len(options))
+		} else {
+			fmt.Fprintf(w, "choose [1-%d]: ", len(options))
+		}
+		answer, err := readAnswer(r)
+		if err != nil {
+			return nil, err
+		}
+		fields := strings.FieldsFunc(answer, func(c rune) bool { return c == ',' || c == ' ' })
+		if len(fields) == 0 || (!multi && len(fields) > 1) {
+			continue
+		}
+		indexes := make([]int, 0, len(fields))
+		for _, field := range fields {
+			n, err := strconv.Atoi(field)
+			if err != nil || n < 1 || n > len(options) {
+				indexes = nil
+				break
+			}
+			indexes = append(indexes, n-1)
+		}
+		if indexes != nil {
+			return indexes, nil
+		}
+	}
+}
+
+// selectMenu reads keys from terminal in raw mode: up/down(or k/j, Ctrl-P/Ctrl-N)
+// moves cursor, space toggles option of multi-select, enter confirms, Ctrl-C cancels
+func selectMenu(r *bufio.Reader, w io.Writer, label string, options []string, multi bool) ([]int, error) {
+	var (
+		cursor  int
+		checked = make([]bool, len(options))
+	)
+	render := func(redraw bool) {
+		if redraw {
+			fmt.Fprintf(w, "\x1b[%dA", len(options))
+		}
+		for i, option := range options {
+			pointer := "  "
+			if i == cursor {
+				pointer = "> "
+			}
+			box := ""
+			if multi {
+				box = "[ ] "
+				if checked[i] {
+					box = "[x] "
+				}
+			}
+			fmt.Fprintf(w, "\r\x1b[K%s%s%s\r\n", pointer, box, option)
+		}
+	}
+	fmt.Fprintf(w, "%s:\r\n", label)
+	render(false)
+	for {
+		c, _, err := r.ReadRune()
+		if err != nil {
+			return nil, err
+		}
+		switch c {
+		case '\r', '\n':
+			if !multi {
+				return []int{cursor}, nil
+			}
+			indexes := []int{}
+			for i, ok := range checked {
+				if ok {
+					indexes = append(indexes, i)
+				}
+			}
+			return indexes, nil
+		case 3, 4: // Ctrl-C, Ctrl-D
+			return nil, errors.New("canceled")
+		case ' ':
+			if multi {
+				checked[cursor] = !checked[cursor]
+			}
+		case 'k', 16: // Ctrl-P
+			cursor = (cursor + len(options) - 1) % len(options)
+		case 'j', 14: // Ctrl-N
+			cursor = (cursor + 1) % len(options)
+		case 27:
+			switch readEscape(r) {
+			case 'A':
+				cursor = (cursor + len(options) - 1) % len(options)
+			case 'B':
+				cursor = (cursor + 1) % len(options)
+			}
+		}
+		render(true)
+	}
+}
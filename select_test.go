@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectNumbered(t *testing.T) {
+	w := new(bytes.Buffer)
+	options := []string{"dev", "staging", "prod"}
+	r := bufio.NewReader(strings.NewReader("4\n1,2\nx\n2\n1, 3\n"))
+	indexes, err := selectNumbered(r, w, "cluster", options, false)
+	require.Nil(t, err)
+	assert.Equal(t, indexes, []int{1})
+	assert.True(t, strings.HasPrefix(w.String(), "cluster:\n  1) dev\n  2) staging\n  3) prod\nchoose [1-3]: "))
+	assert.Equal(t, strings.Count(w.String(), "choose"), 4)
+
+	indexes, err = selectNumbered(r, w, "cluster", options, true)
+	require.Nil(t, err)
+	assert.Equal(t, indexes, []int{0, 2})
+
+	_, err = selectNumbered(r, w, "cluster", options, false)
+	assert.Equal(t, err, io.EOF)
+}
+
+func TestSelectMenu(t *testing.T) {
+	w := new(bytes.Buffer)
+	options := []string{"dev", "staging", "prod"}
+	r := bufio.NewReader(strings.NewReader("\x1b[B\x1b[B\x1b[A\r" + "k \x0e\x0e \rj\x03"))
+	indexes, err := selectMenu(r, w, "cluster", options, false)
+	require.Nil(t, err)
+	assert.Equal(t, indexes, []int{1})
+	assert.Contains(t, w.String(), "> staging")
+
+	w.Reset()
+	indexes, err = selectMenu(r, w, "clusters", options, true)
+	require.Nil(t, err)
+	assert.Equal(t, indexes, []int{1, 2})
+	assert.Contains(t, w.String(), "> [x] prod")
+
+	_, err = selectMenu(r, w, "cluster", options, false)
+	assert.Error(t, err)
+}
+
+func TestContextSelect(t *testing.T) {
+	defer func(r io.Reader, w io.Writer) { PromptInput, PromptOutput = r, w }(PromptInput, PromptOutput)
+	PromptInput = strings.NewReader("2\n1 2\n")
+	PromptOutput = new(bytes.Buffer)
+
+	ctx := &Context{}
+	index, err := ctx.Select("profile", []string{"a", "b"})
+	require.Nil(t, err)
+	assert.Equal(t, index, 1)
+	_, err = ctx.MultiSelect("profile", nil)
+	assert.Error(t, err)
+}
+
+func TestContextSelectPiped(t *testing.T) {
+	defer func(r io.Reader, w io.Writer) { PromptInput, PromptOutput = r, w }(PromptInput, PromptOutput)
+	PromptInput = strings.NewReader("2\n1\n1,3\n")
+	PromptOutput = new(bytes.Buffer)
+
+	// answers read ahead by the first prompt are left for following ones
+	ctx := &Context{}
+	index, err := ctx.Select("profile", []string{"a", "b"})
+	require.Nil(t, err)
+	assert.Equal(t, index, 1)
+	index, err = ctx.Select("region", []string{"x", "y"})
+	require.Nil(t, err)
+	assert.Equal(t, index, 0)
+	indexes, err := ctx.MultiSelect("zones", []string{"1a", "1b", "1c"})
+	require.Nil(t, err)
+	assert.Equal(t, indexes, []int{0, 2})
+}
@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// Server serves command tree over HTTP, WebSocket and unix socket with graceful shutdown,
+// in-flight commands are drained by Shutdown
+//
+//	server := cli.NewServer(root)
+//	server.WebSocketPath = "/console"
+//	server.ShutdownOnSignal(10 * time.Second)
+//	server.ListenAndServeHTTP(":8080")
+type Server struct {
+	Root *Command
+
+	// WebSocketPath is path of WebSocketHandler served by ServeHTTP behind HTTPMiddlewares
+	// of root, e.g. "/console", WebSocket console isn't served if it's empty
+	WebSocketPath string
+
+	goctx  context.Context // cancelled after draining timeout, returned by Context.Context
+	cancel context.CancelFunc
+	drain  chan struct{} // closed while shutting down
+	conns  sync.WaitGroup
+
+	locker      sync.Mutex // protect following data
+	closed      bool
+	listeners   map[net.Listener]struct{}
+	httpServers map[*http.Server]struct{}
+}
+
+// NewServer creates a Server for root, IsServer of root is set with true
+func NewServer(root *Command) *Server {
+	root.SetIsServer(true)
+	goctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		Root:        root,
+		goctx:       goctx,
+		cancel:      cancel,
+		drain:       make(chan struct{}),
+		listeners:   make(map[net.Listener]struct{}),
+		httpServers: make(map[*http.Server]struct{}),
+	}
+}
+
+// ListenAndServeHTTP listens on TCP address addr and calls ServeHTTP
+func (s *Server) ListenAndServeHTTP(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.ServeHTTP(l)
+}
+
+// ServeHTTP serves requests accepted by l with HTTPHandler of root and WebSocketHandler
+// on WebSocketPath, nil returned after Shutdown
+func (s *Server) ServeHTTP(l net.Listener) error {
+	hs := &http.Server{
+		Handler:     s.httpHandler(),
+		BaseContext: func(net.Listener) context.Context { return s.goctx },
+	}
+	s.locker.Lock()
+	if s.closed {
+		s.locker.Unlock()
+		l.Close()
+		return nil
+	}
+	s.httpServers[hs] = struct{}{}
+	s.locker.Unlock()
+	if err := hs.Serve(l); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// httpHandler returns HTTPHandler of root, requests to WebSocketPath are served by WebSocketHandler,
+// both are wrapped by HTTPMiddlewares of root
+func (s *Server) httpHandler() http.Handler {
+	if s.WebSocketPath == "" {
+		return s.Root.HTTPHandler()
+	}
+	ws := s.WebSocketHandler()
+	return s.Root.wrapHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == s.WebSocketPath {
+			ws.ServeHTTP(w, r)
+			return
+		}
+		s.Root.ServeHTTP(w, r)
+	}))
+}
+
+// WebSocketHandler returns handler of WebSocket console like Command.ServeWebSocket,
+// the connection is closed after running command while shutting down
+func (s *Server) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.track() {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return
+		}
+		defer s.conns.Done()
+		s.Root.serveWebSocket(w, r, s.drain)
+	})
+}
+
+// ListenAndServeUnix listens on unix socket like Command.ListenAndServeUnix and calls ServeUnix
+func (s *Server) ListenAndServeUnix(socket string) error {
+	l, err := listenUnix(socket)
+	if err != nil {
+		return err
+	}
+	return s.ServeUnix(l)
+}
+
+// ServeUnix serves command lines forwarded to l like Command.ServeUnix, nil returned after Shutdown
+func (s *Server) ServeUnix(l net.Listener) error {
+	s.locker.Lock()
+	if s.closed {
+		s.locker.Unlock()
+		l.Close()
+		return nil
+	}
+	s.listeners[l] = struct{}{}
+	s.locker.Unlock()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if s.isClosed() {
+				return nil
+			}
+			return err
+		}
+		if !s.track() {
+			conn.Close()
+			return nil
+		}
+		go func() {
+			defer s.conns.Done()
+			s.Root.serveUnixConn(s.goctx, conn)
+		}()
+	}
+}
+
+// track adds a connection to drain, returns false if server is shutting down
+func (s *Server) track() bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns.Add(1)
+	return true
+}
+
+func (s *Server) isClosed() bool {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return s.closed
+}
+
+// Shutdown stops accepting connections and waits for in-flight commands finished.
+// If goctx is done before that, Context.Context of running commands are cancelled
+// and error of goctx is returned.
+func (s *Server) Shutdown(goctx context.Context) error {
+	s.locker.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.drain)
+		for l := range s.listeners {
+			l.Close()
+		}
+	}
+	httpServers := make([]*http.Server, 0, len(s.httpServers))
+	for hs := range s.httpServers {
+		httpServers = append(httpServers, hs)
+	}
+	s.locker.Unlock()
+
+	var (
+		wg   sync.WaitGroup
+		errs = make(chan error, len(httpServers))
+		done = make(chan struct{})
+	)
+	for _, hs := range httpServers {
+		wg.Add(1)
+		go func(hs *http.Server) {
+			defer wg.Done()
+			if err := hs.Shutdown(goctx); err != nil {
+				errs <- err
+			}
+		}(hs)
+	}
+	go func() {
+		wg.Wait()
+		s.conns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.cancel()
+	case <-goctx.Done():
+		s.cancel()
+		return goctx.Err()
+	}
+	select {
+	case err := <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+// ShutdownOnSignal shuts down server gracefully with timeout while receiving one of
+// signals, os.Interrupt used if no signals. The next signal kills the process.
+func (s *Server) ShutdownOnSignal(timeout time.Duration, signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, signals...)
+	go func() {
+		defer signal.Stop(ch)
+		select {
+		case <-ch:
+		case <-s.drain:
+			// shut down by others
+			return
+		}
+		signal.Stop(ch)
+		goctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err := s.Shutdown(goctx); err != nil {
+			debugf("shutdown: %v", err)
+		}
+	}()
+}
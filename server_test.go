@@ -0,0 +1,228 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerShutdown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "app.sock")
+
+	var (
+		started = make(chan struct{}, 2)
+		release = make(chan struct{})
+	)
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "slow",
+		Fn: func(ctx *Context) error {
+			started <- struct{}{}
+			select {
+			case <-release:
+				ctx.String("finished\n")
+			case <-ctx.Context().Done():
+				ctx.String("cancelled\n")
+			}
+			return nil
+		},
+	})
+
+	server := NewServer(root)
+	assert.True(t, root.IsServer())
+	unixDone := make(chan error, 1)
+	go func() { unixDone <- server.ListenAndServeUnix(socket) }()
+	httpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	httpDone := make(chan error, 1)
+	go func() { httpDone <- server.ServeHTTP(httpListener) }()
+
+	// wait for unix socket
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	unixOut := new(bytes.Buffer)
+	unixStatus := make(chan int, 1)
+	go func() {
+		status, _ := ForwardUnix(socket, []string{"slow"}, unixOut, ioutil.Discard)
+		unixStatus <- status
+	}()
+	httpBody := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + httpListener.Addr().String() + "/slow")
+		if err != nil {
+			httpBody <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := ioutil.ReadAll(resp.Body)
+		httpBody <- string(data)
+	}()
+	<-started
+	<-started
+
+	// in-flight commands are drained
+	shutdownDone := make(chan error, 1)
+	go func() { shutdownDone <- server.Shutdown(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	assert.Nil(t, <-shutdownDone)
+	assert.Equal(t, <-unixStatus, 0)
+	assert.Equal(t, unixOut.String(), "finished\n")
+	assert.Equal(t, <-httpBody, "finished\n")
+	assert.Nil(t, <-unixDone)
+	assert.Nil(t, <-httpDone)
+
+	// new connections are refused after shutdown
+	_, err = ForwardUnix(socket, []string{"slow"}, ioutil.Discard, ioutil.Discard)
+	assert.NotNil(t, err)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "app.sock")
+
+	started := make(chan struct{})
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "hang",
+		Fn: func(ctx *Context) error {
+			close(started)
+			<-ctx.Context().Done()
+			ctx.String("cancelled\n")
+			return nil
+		},
+	})
+	server := NewServer(root)
+	l, err := listenUnix(socket)
+	require.Nil(t, err)
+	go server.ServeUnix(l)
+
+	out := new(bytes.Buffer)
+	done := make(chan struct{})
+	go func() {
+		ForwardUnix(socket, []string{"hang"}, out, ioutil.Discard)
+		close(done)
+	}()
+	<-started
+
+	goctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, server.Shutdown(goctx), context.DeadlineExceeded)
+	<-done
+	assert.Equal(t, out.String(), "cancelled\n")
+}
+
+func TestServerWebSocketPath(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "hello",
+		Fn: func(ctx *Context) error {
+			ctx.String("hello\n")
+			return nil
+		},
+	})
+	server := NewServer(root)
+	server.WebSocketPath = "/console"
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	addr := l.Addr().String()
+	l.Close()
+	done := make(chan error, 1)
+	go func() { done <- server.ListenAndServeHTTP(addr) }()
+	for i := 0; i < 100; i++ {
+		if conn, err := net.Dial("tcp", addr); err == nil {
+			conn.Close()
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	c := dialWebSocket(t, addr)
+	c.send(wsText, "hello")
+	assert.Equal(t, c.output(t), "hello\n")
+	c.conn.Close()
+
+	resp, err := http.Get("http://" + addr + "/hello")
+	require.Nil(t, err)
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, string(data), "hello\n")
+
+	assert.Nil(t, server.Shutdown(context.Background()))
+	assert.Nil(t, <-done)
+}
+
+func TestServerWebSocketMiddlewares(t *testing.T) {
+	logs := new(bytes.Buffer)
+	root := &Command{
+		Name:            "app",
+		HTTPMiddlewares: []Middleware{LogRequests(logs), TokenAuth(StaticTokens("secret"))},
+	}
+	root.Register(&Command{
+		Name: "hello",
+		Fn: func(ctx *Context) error {
+			ctx.String("hello\n")
+			return nil
+		},
+	})
+	server := NewServer(root)
+	server.WebSocketPath = "/console"
+	ts := httptest.NewServer(server.httpHandler())
+	defer ts.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+
+	handshake := func(token string) (*wsTestClient, int) {
+		conn, err := net.Dial("tcp", addr)
+		require.Nil(t, err)
+		req := "GET /console HTTP/1.1\r\nHost: " + addr +
+			"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+		if token != "" {
+			req += "Authorization: Bearer " + token + "\r\n"
+		}
+		io.WriteString(conn, req+"\r\n")
+		reader := bufio.NewReader(conn)
+		resp, err := http.ReadResponse(reader, nil)
+		require.Nil(t, err)
+		return &wsTestClient{conn: conn, reader: reader}, resp.StatusCode
+	}
+
+	c, code := handshake("")
+	c.conn.Close()
+	assert.Equal(t, code, http.StatusUnauthorized)
+	c, code = handshake("wrong")
+	c.conn.Close()
+	assert.Equal(t, code, http.StatusUnauthorized)
+
+	c, code = handshake("secret")
+	require.Equal(t, code, http.StatusSwitchingProtocols)
+	c.send(wsText, "hello")
+	assert.Equal(t, c.output(t), "hello\n")
+	c.conn.Close()
+
+	assert.Contains(t, logs.String(), "GET /console 401")
+}
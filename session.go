@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/labstack/gommon/color"
+)
+
+// RemoteSession is a session of a remote shell, e.g. an SSH session, see ServeSession
+type RemoteSession interface {
+	io.ReadWriter
+
+	// Stderr returns writer for errors and diagnostics
+	Stderr() io.Writer
+	// Args returns command line of session, empty for interactive sessions
+	Args() []string
+	// ReadLine reads next command line of interactive session, io.EOF ends session
+	ReadLine() (string, error)
+	// IsTerminal reports whether a terminal is attached to session, output is colored if true
+	IsTerminal() bool
+	// Context is cancelled while session closed
+	Context() context.Context
+}
+
+// ServeSession runs commands of a remote session and returns exit status of session.
+// A command session runs Args once with session as stdin, interactive sessions run
+// command lines one by one like REPL and share Context.Session.
+func (cmd *Command) ServeSession(s RemoteSession) int {
+	var (
+		session = make(map[string]interface{})
+		setup   = func(ctx *Context) {
+			ctx.reader = s
+			ctx.errWriter = s.Stderr()
+			ctx.goctx = s.Context()
+			ctx.session = session
+		}
+		clr = color.Color{}
+		inv = invocation{color: ColorAlways, remote: true}
+	)
+	if !s.IsTerminal() {
+		clr.Disable()
+		inv.color = ColorNever
+	}
+	if args := s.Args(); len(args) > 0 {
+		err := cmd.invoke(args, s, nil, inv, setup)
+		if err != nil {
+			fmt.Fprintln(s.Stderr(), err)
+		}
+		return ExitCode(err)
+	}
+	for {
+		line, err := s.ReadLine()
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintln(s.Stderr(), cmd.theme().wrapErr(err, "", clr))
+			return 1
+		}
+		args, err := SplitArgs(line)
+		if err != nil {
+			fmt.Fprintln(s, cmd.theme().wrapErr(err, "", clr))
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if (args[0] == "exit" || args[0] == "quit") && len(args) == 1 && cmd.findChild(args[0]) == nil {
+			return 0
+		}
+		if err := cmd.invoke(args, s, nil, inv, setup); err != nil {
+			fmt.Fprintln(s, err)
+		}
+	}
+}
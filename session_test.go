@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testSession struct {
+	*bufio.Reader
+	bytes.Buffer
+	stderr bytes.Buffer
+	args   []string
+	tty    bool
+	goctx  context.Context
+}
+
+func (s *testSession) Read(p []byte) (int, error) { return s.Reader.Read(p) }
+func (s *testSession) Stderr() io.Writer          { return &s.stderr }
+func (s *testSession) Args() []string             { return s.args }
+func (s *testSession) IsTerminal() bool           { return s.tty }
+func (s *testSession) Context() context.Context   { return s.goctx }
+
+func (s *testSession) ReadLine() (string, error) {
+	line, err := s.Reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		return line, nil
+	}
+	return line, err
+}
+
+func newTestSession(input string, args ...string) *testSession {
+	return &testSession{Reader: bufio.NewReader(strings.NewReader(input)), args: args, goctx: context.Background()}
+}
+
+func TestServeSession(t *testing.T) {
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "echo",
+		Fn: func(ctx *Context) error {
+			_, err := io.Copy(ctx, ctx.Reader())
+			return err
+		},
+	})
+	root.Register(&Command{
+		Name: "set",
+		Fn: func(ctx *Context) error {
+			ctx.Session()["n"] = len(ctx.Session()) + 1
+			ctx.String("%s\n", ctx.Color().Green("ok"))
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			ctx.Warn("careful")
+			return errors.New("oops")
+		},
+	})
+
+	s := newTestSession("hello", "echo")
+	assert.Equal(t, root.ServeSession(s), 0)
+	assert.Equal(t, s.String(), "hello")
+
+	s = newTestSession("", "fail")
+	assert.Equal(t, root.ServeSession(s), 1)
+	assert.Equal(t, s.String(), "")
+	assert.Equal(t, s.stderr.String(), "WARN! careful\noops\n")
+
+	s = newTestSession("set\n\nfail\n\"bad\nexit\nset\n")
+	assert.Equal(t, root.ServeSession(s), 0)
+	assert.Equal(t, s.String(), "ok\noops\nERR! unterminated quoted string\n")
+
+	s = newTestSession("set")
+	s.tty = true
+	assert.Equal(t, root.ServeSession(s), 0)
+	assert.Equal(t, s.String(), "\x1b[32mok\x1b[0m\n")
+}
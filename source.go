@@ -0,0 +1,168 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+
+	"github.com/labstack/gommon/color"
+)
+
+// Source represents where value of flag comes from
+type Source string
+
+// Value sources
+const (
+	SourceDefault Source = "default" // `dft` tag
+	SourceEnv     Source = "env"     // `env` tag
+	SourceConfig  Source = "config"  // config file, see ConfigFile
+	SourceFlag    Source = "flag"    // command line
+)
+
+// DefaultSources is used while Sources of root command is nil
+var DefaultSources = []Source{SourceDefault, SourceEnv, SourceConfig, SourceFlag}
+
+// Sources checks and returns sources ordered from lowest precedence to highest, e.g.
+//
+//	root.Sources = cli.Sources(cli.SourceDefault, cli.SourceConfig, cli.SourceFlag)
+//
+// Unlisted sources are not read, except command line flags which are always parsed
+// but overridden by any listed source. It panics if source unknown or repeated.
+func Sources(sources ...Source) []Source {
+	seen := map[Source]bool{}
+	for _, source := range sources {
+		switch source {
+		case SourceDefault, SourceEnv, SourceConfig, SourceFlag:
+		default:
+			panic(fmt.Sprintf("unknown source %q", source))
+		}
+		if seen[source] {
+			panic(fmt.Sprintf("source %q repeated", source))
+		}
+		seen[source] = true
+	}
+	return sources
+}
+
+// ValueSource returns source of flag value, empty if flag is not assigned
+func (ctx *Context) ValueSource(name string) Source {
+	if ctx.flagSet == nil {
+		return ""
+	}
+	if fl, ok := ctx.flagSet.lookup(name); ok && fl.isAssigned {
+		return fl.source
+	}
+	return ""
+}
+
+func (fs *flagSet) getSources() []Source {
+	if fs.sources == nil {
+		return DefaultSources
+	}
+	return fs.sources
+}
+
+func (fs *flagSet) hasSource(source Source) bool {
+	return fs.rank(source) >= 0
+}
+
+func (fs *flagSet) rank(source Source) int {
+	for i, s := range fs.getSources() {
+		if s == source {
+			return i
+		}
+	}
+	return -1
+}
+
+// canOverride reports whether value from source can override current value of fl
+func (fs *flagSet) canOverride(fl *flag, source Source) bool {
+	return !fl.isAssigned || fs.rank(source) >= fs.rank(fl.source)
+}
+
+// readSources reads sources other than defaults and command line flags
+func (fs *flagSet) readSources(clr color.Color) error {
+	for _, source := range fs.getSources() {
+		switch source {
+		case SourceEnv:
+			if err := fs.readEnv(clr); err != nil {
+				return err
+			}
+		case SourceConfig:
+			if fs.configFile == nil {
+				continue
+			}
+			if err := fs.readConfig(clr); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (fs *flagSet) readEnv(clr color.Color) error {
+	for _, fl := range fs.flagSlice {
+		if fl.tag.env == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(fl.tag.env)
+		if !ok || !fs.canOverride(fl, SourceEnv) {
+			continue
+		}
+		if err := fl.setSourceValue(SourceEnv, value, clr); err != nil {
+			return fmt.Errorf("env %s: parameter %s invalid: %w", clr.Bold(fl.tag.env), clr.Bold(fl.name()), err)
+		}
+		if !fl.isNeedDelaySet {
+			fs.values[fl.valueKey()] = []string{fl.valueString()}
+		}
+	}
+	return nil
+}
+
+// setSourceValue sets value read from source like a default value,
+// list and object values are set element by element
+func (fl *flag) setSourceValue(source Source, value interface{}, clr color.Color) error {
+	var elems []string
+	switch v := value.(type) {
+	case []interface{}:
+		for _, elem := range v {
+			elems = append(elems, sourceString(elem))
+		}
+	case map[string]interface{}:
+		for key, elem := range v {
+			elems = append(elems, key+fl.tag.sep+sourceString(elem))
+		}
+	case map[interface{}]interface{}:
+		for key, elem := range v {
+			elems = append(elems, sourceString(key)+fl.tag.sep+sourceString(elem))
+		}
+	default:
+		elems = []string{sourceString(v)}
+	}
+	if !fl.isNeedDelaySet {
+		// drop current value, e.g. default value of slice
+		fl.value.Set(reflect.Zero(fl.field.Type))
+	} else if len(elems) != 1 {
+		return fmt.Errorf("unexpected %d values", len(elems))
+	}
+	for _, elem := range elems {
+		if err := fl.setDefault(elem, clr); err != nil {
+			return err
+		}
+	}
+	fl.source = source
+	return nil
+}
+
+func sourceString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
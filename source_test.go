@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-sources")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	configPath := filepath.Join(dir, "app.json")
+	require.Nil(t, ioutil.WriteFile(configPath, []byte(`{"a": "config", "b": "config"}`), 0644))
+	defer os.Unsetenv("CLI_TEST_SOURCE_A")
+	defer os.Unsetenv("CLI_TEST_SOURCE_C")
+	os.Setenv("CLI_TEST_SOURCE_A", "env")
+	os.Setenv("CLI_TEST_SOURCE_C", "env")
+
+	type argT struct {
+		A string `cli:"a" dft:"default" env:"CLI_TEST_SOURCE_A"`
+		B string `cli:"b" dft:"default"`
+		C string `cli:"c" dft:"default" env:"CLI_TEST_SOURCE_C"`
+		D string `cli:"d" dft:"default"`
+		E string `cli:"e"`
+	}
+	type result struct {
+		argv    argT
+		sources map[string]Source
+	}
+	run := func(sources []Source, args ...string) result {
+		var res result
+		err := (&Command{
+			Argv:       func() interface{} { return new(argT) },
+			ConfigFile: &ConfigFile{Paths: []string{configPath}},
+			Sources:    sources,
+			Fn: func(ctx *Context) error {
+				res.argv = *ctx.Argv().(*argT)
+				res.sources = map[string]Source{}
+				for _, name := range []string{"a", "b", "c", "d", "e"} {
+					res.sources[name] = ctx.ValueSource(name)
+				}
+				return nil
+			},
+		}).RunWith(args, ioutil.Discard, nil)
+		require.Nil(t, err)
+		return res
+	}
+
+	res := run(nil, "-b", "flag", "-d=flag")
+	assert.Equal(t, res.argv, argT{A: "config", B: "flag", C: "env", D: "flag"})
+	assert.Equal(t, res.sources, map[string]Source{"a": SourceConfig, "b": SourceFlag, "c": SourceEnv, "d": SourceFlag, "e": ""})
+
+	// flags < env < config
+	res = run(Sources(SourceDefault, SourceFlag, SourceEnv, SourceConfig), "-a", "flag", "-c", "flag", "-d", "flag")
+	assert.Equal(t, res.argv, argT{A: "config", B: "config", C: "env", D: "flag"})
+	assert.Equal(t, res.sources, map[string]Source{"a": SourceConfig, "b": SourceConfig, "c": SourceEnv, "d": SourceFlag, "e": ""})
+
+	// only flags and env
+	res = run(Sources(SourceEnv, SourceFlag), "-c", "flag")
+	assert.Equal(t, res.argv, argT{A: "env", C: "flag"})
+	assert.Equal(t, res.sources, map[string]Source{"a": SourceEnv, "b": "", "c": SourceFlag, "d": "", "e": ""})
+
+	assert.Panics(t, func() { Sources(SourceEnv, SourceEnv) })
+	assert.Panics(t, func() { Sources("unknown") })
+}
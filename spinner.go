@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinner shows an animation for an indeterminate operation on terminal,
+// animation is suppressed if ProgressOutput is not a terminal, e.g.
+//
+//	spinner := ctx.Spinner("fetching").Start()
+//	if err := fetch(); err != nil {
+//		spinner.Fail(err.Error())
+//		return err
+//	}
+//	spinner.Success("")
+type Spinner struct {
+	Interval time.Duration // Interval of frames, 100ms by default
+
+	ctx    *Context
+	out    io.Writer
+	label  string
+	tty    bool
+	locker sync.Mutex // protect following data
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// Spinner creates a spinner with label, call Start to show it
+func (ctx *Context) Spinner(label string) *Spinner {
+	out := ctx.stderr(ProgressOutput)
+	return &Spinner{
+		Interval: 100 * time.Millisecond,
+		ctx:      ctx,
+		out:      out,
+		label:    label,
+		tty:      isTerminalWriter(out),
+	}
+}
+
+// Start starts animation, it does nothing if spinner is running or not on terminal
+func (s *Spinner) Start() *Spinner {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if !s.tty || s.stop != nil {
+		return s
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.loop(s.stop, s.done)
+	return s
+}
+
+func (s *Spinner) loop(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for i := 0; ; i++ {
+		fmt.Fprintf(s.out, "\r\033[K%s %s", s.ctx.color.Cyan(spinnerFrames[i%len(spinnerFrames)]), s.label)
+		select {
+		case <-stop:
+			fmt.Fprint(s.out, "\r\033[K")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops animation and clears the line
+func (s *Spinner) Stop() {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop, s.done = nil, nil
+}
+
+// Success stops spinner and writes a success line with message, label used if message is empty
+func (s *Spinner) Success(message string) {
+	s.finish(s.ctx.color.Green("✔"), message)
+}
+
+// Fail stops spinner and writes a failure line with message, label used if message is empty
+func (s *Spinner) Fail(message string) {
+	s.finish(s.ctx.color.Red("✘"), message)
+}
+
+func (s *Spinner) finish(symbol, message string) {
+	s.Stop()
+	if message == "" {
+		message = s.label
+	}
+	fmt.Fprintf(s.out, "%s %s\n", symbol, message)
+}
@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpinner(t *testing.T) {
+	defer func(w io.Writer) { ProgressOutput = w }(ProgressOutput)
+	w := new(bytes.Buffer)
+	ProgressOutput = w
+
+	ctx := &Context{}
+	ctx.color.Disable()
+	spinner := ctx.Spinner("fetching").Start()
+	spinner.Stop()
+	spinner.Stop()
+	assert.Equal(t, w.String(), "")
+	spinner.Success("")
+	ctx.Spinner("uploading").Start().Fail("upload failed")
+	assert.Equal(t, w.String(), "✔ fetching\n✘ upload failed\n")
+
+	// animation on terminal
+	w.Reset()
+	spinner = ctx.Spinner("working")
+	spinner.tty = true
+	spinner.Start().Start()
+	spinner.Success("done")
+	assert.Contains(t, w.String(), " working")
+	assert.Contains(t, w.String(), "\r\033[K✔ done\n")
+}
@@ -0,0 +1,262 @@
+//go:build ssh
+// +build ssh
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// SSHHandshakeTimeout is max duration for clients to finish SSH handshake,
+// connections are closed if handshake isn't finished in time
+var SSHHandshakeTimeout = 10 * time.Second
+
+// SSHServer serves command tree over SSH with public key authentication,
+// it's built with tag `ssh`, e.g. `go build -tags ssh`.
+//
+//	server, err := cli.NewSSHServer(root, "host_key", "authorized_keys")
+//	if err != nil { ... }
+//	server.ListenAndServe(":2222")
+//
+// `ssh -p 2222 host user list` runs `user list`, and `ssh -p 2222 host` opens an
+// interactive session. Output is colored if client requested a PTY.
+type SSHServer struct {
+	Root   *Command
+	Config *ssh.ServerConfig
+	Prompt string // Prompt of interactive sessions, "<root name>> " used if empty
+}
+
+// NewSSHServer creates an SSHServer with host key loaded from hostKeyFile, clients are
+// authenticated by public keys listed in authorizedKeysFile(format of ~/.ssh/authorized_keys)
+func NewSSHServer(root *Command, hostKeyFile, authorizedKeysFile string) (*SSHServer, error) {
+	data, err := ioutil.ReadFile(hostKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	hostKey, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, err
+	}
+	authorized, err := LoadAuthorizedKeys(authorizedKeysFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &ssh.ServerConfig{PublicKeyCallback: AuthorizedKeys(authorized...)}
+	config.AddHostKey(hostKey)
+	return &SSHServer{Root: root, Config: config}, nil
+}
+
+// LoadAuthorizedKeys reads public keys from file with format of ~/.ssh/authorized_keys
+func LoadAuthorizedKeys(filename string) ([]ssh.PublicKey, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	keys := []ssh.PublicKey{}
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		data = rest
+	}
+	return keys, nil
+}
+
+// AuthorizedKeys returns PublicKeyCallback of ssh.ServerConfig which accepts listed keys
+func AuthorizedKeys(keys ...ssh.PublicKey) func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+	return func(meta ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		for _, k := range keys {
+			if bytes.Equal(k.Marshal(), key.Marshal()) {
+				return &ssh.Permissions{}, nil
+			}
+		}
+		return nil, errors.New("unauthorized public key")
+	}
+}
+
+// ListenAndServe listens on TCP address addr and serves SSH connections
+func (s *SSHServer) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+// Serve accepts SSH connections on l until l closed
+func (s *SSHServer) Serve(l net.Listener) error {
+	s.Root.SetIsServer(true)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *SSHServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(SSHHandshakeTimeout))
+	sconn, channels, requests, err := ssh.NewServerConn(conn, s.Config)
+	if err != nil {
+		debugf("ssh: handshake: %v", err)
+		return
+	}
+	defer sconn.Close()
+	conn.SetDeadline(time.Time{})
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			debugf("ssh: accept channel: %v", err)
+			continue
+		}
+		go s.serveChannel(channel, requests)
+	}
+}
+
+func (s *SSHServer) serveChannel(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	// context of session is cancelled while channel closed
+	goctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var (
+		pty     bool
+		started bool
+	)
+	for req := range requests {
+		switch req.Type {
+		case "pty-req":
+			pty = true
+			req.Reply(true, nil)
+		case "env", "window-change":
+			req.Reply(true, nil)
+		case "shell", "exec":
+			if started {
+				req.Reply(false, nil)
+				continue
+			}
+			started = true
+			var args []string
+			if req.Type == "exec" {
+				line, err := parseSSHString(req.Payload)
+				if err == nil {
+					args, err = SplitArgs(line)
+				}
+				if err != nil {
+					req.Reply(false, nil)
+					return
+				}
+			}
+			req.Reply(true, nil)
+			go func() {
+				status := s.Root.ServeSession(s.newSession(goctx, channel, args, pty))
+				var payload [4]byte
+				binary.BigEndian.PutUint32(payload[:], uint32(status))
+				channel.SendRequest("exit-status", false, payload[:])
+				channel.Close()
+			}()
+		default:
+			req.Reply(false, nil)
+		}
+	}
+}
+
+// parseSSHString parses payload of exec request, a uint32 length prefixed string
+func parseSSHString(payload []byte) (string, error) {
+	if len(payload) < 4 {
+		return "", errors.New("malformed exec request")
+	}
+	n := binary.BigEndian.Uint32(payload)
+	if uint32(len(payload)-4) < n {
+		return "", errors.New("malformed exec request")
+	}
+	return string(payload[4 : 4+n]), nil
+}
+
+func (s *SSHServer) newSession(goctx context.Context, channel ssh.Channel, args []string, pty bool) *sshSession {
+	session := &sshSession{
+		channel: channel,
+		args:    args,
+		pty:     pty,
+		goctx:   goctx,
+	}
+	prompt := s.Prompt
+	if prompt == "" {
+		prompt = s.Root.Name + "> "
+	}
+	if pty {
+		// PTY of client is in raw mode, terminal echoes input and translates newlines
+		session.terminal = term.NewTerminal(channel, prompt)
+	} else {
+		session.reader = bufio.NewReader(channel)
+	}
+	return session
+}
+
+// sshSession implements RemoteSession
+type sshSession struct {
+	channel  ssh.Channel
+	terminal *term.Terminal
+	reader   *bufio.Reader
+	args     []string
+	pty      bool
+	goctx    context.Context
+}
+
+func (s *sshSession) Read(p []byte) (int, error) {
+	if s.reader != nil {
+		return s.reader.Read(p)
+	}
+	return s.channel.Read(p)
+}
+
+func (s *sshSession) Write(p []byte) (int, error) {
+	if s.terminal != nil {
+		return s.terminal.Write(p)
+	}
+	return s.channel.Write(p)
+}
+
+func (s *sshSession) Stderr() io.Writer {
+	if s.terminal != nil {
+		// stderr of PTY is merged into terminal
+		return s.terminal
+	}
+	return s.channel.Stderr()
+}
+
+func (s *sshSession) Args() []string { return s.args }
+
+func (s *sshSession) ReadLine() (string, error) {
+	if s.terminal != nil {
+		return s.terminal.ReadLine()
+	}
+	line, err := s.reader.ReadString('\n')
+	if err == io.EOF && line != "" {
+		// last line without newline
+		return line, nil
+	}
+	return line, err
+}
+
+func (s *sshSession) IsTerminal() bool         { return s.pty }
+func (s *sshSession) Context() context.Context { return s.goctx }
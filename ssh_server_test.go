@@ -0,0 +1,246 @@
+//go:build ssh
+// +build ssh
+
+package cli
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func newSSHTestSigner(t *testing.T) (ssh.Signer, ed25519.PrivateKey) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.Nil(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	require.Nil(t, err)
+	return signer, key
+}
+
+func newSSHTestServer(t *testing.T, clientKey ssh.PublicKey) *SSHServer {
+	type helloT struct {
+		Name string `cli:"n"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "hello",
+		Argv: func() interface{} { return new(helloT) },
+		Fn: func(ctx *Context) error {
+			ctx.String("hello %s\n", ctx.Argv().(*helloT).Name)
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			return errors.New("failed")
+		},
+	})
+	hostKey, _ := newSSHTestSigner(t)
+	config := &ssh.ServerConfig{PublicKeyCallback: AuthorizedKeys(clientKey)}
+	config.AddHostKey(hostKey)
+	return &SSHServer{Root: root, Config: config}
+}
+
+// serveSSHTest serves s on a loopback listener, the listener is closed by returned function
+func serveSSHTest(t *testing.T, s *SSHServer) (string, func()) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	go s.Serve(l)
+	return l.Addr().String(), func() { l.Close() }
+}
+
+func dialSSHTest(addr string, signer ssh.Signer) (*ssh.Client, error) {
+	return ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            "test",
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+}
+
+func TestNewSSHServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-ssh")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	_, hostKey := newSSHTestSigner(t)
+	block, err := ssh.MarshalPrivateKey(hostKey, "")
+	require.Nil(t, err)
+	hostKeyFile := filepath.Join(dir, "host_key")
+	require.Nil(t, ioutil.WriteFile(hostKeyFile, pem.EncodeToMemory(block), 0600))
+	signer, _ := newSSHTestSigner(t)
+	other, _ := newSSHTestSigner(t)
+	authorizedKeysFile := filepath.Join(dir, "authorized_keys")
+	require.Nil(t, ioutil.WriteFile(authorizedKeysFile, append(ssh.MarshalAuthorizedKey(signer.PublicKey()), ssh.MarshalAuthorizedKey(other.PublicKey())...), 0600))
+
+	root := &Command{Name: "app"}
+	s, err := NewSSHServer(root, hostKeyFile, authorizedKeysFile)
+	require.Nil(t, err)
+	assert.Equal(t, s.Root, root)
+
+	keys, err := LoadAuthorizedKeys(authorizedKeysFile)
+	require.Nil(t, err)
+	assert.Equal(t, len(keys), 2)
+
+	_, err = NewSSHServer(root, filepath.Join(dir, "missing"), authorizedKeysFile)
+	assert.Error(t, err)
+	_, err = NewSSHServer(root, authorizedKeysFile, authorizedKeysFile)
+	assert.Error(t, err)
+	_, err = NewSSHServer(root, hostKeyFile, filepath.Join(dir, "missing"))
+	assert.Error(t, err)
+	require.Nil(t, ioutil.WriteFile(authorizedKeysFile, []byte("not a key\n"), 0600))
+	_, err = NewSSHServer(root, hostKeyFile, authorizedKeysFile)
+	assert.Error(t, err)
+}
+
+func TestSSHServerAuth(t *testing.T) {
+	signer, _ := newSSHTestSigner(t)
+	other, _ := newSSHTestSigner(t)
+	addr, stop := serveSSHTest(t, newSSHTestServer(t, signer.PublicKey()))
+	defer stop()
+
+	_, err := dialSSHTest(addr, other)
+	assert.Error(t, err)
+
+	client, err := dialSSHTest(addr, signer)
+	require.Nil(t, err)
+	client.Close()
+}
+
+func TestSSHServerExec(t *testing.T) {
+	signer, _ := newSSHTestSigner(t)
+	addr, stop := serveSSHTest(t, newSSHTestServer(t, signer.PublicKey()))
+	defer stop()
+	client, err := dialSSHTest(addr, signer)
+	require.Nil(t, err)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	require.Nil(t, err)
+	out, err := session.Output("hello -n 'big world'")
+	require.Nil(t, err)
+	assert.Equal(t, string(out), "hello big world\n")
+
+	// exit status of command is sent to client
+	session, err = client.NewSession()
+	require.Nil(t, err)
+	err = session.Run("fail")
+	if assert.IsType(t, &ssh.ExitError{}, err) {
+		assert.Equal(t, err.(*ssh.ExitError).ExitStatus(), 1)
+	}
+
+	// malformed command line is rejected
+	session, err = client.NewSession()
+	require.Nil(t, err)
+	assert.Error(t, session.Run("hello -n 'unterminated"))
+
+	// only session channels are accepted
+	_, _, err = client.OpenChannel("direct-tcpip", nil)
+	assert.Error(t, err)
+}
+
+func TestSSHServerShell(t *testing.T) {
+	signer, _ := newSSHTestSigner(t)
+	addr, stop := serveSSHTest(t, newSSHTestServer(t, signer.PublicKey()))
+	defer stop()
+	client, err := dialSSHTest(addr, signer)
+	require.Nil(t, err)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	require.Nil(t, err)
+	stdout := new(bytes.Buffer)
+	session.Stdin = strings.NewReader("hello -n a\nhello -n b\nexit\nhello -n c\n")
+	session.Stdout = stdout
+	require.Nil(t, session.Shell())
+	require.Nil(t, session.Wait())
+	assert.Equal(t, stdout.String(), "hello a\nhello b\n")
+}
+
+func TestSSHServerRequests(t *testing.T) {
+	signer, _ := newSSHTestSigner(t)
+	addr, stop := serveSSHTest(t, newSSHTestServer(t, signer.PublicKey()))
+	defer stop()
+	client, err := dialSSHTest(addr, signer)
+	require.Nil(t, err)
+	defer client.Close()
+
+	channel, requests, err := client.OpenChannel("session", nil)
+	require.Nil(t, err)
+	go ssh.DiscardRequests(requests)
+	ok, err := channel.SendRequest("env", true, ssh.Marshal(struct{ Name, Value string }{"K", "V"}))
+	require.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = channel.SendRequest("x11-req", true, nil)
+	require.Nil(t, err)
+	assert.False(t, ok)
+	ok, err = channel.SendRequest("exec", true, []byte{0, 0, 0, 9, 'h'})
+	require.Nil(t, err)
+	assert.False(t, ok)
+
+	channel, requests, err = client.OpenChannel("session", nil)
+	require.Nil(t, err)
+	go ssh.DiscardRequests(requests)
+	ok, err = channel.SendRequest("shell", true, nil)
+	require.Nil(t, err)
+	assert.True(t, ok)
+	// a session runs a single shell or command
+	ok, err = channel.SendRequest("exec", true, ssh.Marshal(struct{ Command string }{"hello"}))
+	require.Nil(t, err)
+	assert.False(t, ok)
+	channel.Close()
+}
+
+func TestParseSSHString(t *testing.T) {
+	for i, tt := range []struct {
+		payload []byte
+		s       string
+		ok      bool
+	}{
+		{nil, "", false},
+		{[]byte{0, 0, 0}, "", false},
+		{[]byte{0, 0, 0, 0}, "", true},
+		{[]byte{0, 0, 0, 2, 'h', 'i'}, "hi", true},
+		{[]byte{0, 0, 0, 2, 'h', 'i', '!'}, "hi", true},
+		{[]byte{0, 0, 0, 3, 'h', 'i'}, "", false},
+		{[]byte{0xFF, 0xFF, 0xFF, 0xFF, 'h'}, "", false},
+	} {
+		s, err := parseSSHString(tt.payload)
+		assert.Equal(t, err == nil, tt.ok, "case %d", i)
+		assert.Equal(t, s, tt.s, "case %d", i)
+	}
+}
+
+func TestSSHServerHandshakeTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { SSHHandshakeTimeout = timeout }(SSHHandshakeTimeout)
+	SSHHandshakeTimeout = 50 * time.Millisecond
+
+	signer, _ := newSSHTestSigner(t)
+	s := newSSHTestServer(t, signer.PublicKey())
+	server, client := net.Pipe()
+	defer client.Close()
+	done := make(chan struct{})
+	go func() {
+		s.serveConn(server)
+		close(done)
+	}()
+	// client never finishes handshake
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed after handshake timeout")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !windows
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!windows
+
+package cli
+
+import (
+	"errors"
+	"os"
+)
+
+func dupStatusFile(fd int) (*os.File, error) {
+	return nil, errors.New("status fd is not supported on this platform")
+}
@@ -0,0 +1,20 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly
+// +build linux darwin freebsd netbsd openbsd dragonfly
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// dupStatusFile returns a file of duplicate of fd, fd is owned by parent process,
+// so it's neither closed nor kept after command finished
+func dupStatusFile(fd int) (*os.File, error) {
+	nfd, err := syscall.Dup(fd)
+	if err != nil {
+		return nil, err
+	}
+	syscall.CloseOnExec(nfd)
+	return os.NewFile(uintptr(nfd), "status-fd"), nil
+}
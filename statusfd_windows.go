@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package cli
+
+import (
+	"os"
+	"syscall"
+)
+
+// dupStatusFile returns a file of duplicate of handle fd, fd is owned by parent
+// process, so it's neither closed nor kept after command finished
+func dupStatusFile(fd int) (*os.File, error) {
+	process, err := syscall.GetCurrentProcess()
+	if err != nil {
+		return nil, err
+	}
+	var handle syscall.Handle
+	if err := syscall.DuplicateHandle(process, syscall.Handle(fd), process, &handle, 0, false, syscall.DUPLICATE_SAME_ACCESS); err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), "status-fd"), nil
+}
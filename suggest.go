@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultSuggestThreshold is max differ rate of edit distance, used by FuzzyMatcher
+// if threshold is not positive
+const DefaultSuggestThreshold = 0.3
+
+type (
+	// SuggestMatcher matches input with candidate, smaller score ranks first
+	SuggestMatcher func(input, candidate string) (score float32, ok bool)
+
+	// SuggestOptions configures suggestions of commands not found, e.g.
+	//
+	//	root.Suggest = &cli.SuggestOptions{
+	//		MaxCount: 3,
+	//		Matchers: []cli.SuggestMatcher{cli.PrefixMatcher, cli.FuzzyMatcher(0.2)},
+	//	}
+	SuggestOptions struct {
+		// Threshold is max differ rate of edit distance used by fuzzy matcher if
+		// Matchers is empty, DefaultSuggestThreshold used if zero
+		Threshold float32
+		// MaxCount limits number of suggestions, zero means no limit
+		MaxCount int
+		// Matchers are tried in order for each candidate, candidates matched by earlier
+		// matchers rank first. FuzzyMatcher(Threshold) used if empty.
+		Matchers []SuggestMatcher
+	}
+)
+
+// PrefixMatcher matches candidates which start with input, shorter candidates rank first
+func PrefixMatcher(input, candidate string) (float32, bool) {
+	if input == "" || !strings.HasPrefix(candidate, input) {
+		return 0, false
+	}
+	return float32(len(candidate) - len(input)), true
+}
+
+// FuzzyMatcher returns a matcher which matches candidates by edit distance, candidates
+// with differ rate greater than threshold are not matched
+func FuzzyMatcher(threshold float32) SuggestMatcher {
+	if threshold <= 0 {
+		threshold = DefaultSuggestThreshold
+	}
+	return func(input, candidate string) (float32, bool) {
+		return matchWithMinDifferRate(input, candidate, threshold)
+	}
+}
+
+// matchers returns matchers of opts, opts could be nil
+func (opts *SuggestOptions) matchers() []SuggestMatcher {
+	if opts == nil {
+		return []SuggestMatcher{FuzzyMatcher(DefaultSuggestThreshold)}
+	}
+	if len(opts.Matchers) > 0 {
+		return opts.Matchers
+	}
+	return []SuggestMatcher{FuzzyMatcher(opts.Threshold)}
+}
+
+// suggest returns candidates matched with input by opts, opts could be nil
+func (opts *SuggestOptions) suggest(input string, candidates []string) []string {
+	type rank struct {
+		s       string
+		matcher int
+		score   float32
+	}
+	var (
+		matchers = opts.matchers()
+		ranks    = []rank{}
+	)
+	for _, candidate := range candidates {
+		for i, m := range matchers {
+			if score, ok := m(input, candidate); ok {
+				ranks = append(ranks, rank{s: candidate, matcher: i, score: score})
+				break
+			}
+		}
+	}
+	sort.SliceStable(ranks, func(i, j int) bool {
+		if ranks[i].matcher != ranks[j].matcher {
+			return ranks[i].matcher < ranks[j].matcher
+		}
+		return ranks[i].score < ranks[j].score
+	})
+	if opts != nil && opts.MaxCount > 0 && len(ranks) > opts.MaxCount {
+		ranks = ranks[:opts.MaxCount]
+	}
+	ret := make([]string, 0, len(ranks))
+	for _, r := range ranks {
+		ret = append(ret, r.s)
+	}
+	return ret
+}
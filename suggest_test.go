@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggestOptions(t *testing.T) {
+	root := &Command{Name: "app"}
+	for _, name := range []string{"start", "stop", "status", "stat", "list"} {
+		root.Register(&Command{Name: name, Fn: donothing})
+	}
+	assert.Equal(t, root.Suggestions("stat"), []string{"stat", "start", "status", "stop"})
+
+	root.Suggest = &SuggestOptions{Matchers: []SuggestMatcher{PrefixMatcher, FuzzyMatcher(0)}}
+	assert.Equal(t, root.Suggestions("sta"), []string{"stat", "start", "status", "stop"})
+	root.Suggest.MaxCount = 2
+	assert.Equal(t, root.Suggestions("sta"), []string{"stat", "start"})
+
+	root.Suggest = &SuggestOptions{Threshold: 0.1}
+	assert.Equal(t, root.Suggestions("lst"), []string{})
+	root.Suggest = &SuggestOptions{Threshold: 0.2}
+	assert.Equal(t, root.Suggestions("lst"), []string{"list"})
+
+	// suggestions of children are ranked by root
+	assert.Equal(t, root.findChild("list").Suggestions("lst"), []string{"list"})
+}
+
+func TestSuggestionsHidden(t *testing.T) {
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{Name: "user"})
+	user.Register(&Command{Name: "reset", Fn: donothing})
+	admin := root.Register(&Command{Name: "admin", Hidden: true})
+	admin.Register(&Command{Name: "reset", Fn: donothing})
+	admin.Register(&Command{Name: "purge", Fn: donothing})
+
+	assert.Equal(t, root.Suggestions("user reset"), []string{"user reset"})
+	// descendants of hidden commands are never suggested
+	assert.NotContains(t, root.Suggestions("admin reset"), "admin reset")
+	assert.NotContains(t, root.Suggestions("admin purge"), "admin purge")
+}
+
+func TestPrefixMatcher(t *testing.T) {
+	score, ok := PrefixMatcher("st", "start")
+	assert.True(t, ok)
+	assert.Equal(t, score, float32(3))
+	_, ok = PrefixMatcher("", "start")
+	assert.False(t, ok)
+	_, ok = PrefixMatcher("sp", "start")
+	assert.False(t, ok)
+}
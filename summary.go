@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Summary counts results of a bulk operation, it's written at the end of
+// execution as a line like `created 3, updated 1, skipped 0, failed 2 in 1.2s`,
+// or as a JSON object if output format is json, see OutputFormat
+type Summary struct {
+	locker  sync.Mutex // protect following data
+	start   time.Time
+	created int
+	updated int
+	skipped int
+	failed  int
+	total   int
+}
+
+// SummaryResult is snapshot of a Summary
+type SummaryResult struct {
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Skipped int           `json:"skipped"`
+	Failed  int           `json:"failed"`
+	Total   int           `json:"total,omitempty"`
+	Elapsed time.Duration `json:"-"`
+}
+
+// Summary returns summary of ctx, it's created by first call and
+// written after command finished
+func (ctx *Context) Summary() *Summary {
+	if ctx.summary == nil {
+		start := ctx.startedAt
+		if start.IsZero() {
+			start = time.Now()
+		}
+		ctx.summary = &Summary{start: start}
+	}
+	return ctx.summary
+}
+
+func (s *Summary) add(counter *int, n int) *Summary {
+	s.locker.Lock()
+	*counter += n
+	s.locker.Unlock()
+	return s
+}
+
+// Created adds n to count of created items
+func (s *Summary) Created(n int) *Summary { return s.add(&s.created, n) }
+
+// Updated adds n to count of updated items
+func (s *Summary) Updated(n int) *Summary { return s.add(&s.updated, n) }
+
+// Skipped adds n to count of skipped items
+func (s *Summary) Skipped(n int) *Summary { return s.add(&s.skipped, n) }
+
+// Failed adds n to count of failed items
+func (s *Summary) Failed(n int) *Summary { return s.add(&s.failed, n) }
+
+// Total adds n to count of expected items, it's used for reporting pending
+// items while command interrupted
+func (s *Summary) Total(n int) *Summary { return s.add(&s.total, n) }
+
+// Result returns snapshot of summary
+func (s *Summary) Result() SummaryResult {
+	s.locker.Lock()
+	defer s.locker.Unlock()
+	return SummaryResult{
+		Created: s.created,
+		Updated: s.updated,
+		Skipped: s.skipped,
+		Failed:  s.failed,
+		Total:   s.total,
+		Elapsed: time.Since(s.start),
+	}
+}
+
+// Completed returns count of items which are created, updated, skipped or failed
+func (r SummaryResult) Completed() int {
+	return r.Created + r.Updated + r.Skipped + r.Failed
+}
+
+// Pending returns count of expected items which are not completed
+func (r SummaryResult) Pending() int {
+	if pending := r.Total - r.Completed(); pending > 0 {
+		return pending
+	}
+	return 0
+}
+
+// String returns human-readable summary
+func (r SummaryResult) String() string {
+	return r.format(fmt.Sprintf("failed %d", r.Failed))
+}
+
+// summaryJSON is JSON object of SummaryResult with elapsed seconds
+type summaryJSON struct {
+	SummaryResult
+	Elapsed float64 `json:"elapsed"`
+}
+
+func (r SummaryResult) jsonObject() summaryJSON {
+	return summaryJSON{r, r.Elapsed.Seconds()}
+}
+
+func (r SummaryResult) format(failed string) string {
+	return fmt.Sprintf("created %d, updated %d, skipped %d, %s in %s",
+		r.Created, r.Updated, r.Skipped, failed, formatElapsed(r.Elapsed))
+}
+
+func formatElapsed(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.String()
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(100 * time.Millisecond).String()
+}
+
+func (ctx *Context) writeSummary() {
+	if ctx.summary == nil {
+		return
+	}
+	r := ctx.summary.Result()
+	if ctx.outputFormat() == OutputFormatJSON {
+		ctx.JSONln(r.jsonObject())
+		return
+	}
+	failed := fmt.Sprintf("failed %d", r.Failed)
+	if r.Failed > 0 {
+		failed = ctx.color.Red(failed)
+	}
+	ctx.String("%s\n", r.format(failed))
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummary(t *testing.T) {
+	type argT struct {
+		OutputFormat
+	}
+	root := &Command{
+		Name: "sync",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.Summary().Created(2).Updated(1)
+			ctx.Summary().Skipped(3).Failed(1).Created(1)
+			return nil
+		},
+	}
+
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith(nil, w, nil))
+	assert.True(t, strings.HasPrefix(w.String(), "created 3, updated 1, skipped 3, failed 1 in "))
+
+	w.Reset()
+	assert.Nil(t, root.RunWith([]string{"-o", "json"}, w, nil))
+	var result map[string]interface{}
+	assert.Nil(t, json.Unmarshal(w.Bytes(), &result))
+	assert.Equal(t, result["created"], float64(3))
+	assert.Equal(t, result["failed"], float64(1))
+	_, ok := result["elapsed"]
+	assert.True(t, ok)
+
+	// no summary written if Summary not called
+	root.Fn = donothing
+	w.Reset()
+	assert.Nil(t, root.RunWith(nil, w, nil))
+	assert.Equal(t, w.String(), "")
+}
+
+func TestFormatElapsed(t *testing.T) {
+	for _, tc := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{500 * time.Microsecond, "500µs"},
+		{123456 * time.Microsecond, "123ms"},
+		{1234 * time.Millisecond, "1.2s"},
+		{90 * time.Second, "1m30s"},
+	} {
+		assert.Equal(t, formatElapsed(tc.d), tc.want)
+	}
+}
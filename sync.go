@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SyncOp is kind of sync operation
+type SyncOp int
+
+// Sync operations planned by Syncer
+const (
+	SyncCreate SyncOp = iota
+	SyncUpdate
+	SyncDelete
+)
+
+func (op SyncOp) String() string {
+	switch op {
+	case SyncCreate:
+		return "create"
+	case SyncUpdate:
+		return "update"
+	case SyncDelete:
+		return "delete"
+	}
+	return fmt.Sprintf("SyncOp(%d)", int(op))
+}
+
+type (
+	// SyncItem is an entry of source or target listing
+	SyncItem struct {
+		Key     string // Unique key of item, e.g. relative path of file
+		Size    int64
+		ModTime time.Time
+		Hash    string // Optional content hash, compared if both sides have
+	}
+
+	// SyncOperation is a planned operation, Source is empty for SyncDelete
+	// and Target is empty for SyncCreate
+	SyncOperation struct {
+		Op     SyncOp
+		Key    string
+		Source SyncItem
+		Target SyncItem
+	}
+
+	// Syncer makes target the same as source, it's direction-agnostic:
+	// swap Source and Target listings for upload and download.
+	Syncer struct {
+		Source func() ([]SyncItem, error) // Lists source items
+		Target func() ([]SyncItem, error) // Lists target items
+
+		// Equal reports whether target item is up to date,
+		// hashes are compared if both not empty, otherwise sizes and modification times.
+		Equal func(source, target SyncItem) bool
+
+		// Apply executes an operation, it's called concurrently if Parallel > 1
+		Apply func(op SyncOperation) error
+
+		Delete   bool // Deletes target items which are missing in source
+		DryRun   bool // Shows plan without executing
+		Parallel int  // Maximum number of concurrent operations, 1 if not positive
+	}
+)
+
+func syncItemEqual(source, target SyncItem) bool {
+	if source.Hash != "" && target.Hash != "" {
+		return source.Hash == target.Hash
+	}
+	return source.Size == target.Size && source.ModTime.Equal(target.ModTime)
+}
+
+// Plan compares listings and returns operations sorted by key
+func (s *Syncer) Plan() ([]SyncOperation, error) {
+	sources, err := s.Source()
+	if err != nil {
+		return nil, fmt.Errorf("list source: %v", err)
+	}
+	targets, err := s.Target()
+	if err != nil {
+		return nil, fmt.Errorf("list target: %v", err)
+	}
+	equal := s.Equal
+	if equal == nil {
+		equal = syncItemEqual
+	}
+	targetMap := make(map[string]SyncItem, len(targets))
+	for _, item := range targets {
+		targetMap[item.Key] = item
+	}
+	ops := []SyncOperation{}
+	for _, source := range sources {
+		target, ok := targetMap[source.Key]
+		if !ok {
+			ops = append(ops, SyncOperation{Op: SyncCreate, Key: source.Key, Source: source})
+			continue
+		}
+		delete(targetMap, source.Key)
+		if !equal(source, target) {
+			ops = append(ops, SyncOperation{Op: SyncUpdate, Key: source.Key, Source: source, Target: target})
+		}
+	}
+	if s.Delete {
+		for _, target := range targetMap {
+			ops = append(ops, SyncOperation{Op: SyncDelete, Key: target.Key, Target: target})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Key < ops[j].Key })
+	return ops, nil
+}
+
+// Sync plans operations of s, writes the plan and executes it.
+// Progress is emitted as progress events, see EmitProgress.
+// Errors of operations don't stop others, they are returned together.
+// Queueing stops if Context.Context is done, and the context error is returned
+// together with errors of applied operations.
+func (ctx *Context) Sync(s *Syncer) error {
+	ops, err := s.Plan()
+	if err != nil {
+		return err
+	}
+	ctx.writeSyncPlan(ops)
+	if s.DryRun || len(ops) == 0 {
+		return nil
+	}
+
+	parallel := s.Parallel
+	if parallel <= 0 {
+		parallel = 1
+	}
+	var (
+		wg      sync.WaitGroup
+		locker  sync.Mutex // protect done and errs
+		done    int64
+		errs    multiError
+		queue   = make(chan SyncOperation)
+		total   = int64(len(ops))
+		goctx   = ctx.Context()
+		stopped error
+		// progress is drawn on terminal, operations are emitted by progress events
+		bar = ctx.ProgressBar(total)
+	)
+	if bar.tty {
+		defer bar.Finish()
+	} else {
+		bar = nil
+	}
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for op := range queue {
+				err := s.Apply(op)
+				locker.Lock()
+				done++
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s %s: %w", op.Op, op.Key, err))
+				}
+				if bar != nil {
+					bar.step(done, op.Op.String()+" "+op.Key)
+				} else {
+					ctx.EmitProgress(done, total, op.Op.String()+" "+op.Key)
+				}
+				locker.Unlock()
+			}
+		}()
+	}
+queue:
+	for _, op := range ops {
+		select {
+		case queue <- op:
+		case <-goctx.Done():
+			stopped = goctx.Err()
+			break queue
+		}
+	}
+	close(queue)
+	wg.Wait()
+	if stopped != nil {
+		errs = append(errs, stopped)
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (ctx *Context) writeSyncPlan(ops []SyncOperation) {
+	if len(ops) == 0 {
+		ctx.String("up to date\n")
+		return
+	}
+	for _, op := range ops {
+		switch op.Op {
+		case SyncCreate:
+			ctx.String("%s %s\n", ctx.color.Green("+"), op.Key)
+		case SyncUpdate:
+			ctx.String("%s %s\n", ctx.color.Yellow("~"), op.Key)
+		case SyncDelete:
+			ctx.String("%s %s\n", ctx.color.Red("-"), op.Key)
+		}
+	}
+}
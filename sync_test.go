@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyncerPlan(t *testing.T) {
+	now := time.Now()
+	s := &Syncer{
+		Source: func() ([]SyncItem, error) {
+			return []SyncItem{
+				{Key: "c", Size: 1, ModTime: now},
+				{Key: "a", Size: 1, ModTime: now},
+				{Key: "b", Size: 2, ModTime: now},
+				{Key: "d", Hash: "x", Size: 3},
+			}, nil
+		},
+		Target: func() ([]SyncItem, error) {
+			return []SyncItem{
+				{Key: "a", Size: 1, ModTime: now},
+				{Key: "b", Size: 1, ModTime: now},
+				{Key: "d", Hash: "x", Size: 4},
+				{Key: "e"},
+			}, nil
+		},
+	}
+	ops, err := s.Plan()
+	require.Nil(t, err)
+	assert.Equal(t, len(ops), 2)
+	assert.Equal(t, ops[0].Op, SyncUpdate)
+	assert.Equal(t, ops[0].Key, "b")
+	assert.Equal(t, ops[1].Op, SyncCreate)
+	assert.Equal(t, ops[1].Key, "c")
+
+	s.Delete = true
+	ops, err = s.Plan()
+	require.Nil(t, err)
+	assert.Equal(t, len(ops), 3)
+	assert.Equal(t, ops[2].Op, SyncDelete)
+	assert.Equal(t, ops[2].Key, "e")
+
+	s.Target = func() ([]SyncItem, error) { return nil, errors.New("offline") }
+	_, err = s.Plan()
+	assert.Equal(t, err.Error(), "list target: offline")
+}
+
+func TestContextSync(t *testing.T) {
+	var (
+		locker  sync.Mutex
+		applied []string
+	)
+	s := &Syncer{
+		Source: func() ([]SyncItem, error) {
+			return []SyncItem{{Key: "a"}, {Key: "b"}, {Key: "c"}}, nil
+		},
+		Target: func() ([]SyncItem, error) {
+			return []SyncItem{{Key: "c"}, {Key: "d"}}, nil
+		},
+		Apply: func(op SyncOperation) error {
+			locker.Lock()
+			defer locker.Unlock()
+			applied = append(applied, op.Op.String()+" "+op.Key)
+			if op.Key == "b" {
+				return errors.New("permission denied")
+			}
+			return nil
+		},
+		Delete:   true,
+		DryRun:   true,
+		Parallel: 2,
+	}
+	root := &Command{
+		Name: "sync",
+		Fn: func(ctx *Context) error {
+			return ctx.Sync(s)
+		},
+	}
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith(nil, w, nil))
+	assert.Equal(t, w.String(), "+ a\n+ b\n- d\n")
+	assert.Equal(t, len(applied), 0)
+
+	s.DryRun = false
+	w.Reset()
+	err := root.RunWith(nil, w, nil)
+	assert.Equal(t, err.Error(), "create b: permission denied")
+	sort.Strings(applied)
+	assert.Equal(t, applied, []string{"create a", "create b", "delete d"})
+}
+
+func TestContextSyncStopped(t *testing.T) {
+	var applied int32
+	s := &Syncer{
+		Source: func() ([]SyncItem, error) {
+			return []SyncItem{{Key: "a"}, {Key: "b"}, {Key: "c"}, {Key: "d"}, {Key: "e"}}, nil
+		},
+		Target: func() ([]SyncItem, error) { return nil, nil },
+		Apply: func(op SyncOperation) error {
+			atomic.AddInt32(&applied, 1)
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+	}
+	root := &Command{
+		Name:    "sync",
+		Timeout: 30 * time.Millisecond,
+		Fn: func(ctx *Context) error {
+			return ctx.Sync(s)
+		},
+	}
+	err := root.RunWith(nil, ioutil.Discard, nil)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, atomic.LoadInt32(&applied) < 5)
+}
+
+func TestContextSyncProgress(t *testing.T) {
+	type argT struct {
+		EventsHelper
+	}
+	defer func(w io.Writer) { EventsOutput = w }(EventsOutput)
+	defer func(w io.Writer) { ProgressOutput = w }(ProgressOutput)
+	events, progress := new(bytes.Buffer), new(bytes.Buffer)
+	EventsOutput, ProgressOutput = events, progress
+
+	s := &Syncer{
+		Source: func() ([]SyncItem, error) {
+			return []SyncItem{{Key: "a"}, {Key: "b"}}, nil
+		},
+		Target: func() ([]SyncItem, error) { return nil, nil },
+		Apply:  func(op SyncOperation) error { return nil },
+	}
+	root := &Command{
+		Name: "sync",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			return ctx.Sync(s)
+		},
+	}
+	require.Nil(t, root.RunWith([]string{"--events", "ndjson"}, new(bytes.Buffer), nil))
+	// progress bar is drawn only on terminal
+	assert.Equal(t, progress.String(), "")
+	var messages []string
+	for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+		var event Event
+		require.Nil(t, json.Unmarshal([]byte(line), &event), line)
+		if event.Type == EventProgress {
+			assert.Equal(t, event.Total, int64(2))
+			messages = append(messages, event.Message)
+		}
+	}
+	assert.Equal(t, messages, []string{"create a", "create b"})
+}
@@ -0,0 +1,177 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Table represents a text table, columns are sized by display width of cells
+type Table struct {
+	Headers []string
+	Rows    [][]string
+	Border  bool // Draw borders with box-drawing characters
+}
+
+// NewTableOf creates a table from a slice of structs or struct pointers,
+// headers are names of exported fields or `table` tags of fields,
+// fields tagged with `table:"-"` are ignored.
+//
+//	type pod struct {
+//		Name   string `table:"NAME"`
+//		Status string `table:"STATUS"`
+//		Node   string `table:"-"`
+//	}
+func NewTableOf(slice interface{}) (*Table, error) {
+	val := reflect.ValueOf(slice)
+	if val.Kind() != reflect.Slice && val.Kind() != reflect.Array {
+		return nil, fmt.Errorf("table: %T is not a slice", slice)
+	}
+	typ := val.Type().Elem()
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("table: element of %T is not a struct", slice)
+	}
+	table := &Table{}
+	fields := []int{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := field.Tag.Get("table")
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		table.Headers = append(table.Headers, name)
+		fields = append(fields, i)
+	}
+	for i := 0; i < val.Len(); i++ {
+		elem := val.Index(i)
+		row := make([]string, len(fields))
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				table.Rows = append(table.Rows, row)
+				continue
+			}
+			elem = elem.Elem()
+		}
+		for j, index := range fields {
+			row[j] = cellString(elem.Field(index))
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table, nil
+}
+
+func cellString(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// String renders table
+func (t *Table) String() string {
+	columns := len(t.Headers)
+	for _, row := range t.Rows {
+		if len(row) > columns {
+			columns = len(row)
+		}
+	}
+	widths := make([]int, columns)
+	measure := func(row []string) {
+		for i, cell := range row {
+			if w := stringWidth(cell); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	measure(t.Headers)
+	for _, row := range t.Rows {
+		measure(row)
+	}
+
+	var (
+		buf  bytes.Buffer
+		line = func(left, middle, right string) {
+			buf.WriteString(left)
+			for i, w := range widths {
+				if i > 0 {
+					buf.WriteString(middle)
+				}
+				buf.WriteString(strings.Repeat("─", w+2))
+			}
+			buf.WriteString(right)
+			buf.WriteByte('\n')
+		}
+		writeRow = func(row []string) {
+			if t.Border {
+				buf.WriteString("│ ")
+			}
+			for i, w := range widths {
+				cell := ""
+				if i < len(row) {
+					cell = row[i]
+				}
+				if i > 0 {
+					if t.Border {
+						buf.WriteString(" │ ")
+					} else {
+						buf.WriteString("  ")
+					}
+				}
+				buf.WriteString(cell)
+				if t.Border || i < len(widths)-1 {
+					buf.WriteString(strings.Repeat(" ", w-stringWidth(cell)))
+				}
+			}
+			if t.Border {
+				buf.WriteString(" │")
+			}
+			buf.WriteByte('\n')
+		}
+	)
+	if t.Border {
+		line("┌", "┬", "┐")
+	}
+	if len(t.Headers) > 0 {
+		writeRow(t.Headers)
+		if t.Border {
+			line("├", "┼", "┤")
+		}
+	}
+	for _, row := range t.Rows {
+		writeRow(row)
+	}
+	if t.Border {
+		line("└", "┴", "┘")
+	}
+	return buf.String()
+}
+
+// Table writes rows as a table with headers to writer
+func (ctx *Context) Table(headers []string, rows [][]string) *Context {
+	return ctx.RenderTable(&Table{Headers: headers, Rows: rows})
+}
+
+// TableOf writes a slice of structs as a table to writer, see NewTableOf
+func (ctx *Context) TableOf(slice interface{}) error {
+	table, err := NewTableOf(slice)
+	if err != nil {
+		return err
+	}
+	ctx.RenderTable(table)
+	return nil
+}
+
+// RenderTable writes table to writer
+func (ctx *Context) RenderTable(table *Table) *Context {
+	return ctx.String("%s", table.String())
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTable(t *testing.T) {
+	table := &Table{
+		Headers: []string{"NAME", "STATUS"},
+		Rows:    [][]string{{"api", "running"}, {"数据库", "ok"}, {"x"}},
+	}
+	assert.Equal(t, table.String(), ""+
+		"NAME    STATUS\n"+
+		"api     running\n"+
+		"数据库  ok\n"+
+		"x       \n")
+	table.Border = true
+	assert.Equal(t, table.String(), ""+
+		"┌────────┬─────────┐\n"+
+		"│ NAME   │ STATUS  │\n"+
+		"├────────┼─────────┤\n"+
+		"│ api    │ running │\n"+
+		"│ 数据库 │ ok      │\n"+
+		"│ x      │         │\n"+
+		"└────────┴─────────┘\n")
+}
+
+func TestTableOf(t *testing.T) {
+	type pod struct {
+		Name     string `table:"NAME"`
+		Restarts int
+		Node     *string `table:"NODE"`
+		Secret   string  `table:"-"`
+		internal string
+	}
+	node := "n1"
+	table, err := NewTableOf([]*pod{{Name: "a", Restarts: 2, Node: &node}, nil, {Name: "b"}})
+	require.Nil(t, err)
+	assert.Equal(t, table.Headers, []string{"NAME", "Restarts", "NODE"})
+	assert.Equal(t, table.Rows, [][]string{{"a", "2", "n1"}, {"", "", ""}, {"b", "0", ""}})
+
+	_, err = NewTableOf(pod{})
+	assert.Error(t, err)
+	_, err = NewTableOf([]int{1})
+	assert.Error(t, err)
+
+	w := new(bytes.Buffer)
+	ctx := &Context{writer: w}
+	require.Nil(t, ctx.TableOf([]pod{{Name: "a"}}))
+	ctx.Table([]string{"A"}, [][]string{{"1"}})
+	assert.Equal(t, w.String(), "NAME  Restarts  NODE\na     0         \nA\n1\n")
+}
@@ -1,6 +1,7 @@
 package cli
 
 import (
+	"fmt"
 	"reflect"
 	"strings"
 )
@@ -12,11 +13,17 @@ const (
 
 	tagUsage  = "usage"
 	tagDefaut = "dft"
+	tagEnv    = "env"
+	tagOS     = "os"   // restricts flag to GOOS list, e.g. `os:"linux,darwin"`
+	tagArch   = "arch" // restricts flag to GOARCH list, e.g. `arch:"amd64,arm64"`
 	tagName   = "name"
 	tagPrompt = "prompt"
 	tagParser = "parser"
 	tagSep    = "sep" // used to seperate key/value pair of map, default is `=`
 
+	tagAlias    = "alias"    // deprecated names of flag, e.g. `alias:"old-name"`
+	tagAliasMap = "aliasmap" // name of AliasMapper for values of deprecated names
+
 	dashOne = "-"
 	dashTwo = "--"
 
@@ -41,10 +48,17 @@ type tagProperty struct {
 
 	usage         string            `usage:"usage string"`
 	dft           string            `dft:"default value or expression"`
+	env           string            `env:"environment variable"`
 	name          string            `name:"tag reference name"`
 	prompt        string            `prompt:"prompt string"`
 	sep           string            `sep:"string for seperate kay/value pair of map"`
 	parserCreator FlagParserCreator `parser:"parser for flag"`
+	aliases       []string          `alias:"deprecated names"`
+	aliasMapper   AliasMapper       `aliasmap:"mapper for values of deprecated names"`
+
+	// unsupported is current platform if flag restricted by `os` or `arch` tags,
+	// empty if flag is supported
+	unsupported string
 
 	// flag names
 	shortNames []string
@@ -95,6 +109,12 @@ func parseTag(fieldName string, tag reflect.StructTag) (p *tagProperty, isEmpty
 	// `dft` TAG
 	p.dft = tag.Get(tagDefaut)
 
+	// `env` TAG
+	p.env = tag.Get(tagEnv)
+
+	// `os` and `arch` TAGs
+	p.unsupported = unsupportedPlatform(splitTagList(tag.Get(tagOS)), splitTagList(tag.Get(tagArch)))
+
 	// `name` TAG
 	p.name = tag.Get(tagName)
 
@@ -108,6 +128,17 @@ func parseTag(fieldName string, tag reflect.StructTag) (p *tagProperty, isEmpty
 		}
 	}
 
+	// `alias` and `aliasmap` TAGs
+	p.aliases = parseAliasNames(tag.Get(tagAlias))
+	if mapperName := tag.Get(tagAliasMap); mapperName != "" {
+		mapper, ok := aliasMappers[mapperName]
+		if !ok {
+			err = fmt.Errorf("aliasmap %s of field %s not registered", mapperName, fieldName)
+			return
+		}
+		p.aliasMapper = mapper
+	}
+
 	// `sep` TAG
 	p.sep = defaultSepForKeyValueOfMap
 	if sep := tag.Get(tagSep); sep != "" {
@@ -0,0 +1,189 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type (
+	// Invocation is a record of running command
+	Invocation struct {
+		Command  string        `json:"command"`
+		Time     time.Time     `json:"time"`
+		Duration time.Duration `json:"duration"`
+		Failed   bool          `json:"failed"`
+	}
+
+	// TelemetryStore stores invocation history locally, it's opt-in by Command.Telemetry
+	TelemetryStore interface {
+		Record(Invocation) error
+		Invocations() ([]Invocation, error)
+	}
+
+	// CommandStats is summary of invocations of a command
+	CommandStats struct {
+		Command     string
+		Count       int
+		Failures    int
+		AvgDuration time.Duration
+	}
+)
+
+// FailureRate returns rate of failed invocations
+func (s CommandStats) FailureRate() float64 {
+	if s.Count == 0 {
+		return 0
+	}
+	return float64(s.Failures) / float64(s.Count)
+}
+
+type fileTelemetry struct {
+	filename string
+}
+
+// NewFileTelemetry creates a TelemetryStore which appends invocations as NDJSON to filename
+func NewFileTelemetry(filename string) TelemetryStore {
+	return &fileTelemetry{filename: filename}
+}
+
+func (t *fileTelemetry) Record(invocation Invocation) error {
+	data, err := json.Marshal(invocation)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.filename), 0755); err != nil {
+		return err
+	}
+	unlock, err := LockFile(t.filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	file, err := os.OpenFile(t.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+func (t *fileTelemetry) Invocations() ([]Invocation, error) {
+	file, err := os.Open(t.filename)
+	if os.IsNotExist(err) {
+		return []Invocation{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	invocations := []Invocation{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var invocation Invocation
+		// skip broken lines, e.g. partial write
+		if err := json.Unmarshal(scanner.Bytes(), &invocation); err == nil {
+			invocations = append(invocations, invocation)
+		}
+	}
+	return invocations, scanner.Err()
+}
+
+// recordInvocation records invocation if telemetry of root command enabled
+func (cmd *Command) recordInvocation(ctx *Context, start time.Time, err error) {
+	if cmd.Telemetry == nil {
+		return
+	}
+	cmd.Telemetry.Record(Invocation{
+		Command:  ctx.Path(),
+		Time:     start,
+		Duration: time.Since(start),
+		Failed:   err != nil && err != ExitError,
+	})
+}
+
+// SummarizeInvocations summarizes invocations by command, sorted by count
+func SummarizeInvocations(invocations []Invocation) []CommandStats {
+	var (
+		index     = map[string]int{}
+		stats     = []CommandStats{}
+		durations = []time.Duration{}
+	)
+	for _, invocation := range invocations {
+		i, ok := index[invocation.Command]
+		if !ok {
+			i = len(stats)
+			index[invocation.Command] = i
+			stats = append(stats, CommandStats{Command: invocation.Command})
+			durations = append(durations, 0)
+		}
+		stats[i].Count++
+		if invocation.Failed {
+			stats[i].Failures++
+		}
+		durations[i] += invocation.Duration
+	}
+	for i := range stats {
+		stats[i].AvgDuration = durations[i] / time.Duration(stats[i].Count)
+	}
+	sort.SliceStable(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Command < stats[j].Command
+	})
+	return stats
+}
+
+type statsT struct {
+	Helper
+	Top int `cli:"n,top" usage:"show top n commands" dft:"10"`
+}
+
+// StatsCommand returns a command which summarizes invocation history recorded by
+// telemetry store of root command, e.g. `app stats`
+func StatsCommand(desc string) *Command {
+	if desc == "" {
+		desc = "summarize usage of commands"
+	}
+	return &Command{
+		Name: "stats",
+		Desc: desc,
+		Argv: func() interface{} { return new(statsT) },
+		Fn: func(ctx *Context) error {
+			root := ctx.Command().Root()
+			if root.Telemetry == nil {
+				return fmt.Errorf("telemetry is not enabled")
+			}
+			invocations, err := root.Telemetry.Invocations()
+			if err != nil {
+				return err
+			}
+			stats := SummarizeInvocations(invocations)
+			if top := ctx.Argv().(*statsT).Top; top > 0 && len(stats) > top {
+				stats = stats[:top]
+			}
+			rows := make([][]string, 0, len(stats))
+			for _, s := range stats {
+				name := s.Command
+				if name == "" {
+					name = root.Name
+				}
+				rows = append(rows, []string{
+					name,
+					strconv.Itoa(s.Count),
+					fmt.Sprintf("%.1f%%", s.FailureRate()*100),
+					s.AvgDuration.Round(time.Millisecond).String(),
+				})
+			}
+			ctx.Table([]string{"COMMAND", "COUNT", "FAILURE RATE", "AVG DURATION"}, rows)
+			return nil
+		},
+	}
+}
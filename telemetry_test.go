@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeInvocations(t *testing.T) {
+	stats := SummarizeInvocations([]Invocation{
+		{Command: "a", Duration: time.Second},
+		{Command: "b", Duration: time.Second, Failed: true},
+		{Command: "b", Duration: 3 * time.Second},
+		{Command: "c"},
+	})
+	assert.Equal(t, stats, []CommandStats{
+		{Command: "b", Count: 2, Failures: 1, AvgDuration: 2 * time.Second},
+		{Command: "a", Count: 1, AvgDuration: time.Second},
+		{Command: "c", Count: 1},
+	})
+	assert.Equal(t, stats[0].FailureRate(), 0.5)
+}
+
+func TestStatsCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cli-telemetry")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	root := &Command{Name: "app"}
+	root.Register(StatsCommand(""))
+	assert.Error(t, root.RunWith([]string{"stats"}, ioutil.Discard, nil))
+
+	root.Telemetry = NewFileTelemetry(filepath.Join(dir, "sub", "telemetry.ndjson"))
+	root.Register(&Command{Name: "ok", Fn: donothing})
+	root.Register(&Command{Name: "fail", Fn: func(*Context) error { return fmt.Errorf("failed") }})
+	for _, args := range [][]string{{"ok"}, {"ok"}, {"fail"}, {"ok"}} {
+		root.RunWith(args, ioutil.Discard, nil)
+	}
+	invocations, err := root.Telemetry.Invocations()
+	require.Nil(t, err)
+	assert.Equal(t, len(invocations), 4)
+
+	w := new(bytes.Buffer)
+	require.Nil(t, root.RunWith([]string{"stats", "-n", "1"}, w, nil))
+	lines := strings.Split(strings.TrimSpace(w.String()), "\n")
+	require.Equal(t, len(lines), 2)
+	assert.True(t, strings.HasPrefix(lines[0], "COMMAND"))
+	assert.Equal(t, strings.Fields(lines[1])[:3], []string{"ok", "3", "0.0%"})
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+type (
+	// TempKeeper represents interface for keeping temp workspace on failure
+	TempKeeper interface {
+		KeepTempOnFailure() bool
+	}
+
+	// TempHelper is builtin keep-temp flag
+	TempHelper struct {
+		KeepTemp bool `cli:"keep-temp" usage:"keep temp workspace if command failed" json:"-"`
+	}
+)
+
+// KeepTempOnFailure implements TempKeeper interface
+func (h TempHelper) KeepTempOnFailure() bool {
+	return h.KeepTemp
+}
+
+// Defer registers fn which called after command finished,
+// functions are called in reverse order of registration
+func (ctx *Context) Defer(fn func()) {
+	ctx.defers = append(ctx.defers, fn)
+}
+
+func (ctx *Context) runDefers(err error) {
+	ctx.err = err
+	for i := len(ctx.defers) - 1; i >= 0; i-- {
+		ctx.defers[i]()
+	}
+	ctx.defers = nil
+}
+
+// TempDir returns a temp workspace of current invocation, it's created on first call
+// and removed after command finished. The workspace is kept if command failed
+// and argv implements TempKeeper which returns true.
+func (ctx *Context) TempDir() (string, error) {
+	if ctx.tempDir != "" {
+		return ctx.tempDir, nil
+	}
+	prefix := "cli-"
+	if ctx.command != nil && ctx.command.Root().Name != "" {
+		prefix = ctx.command.Root().Name + "-"
+	}
+	dir, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		return "", err
+	}
+	ctx.tempDir = dir
+	ctx.Defer(func() {
+		if ctx.err != nil && ctx.err != ExitError && ctx.keepTemp() {
+			fmt.Fprintf(ctx.stderr(os.Stderr), "temp workspace kept at %s\n", dir)
+			return
+		}
+		os.RemoveAll(dir)
+	})
+	return dir, nil
+}
+
+func (ctx *Context) keepTemp() bool {
+	for _, argv := range ctx.allArgvs() {
+		if keeper, ok := argv.(TempKeeper); ok && keeper.KeepTempOnFailure() {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextTempDir(t *testing.T) {
+	type argT struct {
+		TempHelper
+		Fail bool `cli:"fail"`
+	}
+	var dir string
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			var err error
+			dir, err = ctx.TempDir()
+			require.Nil(t, err)
+			again, err := ctx.TempDir()
+			require.Nil(t, err)
+			assert.Equal(t, again, dir)
+			require.Nil(t, ioutil.WriteFile(filepath.Join(dir, "x"), []byte("x"), 0644))
+			if ctx.Argv().(*argT).Fail {
+				return fmt.Errorf("failed")
+			}
+			return nil
+		},
+	}
+	exists := func(path string) bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	assert.Nil(t, root.RunWith(nil, ioutil.Discard, nil))
+	assert.False(t, exists(dir))
+
+	assert.Error(t, root.RunWith([]string{"--fail"}, ioutil.Discard, nil))
+	assert.False(t, exists(dir))
+
+	assert.Nil(t, root.RunWith([]string{"--keep-temp"}, ioutil.Discard, nil))
+	assert.False(t, exists(dir))
+
+	stderr := new(bytes.Buffer)
+	assert.Error(t, root.RunWithWriter([]string{"--fail", "--keep-temp"}, ioutil.Discard, RunOptions{ErrWriter: stderr}))
+	assert.True(t, exists(dir))
+	assert.Equal(t, stderr.String(), "temp workspace kept at "+dir+"\n")
+	os.RemoveAll(dir)
+}
+
+func TestContextDefer(t *testing.T) {
+	calls := []int{}
+	assert.Nil(t, (&Command{Fn: func(ctx *Context) error {
+		ctx.Defer(func() { calls = append(calls, 1) })
+		ctx.Defer(func() { calls = append(calls, 2) })
+		return nil
+	}}).RunWith(nil, ioutil.Discard, nil))
+	assert.Equal(t, calls, []int{2, 1})
+}
+
+func TestContextDeferOnPanic(t *testing.T) {
+	called := false
+	func() {
+		defer func() { assert.Equal(t, recover(), "oops") }()
+		(&Command{Fn: func(ctx *Context) error {
+			ctx.Defer(func() { called = true })
+			panic("oops")
+		}}).RunWith(nil, ioutil.Discard, nil)
+	}()
+	assert.True(t, called)
+}
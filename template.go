@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TemplateFuncs are functions available in templates of RenderTemplate, e.g.
+//
+//	{{json .}}, {{join .Tags ","}}, {{upper .Name}}, {{pad .Name 10}}, {{truncate .ID 12}}
+//
+// Add functions to it before rendering to extend templates.
+var TemplateFuncs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"yaml": func(v interface{}) (string, error) {
+		data, err := marshalYAML(v)
+		return strings.TrimSuffix(string(data), "\n"), err
+	},
+	"join":  strings.Join,
+	"split": strings.Split,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"title": func(s string) string {
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 {
+			return s
+		}
+		return string(unicode.ToUpper(r)) + s[size:]
+	},
+	"pad": padRight,
+	"truncate": func(s string, n int) string {
+		if rs := []rune(s); len(rs) > n {
+			return string(rs[:n])
+		}
+		return s
+	},
+}
+
+// RenderTemplate renders data with user template tmpl(text/template) and a newline, like
+// `--format '{{.Name}}'` of docker and kubectl. Functions of TemplateFuncs are available.
+func (ctx *Context) RenderTemplate(tmpl string, data interface{}) error {
+	t, err := template.New("format").Funcs(TemplateFuncs).Parse(tmpl)
+	if err != nil {
+		return err
+	}
+	if err := t.Execute(ctx.Writer(), data); err != nil {
+		return err
+	}
+	ctx.String("\n")
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	type container struct {
+		ID   string   `json:"id"`
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	c := container{ID: "0123456789abcdef", Name: "web", Tags: []string{"a", "b"}}
+	for _, tt := range []struct {
+		tmpl string
+		want string
+	}{
+		{"{{.Name}}", "web\n"},
+		{"{{json .}}", `{"id":"0123456789abcdef","name":"web","tags":["a","b"]}` + "\n"},
+		{"{{yaml .Tags}}", "- a\n- b\n"},
+		{`{{join .Tags ","}}`, "a,b\n"},
+		{`{{index (split "x:y" ":") 1}}`, "y\n"},
+		{"{{upper .Name}} {{lower \"AB\"}} {{title .Name}}", "WEB ab Web\n"},
+		{"{{title \"élan\"}}", "Élan\n"},
+		{"[{{pad .Name 5}}] {{truncate .ID 12}}", "[web  ] 0123456789ab\n"},
+	} {
+		w := new(bytes.Buffer)
+		ctx := &Context{writer: w}
+		assert.Nil(t, ctx.RenderTemplate(tt.tmpl, c))
+		assert.Equal(t, w.String(), tt.want)
+	}
+
+	ctx := &Context{writer: new(bytes.Buffer)}
+	assert.Error(t, ctx.RenderTemplate("{{.Name", c))
+	assert.Error(t, ctx.RenderTemplate("{{.Unknown}}", c))
+}
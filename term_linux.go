@@ -0,0 +1,11 @@
+//go:build linux
+// +build linux
+
+package cli
+
+import "syscall"
+
+const (
+	ioctlGetTermios = syscall.TCGETS
+	ioctlSetTermios = syscall.TCSETS
+)
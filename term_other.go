@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package cli
+
+import "errors"
+
+func makeRaw(fd int) (restore func() error, err error) {
+	return nil, errors.New("raw mode of terminal not supported")
+}
+
+func terminalSize(fd int) (width, height int, err error) {
+	return 0, 0, errors.New("size of terminal not supported")
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/labstack/gommon/color"
+	"github.com/mattn/go-isatty"
+)
+
+// Color modes of flag `--color`, see ColorHelper
+const (
+	ColorAuto   = "auto"   // colored if output is a terminal and NO_COLOR is empty
+	ColorAlways = "always" // always colored, NO_COLOR is ignored
+	ColorNever  = "never"  // never colored
+)
+
+type (
+	// ColorFunc colors msg with clr, method expressions of color.Color could be used,
+	// e.g. (*color.Color).Cyan
+	ColorFunc func(clr *color.Color, msg interface{}, styles ...string) string
+
+	// Theme represents colors of usages and errors, it's set to Theme of root command.
+	// Nil fields are colored like DefaultTheme.
+	Theme struct {
+		Header     ColorFunc // Headers of usage, e.g. "Options:"
+		Flag       ColorFunc // Names and value names of flags
+		Default    ColorFunc // Default values of flags
+		Required   ColorFunc // Mark of required flags
+		Error      ColorFunc // Prefix "ERR!" of errors
+		Suggestion ColorFunc // Suggested commands and options
+		Command    ColorFunc // Commands not found
+	}
+
+	// ColorCapable represents interface of writers which report whether output
+	// supports color, e.g. writers of remote terminals. Writers which are not
+	// *os.File could implement it to be colored in auto mode.
+	ColorCapable interface {
+		ColorCapable() bool
+	}
+
+	// ColorChooser represents interface for choosing color mode
+	ColorChooser interface {
+		ColorMode() string
+	}
+
+	// ColorHelper is builtin color flag, one of auto(default), always and never
+	ColorHelper struct {
+		Color string `cli:"color" usage:"colorize output: auto, always or never" dft:"auto" json:"-"`
+	}
+)
+
+// ColorMode implements ColorChooser interface
+func (h ColorHelper) ColorMode() string {
+	return h.Color
+}
+
+// DefaultTheme returns builtin theme
+func DefaultTheme() Theme {
+	return Theme{
+		Header:     (*color.Color).Bold,
+		Flag:       (*color.Color).Bold,
+		Default:    (*color.Color).Grey,
+		Required:   (*color.Color).Red,
+		Error:      (*color.Color).Red,
+		Suggestion: (*color.Color).Bold,
+		Command:    (*color.Color).Yellow,
+	}
+}
+
+// withDefaults returns a copy of theme whose nil fields are filled by DefaultTheme
+func (theme *Theme) withDefaults() *Theme {
+	t := DefaultTheme()
+	if theme == nil {
+		return &t
+	}
+	for _, f := range []struct{ dst, src *ColorFunc }{
+		{&t.Header, &theme.Header},
+		{&t.Flag, &theme.Flag},
+		{&t.Default, &theme.Default},
+		{&t.Required, &theme.Required},
+		{&t.Error, &theme.Error},
+		{&t.Suggestion, &theme.Suggestion},
+		{&t.Command, &theme.Command},
+	} {
+		if *f.src != nil {
+			*f.dst = *f.src
+		}
+	}
+	return &t
+}
+
+// theme returns Theme of root command with defaults
+func (cmd *Command) theme() *Theme {
+	return cmd.Root().Theme.withDefaults()
+}
+
+// colorMode returns color mode chosen by argv which implements ColorChooser,
+// empty if no argv chose
+func (ctx *Context) colorMode() string {
+	for _, argv := range ctx.allArgvs() {
+		if chooser, ok := argv.(ColorChooser); ok {
+			if mode := chooser.ColorMode(); mode != "" {
+				return mode
+			}
+		}
+	}
+	return ""
+}
+
+// colorSwitch enables clr by mode, auto(or empty) mode enables clr if output is a
+// terminal or a ColorCapable writer which supports color, and environment variable
+// NO_COLOR is empty
+func colorSwitch(clr *color.Color, mode string, w io.Writer, fds ...uintptr) {
+	clr.Disable()
+	switch mode {
+	case ColorAlways:
+		clr.Enable()
+		return
+	case ColorNever:
+		return
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return
+	}
+	if len(fds) > 0 {
+		if isatty.IsTerminal(fds[0]) {
+			clr.Enable()
+		}
+	} else if capable, ok := w.(ColorCapable); ok {
+		if capable.ColorCapable() {
+			clr.Enable()
+		}
+	} else if file, ok := writerFile(w); ok && isatty.IsTerminal(file.Fd()) {
+		clr.Enable()
+	}
+}
+
+// switchColor switches color of ctx by mode chosen by argv, auto mode keeps color
+// switched by output
+func (ctx *Context) switchColor(w io.Writer) error {
+	switch mode := ctx.colorMode(); mode {
+	case "", ColorAuto:
+	case ColorAlways, ColorNever:
+		colorSwitch(&ctx.color, mode, w)
+	default:
+		return throwParameterInvalid(ctx.color.Bold("--color"),
+			fmt.Errorf("color mode must be one of %s, %s and %s", ColorAuto, ColorAlways, ColorNever))
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/gommon/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTheme(t *testing.T) {
+	type argT struct {
+		Name string `cli:"*name" usage:"name of user" dft:"guest"`
+	}
+	clr := color.Color{}
+	clr.Enable()
+	ctx := &Context{color: clr}
+
+	root := &Command{Name: "app"}
+	user := root.Register(&Command{
+		Name: "user",
+		Argv: func() interface{} { return new(argT) },
+		Fn:   donothing,
+	})
+	plain := func(clr *color.Color, msg interface{}, styles ...string) string {
+		return "<" + msg.(string) + ">"
+	}
+	builtin := user.Usage(ctx)
+	// nil fields are colored like DefaultTheme
+	root.Theme = &Theme{Header: plain, Required: plain}
+	assert.Equal(t, user.Usage(ctx), builtin)
+	root.InvalidateUsage()
+	assert.Equal(t, user.Usage(ctx), "<Options>:\n\n  --name"+clr.Grey("[=guest]")+"  <*>name of user\n")
+
+	root.Theme = &Theme{Error: plain, Command: plain}
+	err := root.RunWith([]string{"usr"}, new(bytes.Buffer), nil)
+	assert.Error(t, err)
+	assert.Equal(t, err.Error(), "<ERR!> command <usr> not found\nDid you mean user?")
+}
+
+type colorArgT struct {
+	ColorHelper
+	Name string `cli:"name"`
+}
+
+func (argv *colorArgT) Validate(ctx *Context) error {
+	if argv.Name == "invalid" {
+		return fmt.Errorf("invalid name")
+	}
+	return nil
+}
+
+func TestColorMode(t *testing.T) {
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(colorArgT) },
+		Fn: func(ctx *Context) error {
+			ctx.String("%s", ctx.Color().Bold("x"))
+			return nil
+		},
+	}
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{}, "x"},
+		{[]string{"--color=auto"}, "x"},
+		{[]string{"--color=never"}, "x"},
+		{[]string{"--color=always"}, "\x1b[1mx\x1b[0m"},
+	} {
+		w := new(bytes.Buffer)
+		assert.Nil(t, root.RunWith(tt.args, w, nil))
+		assert.Equal(t, w.String(), tt.want, "args: %v", tt.args)
+	}
+	err := root.RunWith([]string{"--color=sometimes"}, new(bytes.Buffer), nil)
+	assert.Error(t, err)
+
+	// errors after parsing flags are colored by mode
+	err = root.RunWith([]string{"--color=always", "--name=invalid"}, new(bytes.Buffer), nil)
+	assert.Error(t, err)
+	clr := color.Color{}
+	clr.Enable()
+	assert.Contains(t, err.Error(), clr.Red("ERR!"))
+}
+
+func TestNoColor(t *testing.T) {
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+
+	clr := color.Color{}
+	os.Setenv("NO_COLOR", "1")
+	colorSwitch(&clr, ColorAuto, os.Stdout)
+	assert.False(t, colorEnabled(clr))
+	colorSwitch(&clr, ColorAlways, os.Stdout)
+	assert.True(t, colorEnabled(clr))
+	colorSwitch(&clr, ColorNever, os.Stdout)
+	assert.False(t, colorEnabled(clr))
+}
+
+type colorCapableWriter struct {
+	bytes.Buffer
+	color bool
+}
+
+func (w *colorCapableWriter) ColorCapable() bool { return w.color }
+
+func TestRunWithWriter(t *testing.T) {
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	os.Unsetenv("NO_COLOR")
+
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(colorArgT) },
+		Fn: func(ctx *Context) error {
+			data, _ := ioutil.ReadAll(ctx.Reader())
+			ctx.String("%s", ctx.Color().Bold(string(data)))
+			return nil
+		},
+	}
+	bold := "\x1b[1mx\x1b[0m"
+	for _, tt := range []struct {
+		writer *colorCapableWriter
+		opts   RunOptions
+		args   []string
+		want   string
+	}{
+		{&colorCapableWriter{}, RunOptions{}, nil, "x"},
+		{&colorCapableWriter{color: true}, RunOptions{}, nil, bold},
+		{&colorCapableWriter{color: true}, RunOptions{Color: ColorNever}, nil, "x"},
+		{&colorCapableWriter{}, RunOptions{Color: ColorAlways}, nil, bold},
+		// flag --color overrides options
+		{&colorCapableWriter{}, RunOptions{Color: ColorAlways}, []string{"--color=never"}, "x"},
+	} {
+		tt.opts.Reader = strings.NewReader("x")
+		assert.Nil(t, root.RunWithWriter(tt.args, tt.writer, tt.opts))
+		assert.Equal(t, tt.writer.String(), tt.want, "opts: %v", tt.opts)
+	}
+
+	// NO_COLOR disables color of capable writers in auto mode
+	os.Setenv("NO_COLOR", "1")
+	w := &colorCapableWriter{color: true}
+	assert.Nil(t, root.RunWithWriter(nil, w, RunOptions{Reader: strings.NewReader("x")}))
+	assert.Equal(t, w.String(), "x")
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TimeoutExitCode is exit code of timed out command, as same as `timeout` of coreutils
+const TimeoutExitCode = 124
+
+type (
+	// Timeouter represents interface for overriding Timeout of command
+	Timeouter interface {
+		CommandTimeout() time.Duration
+	}
+
+	// TimeoutHelper is builtin timeout flag, e.g. `--timeout 30s`
+	TimeoutHelper struct {
+		Timeout time.Duration `cli:"timeout" usage:"cancel command if it runs longer than timeout, e.g. 30s, 5m" parser:"duration" json:"-"`
+	}
+)
+
+// CommandTimeout implements Timeouter interface
+func (h TimeoutHelper) CommandTimeout() time.Duration {
+	return h.Timeout
+}
+
+// timeoutError is returned while command failed after its timeout exceeded
+type timeoutError struct {
+	err     error
+	timeout time.Duration
+}
+
+func (e timeoutError) Error() string {
+	return fmt.Sprintf("command timed out after %s", e.timeout)
+}
+
+// Unwrap returns error returned by command, e.g. context.DeadlineExceeded
+func (e timeoutError) Unwrap() error { return e.err }
+
+// IsTimeout reports whether err is returned by a command which exceeded its timeout
+func IsTimeout(err error) bool {
+	var e timeoutError
+	return errors.As(err, &e)
+}
+
+// commandTimeout returns timeout of command, value of Timeouter overrides Timeout of command
+func (ctx *Context) commandTimeout() time.Duration {
+	for _, argv := range ctx.allArgvs() {
+		if t, ok := argv.(Timeouter); ok && t.CommandTimeout() > 0 {
+			return t.CommandTimeout()
+		}
+	}
+	return ctx.command.Timeout
+}
+
+// applyTimeout cancels Context.Context after timeout of command, the returned
+// function releases resources of timer
+func (ctx *Context) applyTimeout() func() {
+	timeout := ctx.commandTimeout()
+	if timeout <= 0 {
+		return func() {}
+	}
+	goctx, cancel := context.WithTimeout(ctx.Context(), timeout)
+	ctx.goctx = goctx
+	ctx.timeout = timeout
+	return cancel
+}
+
+func (ctx *Context) timedOut() bool {
+	return ctx.timeout > 0 && ctx.goctx.Err() == context.DeadlineExceeded &&
+		time.Since(ctx.startedAt) >= ctx.timeout
+}
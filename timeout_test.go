@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutT struct {
+	TimeoutHelper
+}
+
+func TestTimeout(t *testing.T) {
+	wait := func(ctx *Context) error {
+		<-ctx.Context().Done()
+		return ctx.Context().Err()
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Fn:      wait,
+	})
+	root.Register(&Command{
+		Name: "wait",
+		Argv: func() interface{} { return new(timeoutT) },
+		Fn:   wait,
+	})
+	root.Register(&Command{
+		Name:    "fast",
+		Timeout: time.Minute,
+		Fn: func(ctx *Context) error {
+			if _, ok := ctx.Context().Deadline(); !ok {
+				return errors.New("no deadline")
+			}
+			return nil
+		},
+	})
+
+	err := root.RunWith([]string{"slow"}, new(bytes.Buffer), nil)
+	assert.True(t, IsTimeout(err))
+	assert.False(t, IsInterrupted(err))
+	assert.Equal(t, err.Error(), "command timed out after 10ms")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, ExitCode(err), TimeoutExitCode)
+	assert.Equal(t, ExitCode(fmt.Errorf("wrapped: %w", err)), TimeoutExitCode)
+	assert.Equal(t, httpStatusCode(err), http.StatusGatewayTimeout)
+
+	err = root.RunWith([]string{"wait", "--timeout", "20ms"}, new(bytes.Buffer), nil)
+	assert.True(t, IsTimeout(err))
+	assert.Equal(t, err.Error(), "command timed out after 20ms")
+
+	assert.Nil(t, root.RunWith([]string{"fast"}, new(bytes.Buffer), nil))
+
+	// deadline of caller is an interruption
+	goctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err = NewEngine(root).Execute(goctx, Execution{Args: []string{"wait", "--timeout=1m"}, Stdout: new(bytes.Buffer)})
+	assert.False(t, IsTimeout(err))
+	assert.True(t, IsInterrupted(err))
+}
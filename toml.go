@@ -0,0 +1,335 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tomlDecoder decodes a TOML document
+type tomlDecoder struct {
+	data string
+	pos  int
+	line int
+}
+
+// unmarshalTOML decodes a TOML document into v, it supports key/value pairs, tables,
+// strings, numbers, booleans, arrays and inline tables. Arrays of tables and
+// multi-line strings are not supported, dates are decoded as strings. Decoded value
+// is stored into v by encoding/json, so v is filled in the same way as by a JSON document.
+func unmarshalTOML(data []byte, v interface{}) error {
+	d := &tomlDecoder{data: string(data), line: 1}
+	root := map[string]interface{}{}
+	table := root
+	for {
+		d.skipSpace(true)
+		if d.eof() {
+			break
+		}
+		if d.peek() == '[' {
+			if strings.HasPrefix(d.data[d.pos:], "[[") {
+				return d.errorf("arrays of tables are not supported")
+			}
+			d.pos++
+			path, err := d.key(']')
+			if err != nil {
+				return err
+			}
+			d.pos++
+			if table, err = d.table(root, path, true); err != nil {
+				return err
+			}
+		} else {
+			path, err := d.key('=')
+			if err != nil {
+				return err
+			}
+			d.pos++
+			d.skipSpace(false)
+			value, err := d.value()
+			if err != nil {
+				return err
+			}
+			if err := d.set(table, path, value); err != nil {
+				return err
+			}
+		}
+		if err := d.endOfLine(); err != nil {
+			return err
+		}
+	}
+	return assignJSON(root, v)
+}
+
+func (d *tomlDecoder) eof() bool  { return d.pos >= len(d.data) }
+func (d *tomlDecoder) peek() byte { return d.data[d.pos] }
+
+func (d *tomlDecoder) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("toml: line %d: %s", d.line, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips spaces and comments, and newlines if multiline
+func (d *tomlDecoder) skipSpace(multiline bool) {
+	for !d.eof() {
+		switch c := d.peek(); {
+		case c == ' ' || c == '\t' || c == '\r':
+		case c == '\n' && multiline:
+			d.line++
+		case c == '#':
+			for !d.eof() && d.peek() != '\n' {
+				d.pos++
+			}
+			continue
+		default:
+			return
+		}
+		d.pos++
+	}
+}
+
+func (d *tomlDecoder) endOfLine() error {
+	d.skipSpace(false)
+	if d.eof() {
+		return nil
+	}
+	if d.peek() != '\n' {
+		return d.errorf("unexpected %q", d.rest())
+	}
+	return nil
+}
+
+// rest returns rest of current line for error messages
+func (d *tomlDecoder) rest() string {
+	rest := d.data[d.pos:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	return strings.TrimSpace(rest)
+}
+
+// key decodes a dotted key terminated by end, end is not consumed
+func (d *tomlDecoder) key(end byte) ([]string, error) {
+	var path []string
+	for {
+		d.skipSpace(false)
+		if d.eof() || d.peek() == '\n' {
+			return nil, d.errorf("expected key")
+		}
+		var part string
+		switch d.peek() {
+		case '"', '\'':
+			s, err := d.str()
+			if err != nil {
+				return nil, err
+			}
+			part = s
+		default:
+			start := d.pos
+			for !d.eof() && isTOMLBareKeyChar(d.peek()) {
+				d.pos++
+			}
+			if start == d.pos {
+				return nil, d.errorf("invalid key %q", d.rest())
+			}
+			part = d.data[start:d.pos]
+		}
+		path = append(path, part)
+		d.skipSpace(false)
+		if d.eof() {
+			return nil, d.errorf("expected %c", end)
+		}
+		switch d.peek() {
+		case '.':
+			d.pos++
+		case end:
+			return path, nil
+		default:
+			return nil, d.errorf("expected %c after key %s", end, strings.Join(path, "."))
+		}
+	}
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// table returns table of path under root, tables are created if missing
+func (d *tomlDecoder) table(root map[string]interface{}, path []string, header bool) (map[string]interface{}, error) {
+	table := root
+	for i, key := range path {
+		switch v := table[key].(type) {
+		case nil:
+			sub := map[string]interface{}{}
+			table[key] = sub
+			table = sub
+		case map[string]interface{}:
+			if header && i == len(path)-1 {
+				return nil, d.errorf("duplicate table %s", strings.Join(path, "."))
+			}
+			table = v
+		default:
+			return nil, d.errorf("key %s is not a table", strings.Join(path[:i+1], "."))
+		}
+	}
+	return table, nil
+}
+
+func (d *tomlDecoder) set(table map[string]interface{}, path []string, value interface{}) error {
+	table, err := d.table(table, path[:len(path)-1], false)
+	if err != nil {
+		return err
+	}
+	key := path[len(path)-1]
+	if _, ok := table[key]; ok {
+		return d.errorf("duplicate key %s", strings.Join(path, "."))
+	}
+	table[key] = value
+	return nil
+}
+
+func (d *tomlDecoder) value() (interface{}, error) {
+	if d.eof() || d.peek() == '\n' {
+		return nil, d.errorf("expected value")
+	}
+	switch d.peek() {
+	case '"', '\'':
+		return d.str()
+	case '[':
+		d.pos++
+		items := []interface{}{}
+		for {
+			d.skipSpace(true)
+			if d.eof() {
+				return nil, d.errorf("unterminated array")
+			}
+			if d.peek() == ']' {
+				d.pos++
+				return items, nil
+			}
+			item, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			d.skipSpace(true)
+			if !d.eof() && d.peek() == ',' {
+				d.pos++
+			} else if d.eof() || d.peek() != ']' {
+				return nil, d.errorf("expected , or ] in array")
+			}
+		}
+	case '{':
+		d.pos++
+		table := map[string]interface{}{}
+		for {
+			d.skipSpace(false)
+			if !d.eof() && d.peek() == '}' && len(table) == 0 {
+				d.pos++
+				return table, nil
+			}
+			path, err := d.key('=')
+			if err != nil {
+				return nil, err
+			}
+			d.pos++
+			d.skipSpace(false)
+			value, err := d.value()
+			if err != nil {
+				return nil, err
+			}
+			if err := d.set(table, path, value); err != nil {
+				return nil, err
+			}
+			d.skipSpace(false)
+			if d.eof() {
+				return nil, d.errorf("unterminated inline table")
+			}
+			switch d.peek() {
+			case ',':
+				d.pos++
+			case '}':
+				d.pos++
+				return table, nil
+			default:
+				return nil, d.errorf("expected , or } in inline table")
+			}
+		}
+	}
+	start := d.pos
+	for !d.eof() && strings.IndexByte(" \t\r\n,]}#", d.peek()) < 0 {
+		d.pos++
+	}
+	return d.scalar(d.data[start:d.pos])
+}
+
+// str decodes a basic or literal string
+func (d *tomlDecoder) str() (string, error) {
+	quote := d.peek()
+	if strings.HasPrefix(d.data[d.pos:], strings.Repeat(string(quote), 3)) {
+		return "", d.errorf("multi-line strings are not supported")
+	}
+	for i := d.pos + 1; i < len(d.data) && d.data[i] != '\n'; i++ {
+		switch d.data[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			raw := d.data[d.pos : i+1]
+			d.pos = i + 1
+			if quote == '\'' {
+				return raw[1 : len(raw)-1], nil
+			}
+			s, err := strconv.Unquote(raw)
+			if err != nil {
+				return "", d.errorf("invalid string %s", raw)
+			}
+			return s, nil
+		}
+	}
+	return "", d.errorf("unterminated string")
+}
+
+// tomlString returns s as a basic string
+func tomlString(s string) string {
+	buf := new(strings.Builder)
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '\\':
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\t':
+			buf.WriteString(`\t`)
+		case '\r':
+			buf.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(buf, `\u%04X`, r)
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+	return buf.String()
+}
+
+func (d *tomlDecoder) scalar(s string) (interface{}, error) {
+	switch s {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if n, ok := yamlNumber(s); ok {
+		return n, nil
+	}
+	// local date, time or datetime, e.g. 1979-05-27T07:32:00Z
+	if s != "" && s[0] >= '0' && s[0] <= '9' && strings.ContainsAny(s, "-:") {
+		return s, nil
+	}
+	return nil, d.errorf("invalid value %q", s)
+}
@@ -0,0 +1,64 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalTOML(t *testing.T) {
+	for i, tc := range []struct {
+		src  string
+		want interface{}
+	}{
+		{"", map[string]interface{}{}},
+		{"# comment\n\n", map[string]interface{}{}},
+		{"a = 1\nb = -2.5\nc = 0x10\nd = 1_000\ne = true\nf = false", map[string]interface{}{
+			"a": 1.0, "b": -2.5, "c": 16.0, "d": 1000.0, "e": true, "f": false,
+		}},
+		{`a = "x # y" # comment` + "\nb = 'C:\\path'\nc = \"\\u00e9\\t\"\n\"quoted key\" = 1\nday = 1979-05-27T07:32:00Z", map[string]interface{}{
+			"a": "x # y", "b": `C:\path`, "c": "é\t", "quoted key": 1.0, "day": "1979-05-27T07:32:00Z",
+		}},
+		{"a = [1, [\"x\", 'y'], ]\nb = [\n  1, # one\n  2,\n]\nc = []", map[string]interface{}{
+			"a": []interface{}{1.0, []interface{}{"x", "y"}},
+			"b": []interface{}{1.0, 2.0},
+			"c": []interface{}{},
+		}},
+		{"t = {k = \"v\", n.m = 1}\ne = {}", map[string]interface{}{
+			"t": map[string]interface{}{"k": "v", "n": map[string]interface{}{"m": 1.0}},
+			"e": map[string]interface{}{},
+		}},
+		{"top = 1\n[server]\nhost = \"h\"\n[server.tls]\nca.file = \"ca.pem\"\n[\"db\"]\nport = 5432", map[string]interface{}{
+			"top": 1.0,
+			"server": map[string]interface{}{
+				"host": "h",
+				"tls":  map[string]interface{}{"ca": map[string]interface{}{"file": "ca.pem"}},
+			},
+			"db": map[string]interface{}{"port": 5432.0},
+		}},
+	} {
+		var got interface{}
+		if assert.Nil(t, unmarshalTOML([]byte(tc.src), &got), "#%d", i) {
+			assert.Equal(t, got, tc.want, "#%d", i)
+		}
+	}
+
+	for i, src := range []string{
+		"a = 1\na = 2",
+		"a",
+		"a = ",
+		"a = 1 2",
+		"a = [1, 2",
+		"a = {k = 1",
+		"a = \"unterminated",
+		"a = \"\"\"multi\"\"\"",
+		"a = unquoted",
+		"[[items]]\nname = 1",
+		"[t]\n[t]",
+		"a = 1\n[a]",
+		"= 1",
+	} {
+		var got interface{}
+		assert.Error(t, unmarshalTOML([]byte(src), &got), "#%d", i)
+	}
+}
@@ -0,0 +1,215 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Frame kinds of unix socket protocol, a frame is a kind byte, uint32 length and payload
+const (
+	unixStdout byte = 'o'
+	unixStderr byte = 'e'
+	unixExit   byte = 'x'
+)
+
+// unixRequest is the first line sent by client of unix socket daemon
+type unixRequest struct {
+	Args  []string `json:"args"`
+	Color bool     `json:"color,omitempty"` // Output of client is a terminal and NO_COLOR is empty
+}
+
+// ListenAndServeUnix listens on unix socket and serves command lines forwarded by
+// ForwardUnix or RunOrForward. Stale socket file is removed before listening.
+func (cmd *Command) ListenAndServeUnix(socket string) error {
+	l, err := listenUnix(socket)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return cmd.ServeUnix(l)
+}
+
+func listenUnix(socket string) (net.Listener, error) {
+	if conn, err := net.Dial("unix", socket); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("socket %s is in use", socket)
+	}
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return net.Listen("unix", socket)
+}
+
+// ServeUnix sets IsServer with true and serves connections accepted by l until l closed.
+// It makes a daemon process which keeps state, e.g. caches and connections in
+// package-level variables, across invocations of commands with expensive startup.
+// Commands of different connections run concurrently, Context.Context of a command
+// is cancelled if its client hung up.
+func (cmd *Command) ServeUnix(l net.Listener) error {
+	cmd.SetIsServer(true)
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go cmd.serveUnixConn(context.Background(), conn)
+	}
+}
+
+// serveUnixConn runs a command line forwarded by conn, Context.Context is derived
+// from goctx and cancelled if client hung up
+func (cmd *Command) serveUnixConn(goctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		debugf("unix: read request: %v", err)
+		return
+	}
+	req := new(unixRequest)
+	if err := json.Unmarshal([]byte(line), req); err != nil {
+		debugf("unix: decode request: %v", err)
+		return
+	}
+	goctx, cancel := context.WithCancel(goctx)
+	defer cancel()
+	go func() {
+		// client sends nothing after request, read returns once it hung up
+		io.Copy(ioutil.Discard, reader)
+		cancel()
+	}()
+	var (
+		locker sync.Mutex
+		stdout = &unixWriter{conn: conn, kind: unixStdout, locker: &locker, color: req.Color}
+		stderr = &unixWriter{conn: conn, kind: unixStderr, locker: &locker, color: req.Color}
+		setup  = func(ctx *Context) {
+			ctx.reader = strings.NewReader("")
+			ctx.errWriter = stderr
+			ctx.goctx = goctx
+		}
+	)
+	err = cmd.runRemote(req.Args, stdout, nil, setup)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+	}
+	var status [4]byte
+	binary.BigEndian.PutUint32(status[:], uint32(ExitCode(err)))
+	stdout.writeFrame(unixExit, status[:])
+}
+
+// unixWriter writes data as frames of kind
+type unixWriter struct {
+	conn   net.Conn
+	kind   byte
+	locker *sync.Mutex // shared by writers of a connection
+	color  bool        // output of client is colored
+}
+
+// ColorCapable implements ColorCapable interface
+func (w *unixWriter) ColorCapable() bool {
+	return w.color
+}
+
+func (w *unixWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if err := w.writeFrame(w.kind, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *unixWriter) writeFrame(kind byte, payload []byte) error {
+	frame := make([]byte, 5, 5+len(payload))
+	frame[0] = kind
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(payload)))
+	frame = append(frame, payload...)
+	w.locker.Lock()
+	defer w.locker.Unlock()
+	_, err := w.conn.Write(frame)
+	return err
+}
+
+// ForwardUnix forwards args to daemon listening on socket, output of command is
+// written to stdout and stderr. Exit status of command is returned, see ExitCode.
+func ForwardUnix(socket string, args []string, stdout, stderr io.Writer) (int, error) {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	return forwardUnix(conn, args, stdout, stderr)
+}
+
+func forwardUnix(conn net.Conn, args []string, stdout, stderr io.Writer) (int, error) {
+	color := isTerminalWriter(stdout) && os.Getenv("NO_COLOR") == ""
+	data, err := json.Marshal(unixRequest{Args: args, Color: color})
+	if err != nil {
+		return 0, err
+	}
+	if _, err := conn.Write(append(data, '\n')); err != nil {
+		return 0, err
+	}
+	reader := bufio.NewReader(conn)
+	for {
+		var header [5]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			if err == io.EOF {
+				err = errors.New("daemon closed connection")
+			}
+			return 0, err
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[1:]))
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			return 0, err
+		}
+		switch header[0] {
+		case unixStdout:
+			stdout.Write(payload)
+		case unixStderr:
+			stderr.Write(payload)
+		case unixExit:
+			if len(payload) != 4 {
+				return 0, errors.New("malformed exit frame")
+			}
+			return int(int32(binary.BigEndian.Uint32(payload))), nil
+		default:
+			return 0, fmt.Errorf("unknown frame %q", header[0])
+		}
+	}
+}
+
+// RunOrForward forwards args to daemon if it's listening on socket, otherwise
+// runs cmd in current process. Exit status is returned, e.g.
+//
+//	os.Exit(root.RunOrForward("/tmp/app.sock", os.Args[1:]))
+func (cmd *Command) RunOrForward(socket string, args []string) int {
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		debugf("daemon %s not available: %v", socket, err)
+		if err := cmd.Run(args); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return ExitCode(err)
+		}
+		return 0
+	}
+	defer conn.Close()
+	status, err := forwardUnix(conn, args, os.Stdout, os.Stderr)
+	if err != nil {
+		// command may have run, so it's not retried locally
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return status
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unix")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "app.sock")
+
+	var calls int32
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "count",
+		Fn: func(ctx *Context) error {
+			// state is kept by daemon process across invocations
+			ctx.String("%d\n", atomic.AddInt32(&calls, 1))
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "fail",
+		Fn: func(ctx *Context) error {
+			ctx.String("partial\n")
+			ctx.Warn("careful")
+			return errors.New("oops")
+		},
+	})
+
+	l, err := net.Listen("unix", socket)
+	require.Nil(t, err)
+	defer l.Close()
+	go root.ServeUnix(l)
+
+	for i, want := range []string{"1\n", "2\n"} {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		status, err := ForwardUnix(socket, []string{"count"}, stdout, stderr)
+		require.Nil(t, err, "#%d", i)
+		assert.Equal(t, status, 0)
+		assert.Equal(t, stdout.String(), want)
+		assert.Equal(t, stderr.String(), "")
+	}
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	status, err := ForwardUnix(socket, []string{"fail"}, stdout, stderr)
+	require.Nil(t, err)
+	assert.Equal(t, status, 1)
+	assert.Equal(t, stdout.String(), "partial\n")
+	assert.Equal(t, stderr.String(), "WARN! careful\noops\n")
+
+	// socket in use
+	assert.NotNil(t, root.ListenAndServeUnix(socket))
+
+	_, err = ForwardUnix(filepath.Join(dir, "missing.sock"), nil, stdout, stderr)
+	assert.NotNil(t, err)
+	assert.Equal(t, root.RunOrForward(filepath.Join(dir, "missing.sock"), []string{"count"}), 0)
+	assert.Equal(t, atomic.LoadInt32(&calls), int32(3))
+}
+
+func TestServeUnixHangUp(t *testing.T) {
+	dir, err := ioutil.TempDir("", "unix")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "app.sock")
+
+	var (
+		started   = make(chan struct{})
+		cancelled = make(chan struct{})
+	)
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "hang",
+		Fn: func(ctx *Context) error {
+			close(started)
+			<-ctx.Context().Done()
+			close(cancelled)
+			return nil
+		},
+	})
+	l, err := net.Listen("unix", socket)
+	require.Nil(t, err)
+	defer l.Close()
+	go root.ServeUnix(l)
+
+	conn, err := net.Dial("unix", socket)
+	require.Nil(t, err)
+	_, err = conn.Write([]byte(`{"args":["hang"]}` + "\n"))
+	require.Nil(t, err)
+	<-started
+	conn.Close()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("context of command isn't cancelled after client hung up")
+	}
+}
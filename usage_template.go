@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+)
+
+type (
+	// UsageData is data of usage templates, see SetUsageTemplate
+	UsageData struct {
+		Name     string       // Name of command
+		Path     string       // Space-separated full name of command without root name
+		Root     string       // Name of root command
+		Desc     string       // Brief description of command
+		Text     string       // Detailed description of command
+		Flags    []UsageFlag  // Flags of command and global flags of ancestors
+		Children []UsageChild // Visible children of command
+		Examples []Example    // Examples of command
+		Color    bool         // Whether output is colored
+		Width    int          // Width of usage in columns, zero if unknown, see SetUsageWidth
+
+		// Sections rendered by builtin usage, for templates which only restyle part of usage
+		FlagsUsage    string
+		ChildrenUsage string
+		ExamplesUsage string
+	}
+
+	// UsageFlag is a flag listed in usage
+	UsageFlag struct {
+		Names    []string // Flag names with dashes, e.g. -n, --name
+		Usage    string   // Value of tag `usage`
+		Default  string   // Value of tag `dft`
+		Env      string   // Value of tag `env`
+		Value    string   // Value of tag `name`, e.g. FILE for `-o=FILE`
+		Required bool
+	}
+
+	// UsageChild is a child command listed in usage
+	UsageChild struct {
+		Name    string
+		Aliases []string
+		Desc    string
+	}
+)
+
+// SetUsageTemplate sets template(text/template) of usage for command and descendants
+// which have no usage template, e.g. set it on root to restyle all usages. Template
+// of nearest ancestor is used, nil restores builtin usage. Data of template is
+// UsageData, functions of TemplateFuncs are available if tmpl created like
+//
+//	template.New("usage").Funcs(cli.TemplateFuncs).Parse(...)
+//
+// Builtin usage is used if rendering failed. UsageFn takes precedence over templates.
+func (cmd *Command) SetUsageTemplate(tmpl *template.Template) {
+	cmd.locker.Lock()
+	cmd.usageTemplate = tmpl
+	cmd.locker.Unlock()
+	cmd.InvalidateUsage()
+}
+
+// getUsageTemplate returns usage template of cmd or nearest ancestor
+func (cmd *Command) getUsageTemplate() *template.Template {
+	for cur := cmd; cur != nil; cur = cur.parent {
+		cur.locker.Lock()
+		tmpl := cur.usageTemplate
+		cur.locker.Unlock()
+		if tmpl != nil {
+			return tmpl
+		}
+	}
+	return nil
+}
+
+// usageData creates data of usage templates
+func (cmd *Command) usageData(ctx *Context, style UsageStyle, width int) *UsageData {
+	clr := *(ctx.Color())
+	data := &UsageData{
+		Name:          cmd.Name,
+		Path:          cmd.Path(),
+		Root:          cmd.Root().Name,
+		Desc:          cmd.localDesc(),
+		Text:          cmd.Text,
+		Flags:         []UsageFlag{},
+		Children:      []UsageChild{},
+		Examples:      append([]Example{}, cmd.Examples...),
+		Color:         colorEnabled(clr),
+		Width:         width,
+		ChildrenUsage: cmd.childrenDescriptions("  ", "   ", width),
+		ExamplesUsage: cmd.examplesUsage(),
+	}
+	argvList := cmd.flagArgvList()
+	flags := visibleFlags(argvList, clr)
+	if !isEmptyArgvList(argvList) {
+		data.FlagsUsage = flags.styledString(clr, cmd.theme(), style, width)
+	}
+	for _, fl := range flags {
+		data.Flags = append(data.Flags, UsageFlag{
+			Names:    append(append([]string{}, fl.tag.shortNames...), fl.tag.longNames...),
+			Usage:    fl.tag.usage,
+			Default:  fl.tag.dft,
+			Env:      fl.tag.env,
+			Value:    fl.tag.name,
+			Required: fl.tag.isRequired,
+		})
+	}
+	for _, child := range cmd.getChildren() {
+		if child.isHidden() {
+			continue
+		}
+		data.Children = append(data.Children, UsageChild{
+			Name:    child.Name,
+			Aliases: append([]string{}, child.Aliases...),
+			Desc:    child.localDesc(),
+		})
+	}
+	return data
+}
+
+// templateUsage renders usage of cmd with tmpl
+func (cmd *Command) templateUsage(ctx *Context, tmpl *template.Template, style UsageStyle, width int) (string, error) {
+	buff := new(bytes.Buffer)
+	if err := tmpl.Execute(buff, cmd.usageData(ctx, style, width)); err != nil {
+		return "", err
+	}
+	usage := buff.String()
+	if usage != "" && !strings.HasSuffix(usage, "\n") {
+		usage += "\n"
+	}
+	return usage, nil
+}
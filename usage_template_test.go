@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/labstack/gommon/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsageTemplate(t *testing.T) {
+	type argT struct {
+		Name string `cli:"n,name" usage:"name of user" dft:"guest" name:"NAME"`
+		Age  int    `cli:"*age" usage:"age of user"`
+	}
+	clr := color.Color{}
+	clr.Disable()
+	ctx := &Context{color: clr}
+
+	root := &Command{Name: "app", Desc: "an app"}
+	user := root.Register(&Command{
+		Name:     "user",
+		Desc:     "manage users",
+		Argv:     func() interface{} { return new(argT) },
+		Examples: []Example{{Desc: "add user", Command: "user add"}},
+	})
+	user.Register(&Command{Name: "add", Aliases: []string{"new"}, Desc: "add user", Fn: donothing})
+	user.Register(&Command{Name: "secret", Hidden: true, Fn: donothing})
+
+	builtin := user.Usage(ctx)
+	root.SetUsageTemplate(template.Must(template.New("usage").Funcs(TemplateFuncs).Parse(
+		`{{upper .Path}}: {{.Desc}}
+{{range .Flags}}{{join .Names ","}}{{if .Value}}={{.Value}}{{end}}{{if .Default}} ({{.Default}}){{end}}{{if .Required}} required{{end}}: {{.Usage}}
+{{end}}{{range .Children}}{{.Name}} {{.Aliases}}: {{.Desc}}
+{{end}}{{range .Examples}}$ {{$.Root}} {{.Command}}{{end}}`)))
+	assert.Equal(t, user.Usage(ctx), `USER: manage users
+-n,--name=NAME (guest): name of user
+--age required: age of user
+add [new]: add user
+$ app user add
+`)
+
+	// template of nearest ancestor is used
+	user.SetUsageTemplate(template.Must(template.New("usage").Parse("{{.Name}}\n{{.FlagsUsage}}")))
+	assert.Equal(t, user.Usage(ctx), "user\n"+usage(user.argvList(), clr, GetUsageStyle()))
+	assert.Equal(t, root.Usage(ctx), ": an app\nuser []: manage users\n")
+
+	// builtin usage is used if rendering failed
+	user.SetUsageTemplate(template.Must(template.New("usage").Parse("{{.Undefined}}")))
+	assert.Equal(t, user.Usage(ctx), builtin)
+
+	user.SetUsageTemplate(nil)
+	root.SetUsageTemplate(nil)
+	assert.Equal(t, user.Usage(ctx), builtin)
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// WarningOutput is the stream to which warnings are written at the end of execution
+var WarningOutput io.Writer = os.Stderr
+
+type warnings struct {
+	locker sync.Mutex
+	list   []string
+}
+
+// Warn records a non-fatal warning, warnings are written to WarningOutput
+// with prefix `WARN!` after command finished and emitted as warning events
+func (ctx *Context) Warn(format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	ctx.warnings.locker.Lock()
+	ctx.warnings.list = append(ctx.warnings.list, message)
+	ctx.warnings.locker.Unlock()
+	ctx.EmitWarning(message)
+}
+
+// Warnings returns warnings recorded by Warn
+func (ctx *Context) Warnings() []string {
+	ctx.warnings.locker.Lock()
+	defer ctx.warnings.locker.Unlock()
+	return append([]string{}, ctx.warnings.list...)
+}
+
+func (ctx *Context) writeWarnings() {
+	prefix := ctx.color.Yellow("WARN!") + " "
+	for _, message := range ctx.Warnings() {
+		fmt.Fprintln(ctx.stderr(WarningOutput), prefix+message)
+	}
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarn(t *testing.T) {
+	type argT struct {
+		EventsHelper
+	}
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	defer func(w io.Writer) { EventsOutput = w }(EventsOutput)
+	warnings, events := new(bytes.Buffer), new(bytes.Buffer)
+	WarningOutput, EventsOutput = warnings, events
+
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.Warn("disk usage %d%%", 91)
+			ctx.String("done\n")
+			ctx.Warn("deprecated flag")
+			assert.Equal(t, ctx.Warnings(), []string{"disk usage 91%", "deprecated flag"})
+			return nil
+		},
+	}
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith([]string{"--events=ndjson"}, w, nil))
+	assert.Equal(t, w.String(), "done\n")
+	assert.Equal(t, warnings.String(), "WARN! disk usage 91%\nWARN! deprecated flag\n")
+	assert.Contains(t, events.String(), `"message":"disk usage 91%"`)
+}
+
+func TestDeprecatedCommand(t *testing.T) {
+	defer func(w io.Writer) { WarningOutput = w }(WarningOutput)
+	warnings := new(bytes.Buffer)
+	WarningOutput = warnings
+
+	root := &Command{Name: "app"}
+	root.Register(&Command{Name: "old", Deprecated: "use new instead", Fn: donothing})
+	assert.Nil(t, root.RunWith([]string{"old"}, new(bytes.Buffer), nil))
+	assert.Equal(t, warnings.String(), "WARN! command old is deprecated, use new instead\n")
+}
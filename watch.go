@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/labstack/gommon/color"
+)
+
+type (
+	// Watcher represents interface for rerunning command periodically
+	Watcher interface {
+		WatchInterval() time.Duration
+	}
+
+	// WatchHelper is builtin watch-interval flag
+	WatchHelper struct {
+		Watch float64 `cli:"watch-interval" usage:"rerun command every N seconds until interrupted" json:"-"`
+	}
+)
+
+// WatchInterval implements Watcher interface
+func (h WatchHelper) WatchInterval() time.Duration {
+	return time.Duration(h.Watch * float64(time.Second))
+}
+
+func watchInterval(ctx *Context) time.Duration {
+	for _, argv := range ctx.allArgvs() {
+		if watcher, ok := argv.(Watcher); ok && watcher.WatchInterval() > 0 {
+			return watcher.WatchInterval()
+		}
+	}
+	return 0
+}
+
+// Watch wraps a read-only fn with a watch middleware, fn is rerun every interval
+// if argv implements Watcher and WatchInterval returns a positive duration.
+// Output is redrawn in place with changed cells highlighted if writer is a terminal.
+// It returns while Context.Context is done, e.g. interrupted with CancelOnInterrupt,
+// or fn returns an error.
+//
+//	cmd.Fn = cli.Watch(func(ctx *cli.Context) error {
+//		ctx.String("NAME STATUS\n%s %s\n", name, status)
+//		return nil
+//	})
+func Watch(fn CommandFunc) CommandFunc {
+	return func(ctx *Context) error {
+		interval := watchInterval(ctx)
+		if interval <= 0 {
+			return fn(ctx)
+		}
+		var (
+			writer   = ctx.Writer()
+			terminal = ctx.IsTTY()
+			prev     []string
+		)
+		defer func() { ctx.writer = writer }()
+		for {
+			buf := new(bytes.Buffer)
+			ctx.writer = buf
+			err := fn(ctx)
+			ctx.writer = writer
+			if err != nil {
+				return err
+			}
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			if terminal {
+				fmt.Fprintf(writer, "\x1b[H\x1b[2JEvery %v: %s    %s\n\n", interval, ctx.Path(), time.Now().Format(time.RFC1123))
+				writeLines(writer, highlightChanges(lines, prev, ctx.color))
+			} else {
+				writeLines(writer, lines)
+			}
+			prev = lines
+			select {
+			case <-ctx.Context().Done():
+				return nil
+			case <-time.After(interval):
+			}
+		}
+	}
+}
+
+func writeLines(w io.Writer, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+var cellRegexp = regexp.MustCompile(`\S+`)
+
+// highlightChanges highlights cells(whitespace separated fields) of lines
+// which differ from cells at the same position of prev
+func highlightChanges(lines, prev []string, clr color.Color) []string {
+	if prev == nil {
+		return lines
+	}
+	result := make([]string, 0, len(lines))
+	for i, line := range lines {
+		var prevCells []string
+		if i < len(prev) {
+			prevCells = cellRegexp.FindAllString(prev[i], -1)
+		}
+		var (
+			buf   bytes.Buffer
+			last  int
+			index int
+		)
+		for _, loc := range cellRegexp.FindAllStringIndex(line, -1) {
+			cell := line[loc[0]:loc[1]]
+			buf.WriteString(line[last:loc[0]])
+			if index < len(prevCells) && prevCells[index] == cell {
+				buf.WriteString(cell)
+			} else {
+				buf.WriteString(clr.Bold(cell))
+			}
+			last = loc[1]
+			index++
+		}
+		buf.WriteString(line[last:])
+		result = append(result, buf.String())
+	}
+	return result
+}
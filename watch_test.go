@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/labstack/gommon/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	type argT struct {
+		WatchHelper
+	}
+	n := 0
+	root := &Command{
+		Name: "app",
+		Argv: func() interface{} { return new(argT) },
+		Fn: Watch(func(ctx *Context) error {
+			n++
+			if n > 3 {
+				return errors.New("stop")
+			}
+			ctx.String("run %d\n", n)
+			return nil
+		}),
+	}
+	w := new(bytes.Buffer)
+	assert.Nil(t, root.RunWith(nil, w, nil))
+	assert.Equal(t, w.String(), "run 1\n")
+
+	n = 0
+	w.Reset()
+	assert.Error(t, root.RunWith([]string{"--watch-interval=0.001"}, w, nil))
+	assert.Equal(t, w.String(), "run 1\nrun 2\nrun 3\n")
+
+	// watching stops while context is done
+	root = &Command{
+		Name:    "app",
+		Argv:    func() interface{} { return new(argT) },
+		Timeout: 30 * time.Millisecond,
+		Fn:      Watch(func(ctx *Context) error { return nil }),
+	}
+	assert.Nil(t, root.RunWith([]string{"--watch-interval=0.001"}, ioutil.Discard, nil))
+}
+
+func TestHighlightChanges(t *testing.T) {
+	clr := color.Color{}
+	clr.Enable()
+	prev := []string{"NAME  STATUS", "a     running"}
+	lines := []string{"NAME  STATUS", "a     stopped", "b     new"}
+	assert.Equal(t, highlightChanges(lines, nil, clr), lines)
+	assert.Equal(t, highlightChanges(lines, prev, clr), []string{
+		"NAME  STATUS",
+		"a     " + clr.Bold("stopped"),
+		clr.Bold("b") + "     " + clr.Bold("new"),
+	})
+}
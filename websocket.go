@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/labstack/gommon/color"
+)
+
+// MaxWebSocketMessageSize is maximum size of command lines received by ServeWebSocket
+var MaxWebSocketMessageSize int64 = 1 << 20
+
+// CheckWebSocketOrigin reports whether origin of WebSocket handshake is allowed, it
+// protects consoles from cross-site WebSocket hijacking. If nil, requests without
+// header Origin(non-browser clients) and requests whose Origin has same host as
+// request are allowed. See AllowOrigins.
+var CheckWebSocketOrigin func(r *http.Request) bool
+
+// AllowOrigins returns an origin checker which allows requests without header
+// Origin and requests from origins, e.g. "https://console.example.com"
+func AllowOrigins(origins ...string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		for _, o := range origins {
+			if strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// sameOrigin reports whether r has no header Origin or Origin has same host as r
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes, see RFC 6455
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// ServeWebSocket upgrades request to a WebSocket connection which serves as a
+// remote console of cmd, it's designed for web-based consoles, e.g.
+//
+//	http.HandleFunc("/console", root.ServeWebSocket)
+//
+// Each text message received is a command line split by SplitArgs, commands run
+// one by one with output streamed back as text messages, errors are sent
+// as text messages too. An empty text message is sent after each command finished.
+// Commands of a connection share Context.Session like REPL. Output is colored
+// unless disabled by flag --color or environment variable NO_COLOR.
+func (cmd *Command) ServeWebSocket(w http.ResponseWriter, r *http.Request) {
+	cmd.serveWebSocket(w, r, nil)
+}
+
+// serveWebSocket serves a WebSocket connection, connection is closed after running
+// command or immediately if idle while drain closed
+func (cmd *Command) serveWebSocket(w http.ResponseWriter, r *http.Request, drain <-chan struct{}) {
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var (
+		out     = wsWriter{conn}
+		session = make(map[string]interface{})
+		setup   = func(ctx *Context) {
+			ctx.reader = strings.NewReader("")
+			ctx.errWriter = out
+			ctx.session = session
+			ctx.HTTPRequest = r
+			ctx.goctx = r.Context()
+		}
+
+		locker  sync.Mutex // protect busy and closing
+		busy    bool
+		closing bool
+		done    = make(chan struct{})
+	)
+	defer close(done)
+	if drain != nil {
+		go func() {
+			select {
+			case <-drain:
+				locker.Lock()
+				closing = true
+				if !busy {
+					conn.Close()
+				}
+				locker.Unlock()
+			case <-done:
+			}
+		}()
+	}
+	for {
+		line, err := conn.readMessage()
+		locker.Lock()
+		stop := closing
+		busy = !stop && err == nil
+		locker.Unlock()
+		if err != nil {
+			if err != io.EOF && !stop {
+				debugf("websocket: %v", err)
+			}
+			return
+		}
+		if stop {
+			return
+		}
+		args, err := SplitArgs(line)
+		if err != nil {
+			fmt.Fprintln(out, cmd.theme().wrapErr(err, "", color.Color{}))
+		} else if len(args) > 0 {
+			if err := cmd.runRemote(args, out, nil, setup); err != nil {
+				fmt.Fprintln(out, err)
+			}
+		}
+		if err := conn.writeFrame(wsText, nil); err != nil {
+			return
+		}
+		locker.Lock()
+		busy = false
+		stop = closing
+		locker.Unlock()
+		if stop {
+			conn.writeFrame(wsClose, nil)
+			return
+		}
+	}
+}
+
+// upgradeWebSocket completes handshake of WebSocket and hijacks connection
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		http.Error(w, "websocket handshake required", http.StatusBadRequest)
+		return nil, errors.New("bad handshake")
+	}
+	checkOrigin := CheckWebSocketOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		http.Error(w, "websocket origin not allowed", http.StatusForbidden)
+		return nil, errors.New("origin not allowed")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("response can't be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, reader: rw.Reader}, nil
+}
+
+// headerContains reports whether comma separated values of header contain token case-insensitively
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header[http.CanonicalHeaderKey(name)] {
+		for _, s := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn is server side of a WebSocket connection
+type wsConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	locker sync.Mutex // serialize writing frames
+}
+
+func (c *wsConn) Close() error {
+	return c.conn.Close()
+}
+
+// readMessage reads next text or binary message, control frames are handled
+// and io.EOF returned if peer closed the connection
+func (c *wsConn) readMessage() (string, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return "", err
+		}
+		switch opcode {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return "", err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			c.writeFrame(wsClose, nil)
+			return "", io.EOF
+		}
+		message = append(message, payload...)
+		if int64(len(message)) > MaxWebSocketMessageSize {
+			return "", errors.New("message too large")
+		}
+		if fin {
+			return string(message), nil
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return
+	}
+	fin = header[0]&0x80 != 0
+	opcode = header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	length := int64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.reader, ext[:]); err != nil {
+			return
+		}
+		length = int64(binary.BigEndian.Uint64(ext[:]))
+	}
+	if !masked {
+		err = errors.New("unmasked frame from client")
+		return
+	}
+	if length < 0 || length > MaxWebSocketMessageSize {
+		err = errors.New("message too large")
+		return
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode, 0}
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = append(header, byte(n>>8), byte(n))
+	default:
+		header[1] = 127
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		header = append(header, ext[:]...)
+	}
+	c.locker.Lock()
+	defer c.locker.Unlock()
+	if _, err := c.conn.Write(header); err != nil {
+		return err
+	}
+	_, err := c.conn.Write(payload)
+	return err
+}
+
+// wsWriter sends each write as a text message, empty writes are dropped
+// since empty message marks end of command
+type wsWriter struct {
+	conn *wsConn
+}
+
+// ColorCapable implements ColorCapable interface, web-based consoles support color
+func (w wsWriter) ColorCapable() bool { return true }
+
+func (w wsWriter) Write(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if err := w.conn.writeFrame(wsText, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wsTestClient is a minimal client side of WebSocket for tests
+type wsTestClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, addr string) *wsTestClient {
+	conn, err := net.Dial("tcp", addr)
+	require.Nil(t, err)
+	io.WriteString(conn, "GET /console HTTP/1.1\r\nHost: "+addr+
+		"\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	require.Nil(t, err)
+	assert.Equal(t, resp.StatusCode, 101)
+	assert.Equal(t, resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+	return &wsTestClient{conn: conn, reader: reader}
+}
+
+func (c *wsTestClient) send(opcode byte, message string) {
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opcode, 0x80 | byte(len(message))}
+	frame = append(frame, mask...)
+	for i := 0; i < len(message); i++ {
+		frame = append(frame, message[i]^mask[i%4])
+	}
+	c.conn.Write(frame)
+}
+
+func (c *wsTestClient) recv() (byte, string, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return 0, "", err
+	}
+	length := int(header[1] & 0x7F)
+	if length == 126 {
+		var ext [2]byte
+		io.ReadFull(c.reader, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	}
+	payload := make([]byte, length)
+	_, err := io.ReadFull(c.reader, payload)
+	return header[0] & 0x0F, string(payload), err
+}
+
+// output reads text messages until an empty one
+func (c *wsTestClient) output(t *testing.T) string {
+	var out strings.Builder
+	for {
+		opcode, message, err := c.recv()
+		require.Nil(t, err)
+		assert.Equal(t, opcode, byte(wsText))
+		if message == "" {
+			return out.String()
+		}
+		out.WriteString(message)
+	}
+}
+
+func TestServeWebSocket(t *testing.T) {
+	defer os.Setenv("NO_COLOR", os.Getenv("NO_COLOR"))
+	os.Unsetenv("NO_COLOR")
+
+	type argT struct {
+		ColorHelper
+		Name string `cli:"name"`
+	}
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "hello",
+		Argv: func() interface{} { return new(argT) },
+		Fn: func(ctx *Context) error {
+			ctx.String("hello %s\n", ctx.Color().Green(ctx.Argv().(*argT).Name))
+			ctx.Session()["last"] = ctx.Argv().(*argT).Name
+			return nil
+		},
+	})
+	root.Register(&Command{
+		Name: "last",
+		Fn: func(ctx *Context) error {
+			ctx.String("%v\n", ctx.Session()["last"])
+			return errors.New("done")
+		},
+	})
+	server := httptest.NewServer(http.HandlerFunc(root.ServeWebSocket))
+	defer server.Close()
+
+	c := dialWebSocket(t, server.Listener.Addr().String())
+	defer c.conn.Close()
+
+	c.send(wsText, `hello --name "Jack Ma"`)
+	assert.Equal(t, c.output(t), "hello \x1b[32mJack Ma\x1b[0m\n")
+
+	c.send(wsPing, "x")
+	opcode, message, err := c.recv()
+	require.Nil(t, err)
+	assert.Equal(t, opcode, byte(wsPong))
+	assert.Equal(t, message, "x")
+
+	c.send(wsText, "last")
+	assert.Equal(t, c.output(t), "Jack Ma\ndone\n")
+
+	c.send(wsText, "   ")
+	assert.Equal(t, c.output(t), "")
+
+	// color is disabled by flag --color and NO_COLOR
+	c.send(wsText, `hello --name Jack --color=never`)
+	assert.Equal(t, c.output(t), "hello Jack\n")
+	os.Setenv("NO_COLOR", "1")
+	c.send(wsText, `hello --name Jack`)
+	assert.Equal(t, c.output(t), "hello Jack\n")
+
+	c.send(wsClose, "")
+	opcode, _, err = c.recv()
+	require.Nil(t, err)
+	assert.Equal(t, opcode, byte(wsClose))
+}
+
+func TestServeWebSocketBadHandshake(t *testing.T) {
+	root := &Command{Name: "app"}
+	resp := httptest.NewRecorder()
+	root.ServeWebSocket(resp, httptest.NewRequest("GET", "/console", nil))
+	assert.Equal(t, resp.Code, 400)
+}
+
+func TestServeWebSocketOrigin(t *testing.T) {
+	defer func(check func(*http.Request) bool) { CheckWebSocketOrigin = check }(CheckWebSocketOrigin)
+
+	root := &Command{Name: "app"}
+	handshake := func(origin string) int {
+		req := httptest.NewRequest("GET", "http://console.example.com/console", nil)
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		req.Header.Set("Sec-WebSocket-Version", "13")
+		req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		// recorder can't be hijacked, 500 means origin allowed
+		resp := httptest.NewRecorder()
+		root.ServeWebSocket(resp, req)
+		return resp.Code
+	}
+	assert.Equal(t, handshake(""), 500)
+	assert.Equal(t, handshake("http://console.example.com"), 500)
+	assert.Equal(t, handshake("http://evil.com"), 403)
+
+	CheckWebSocketOrigin = AllowOrigins("http://evil.com")
+	assert.Equal(t, handshake("http://evil.com"), 500)
+	assert.Equal(t, handshake("http://console.example.com"), 403)
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+	"strings"
+	"unicode"
+)
+
+// wideRanges are ranges of East Asian wide and fullwidth characters
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115F},   // Hangul Jamo
+	{0x2E80, 0x303E},   // CJK Radicals .. CJK Symbols and Punctuation
+	{0x3041, 0x33FF},   // Hiragana .. CJK Compatibility
+	{0x3400, 0x4DBF},   // CJK Unified Ideographs Extension A
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0xA000, 0xA4CF},   // Yi
+	{0xAC00, 0xD7A3},   // Hangul Syllables
+	{0xF900, 0xFAFF},   // CJK Compatibility Ideographs
+	{0xFE30, 0xFE4F},   // CJK Compatibility Forms
+	{0xFF00, 0xFF60},   // Fullwidth Forms
+	{0xFFE0, 0xFFE6},   // Fullwidth Signs
+	{0x1F300, 0x1F64F}, // Miscellaneous Symbols and Pictographs, Emoticons
+	{0x1F900, 0x1F9FF}, // Supplemental Symbols and Pictographs
+	{0x20000, 0x3FFFD}, // CJK Unified Ideographs Extension B ..
+}
+
+// runeWidth returns number of terminal columns occupied by r
+func runeWidth(r rune) int {
+	if r == 0 || unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r) {
+		return 0
+	}
+	if r < 0x1100 {
+		return 1
+	}
+	for _, wide := range wideRanges {
+		if r >= wide.lo && r <= wide.hi {
+			return 2
+		}
+	}
+	return 1
+}
+
+// stringWidth returns number of terminal columns occupied by s, escape sequences
+// of colors are not counted
+func stringWidth(s string) int {
+	var (
+		width  = 0
+		escape = false
+	)
+	for _, r := range s {
+		switch {
+		case escape:
+			// CSI sequence ends with a letter, e.g. \x1b[1;31m
+			if r >= '@' && r <= '~' && r != '[' {
+				escape = false
+			}
+		case r == '\x1b':
+			escape = true
+		default:
+			width += runeWidth(r)
+		}
+	}
+	return width
+}
+
+// padRight appends spaces to s until s occupies width columns
+func padRight(s string, width int) string {
+	if n := stringWidth(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
+
+// padLeft prepends spaces to s until s occupies width columns
+func padLeft(s string, width int) string {
+	if n := stringWidth(s); n < width {
+		return strings.Repeat(" ", width-n) + s
+	}
+	return s
+}
+
+// minWrapWidth is the narrowest column wrapped by wrapText, narrower columns are not
+// wrapped since a few words per line is harder to read than overflowed lines
+const minWrapWidth = 20
+
+// wrapText splits s into lines not wider than width columns at spaces, newlines of s
+// are kept and words wider than width are not split. s is not wrapped if width is
+// less than minWrapWidth.
+func wrapText(s string, width int) []string {
+	lines := strings.Split(s, "\n")
+	if width < minWrapWidth {
+		return lines
+	}
+	wrapped := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if stringWidth(line) <= width {
+			wrapped = append(wrapped, line)
+			continue
+		}
+		var (
+			cur      = ""
+			curWidth = 0
+		)
+		for _, word := range strings.Fields(line) {
+			w := stringWidth(word)
+			if cur != "" && curWidth+1+w > width {
+				wrapped = append(wrapped, cur)
+				cur, curWidth = "", 0
+			}
+			if cur != "" {
+				cur += " "
+				curWidth++
+			}
+			cur += word
+			curWidth += w
+		}
+		wrapped = append(wrapped, cur)
+	}
+	return wrapped
+}
+
+// wrapIndent wraps s to fit width columns after indent columns, lines after the
+// first one are indented
+func wrapIndent(s string, indent, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return strings.Join(wrapText(s, width-indent), "\n"+strings.Repeat(" ", indent))
+}
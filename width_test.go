@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/labstack/gommon/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringWidth(t *testing.T) {
+	assert.Equal(t, stringWidth("abc"), 3)
+	assert.Equal(t, stringWidth("数据"), 4)
+	assert.Equal(t, stringWidth("é"), 1)
+	assert.Equal(t, stringWidth("e\u0301"), 1)
+	assert.Equal(t, stringWidth("ｈｉ"), 4)
+	assert.Equal(t, stringWidth("\x1b[1;31m数据\x1b[0m"), 4)
+	assert.Equal(t, padRight("数据", 6), "数据  ")
+	assert.Equal(t, padLeft("\x1b[1mab\x1b[0m", 3), " \x1b[1mab\x1b[0m")
+	assert.Equal(t, padRight("abc", 2), "abc")
+}
+
+func TestUnicodeAlignment(t *testing.T) {
+	type argT struct {
+		City string `cli:"c,city" usage:"城市" dft:"北京"`
+		File string `cli:"f,file" usage:"文件" name:"文件"`
+		Ok   bool   `cli:"ok" usage:"ok"`
+	}
+	clr := color.Color{}
+	clr.Disable()
+	assert.Equal(t, usage([]interface{}{new(argT)}, clr, NormalStyle), `  -c, --city[=北京]   城市
+  -f, --file=文件     文件
+      --ok            ok
+`)
+
+	root := &Command{Name: "app"}
+	root.Register(&Command{Name: "ls", Desc: "列出文件", Aliases: []string{"列表"}})
+	root.Register(&Command{Name: "remove", Desc: "删除文件"})
+	assert.Equal(t, root.ChildrenDescriptions("  ", "   "), "  ls       列出文件(aliases 列表)\n  remove   删除文件\n")
+}
+
+func TestWrapText(t *testing.T) {
+	assert.Equal(t, wrapText("short text", 20), []string{"short text"})
+	assert.Equal(t, wrapText("the quick brown fox jumps over the lazy dog", 20),
+		[]string{"the quick brown fox", "jumps over the lazy", "dog"})
+	assert.Equal(t, wrapText("first line\nsecond   line is longer than width", 20),
+		[]string{"first line", "second line is", "longer than width"})
+	assert.Equal(t, wrapText("数据数据数据 数据数据数据 数据", 20), []string{"数据数据数据", "数据数据数据 数据"})
+	assert.Equal(t, wrapText("averyveryverylongwordwhichisnotsplit x", 20), []string{"averyveryverylongwordwhichisnotsplit", "x"})
+	// narrow columns are not wrapped
+	assert.Equal(t, wrapText("the quick brown fox jumps", 10), []string{"the quick brown fox jumps"})
+	assert.Equal(t, wrapIndent("the quick brown fox jumps over", 4, 24), "the quick brown fox\n    jumps over")
+	assert.Equal(t, wrapIndent("the quick brown fox jumps over", 4, 0), "the quick brown fox jumps over")
+}
+
+func TestUsageWidth(t *testing.T) {
+	type argT struct {
+		Name string `cli:"n,name" usage:"name of the user which is shown in the greeting message"`
+	}
+	defer SetUsageWidth(0)
+	SetUsageWidth(50)
+	clr := color.Color{}
+	clr.Disable()
+	root := &Command{Name: "app"}
+	root.Register(&Command{
+		Name: "hello",
+		Desc: "say hello to the user with a customizable greeting message",
+		Argv: func() interface{} { return new(argT) },
+	})
+	assert.Equal(t, root.Usage(&Context{color: clr}), `Commands:
+
+  hello   say hello to the user with a
+          customizable greeting message
+`)
+	assert.Equal(t, root.findChild("hello").Usage(&Context{color: clr}), `say hello to the user with a customizable greeting message
+
+Options:
+
+  -n, --name   name of the user which is shown in
+               the greeting message
+`)
+
+	// usage is not wrapped if wrapping disabled
+	SetUsageWidth(-1)
+	assert.Equal(t, root.Usage(&Context{color: clr}), `Commands:
+
+  hello   say hello to the user with a customizable greeting message
+`)
+}
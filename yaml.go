@@ -0,0 +1,473 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// yamlNode is a value decoded from JSON with order of object keys kept
+type yamlNode struct {
+	scalar string // formatted scalar if keys and items are nil
+	keys   []string
+	values []*yamlNode
+	items  []*yamlNode
+	object bool
+	array  bool
+}
+
+// marshalYAML encodes obj as YAML, obj is encoded by encoding/json first,
+// so fields are named and omitted in the same way as JSON
+func marshalYAML(obj interface{}) ([]byte, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	node, err := decodeYAMLNode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	node.write(buf, 0)
+	return buf.Bytes(), nil
+}
+
+func decodeYAMLNode(decoder *json.Decoder) (*yamlNode, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := token.(type) {
+	case json.Delim:
+		node := &yamlNode{object: t == '{', array: t == '['}
+		for decoder.More() {
+			if node.object {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				node.keys = append(node.keys, yamlString(key.(string)))
+			}
+			value, err := decodeYAMLNode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			if node.object {
+				node.values = append(node.values, value)
+			} else {
+				node.items = append(node.items, value)
+			}
+		}
+		// read closing delimiter
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return node, nil
+	case string:
+		return &yamlNode{scalar: yamlString(t)}, nil
+	case json.Number:
+		return &yamlNode{scalar: t.String()}, nil
+	case bool:
+		return &yamlNode{scalar: strconv.FormatBool(t)}, nil
+	}
+	return &yamlNode{scalar: "null"}, nil
+}
+
+// inline returns inline form of scalar, empty object or empty array
+func (node *yamlNode) inline() (string, bool) {
+	switch {
+	case node.object && len(node.keys) == 0:
+		return "{}", true
+	case node.array && len(node.items) == 0:
+		return "[]", true
+	case !node.object && !node.array:
+		return node.scalar, true
+	}
+	return "", false
+}
+
+func (node *yamlNode) write(w io.Writer, indent int) {
+	prefix := strings.Repeat(" ", indent)
+	if s, ok := node.inline(); ok {
+		io.WriteString(w, prefix+s+"\n")
+		return
+	}
+	if node.object {
+		for i, key := range node.keys {
+			value := node.values[i]
+			if s, ok := value.inline(); ok {
+				io.WriteString(w, prefix+key+": "+s+"\n")
+				continue
+			}
+			io.WriteString(w, prefix+key+":\n")
+			value.write(w, indent+2)
+		}
+		return
+	}
+	for _, item := range node.items {
+		if s, ok := item.inline(); ok {
+			io.WriteString(w, prefix+"- "+s+"\n")
+			continue
+		}
+		// write item with deeper indent, then replace indent of first line with `- `
+		buf := new(bytes.Buffer)
+		item.write(buf, indent+2)
+		data := buf.Bytes()
+		io.WriteString(w, prefix+"- ")
+		w.Write(data[indent+2:])
+	}
+}
+
+// yamlString returns s as a plain scalar if it's unambiguous, or a double-quoted scalar
+func yamlString(s string) string {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, "\n\r\t\"\\") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") ||
+		strings.IndexAny(s[:1], "-?:,[]{}#&*!|>'%@`") >= 0 {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~":
+		return strconv.Quote(s)
+	}
+	if _, ok := yamlNumber(s); ok {
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return strconv.Quote(s)
+	}
+	for _, r := range s {
+		if !strconv.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// YAML writes yaml string of obj to writer, fields are named and omitted in the same way as JSON
+func (ctx *Context) YAML(obj interface{}) *Context {
+	data, err := marshalYAML(obj)
+	return ctx.writeEncoded(bytes.TrimSuffix(data, []byte("\n")), err)
+}
+
+// YAMLln writes yaml string of obj end with "\n" to writer
+func (ctx *Context) YAMLln(obj interface{}) *Context {
+	return ctx.YAML(obj).String("\n")
+}
+
+// yamlLine is a line of YAML document without comment
+type yamlLine struct {
+	no     int
+	indent int
+	text   string
+}
+
+// unmarshalYAML decodes a YAML document into v, it supports the subset of YAML used by
+// config files: block and flow mappings and sequences, plain and quoted scalars and
+// comments. Anchors, tags and multi-line scalars are not supported. Decoded value is
+// stored into v by encoding/json, so v is filled in the same way as by a JSON document.
+func unmarshalYAML(data []byte, v interface{}) error {
+	var lines []yamlLine
+	for i, text := range strings.Split(string(data), "\n") {
+		text = strings.TrimRight(yamlStripComment(text), " \t\r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return fmt.Errorf("yaml: line %d: tab character used as indentation", i+1)
+		}
+		lines = append(lines, yamlLine{no: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	var value interface{} = map[string]interface{}{}
+	if len(lines) > 0 {
+		var err error
+		if value, lines, err = decodeYAMLBlock(lines, lines[0].indent); err != nil {
+			return err
+		}
+		if len(lines) > 0 {
+			return fmt.Errorf("yaml: line %d: unexpected indentation", lines[0].no)
+		}
+	}
+	return assignJSON(value, v)
+}
+
+// decodeYAMLBlock decodes leading lines with indentation indent as a mapping or
+// a sequence, unused lines are returned
+func decodeYAMLBlock(lines []yamlLine, indent int) (interface{}, []yamlLine, error) {
+	if isYAMLItem(lines[0].text) {
+		items := []interface{}{}
+		for len(lines) > 0 && lines[0].indent == indent && isYAMLItem(lines[0].text) {
+			line := lines[0]
+			rest := strings.TrimLeft(line.text[1:], " ")
+			lines = lines[1:]
+			var (
+				item interface{}
+				err  error
+			)
+			switch {
+			case rest == "":
+				item, lines, err = decodeYAMLNested(lines, indent, false)
+			case isYAMLItem(rest) || yamlKeyIndex(rest) >= 0:
+				// compact nested collection, e.g. `- name: a`
+				child := yamlLine{no: line.no, indent: indent + len(line.text) - len(rest), text: rest}
+				item, lines, err = decodeYAMLBlock(append([]yamlLine{child}, lines...), child.indent)
+			default:
+				item, err = decodeYAMLScalar(rest, line.no)
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			items = append(items, item)
+		}
+		return items, lines, nil
+	}
+
+	m := map[string]interface{}{}
+	for len(lines) > 0 && lines[0].indent == indent {
+		line := lines[0]
+		i := yamlKeyIndex(line.text)
+		if i < 0 {
+			return nil, nil, fmt.Errorf("yaml: line %d: expected key: value", line.no)
+		}
+		key, err := decodeYAMLKey(line.text[:i], line.no)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := m[key]; ok {
+			return nil, nil, fmt.Errorf("yaml: line %d: duplicate key %s", line.no, key)
+		}
+		rest := strings.TrimLeft(line.text[i+1:], " ")
+		lines = lines[1:]
+		if rest == "" {
+			m[key], lines, err = decodeYAMLNested(lines, indent, true)
+		} else {
+			m[key], err = decodeYAMLScalar(rest, line.no)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return m, lines, nil
+}
+
+// decodeYAMLNested decodes value of a key or an item in following lines, null if absent.
+// Sequence of a key may have the same indentation as the key.
+func decodeYAMLNested(lines []yamlLine, indent int, key bool) (interface{}, []yamlLine, error) {
+	if len(lines) == 0 || lines[0].indent < indent {
+		return nil, lines, nil
+	}
+	if lines[0].indent > indent || (key && isYAMLItem(lines[0].text)) {
+		return decodeYAMLBlock(lines, lines[0].indent)
+	}
+	return nil, lines, nil
+}
+
+func isYAMLItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// yamlKeyIndex returns index of colon separating key and value of text, or -1
+func yamlKeyIndex(text string) int {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && i == 0:
+			quote = c
+		case c == '[' || c == '{':
+			if i == 0 {
+				return -1
+			}
+		case c == ':' && (i+1 == len(text) || text[i+1] == ' '):
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeYAMLKey decodes key of mapping, plain keys are kept as is, e.g. `n` and `1`
+func decodeYAMLKey(s string, no int) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		return s, nil
+	}
+	key, err := decodeYAMLScalar(s, no)
+	if err != nil {
+		return "", err
+	}
+	return key.(string), nil
+}
+
+// decodeYAMLScalar decodes a scalar or a flow collection
+func decodeYAMLScalar(s string, no int) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	switch s[0] {
+	case '[', '{':
+		value, rest, err := decodeYAMLFlow(s, no)
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = fmt.Errorf("yaml: line %d: unexpected %q after flow collection", no, rest)
+		}
+		return value, err
+	case '"':
+		value, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("yaml: line %d: invalid double-quoted scalar %s", no, s)
+		}
+		return value, nil
+	case '\'':
+		if len(s) < 2 || s[len(s)-1] != '\'' {
+			return nil, fmt.Errorf("yaml: line %d: invalid single-quoted scalar %s", no, s)
+		}
+		return strings.Replace(s[1:len(s)-1], "''", "'", -1), nil
+	case '&', '*', '!', '|', '>', '%', '@', '`':
+		return nil, fmt.Errorf("yaml: line %d: unsupported syntax %s", no, s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "yes", "on", "y":
+		return true, nil
+	case "false", "no", "off", "n":
+		return false, nil
+	case "null", "~":
+		return nil, nil
+	}
+	if n, ok := yamlNumber(s); ok {
+		return n, nil
+	}
+	return s, nil
+}
+
+// yamlNumber returns s as json.Number if it's an integer or a float
+func yamlNumber(s string) (json.Number, bool) {
+	num := strings.Replace(s, "_", "", -1)
+	base := 10
+	if strings.HasPrefix(num, "0x") || strings.HasPrefix(num, "0o") {
+		base = 0
+	}
+	if i, err := strconv.ParseInt(num, base, 64); err == nil {
+		return json.Number(strconv.FormatInt(i, 10)), true
+	}
+	if f, err := strconv.ParseFloat(num, 64); err == nil && base == 10 &&
+		!math.IsInf(f, 0) && !math.IsNaN(f) && !strings.ContainsAny(num, "xXpP") {
+		return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), true
+	}
+	return "", false
+}
+
+// decodeYAMLFlow decodes flow collection at the beginning of s, rest of s is returned
+func decodeYAMLFlow(s string, no int) (interface{}, string, error) {
+	open, close := s[0], byte(']')
+	if open == '{' {
+		close = '}'
+	}
+	var (
+		items = []interface{}{}
+		m     = map[string]interface{}{}
+	)
+	s = strings.TrimLeft(s[1:], " ")
+	for {
+		if s == "" {
+			return nil, "", fmt.Errorf("yaml: line %d: unterminated flow collection", no)
+		}
+		if s[0] == close {
+			break
+		}
+		var (
+			key  string
+			elem interface{}
+			err  error
+		)
+		if open == '{' {
+			i := yamlKeyIndex(s)
+			if i < 0 {
+				return nil, "", fmt.Errorf("yaml: line %d: expected key: value in flow mapping", no)
+			}
+			if key, err = decodeYAMLKey(s[:i], no); err != nil {
+				return nil, "", err
+			}
+			s = strings.TrimLeft(s[i+1:], " ")
+		}
+		if s != "" && (s[0] == '[' || s[0] == '{') {
+			elem, s, err = decodeYAMLFlow(s, no)
+		} else {
+			end := yamlFlowEnd(s)
+			elem, err = decodeYAMLScalar(strings.TrimSpace(s[:end]), no)
+			s = s[end:]
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if open == '{' {
+			m[key] = elem
+		} else {
+			items = append(items, elem)
+		}
+		s = strings.TrimLeft(s, " ")
+		if strings.HasPrefix(s, ",") {
+			s = strings.TrimLeft(s[1:], " ")
+		} else if s == "" || s[0] != close {
+			return nil, "", fmt.Errorf("yaml: line %d: expected , or %c in flow collection", no, close)
+		}
+	}
+	if open == '{' {
+		return m, s[1:], nil
+	}
+	return items, s[1:], nil
+}
+
+// yamlFlowEnd returns end of scalar in flow collection
+func yamlFlowEnd(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case (c == '"' || c == '\'') && strings.TrimSpace(s[:i]) == "":
+			quote = c
+		case c == ',' || c == ']' || c == '}':
+			return i
+		}
+	}
+	return len(s)
+}
+
+// yamlStripComment removes comment starting with `#` outside of quoted scalars
+func yamlStripComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 || strings.IndexByte(" :[{,-", text[i-1]) >= 0 {
+				quote = c
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return text[:i]
+		}
+	}
+	return text
+}
@@ -0,0 +1,158 @@
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalYAML(t *testing.T) {
+	type container struct {
+		Name  string            `json:"name"`
+		Ports []int             `json:"ports"`
+		Env   map[string]string `json:"env,omitempty"`
+	}
+	type pod struct {
+		Name       string      `json:"name"`
+		Ready      bool        `json:"ready"`
+		Restarts   int         `json:"restarts"`
+		Labels     []string    `json:"labels"`
+		Containers []container `json:"containers"`
+		Note       *string     `json:"note"`
+		Secret     string      `json:"-"`
+		Empty      struct{}    `json:"empty"`
+	}
+	data, err := marshalYAML(pod{
+		Name:     "api: v1",
+		Restarts: 3,
+		Labels:   []string{"web", "true", "12", ""},
+		Containers: []container{
+			{Name: "app", Ports: []int{80, 443}, Env: map[string]string{"B": "2", "A": "# x"}},
+			{Name: "sidecar"},
+		},
+	})
+	require.Nil(t, err)
+	assert.Equal(t, string(data), `name: "api: v1"
+ready: false
+restarts: 3
+labels:
+  - web
+  - "true"
+  - "12"
+  - ""
+containers:
+  - name: app
+    ports:
+      - 80
+      - 443
+    env:
+      A: "# x"
+      B: "2"
+  - name: sidecar
+    ports: null
+note: null
+empty: {}
+`)
+
+	data, err = marshalYAML([][]string{{"a"}, {}})
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "- - a\n- []\n")
+
+	data, err = marshalYAML("hello world")
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "hello world\n")
+}
+
+func TestContextYAML(t *testing.T) {
+	w := new(bytes.Buffer)
+	ctx := &Context{writer: w}
+	ctx.YAML(map[string]int{"a": 1}).String("|")
+	ctx.YAMLln([]int{1, 2})
+	assert.Equal(t, w.String(), "a: 1|- 1\n- 2\n")
+}
+
+func TestUnmarshalYAML(t *testing.T) {
+	for i, tc := range []struct {
+		src  string
+		want interface{}
+	}{
+		{"", map[string]interface{}{}},
+		{"---\n# comment only\n", map[string]interface{}{}},
+		{"a: 1\nb: -2.5\nc: 0x10\nd: 1_000\ne: 1.2.3", map[string]interface{}{"a": 1.0, "b": -2.5, "c": 16.0, "d": 1000.0, "e": "1.2.3"}},
+		{"a: true\nb: off\nc: ~\nd:\ne: null", map[string]interface{}{"a": true, "b": false, "c": nil, "d": nil, "e": nil}},
+		{`a: "x: y # z"` + "\nb: 'it''s' # note\nc: it's #1\nd: \"\\u00e9\\n\"", map[string]interface{}{"a": "x: y # z", "b": "it's", "c": "it's", "d": "é\n"}},
+		{"url: http://example.com:80/a#b\n\"quoted key\": v", map[string]interface{}{"url": "http://example.com:80/a#b", "quoted key": "v"}},
+		{"a:\n- 1\n- x\nb:\n  - [1, [2, 3]]\n  - {k: v, n: {m: 1}}\n", map[string]interface{}{
+			"a": []interface{}{1.0, "x"},
+			"b": []interface{}{
+				[]interface{}{1.0, []interface{}{2.0, 3.0}},
+				map[string]interface{}{"k": "v", "n": map[string]interface{}{"m": 1.0}},
+			},
+		}},
+		{"items:\n  - name: a\n    port: 1\n  - name: b\n  -\n    - c\nempty: []\n", map[string]interface{}{
+			"items": []interface{}{
+				map[string]interface{}{"name": "a", "port": 1.0},
+				map[string]interface{}{"name": "b"},
+				[]interface{}{"c"},
+			},
+			"empty": []interface{}{},
+		}},
+		{"a:\n  b:\n    c: \"1\"\n  d: [ ]\n", map[string]interface{}{
+			"a": map[string]interface{}{"b": map[string]interface{}{"c": "1"}, "d": []interface{}{}},
+		}},
+		{"- a\n- b", []interface{}{"a", "b"}},
+	} {
+		var got interface{}
+		if assert.Nil(t, unmarshalYAML([]byte(tc.src), &got), "#%d", i) {
+			assert.Equal(t, got, tc.want, "#%d", i)
+		}
+	}
+
+	for i, src := range []string{
+		"a: 1\n  b: 2",
+		"a: 1\na: 2",
+		"just a scalar",
+		"a: [1, 2",
+		"a: {k v}",
+		"a: \"unterminated",
+		"a: &anchor 1",
+		"a: |\n  text",
+		"\ta: 1",
+		"a: [1] x",
+	} {
+		var got interface{}
+		assert.Error(t, unmarshalYAML([]byte(src), &got), "#%d", i)
+	}
+
+	// documents written by marshalYAML can be decoded
+	type doc struct {
+		Name  string            `json:"name"`
+		Tags  []string          `json:"tags"`
+		Env   map[string]string `json:"env"`
+		Items []map[string]int  `json:"items"`
+	}
+	src := doc{
+		Name:  "api: v1",
+		Tags:  []string{"true", "12", "", "- x", "#c"},
+		Env:   map[string]string{"k": "a 'b' \"c\""},
+		Items: []map[string]int{{"a": 1, "b": 2}, {}},
+	}
+	data, err := marshalYAML(src)
+	require.Nil(t, err)
+	var got doc
+	if assert.Nil(t, unmarshalYAML(data, &got), string(data)) {
+		assert.Equal(t, got, src)
+	}
+}
+
+func TestYAMLStringRoundTrip(t *testing.T) {
+	src := []string{"0x1F", "0o755", "1_000", "-2.5", "1e3", "0x", "1.2.3"}
+	data, err := marshalYAML(src)
+	require.Nil(t, err)
+	assert.Equal(t, string(data), "- \"0x1F\"\n- \"0o755\"\n- \"1_000\"\n- \"-2.5\"\n- \"1e3\"\n- 0x\n- 1.2.3\n")
+	var got []string
+	require.Nil(t, unmarshalYAML(data, &got))
+	assert.Equal(t, got, src)
+}